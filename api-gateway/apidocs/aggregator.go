@@ -0,0 +1,144 @@
+package apidocs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"reading-microservices/api-gateway/proxy"
+	"reading-microservices/shared/openapi"
+)
+
+const defaultSpecPath = "/openapi.json"
+
+// 服务之间调用的内部接口不经过网关，不出现在对外文档中
+const (
+	internalPathPrefix = "/api/v1/internal/"
+	internalTag        = "内部接口"
+)
+
+// Aggregator 从各服务拉取 OpenAPI 文档并合并
+type Aggregator struct {
+	services map[string]proxy.ServiceConfig
+	client   *http.Client
+
+	mu        sync.RWMutex
+	doc       *openapi.Document
+	validator *openapi.Validator
+}
+
+func NewAggregator(services map[string]proxy.ServiceConfig) *Aggregator {
+	a := &Aggregator{
+		services: services,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+	a.setDocument(newGatewayDocument())
+	return a
+}
+
+func newGatewayDocument() *openapi.Document {
+	doc := openapi.NewDocument("Reading Microservices API", "1.0.0")
+	doc.Info.Description = "由 API 网关聚合的各服务接口文档"
+	return doc
+}
+
+// Refresh 重新拉取所有服务文档；单个服务失败不影响其他服务
+func (a *Aggregator) Refresh(ctx context.Context) {
+	names := make([]string, 0, len(a.services))
+	for name := range a.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	merged := newGatewayDocument()
+	for _, name := range names {
+		doc, err := a.fetch(ctx, a.services[name])
+		if err != nil {
+			logrus.Warnf("Fetch OpenAPI document from %s failed: %v", name, err)
+			continue
+		}
+		removeInternal(doc)
+		openapi.Merge(merged, doc, name)
+	}
+	a.setDocument(merged)
+}
+
+// removeInternal 去掉内部接口及其标签
+func removeInternal(doc *openapi.Document) {
+	for path := range doc.Paths {
+		if strings.HasPrefix(path, internalPathPrefix) {
+			delete(doc.Paths, path)
+		}
+	}
+	tags := doc.Tags[:0]
+	for _, tag := range doc.Tags {
+		if tag.Name != internalTag {
+			tags = append(tags, tag)
+		}
+	}
+	doc.Tags = tags
+}
+
+func (a *Aggregator) fetch(ctx context.Context, service proxy.ServiceConfig) (*openapi.Document, error) {
+	specPath := service.OpenAPI
+	if specPath == "" {
+		specPath = defaultSpecPath
+	}
+	url := fmt.Sprintf("http://%s:%d%s", service.Host, service.Port, specPath)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	var doc openapi.Document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Start 启动定时刷新
+func (a *Aggregator) Start(interval time.Duration) {
+	a.Refresh(context.Background())
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			a.Refresh(context.Background())
+		}
+	}()
+}
+
+func (a *Aggregator) setDocument(doc *openapi.Document) {
+	validator := openapi.NewValidator(doc)
+	a.mu.Lock()
+	a.doc = doc
+	a.validator = validator
+	a.mu.Unlock()
+}
+
+func (a *Aggregator) Document() *openapi.Document {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.doc
+}
+
+func (a *Aggregator) Validator() *openapi.Validator {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.validator
+}
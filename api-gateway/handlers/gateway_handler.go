@@ -3,15 +3,24 @@ package handlers
 import (
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"reading-microservices/api-gateway/apidocs"
 	"reading-microservices/api-gateway/proxy"
+	"reading-microservices/shared/openapi"
+	"strings"
 )
 
 type GatewayHandler struct {
 	serviceProxy *proxy.ServiceProxy
+	apiDocs      *apidocs.Aggregator
+	docsPage     gin.HandlerFunc
 }
 
-func NewGatewayHandler(serviceProxy *proxy.ServiceProxy) *GatewayHandler {
-	return &GatewayHandler{serviceProxy: serviceProxy}
+func NewGatewayHandler(serviceProxy *proxy.ServiceProxy, apiDocs *apidocs.Aggregator) *GatewayHandler {
+	return &GatewayHandler{
+		serviceProxy: serviceProxy,
+		apiDocs:      apiDocs,
+		docsPage:     openapi.DocsHandler("Reading Microservices API", "/openapi.json"),
+	}
 }
 
 func (h *GatewayHandler) Health(c *gin.Context) {
@@ -37,3 +46,31 @@ func (h *GatewayHandler) ServiceStatus(c *gin.Context) {
 func (h *GatewayHandler) ProxyService(service string) gin.HandlerFunc {
 	return h.serviceProxy.ProxyToService(service)
 }
+
+// ProxyByPrefix 按 *path 的第一段选择处理链，用于同一前缀下分属不同服务的接口（如 /admin/users、/admin/content）
+func (h *GatewayHandler) ProxyByPrefix(routes map[string][]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		segment := strings.SplitN(strings.TrimPrefix(c.Param("path"), "/"), "/", 2)[0]
+		chain, ok := routes[segment]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "Service not available", "success": false, "data": nil})
+			return
+		}
+		for _, handler := range chain {
+			handler(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+	}
+}
+
+// OpenAPISpec 输出合并后的接口文档
+func (h *GatewayHandler) OpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, h.apiDocs.Document())
+}
+
+// APIDocs 接口文档页面
+func (h *GatewayHandler) APIDocs(c *gin.Context) {
+	h.docsPage(c)
+}
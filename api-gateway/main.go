@@ -3,27 +3,37 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"reading-microservices/api-gateway/apidocs"
 	"reading-microservices/api-gateway/handlers"
 	gatewayMiddleware "reading-microservices/api-gateway/middleware"
 	"reading-microservices/api-gateway/proxy"
 	"reading-microservices/shared/config"
+	"reading-microservices/shared/utils"
 )
 
 type GatewayConfig struct {
-	Server    config.ServerConfig            `mapstructure:"server"`
-	Database  config.DatabaseConfig          `mapstructure:"database"`
-	Redis     config.RedisConfig             `mapstructure:"redis"`
-	JWT       config.JWTConfig               `mapstructure:"jwt"`
-	Consul    config.ConsulConfig            `mapstructure:"consul"`
-	Services  map[string]proxy.ServiceConfig `mapstructure:"services"`
-	RateLimit struct {
+	Server       config.ServerConfig            `mapstructure:"server"`
+	Database     config.DatabaseConfig          `mapstructure:"database"`
+	Redis        config.RedisConfig             `mapstructure:"redis"`
+	SessionRedis config.RedisConfig             `mapstructure:"session_redis"` // 用户服务存放登录会话的 Redis
+	JWT          config.JWTConfig               `mapstructure:"jwt"`
+	Consul       config.ConsulConfig            `mapstructure:"consul"`
+	Services     map[string]proxy.ServiceConfig `mapstructure:"services"`
+	RateLimit    struct {
 		RequestsPerMinute int `mapstructure:"requests_per_minute"`
 		Burst             int `mapstructure:"burst"`
 	} `mapstructure:"rate_limit"`
+	OpenAPI struct {
+		Validate        bool  `mapstructure:"validate"`         // 是否按接口文档校验请求
+		RefreshInterval int   `mapstructure:"refresh_interval"` // 文档刷新间隔（秒）
+		MaxBodyBytes    int64 `mapstructure:"max_body_bytes"`   // 校验时读取的 JSON 请求体上限（字节）
+	} `mapstructure:"openapi"`
 }
 
 func main() {
@@ -48,6 +58,13 @@ func main() {
 		logrus.Info("Redis connected successfully")
 	}
 
+	// 会话存储不可用时需要登录的接口返回 503，连接恢复后自动重连
+	sessionRedis, err := initRedis(cfg.SessionRedis)
+	if err != nil {
+		logrus.Warnf("Session redis init failed: %v", err)
+	}
+	sessions := gatewayMiddleware.NewSessionChecker(sessionRedis)
+
 	// 记录服务配置
 	for name, service := range cfg.Services {
 		logrus.Infof("Service %s: %s:%d", name, service.Host, service.Port)
@@ -55,8 +72,30 @@ func main() {
 
 	serviceProxy := proxy.NewServiceProxy(cfg.Services)
 	rateLimiter := gatewayMiddleware.NewRateLimiter(rdb, cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst)
-	gatewayHandler := handlers.NewGatewayHandler(serviceProxy)
-	router := setupRouter(gatewayHandler, rateLimiter, cfg.JWT.Secret)
+
+	// 聚合各服务的接口文档
+	refreshInterval := time.Duration(cfg.OpenAPI.RefreshInterval) * time.Second
+	if refreshInterval <= 0 {
+		refreshInterval = 5 * time.Minute
+	}
+	apiDocs := apidocs.NewAggregator(cfg.Services)
+	apiDocs.Start(refreshInterval)
+
+	gatewayHandler := handlers.NewGatewayHandler(serviceProxy, apiDocs)
+	var validation gin.HandlerFunc
+	if cfg.OpenAPI.Validate {
+		maxBody := cfg.OpenAPI.MaxBodyBytes
+		if maxBody <= 0 {
+			maxBody = 1 << 20
+		}
+		validation = gatewayMiddleware.RequestValidation(apiDocs, maxBody)
+		logrus.Info("OpenAPI request validation enabled")
+	}
+	router := setupRouter(gatewayHandler, rateLimiter, validation, cfg.JWT.NewVerifier(), sessions)
+	// 客户端 IP 用于限流和下游的登录保护，只接受负载均衡转发的 X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logrus.Fatalf("Invalid trusted proxies: %v", err)
+	}
 
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
 	logrus.Infof("API Gateway starting on %s", addr)
@@ -91,13 +130,26 @@ func initRedis(cfg config.RedisConfig) (*redis.Client, error) {
 	return rdb, err
 }
 
-func setupRouter(handler *handlers.GatewayHandler, rl *gatewayMiddleware.RateLimiter, jwtSecret string) *gin.Engine {
+func setupRouter(handler *handlers.GatewayHandler, rl *gatewayMiddleware.RateLimiter, validation gin.HandlerFunc, verifier utils.TokenVerifier, sessions *gatewayMiddleware.SessionChecker) *gin.Engine {
 	router := gin.Default()
 	router.Use(gin.Recovery())
 	router.GET("/health", handler.Health)
 	router.GET("/status", handler.ServiceStatus)
 
+	// 接口文档
+	router.GET("/openapi.json", handler.OpenAPISpec)
+	router.GET("/docs", handler.APIDocs)
+
+	// 用户服务的 token 验证公钥
+	router.GET("/.well-known/jwks.json", handler.ProxyService("user_service"))
+
+	// 用户上传的文件（头像），本地存储时由用户服务提供；使用对象存储时文件地址直接指向存储或 CDN
+	router.GET("/files/*path", handler.ProxyService("user_service"))
+
 	v1 := router.Group("/api/v1")
+	if validation != nil {
+		v1.Use(validation)
+	}
 	{
 		auth := v1.Group("/auth")
 		auth.Use(rl.IPLimit(30)) // IP限流30次/分钟
@@ -110,17 +162,25 @@ func setupRouter(handler *handlers.GatewayHandler, rl *gatewayMiddleware.RateLim
 		// 用户服务接口 - 必须登录
 		// ------------------------
 		user := v1.Group("/user")
-		user.Use(gatewayMiddleware.AuthMiddleware(jwtSecret))
+		user.Use(gatewayMiddleware.AuthMiddleware(verifier, sessions))
 		user.Use(rl.UserLimit(5000))
 		{
 			user.Any("/*path", handler.ProxyService("user_service"))
 		}
-		v1.Any("/content/*path", gatewayMiddleware.OptionalAuth(jwtSecret), rl.UserLimit(1000), handler.ProxyService("content_service"))
-		v1.Any("/reading/*path", gatewayMiddleware.AuthMiddleware(jwtSecret), rl.UserLimit(2000), handler.ProxyService("reading_service"))
-		v1.Any("/payment/*path", gatewayMiddleware.AuthMiddleware(jwtSecret), rl.UserLimit(100), rl.IPLimit(50), handler.ProxyService("payment_service"))
-		v1.Any("/download/*path", gatewayMiddleware.AuthMiddleware(jwtSecret), gatewayMiddleware.AntiLeechMiddleware(), rl.UserLimit(50), handler.ProxyService("download_service"))
-		v1.Any("/notification/*path", gatewayMiddleware.AuthMiddleware(jwtSecret), rl.UserLimit(200), handler.ProxyService("notification_service"))
-		v1.Any("/admin/*path", gatewayMiddleware.AuthMiddleware(jwtSecret), gatewayMiddleware.RoleMiddleware("admin"), rl.UserLimit(500), handler.ProxyService("admin_service"))
+		// 公开主页和关注，未登录可浏览，关注操作由用户服务要求登录
+		v1.Any("/users/*path", gatewayMiddleware.OptionalAuth(verifier, sessions), rl.UserLimit(1000), handler.ProxyService("user_service"))
+		v1.Any("/content/*path", gatewayMiddleware.OptionalAuth(verifier, sessions), rl.UserLimit(1000), handler.ProxyService("content_service"))
+		v1.Any("/reading/*path", gatewayMiddleware.AuthMiddleware(verifier, sessions), rl.UserLimit(2000), handler.ProxyService("reading_service"))
+		v1.Any("/payment/*path", gatewayMiddleware.AuthMiddleware(verifier, sessions), rl.UserLimit(100), rl.IPLimit(50), handler.ProxyService("payment_service"))
+		v1.Any("/download/*path", gatewayMiddleware.AuthMiddleware(verifier, sessions), gatewayMiddleware.AntiLeechMiddleware(), rl.UserLimit(50), handler.ProxyService("download_service"))
+		v1.Any("/notification/*path", gatewayMiddleware.AuthMiddleware(verifier, sessions), rl.UserLimit(200), handler.ProxyService("notification_service"))
+
+		// 管理接口：用户管理允许运营（moderator），其余仅管理员
+		v1.Any("/admin/*path", gatewayMiddleware.AuthMiddleware(verifier, sessions), rl.UserLimit(500), handler.ProxyByPrefix(map[string][]gin.HandlerFunc{
+			"users":   {gatewayMiddleware.RoleMiddleware("admin", "moderator"), handler.ProxyService("user_service")},
+			"content": {gatewayMiddleware.RoleMiddleware("admin"), handler.ProxyService("content_service")},
+			"payment": {gatewayMiddleware.RoleMiddleware("admin"), handler.ProxyService("payment_service")},
+		}))
 	}
 	return router
 }
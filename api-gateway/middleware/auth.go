@@ -1,54 +1,116 @@
 package middleware
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
 	"net/http"
 	"reading-microservices/shared/utils"
 	"strings"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// accessSessionPrefix 与用户服务 SessionManager 写入的 access session key 一致
+const accessSessionPrefix = "access_session:"
+
+// SessionChecker 检查 access token 对应的会话是否还在用户服务的 Redis 中。
+// 封禁、强制下线、修改角色和修改密码时用户服务会删除会话，网关据此让已签发的 token 立即失效
+type SessionChecker struct {
+	rdb *redis.Client
+}
+
+func NewSessionChecker(rdb *redis.Client) *SessionChecker {
+	return &SessionChecker{rdb: rdb}
+}
+
+// Active 会话存在时返回 true；Redis 不可用时返回错误，由调用方拒绝请求
+func (s *SessionChecker) Active(ctx context.Context, token string) (bool, error) {
+	n, err := s.rdb.Exists(ctx, accessSessionPrefix+token).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func bearerToken(c *gin.Context) string {
+	token := c.GetHeader("Authorization")
+	if len(token) > 7 && strings.ToUpper(token[:7]) == "BEARER " {
+		token = token[7:]
+	}
+	return token
+}
+
+// clearIdentity 移除客户端自带的身份头，只有网关验证通过后才设置
+func clearIdentity(c *gin.Context) {
+	c.Request.Header.Del("X-User-ID")
+	c.Request.Header.Del("X-Username")
+	c.Request.Header.Del("X-User-Role")
+}
+
+// AuthMiddleware 验证 token 签名并确认会话未被撤销；后续 RoleMiddleware 使用的角色
+// 来自仍然有效的会话，角色变更后旧 token 随会话一起失效
+func AuthMiddleware(verifier utils.TokenVerifier, sessions *SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
+		clearIdentity(c)
+		token := bearerToken(c)
 		if token == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Authorization required"})
 			c.Abort()
 			return
 		}
-		if len(token) > 7 && strings.ToUpper(token[:7]) == "BEARER " {
-			token = token[7:]
-		}
-		claims, err := utils.ParseToken(token, jwtSecret)
+		claims, err := verifier.VerifyToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Invalid token"})
 			c.Abort()
 			return
 		}
+		active, err := sessions.Active(c.Request.Context(), token)
+		if err != nil {
+			logrus.Errorf("Session check failed: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "Session store unavailable"})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "Session expired or revoked"})
+			c.Abort()
+			return
+		}
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Request.Header.Set("X-User-ID", claims.UserID)
 		c.Request.Header.Set("X-Username", claims.Username)
+		c.Request.Header.Set("X-User-Role", claims.Role)
 		c.Next()
 	}
 }
 
-func OptionalAuth(jwtSecret string) gin.HandlerFunc {
+// OptionalAuth token 无效或会话已撤销时按未登录处理，并去掉 Authorization 头，
+// 避免下游服务仅凭签名仍识别出用户
+func OptionalAuth(verifier utils.TokenVerifier, sessions *SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("Authorization")
+		clearIdentity(c)
+		token := bearerToken(c)
 		if token == "" {
 			c.Next()
 			return
 		}
-		if len(token) > 7 && strings.ToUpper(token[:7]) == "BEARER " {
-			token = token[7:]
+		claims, err := verifier.VerifyToken(token)
+		if err != nil {
+			c.Request.Header.Del("Authorization")
+			c.Next()
+			return
 		}
-		claims, err := utils.ParseToken(token, jwtSecret)
-		if err == nil {
-			c.Set("user_id", claims.UserID)
-			c.Set("username", claims.Username)
-			c.Request.Header.Set("X-User-ID", claims.UserID)
-			c.Request.Header.Set("X-Username", claims.Username)
+		if active, err := sessions.Active(c.Request.Context(), token); err != nil || !active {
+			c.Request.Header.Del("Authorization")
+			c.Next()
+			return
 		}
+		c.Set("user_id", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Request.Header.Set("X-User-ID", claims.UserID)
+		c.Request.Header.Set("X-Username", claims.Username)
 		c.Next()
 	}
 }
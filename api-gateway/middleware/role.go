@@ -5,13 +5,16 @@ import (
 	"net/http"
 )
 
-func RoleMiddleware(role string) gin.HandlerFunc {
+// RoleMiddleware 只允许指定角色访问，角色取自 token
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get("role")
-		if !exists || userRole != role {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": 403, "message": "Access denied"})
-			return
+		userRole := c.GetString("role")
+		for _, role := range roles {
+			if userRole != "" && userRole == role {
+				c.Next()
+				return
+			}
 		}
-		c.Next()
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"code": 403, "message": "Access denied"})
 	}
 }
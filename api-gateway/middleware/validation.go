@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/api-gateway/apidocs"
+)
+
+// RequestValidation 按聚合后的 OpenAPI 文档校验请求，不合法的请求不再转发给上游服务。
+// 只校验 JSON 请求体，因此只读取 JSON 请求体到内存，且不超过 maxBodyBytes；头像上传等其他请求体直接转发
+func RequestValidation(aggregator *apidocs.Aggregator, maxBodyBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body []byte
+		if c.Request.Body != nil && strings.HasPrefix(c.Request.Header.Get("Content-Type"), "application/json") {
+			data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"code": 413, "message": "Request body too large"})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"code": 400, "message": "Failed to read request body"})
+				return
+			}
+			body = data
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if errs := aggregator.Validator().ValidateRequest(c.Request, body); len(errs) > 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"code":    400,
+				"message": "Invalid request",
+				"errors":  errs,
+			})
+			return
+		}
+		c.Next()
+	}
+}
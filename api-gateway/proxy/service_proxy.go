@@ -18,6 +18,7 @@ type ServiceConfig struct {
 	Host        string `mapstructure:"host"`
 	Port        int    `mapstructure:"port"`
 	HealthCheck string `mapstructure:"health_check"`
+	OpenAPI     string `mapstructure:"openapi"` // 接口文档路径，默认 /openapi.json
 }
 
 type ServiceProxy struct {
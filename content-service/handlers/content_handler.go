@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 	"github.com/gin-gonic/gin"
 	"reading-microservices/shared/utils"
@@ -171,9 +172,9 @@ func (h *ContentHandler) GetNovelByID(c *gin.Context) {
 		return
 	}
 
-	novel, err := h.contentService.GetNovelByID(id)
+	novel, err := h.contentService.GetNovelByID(id, h.hideMature(c))
 	if err != nil {
-		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+		h.handleNotFound(c, err)
 		return
 	}
 
@@ -194,7 +195,7 @@ func (h *ContentHandler) GetNovelsByCategory(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
 
-	novels, total, err := h.contentService.GetNovelsByCategory(categoryID, page, size)
+	novels, total, err := h.contentService.GetNovelsByCategory(categoryID, page, size, h.hideMature(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -209,6 +210,7 @@ func (h *ContentHandler) SearchNovels(c *gin.Context) {
 		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
 		return
 	}
+	params.HideMature = h.hideMature(c)
 
 	novels, total, err := h.contentService.SearchNovels(&params)
 	if err != nil {
@@ -219,6 +221,17 @@ func (h *ContentHandler) SearchNovels(c *gin.Context) {
 	utils.PageSuccess(c, novels, total, params.Page, params.Size)
 }
 
+// SuggestNovels 搜索框输入时的补全，q 为空时返回热门搜索词
+func (h *ContentHandler) SuggestNovels(c *gin.Context) {
+	var query models.SuggestQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	utils.Success(c, h.contentService.SuggestNovels(&query, h.hideMature(c)))
+}
+
 func (h *ContentHandler) UpdateNovel(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -255,10 +268,21 @@ func (h *ContentHandler) DeleteNovel(c *gin.Context) {
 	utils.SuccessWithMessage(c, "Novel deleted successfully", nil)
 }
 
+// ReindexSearch 为缺失或过期的小说补建搜索索引
+func (h *ContentHandler) ReindexSearch(c *gin.Context) {
+	result, err := h.contentService.ReindexSearch()
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
 func (h *ContentHandler) GetFeaturedNovels(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	novels, err := h.contentService.GetFeaturedNovels(limit)
+	novels, err := h.contentService.GetFeaturedNovels(limit, h.hideMature(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -270,7 +294,7 @@ func (h *ContentHandler) GetFeaturedNovels(c *gin.Context) {
 func (h *ContentHandler) GetLatestNovels(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	novels, err := h.contentService.GetLatestNovels(limit)
+	novels, err := h.contentService.GetLatestNovels(limit, h.hideMature(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -303,9 +327,9 @@ func (h *ContentHandler) GetChapterByID(c *gin.Context) {
 		return
 	}
 
-	chapter, err := h.contentService.GetChapterByID(id)
+	chapter, err := h.contentService.GetChapterByID(id, h.hideMature(c))
 	if err != nil {
-		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+		h.handleNotFound(c, err)
 		return
 	}
 
@@ -322,8 +346,12 @@ func (h *ContentHandler) GetChaptersByNovel(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "50"))
 
-	chapters, total, err := h.contentService.GetChaptersByNovel(novelID, page, size)
+	chapters, total, err := h.contentService.GetChaptersByNovel(novelID, page, size, h.hideMature(c))
 	if err != nil {
+		if errors.Is(err, services.ErrMatureContent) {
+			utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+			return
+		}
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -346,9 +374,9 @@ func (h *ContentHandler) GetChapterByNumber(c *gin.Context) {
 		return
 	}
 
-	chapter, err := h.contentService.GetChapterByNumber(novelID, chapterNumber)
+	chapter, err := h.contentService.GetChapterByNumber(novelID, chapterNumber, h.hideMature(c))
 	if err != nil {
-		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+		h.handleNotFound(c, err)
 		return
 	}
 
@@ -391,6 +419,20 @@ func (h *ContentHandler) DeleteChapter(c *gin.Context) {
 	utils.SuccessWithMessage(c, "Chapter deleted successfully", nil)
 }
 
+// hideMature 登录用户处于青少年模式时屏蔽成人向作品
+func (h *ContentHandler) hideMature(c *gin.Context) bool {
+	return h.contentService.HideMature(c.GetString("user_id"))
+}
+
+// handleNotFound 详情接口：青少年模式下的成人向作品返回 403，其余按未找到处理
+func (h *ContentHandler) handleNotFound(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrMatureContent) {
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+		return
+	}
+	utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+}
+
 // Health check
 func (h *ContentHandler) Health(c *gin.Context) {
 	c.JSON(200, gin.H{
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"reading-microservices/content-service/models"
+	"reading-microservices/shared/openapi"
+)
+
+// OpenAPIDocument 内容服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("content-service", "1.0.0")
+	return doc.AddRoutes(
+		// 分类
+		openapi.Route{Method: "GET", Path: "/api/v1/content/categories", Summary: "获取分类列表", Tag: "分类",
+			Response: []models.Category{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/categories/:id", Summary: "获取分类详情", Tag: "分类",
+			Response: models.Category{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/categories/:id/novels", Summary: "分类下的小说", Tag: "分类",
+			Query: openapi.PageQuery{}, Response: []models.NovelListResponse{}, Paged: true},
+
+		// 标签
+		openapi.Route{Method: "GET", Path: "/api/v1/content/tags", Summary: "获取标签列表", Tag: "标签",
+			Response: []models.Tag{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/tags/:id", Summary: "获取标签详情", Tag: "标签",
+			Response: models.Tag{}},
+
+		// 小说
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/search", Summary: "搜索小说", Tag: "小说",
+			Query: models.NovelSearchParams{}, Response: []models.NovelListResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/suggest", Summary: "搜索补全", Tag: "小说",
+			Query: models.SuggestQuery{}, Response: models.SuggestResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/featured", Summary: "推荐小说", Tag: "小说",
+			Response: []models.NovelListResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/latest", Summary: "最新小说", Tag: "小说",
+			Response: []models.NovelListResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/:novel_id", Summary: "小说详情", Tag: "小说",
+			Response: models.NovelDetailResponse{}},
+
+		// 章节
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/:novel_id/chapters", Summary: "章节列表", Tag: "章节",
+			Query: openapi.PageQuery{}, Response: []models.ChapterSummary{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/novels/:novel_id/chapters/:chapter_number", Summary: "按序号获取章节", Tag: "章节",
+			Response: models.ChapterDetailResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/content/chapters/:id", Summary: "章节详情", Tag: "章节",
+			Response: models.ChapterDetailResponse{}},
+
+		// 管理接口
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/content/categories", Summary: "创建分类", Tag: "内容管理", Auth: true,
+			Body: models.CreateCategoryRequest{}, Response: models.Category{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/admin/content/categories/:id", Summary: "更新分类", Tag: "内容管理", Auth: true,
+			Body: models.UpdateCategoryRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/admin/content/categories/:id", Summary: "删除分类", Tag: "内容管理", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/content/tags", Summary: "创建标签", Tag: "内容管理", Auth: true,
+			Body: models.CreateTagRequest{}, Response: models.Tag{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/content/novels", Summary: "创建小说", Tag: "内容管理", Auth: true,
+			Body: models.CreateNovelRequest{}, Response: models.Novel{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/admin/content/novels/:id", Summary: "更新小说", Tag: "内容管理", Auth: true,
+			Body: models.UpdateNovelRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/admin/content/novels/:id", Summary: "删除小说", Tag: "内容管理", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/content/search/reindex", Summary: "补建搜索索引", Tag: "内容管理", Auth: true,
+			Response: models.SearchReindexResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/content/chapters", Summary: "创建章节", Tag: "内容管理", Auth: true,
+			Body: models.CreateChapterRequest{}, Response: models.Chapter{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/admin/content/chapters/:id", Summary: "更新章节", Tag: "内容管理", Auth: true,
+			Body: models.UpdateChapterRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/admin/content/chapters/:id", Summary: "删除章节", Tag: "内容管理", Auth: true},
+	)
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"log"
+	"time"
 
 	"reading-microservices/content-service/handlers"
 	"reading-microservices/content-service/models"
@@ -17,6 +19,9 @@ import (
 	"reading-microservices/content-service/services"
 	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/userclient"
+	"reading-microservices/shared/utils"
 )
 
 func main() {
@@ -50,21 +55,52 @@ func main() {
 		&models.Novel{},
 		&models.NovelTag{},
 		&models.Chapter{},
+		&models.SearchPosting{},
+		&models.SearchDocument{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	// 初始化Repository
 	contentRepo := repositories.NewContentRepository(db)
+	searchRepo := repositories.NewSearchRepository(db)
 
 	// 初始化Service
-	contentService := services.NewContentService(contentRepo)
+	// 用户服务客户端，查询青少年模式以屏蔽成人向作品
+	users := userclient.New(viper.GetString("user_service_url"), cfg.Internal.Token, 5*time.Second)
+	searchConfig := services.DefaultSearchConfig()
+	if err := viper.UnmarshalKey("search", &searchConfig); err != nil {
+		log.Fatal("Failed to load search config:", err)
+	}
+	searchService := services.NewSearchService(searchRepo, contentRepo, searchConfig)
+
+	// 搜索补全索引常驻内存，热门搜索词来自阅读服务的搜索历史
+	suggestConfig := services.DefaultSuggestConfig()
+	if err := viper.UnmarshalKey("suggest", &suggestConfig); err != nil {
+		log.Fatal("Failed to load suggest config:", err)
+	}
+	trending := services.NewTrendingClient(viper.GetString("reading_service_url"), cfg.Internal.Token)
+	suggestService := services.NewSuggestService(contentRepo, trending, suggestConfig)
+	suggestService.Start()
+
+	contentService := services.NewContentService(contentRepo, users, searchService, suggestService)
+
+	// 补建缺失或过期的搜索索引，不阻塞启动
+	go func() {
+		result, err := searchService.Reindex()
+		if err != nil {
+			logrus.Warnf("Search reindex incomplete: %v", err)
+		}
+		if result != nil {
+			logrus.Infof("Search reindex: %d indexed, %d removed", result.Indexed, result.Removed)
+		}
+	}()
 
 	// 初始化Handler
 	contentHandler := handlers.NewContentHandler(contentService)
 
 	// 初始化路由
-	router := setupRouter(contentHandler, cfg.JWT.Secret)
+	router := setupRouter(contentHandler, cfg.JWT.NewVerifier())
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -121,7 +157,7 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(contentHandler *handlers.ContentHandler, jwtSecret string) *gin.Engine {
+func setupRouter(contentHandler *handlers.ContentHandler, verifier utils.TokenVerifier) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -132,11 +168,15 @@ func setupRouter(contentHandler *handlers.ContentHandler, jwtSecret string) *gin
 	// 健康检查
 	router.GET("/health", contentHandler.Health)
 
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
 	// API路由
 	v1 := router.Group("/api/v1")
 	{
-		// 公开API - 不需要认证
+		// 公开API - 不需要认证，携带 token 时按青少年模式过滤
 		public := v1.Group("/content")
+		public.Use(middleware.OptionalJWTAuth(verifier))
 		{
 			// 分类
 			public.GET("/categories", contentHandler.GetCategories)
@@ -149,6 +189,7 @@ func setupRouter(contentHandler *handlers.ContentHandler, jwtSecret string) *gin
 
 			// 小说
 			public.GET("/novels/search", contentHandler.SearchNovels)
+			public.GET("/novels/suggest", contentHandler.SuggestNovels)
 			public.GET("/novels/featured", contentHandler.GetFeaturedNovels)
 			public.GET("/novels/latest", contentHandler.GetLatestNovels)
 			public.GET("/novels/:novel_id", contentHandler.GetNovelByID)
@@ -162,7 +203,7 @@ func setupRouter(contentHandler *handlers.ContentHandler, jwtSecret string) *gin
 
 		// 管理API - 需要认证
 		admin := v1.Group("/admin/content")
-		admin.Use(middleware.JWTAuth(jwtSecret))
+		admin.Use(middleware.JWTAuth(verifier))
 		{
 			// 分类管理
 			admin.POST("/categories", contentHandler.CreateCategory)
@@ -176,6 +217,7 @@ func setupRouter(contentHandler *handlers.ContentHandler, jwtSecret string) *gin
 			admin.POST("/novels", contentHandler.CreateNovel)
 			admin.PUT("/novels/:id", contentHandler.UpdateNovel)
 			admin.DELETE("/novels/:id", contentHandler.DeleteNovel)
+			admin.POST("/search/reindex", contentHandler.ReindexSearch)
 
 			// 章节管理
 			admin.POST("/chapters", contentHandler.CreateChapter)
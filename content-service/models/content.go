@@ -45,6 +45,7 @@ type Novel struct {
 	RatingCount    int        `gorm:"default:0" json:"rating_count"`
 	IsFeatured     bool       `gorm:"default:false" json:"is_featured"`
 	IsFree         bool       `gorm:"default:true" json:"is_free"`
+	IsMature       bool       `gorm:"default:false;index" json:"is_mature"` // 成人向作品，青少年模式下不展示
 	Price          float64    `gorm:"type:decimal(10,2);default:0.00" json:"price"`
 	PublishDate    *time.Time `gorm:"type:date" json:"publish_date"`
 	LastUpdatedAt  *time.Time `json:"last_updated_at"`
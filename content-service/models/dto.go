@@ -46,6 +46,7 @@ type CreateNovelRequest struct {
 	Status      *string `json:"status" binding:"omitempty,oneof=ongoing completed paused"`
 	IsFeatured  *bool   `json:"is_featured"`
 	IsFree      *bool   `json:"is_free"`
+	IsMature    *bool   `json:"is_mature"` // 成人向作品，青少年模式下不展示
 	Price       *float64 `json:"price"`
 	TagIDs      []string `json:"tag_ids"`
 }
@@ -59,6 +60,7 @@ type UpdateNovelRequest struct {
 	Status      *string  `json:"status" binding:"omitempty,oneof=ongoing completed paused"`
 	IsFeatured  *bool    `json:"is_featured"`
 	IsFree      *bool    `json:"is_free"`
+	IsMature    *bool    `json:"is_mature"`
 	Price       *float64 `json:"price"`
 	TagIDs      []string `json:"tag_ids"`
 }
@@ -89,6 +91,13 @@ type NovelSearchParams struct {
 	OrderBy    string `form:"order_by"`
 	Page       int    `form:"page"`
 	Size       int    `form:"size"`
+	HideMature bool   `form:"-"` // 青少年模式下过滤成人向作品，由服务端设置
+}
+
+// SuggestQuery 搜索补全参数，q 可以是书名、作者的开头，也可以是全拼或拼音首字母
+type SuggestQuery struct {
+	Q     string `form:"q" binding:"max=50"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=20"`
 }
 
 type ChapterListParams struct {
@@ -113,10 +122,40 @@ type NovelListResponse struct {
 	RatingCount   int      `json:"rating_count"`
 	IsFeatured    bool     `json:"is_featured"`
 	IsFree        bool     `json:"is_free"`
+	IsMature      bool     `json:"is_mature"`
 	Price         float64  `json:"price"`
 	Tags          []Tag    `json:"tags"`
 	CreatedAt     string   `json:"created_at"`
 	UpdatedAt     string   `json:"updated_at"`
+
+	// 关键词搜索时返回，命中部分用 <em></em> 标出
+	Highlight *NovelHighlight `json:"highlight,omitempty"`
+}
+
+// NovelHighlight 搜索结果高亮，内容已做 HTML 转义，简介只返回命中位置附近的摘要
+type NovelHighlight struct {
+	Title       string `json:"title"`
+	Author      string `json:"author"`
+	Description string `json:"description,omitempty"`
+}
+
+// SuggestItem 补全候选，type 为 title、author 或 keyword（热门搜索词）
+type SuggestItem struct {
+	Text    string `json:"text"`
+	Type    string `json:"type"`
+	NovelID string `json:"novel_id,omitempty"` // type 为 title 时可直接打开该小说
+}
+
+// SuggestResponse q 为空时只返回热门搜索词
+type SuggestResponse struct {
+	Suggestions []SuggestItem `json:"suggestions"`
+	Hot         []string      `json:"hot"`
+}
+
+// SearchReindexResponse 补建索引的结果
+type SearchReindexResponse struct {
+	Indexed int `json:"indexed"`
+	Removed int `json:"removed"`
 }
 
 type NovelDetailResponse struct {
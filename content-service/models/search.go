@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchPosting 倒排索引：词项在某本小说某个字段中出现的次数。
+// 词项列使用二进制排序规则，避免大小写或重音不同的词项被当成重复主键
+type SearchPosting struct {
+	Term    string `gorm:"type:varchar(64) COLLATE utf8mb4_bin;primarykey" json:"term"`
+	NovelID string `gorm:"type:varchar(36);primarykey;index" json:"novel_id"`
+	Field   string `gorm:"type:varchar(20);primarykey" json:"field"`
+	Freq    int    `gorm:"not null" json:"freq"`
+}
+
+// SearchDocument 已建立索引的小说，小说的更新时间晚于 IndexedAt 时需要重建索引
+type SearchDocument struct {
+	NovelID   string    `gorm:"type:varchar(36);primarykey" json:"novel_id"`
+	IndexedAt time.Time `gorm:"not null" json:"indexed_at"`
+}
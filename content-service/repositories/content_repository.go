@@ -25,13 +25,16 @@ type ContentRepository interface {
 	// CreateNovel Novel
 	CreateNovel(novel *models.Novel) error
 	GetNovelByID(id string) (*models.Novel, error)
-	GetNovelsByCategory(categoryID string, page, size int) ([]models.Novel, int64, error)
+	GetNovelsByCategory(categoryID string, page, size int, hideMature bool) ([]models.Novel, int64, error)
 	SearchNovels(params *models.NovelSearchParams) ([]models.Novel, int64, error)
+	GetSearchCandidates(ids []string, params *models.NovelSearchParams) ([]models.Novel, error)
+	GetNovelsByIDs(ids []string) ([]models.Novel, error)
+	GetSuggestSources() ([]models.Novel, error)
 	UpdateNovel(novel *models.Novel) error
 	DeleteNovel(id string) error
 	UpdateNovelStats(novelID string, views *int64, rating *float64, ratingCount *int) error
-	GetFeaturedNovels(limit int) ([]models.Novel, error)
-	GetLatestNovels(limit int) ([]models.Novel, error)
+	GetFeaturedNovels(limit int, hideMature bool) ([]models.Novel, error)
+	GetLatestNovels(limit int, hideMature bool) ([]models.Novel, error)
 
 	// CreateChapter Chapter
 	CreateChapter(chapter *models.Chapter) error
@@ -141,11 +144,14 @@ func (r *contentRepository) GetNovelByID(id string) (*models.Novel, error) {
 	return &novel, nil
 }
 
-func (r *contentRepository) GetNovelsByCategory(categoryID string, page, size int) ([]models.Novel, int64, error) {
+func (r *contentRepository) GetNovelsByCategory(categoryID string, page, size int, hideMature bool) ([]models.Novel, int64, error) {
 	var novels []models.Novel
 	var total int64
 
 	query := r.db.Model(&models.Novel{}).Where("category_id = ?", categoryID)
+	if hideMature {
+		query = query.Where("is_mature = ?", false)
+	}
 
 	// 获取总数
 	query.Count(&total)
@@ -159,39 +165,12 @@ func (r *contentRepository) GetNovelsByCategory(categoryID string, page, size in
 	return novels, total, err
 }
 
+// SearchNovels 按条件筛选小说，关键词搜索走倒排索引（见 SearchService），这里不处理 Keyword
 func (r *contentRepository) SearchNovels(params *models.NovelSearchParams) ([]models.Novel, int64, error) {
 	var novels []models.Novel
 	var total int64
 
-	query := r.db.Model(&models.Novel{})
-
-	// 关键词搜索
-	if params.Keyword != "" {
-		keyword := "%" + params.Keyword + "%"
-		query = query.Where("title LIKE ? OR author LIKE ? OR description LIKE ?", keyword, keyword, keyword)
-	}
-
-	// 分类筛选
-	if params.CategoryID != "" {
-		query = query.Where("category_id = ?", params.CategoryID)
-	}
-
-	// 标签筛选
-	if params.TagIDs != "" {
-		tagIDs := strings.Split(params.TagIDs, ",")
-		query = query.Joins("JOIN novel_tags ON novels.id = novel_tags.novel_id").
-			Where("novel_tags.tag_id IN ?", tagIDs)
-	}
-
-	// 状态筛选
-	if params.Status != "" {
-		query = query.Where("status = ?", params.Status)
-	}
-
-	// 免费筛选
-	if params.IsFree != "" {
-		query = query.Where("is_free = ?", params.IsFree == "true")
-	}
+	query := applyNovelFilters(r.db.Model(&models.Novel{}), params)
 
 	// 获取总数
 	query.Count(&total)
@@ -225,6 +204,69 @@ func (r *contentRepository) SearchNovels(params *models.NovelSearchParams) ([]mo
 	return novels, total, err
 }
 
+// GetSearchCandidates 对搜索命中的小说应用筛选条件，只取排序需要的字段
+func (r *contentRepository) GetSearchCandidates(ids []string, params *models.NovelSearchParams) ([]models.Novel, error) {
+	var novels []models.Novel
+	if len(ids) == 0 {
+		return novels, nil
+	}
+	query := applyNovelFilters(r.db.Model(&models.Novel{}), params)
+	err := query.Select("id", "views_count", "rating", "rating_count", "total_chapters", "last_updated_at", "created_at").
+		Where("id IN ?", ids).Find(&novels).Error
+	return novels, err
+}
+
+// GetNovelsByIDs 不保证返回顺序与 ids 一致
+func (r *contentRepository) GetNovelsByIDs(ids []string) ([]models.Novel, error) {
+	var novels []models.Novel
+	if len(ids) == 0 {
+		return novels, nil
+	}
+	err := r.db.Preload("Category").Preload("Tags").Where("id IN ?", ids).Find(&novels).Error
+	return novels, err
+}
+
+// GetSuggestSources 构建搜索补全所需的书名、作者、阅读量和成人向标记
+func (r *contentRepository) GetSuggestSources() ([]models.Novel, error) {
+	var novels []models.Novel
+	err := r.db.Model(&models.Novel{}).
+		Select("id", "title", "author", "views_count", "is_mature").
+		Find(&novels).Error
+	return novels, err
+}
+
+// applyNovelFilters 分类、标签、状态、免费和青少年模式筛选。
+// 标签用 EXISTS 子查询，小说同时命中多个标签时不会重复出现
+func applyNovelFilters(query *gorm.DB, params *models.NovelSearchParams) *gorm.DB {
+	// 分类筛选
+	if params.CategoryID != "" {
+		query = query.Where("category_id = ?", params.CategoryID)
+	}
+
+	// 标签筛选
+	if params.TagIDs != "" {
+		tagIDs := strings.Split(params.TagIDs, ",")
+		query = query.Where("EXISTS (SELECT 1 FROM novel_tags WHERE novel_tags.novel_id = novels.id AND novel_tags.tag_id IN ?)", tagIDs)
+	}
+
+	// 状态筛选
+	if params.Status != "" {
+		query = query.Where("status = ?", params.Status)
+	}
+
+	// 免费筛选
+	if params.IsFree != "" {
+		query = query.Where("is_free = ?", params.IsFree == "true")
+	}
+
+	// 青少年模式
+	if params.HideMature {
+		query = query.Where("is_mature = ?", false)
+	}
+
+	return query
+}
+
 func (r *contentRepository) UpdateNovel(novel *models.Novel) error {
 	return r.db.Save(novel).Error
 }
@@ -249,17 +291,24 @@ func (r *contentRepository) UpdateNovelStats(novelID string, views *int64, ratin
 	return r.db.Model(&models.Novel{}).Where("id = ?", novelID).Updates(updates).Error
 }
 
-func (r *contentRepository) GetFeaturedNovels(limit int) ([]models.Novel, error) {
+func (r *contentRepository) GetFeaturedNovels(limit int, hideMature bool) ([]models.Novel, error) {
 	var novels []models.Novel
-	err := r.db.Where("is_featured = ?", true).
-		Preload("Category").Preload("Tags").
+	query := r.db.Where("is_featured = ?", true)
+	if hideMature {
+		query = query.Where("is_mature = ?", false)
+	}
+	err := query.Preload("Category").Preload("Tags").
 		Order("views_count DESC").Limit(limit).Find(&novels).Error
 	return novels, err
 }
 
-func (r *contentRepository) GetLatestNovels(limit int) ([]models.Novel, error) {
+func (r *contentRepository) GetLatestNovels(limit int, hideMature bool) ([]models.Novel, error) {
 	var novels []models.Novel
-	err := r.db.Preload("Category").Preload("Tags").
+	query := r.db.Model(&models.Novel{})
+	if hideMature {
+		query = query.Where("is_mature = ?", false)
+	}
+	err := query.Preload("Category").Preload("Tags").
 		Order("created_at DESC").Limit(limit).Find(&novels).Error
 	return novels, err
 }
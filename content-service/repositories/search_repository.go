@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reading-microservices/content-service/models"
+)
+
+type SearchRepository interface {
+	ReplaceDocument(doc *models.SearchDocument, postings []models.SearchPosting) error
+	DeleteDocument(novelID string) error
+	GetDocFreqs(terms []string) (map[string]int64, error)
+	TopScores(query ScoreQuery) ([]ScoredNovel, error)
+	CountDocuments() (int64, error)
+	GetStaleNovelIDs() ([]string, error)
+	GetOrphanDocumentIDs() ([]string, error)
+}
+
+// ScoreQuery 按小说汇总文本相关度的参数
+type ScoreQuery struct {
+	TermWeights map[string]float64 // 各词项的 IDF，只统计这些词项
+	FieldBoosts map[string]float64 // 字段权重，未配置的字段按 1 计算
+	K1          float64            // 词频饱和参数
+	MinMatched  int                // 至少命中的词项数
+	Limit       int                // 取得分最高的前若干本，0 为不限
+}
+
+type ScoredNovel struct {
+	NovelID string
+	Score   float64
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+// ReplaceDocument 在一个事务中替换小说的全部词项并更新索引时间
+func (r *searchRepository) ReplaceDocument(doc *models.SearchDocument, postings []models.SearchPosting) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("novel_id = ?", doc.NovelID).Delete(&models.SearchPosting{}).Error; err != nil {
+			return err
+		}
+		if len(postings) > 0 {
+			if err := tx.CreateInBatches(postings, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "novel_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"indexed_at"}),
+		}).Create(doc).Error
+	})
+}
+
+func (r *searchRepository) DeleteDocument(novelID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("novel_id = ?", novelID).Delete(&models.SearchPosting{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("novel_id = ?", novelID).Delete(&models.SearchDocument{}).Error
+	})
+}
+
+// GetDocFreqs 每个词项出现在多少本小说中，没有出现的词项不返回
+func (r *searchRepository) GetDocFreqs(terms []string) (map[string]int64, error) {
+	freqs := make(map[string]int64, len(terms))
+	if len(terms) == 0 {
+		return freqs, nil
+	}
+	var rows []struct {
+		Term string
+		Docs int64
+	}
+	err := r.db.Model(&models.SearchPosting{}).
+		Select("term, COUNT(DISTINCT novel_id) AS docs").
+		Where("term IN ?", terms).
+		Group("term").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		freqs[row.Term] = row.Docs
+	}
+	return freqs, nil
+}
+
+// TopScores 在数据库中按小说汇总相关度并排序，只返回前 Limit 本，常见词项命中大量小说时不必取回全部词项记录
+func (r *searchRepository) TopScores(query ScoreQuery) ([]ScoredNovel, error) {
+	if len(query.TermWeights) == 0 {
+		return []ScoredNovel{}, nil
+	}
+	terms := make([]string, 0, len(query.TermWeights))
+	var termCase, fieldCase strings.Builder
+	var args []interface{}
+	termCase.WriteString("CASE term")
+	for term, weight := range query.TermWeights {
+		terms = append(terms, term)
+		termCase.WriteString(" WHEN ? THEN ?")
+		args = append(args, term, weight)
+	}
+	termCase.WriteString(" ELSE 0 END")
+	fieldCase.WriteString("CASE field")
+	for field, boost := range query.FieldBoosts {
+		fieldCase.WriteString(" WHEN ? THEN ?")
+		args = append(args, field, boost)
+	}
+	fieldCase.WriteString(" ELSE 1 END")
+	args = append(args, query.K1+1, query.K1)
+
+	expr := "novel_id, SUM((" + termCase.String() + ") * (" + fieldCase.String() + ") * freq * ? / (freq + ?)) AS score"
+	db := r.db.Model(&models.SearchPosting{}).
+		Select(expr, args...).
+		Where("term IN ?", terms).
+		Group("novel_id").
+		Having("COUNT(DISTINCT term) >= ?", query.MinMatched).
+		Order("score DESC, novel_id")
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	var scores []ScoredNovel
+	err := db.Scan(&scores).Error
+	return scores, err
+}
+
+func (r *searchRepository) CountDocuments() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SearchDocument{}).Count(&count).Error
+	return count, err
+}
+
+// GetStaleNovelIDs 还没有索引或索引后又被修改过的小说
+func (r *searchRepository) GetStaleNovelIDs() ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.Novel{}).
+		Joins("LEFT JOIN search_documents ON search_documents.novel_id = novels.id").
+		Where("search_documents.novel_id IS NULL OR search_documents.indexed_at < novels.updated_at").
+		Pluck("novels.id", &ids).Error
+	return ids, err
+}
+
+// GetOrphanDocumentIDs 小说已被删除但索引还在的文档
+func (r *searchRepository) GetOrphanDocumentIDs() ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.SearchDocument{}).
+		Joins("LEFT JOIN novels ON novels.id = search_documents.novel_id").
+		Where("novels.id IS NULL").
+		Pluck("search_documents.novel_id", &ids).Error
+	return ids, err
+}
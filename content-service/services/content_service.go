@@ -1,13 +1,19 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"github.com/sirupsen/logrus"
 	"reading-microservices/content-service/models"
 	"reading-microservices/content-service/repositories"
+	"reading-microservices/shared/userclient"
 	"strings"
 	"time"
 )
 
+// ErrMatureContent 青少年模式下访问成人向作品
+var ErrMatureContent = errors.New("content is not available in teen mode")
+
 type ContentService interface {
 	// Category
 	CreateCategory(req *models.CreateCategoryRequest) (*models.Category, error)
@@ -23,30 +29,38 @@ type ContentService interface {
 
 	// Novel
 	CreateNovel(req *models.CreateNovelRequest) (*models.Novel, error)
-	GetNovelByID(id string) (*models.NovelDetailResponse, error)
-	GetNovelsByCategory(categoryID string, page, size int) ([]models.NovelListResponse, int64, error)
+	GetNovelByID(id string, hideMature bool) (*models.NovelDetailResponse, error)
+	GetNovelsByCategory(categoryID string, page, size int, hideMature bool) ([]models.NovelListResponse, int64, error)
 	SearchNovels(params *models.NovelSearchParams) ([]models.NovelListResponse, int64, error)
+	SuggestNovels(query *models.SuggestQuery, hideMature bool) *models.SuggestResponse
 	UpdateNovel(id string, req *models.UpdateNovelRequest) error
 	DeleteNovel(id string) error
+	ReindexSearch() (*models.SearchReindexResponse, error)
 	UpdateNovelStats(novelID string, views *int64, rating *float64, ratingCount *int) error
-	GetFeaturedNovels(limit int) ([]models.NovelListResponse, error)
-	GetLatestNovels(limit int) ([]models.NovelListResponse, error)
+	GetFeaturedNovels(limit int, hideMature bool) ([]models.NovelListResponse, error)
+	GetLatestNovels(limit int, hideMature bool) ([]models.NovelListResponse, error)
 
 	// Chapter
 	CreateChapter(req *models.CreateChapterRequest) (*models.Chapter, error)
-	GetChapterByID(id string) (*models.ChapterDetailResponse, error)
-	GetChaptersByNovel(novelID string, page, size int) ([]models.ChapterSummary, int64, error)
-	GetChapterByNumber(novelID string, chapterNumber int) (*models.ChapterDetailResponse, error)
+	GetChapterByID(id string, hideMature bool) (*models.ChapterDetailResponse, error)
+	GetChaptersByNovel(novelID string, page, size int, hideMature bool) ([]models.ChapterSummary, int64, error)
+	GetChapterByNumber(novelID string, chapterNumber int, hideMature bool) (*models.ChapterDetailResponse, error)
 	UpdateChapter(id string, req *models.UpdateChapterRequest) error
 	DeleteChapter(id string) error
+
+	// Teen mode
+	HideMature(userID string) bool
 }
 
 type contentService struct {
-	repo repositories.ContentRepository
+	repo    repositories.ContentRepository
+	users   *userclient.Client
+	search  SearchService
+	suggest SuggestService
 }
 
-func NewContentService(repo repositories.ContentRepository) ContentService {
-	return &contentService{repo: repo}
+func NewContentService(repo repositories.ContentRepository, users *userclient.Client, search SearchService, suggest SuggestService) ContentService {
+	return &contentService{repo: repo, users: users, search: search, suggest: suggest}
 }
 
 // Category methods
@@ -162,6 +176,9 @@ func (s *contentService) CreateNovel(req *models.CreateNovelRequest) (*models.No
 	if req.IsFree != nil {
 		novel.IsFree = *req.IsFree
 	}
+	if req.IsMature != nil {
+		novel.IsMature = *req.IsMature
+	}
 	if req.Price != nil {
 		novel.Price = *req.Price
 	}
@@ -175,14 +192,19 @@ func (s *contentService) CreateNovel(req *models.CreateNovelRequest) (*models.No
 		s.repo.AddNovelTags(novel.ID, req.TagIDs)
 	}
 
+	s.indexNovel(novel.ID)
+
 	return novel, nil
 }
 
-func (s *contentService) GetNovelByID(id string) (*models.NovelDetailResponse, error) {
+func (s *contentService) GetNovelByID(id string, hideMature bool) (*models.NovelDetailResponse, error) {
 	novel, err := s.repo.GetNovelByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if hideMature && novel.IsMature {
+		return nil, ErrMatureContent
+	}
 
 	// 获取最新章节
 	latestChapters, _ := s.repo.GetLatestChapters(id, 5)
@@ -195,8 +217,8 @@ func (s *contentService) GetNovelByID(id string) (*models.NovelDetailResponse, e
 	return response, nil
 }
 
-func (s *contentService) GetNovelsByCategory(categoryID string, page, size int) ([]models.NovelListResponse, int64, error) {
-	novels, total, err := s.repo.GetNovelsByCategory(categoryID, page, size)
+func (s *contentService) GetNovelsByCategory(categoryID string, page, size int, hideMature bool) ([]models.NovelListResponse, int64, error) {
+	novels, total, err := s.repo.GetNovelsByCategory(categoryID, page, size, hideMature)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -210,6 +232,26 @@ func (s *contentService) GetNovelsByCategory(categoryID string, page, size int)
 }
 
 func (s *contentService) SearchNovels(params *models.NovelSearchParams) ([]models.NovelListResponse, int64, error) {
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+	if params.Size <= 0 {
+		params.Size = 20
+	}
+
+	if strings.TrimSpace(params.Keyword) != "" {
+		hits, total, err := s.search.Search(params)
+		if err != nil {
+			return nil, 0, err
+		}
+		responses := make([]models.NovelListResponse, len(hits))
+		for i, hit := range hits {
+			responses[i] = *s.convertToNovelListResponse(&hit.Novel)
+			responses[i].Highlight = hit.Highlight
+		}
+		return responses, total, nil
+	}
+
 	novels, total, err := s.repo.SearchNovels(params)
 	if err != nil {
 		return nil, 0, err
@@ -223,6 +265,10 @@ func (s *contentService) SearchNovels(params *models.NovelSearchParams) ([]model
 	return responses, total, nil
 }
 
+func (s *contentService) SuggestNovels(query *models.SuggestQuery, hideMature bool) *models.SuggestResponse {
+	return s.suggest.Suggest(query.Q, query.Limit, hideMature)
+}
+
 func (s *contentService) UpdateNovel(id string, req *models.UpdateNovelRequest) error {
 	novel, err := s.repo.GetNovelByID(id)
 	if err != nil {
@@ -253,6 +299,9 @@ func (s *contentService) UpdateNovel(id string, req *models.UpdateNovelRequest)
 	if req.IsFree != nil {
 		novel.IsFree = *req.IsFree
 	}
+	if req.IsMature != nil {
+		novel.IsMature = *req.IsMature
+	}
 	if req.Price != nil {
 		novel.Price = *req.Price
 	}
@@ -277,19 +326,38 @@ func (s *contentService) UpdateNovel(id string, req *models.UpdateNovelRequest)
 		}
 	}
 
+	s.indexNovel(id)
+
 	return nil
 }
 
 func (s *contentService) DeleteNovel(id string) error {
-	return s.repo.DeleteNovel(id)
+	if err := s.repo.DeleteNovel(id); err != nil {
+		return err
+	}
+	if err := s.search.RemoveNovel(id); err != nil {
+		logrus.Warnf("Failed to remove novel %s from search index: %v", id, err)
+	}
+	return nil
+}
+
+// indexNovel 小说或其标签变更后更新搜索索引。失败不影响本次修改，启动时的补建会再处理
+func (s *contentService) indexNovel(id string) {
+	if err := s.search.IndexNovel(id); err != nil {
+		logrus.Warnf("Failed to index novel %s: %v", id, err)
+	}
+}
+
+func (s *contentService) ReindexSearch() (*models.SearchReindexResponse, error) {
+	return s.search.Reindex()
 }
 
 func (s *contentService) UpdateNovelStats(novelID string, views *int64, rating *float64, ratingCount *int) error {
 	return s.repo.UpdateNovelStats(novelID, views, rating, ratingCount)
 }
 
-func (s *contentService) GetFeaturedNovels(limit int) ([]models.NovelListResponse, error) {
-	novels, err := s.repo.GetFeaturedNovels(limit)
+func (s *contentService) GetFeaturedNovels(limit int, hideMature bool) ([]models.NovelListResponse, error) {
+	novels, err := s.repo.GetFeaturedNovels(limit, hideMature)
 	if err != nil {
 		return nil, err
 	}
@@ -302,8 +370,8 @@ func (s *contentService) GetFeaturedNovels(limit int) ([]models.NovelListRespons
 	return responses, nil
 }
 
-func (s *contentService) GetLatestNovels(limit int) ([]models.NovelListResponse, error) {
-	novels, err := s.repo.GetLatestNovels(limit)
+func (s *contentService) GetLatestNovels(limit int, hideMature bool) ([]models.NovelListResponse, error) {
+	novels, err := s.repo.GetLatestNovels(limit, hideMature)
 	if err != nil {
 		return nil, err
 	}
@@ -351,16 +419,23 @@ func (s *contentService) CreateChapter(req *models.CreateChapterRequest) (*model
 	return chapter, nil
 }
 
-func (s *contentService) GetChapterByID(id string) (*models.ChapterDetailResponse, error) {
+func (s *contentService) GetChapterByID(id string, hideMature bool) (*models.ChapterDetailResponse, error) {
 	chapter, err := s.repo.GetChapterByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkMature(chapter.NovelID, hideMature); err != nil {
+		return nil, err
+	}
 
 	return s.convertToChapterDetailResponse(chapter), nil
 }
 
-func (s *contentService) GetChaptersByNovel(novelID string, page, size int) ([]models.ChapterSummary, int64, error) {
+func (s *contentService) GetChaptersByNovel(novelID string, page, size int, hideMature bool) ([]models.ChapterSummary, int64, error) {
+	if err := s.checkMature(novelID, hideMature); err != nil {
+		return nil, 0, err
+	}
+
 	chapters, total, err := s.repo.GetChaptersByNovel(novelID, page, size)
 	if err != nil {
 		return nil, 0, err
@@ -370,7 +445,11 @@ func (s *contentService) GetChaptersByNovel(novelID string, page, size int) ([]m
 	return summaries, total, nil
 }
 
-func (s *contentService) GetChapterByNumber(novelID string, chapterNumber int) (*models.ChapterDetailResponse, error) {
+func (s *contentService) GetChapterByNumber(novelID string, chapterNumber int, hideMature bool) (*models.ChapterDetailResponse, error) {
+	if err := s.checkMature(novelID, hideMature); err != nil {
+		return nil, err
+	}
+
 	chapter, err := s.repo.GetChapterByNumber(novelID, chapterNumber)
 	if err != nil {
 		return nil, err
@@ -406,6 +485,34 @@ func (s *contentService) DeleteChapter(id string) error {
 	return s.repo.DeleteChapter(id)
 }
 
+// HideMature 用户处于青少年模式时屏蔽成人向作品；未登录不屏蔽，用户服务不可用时按屏蔽处理
+func (s *contentService) HideMature(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	mode, err := s.users.GetTeenMode(context.Background(), userID)
+	if err != nil {
+		logrus.Warnf("Failed to get teen mode for user %s: %v", userID, err)
+		return true
+	}
+	return mode.Enabled
+}
+
+// checkMature 章节按所属小说判断是否为成人向
+func (s *contentService) checkMature(novelID string, hideMature bool) error {
+	if !hideMature {
+		return nil
+	}
+	novel, err := s.repo.GetNovelByID(novelID)
+	if err != nil {
+		return err
+	}
+	if novel.IsMature {
+		return ErrMatureContent
+	}
+	return nil
+}
+
 // Helper methods
 func (s *contentService) convertToNovelListResponse(novel *models.Novel) *models.NovelListResponse {
 	return &models.NovelListResponse{
@@ -423,6 +530,7 @@ func (s *contentService) convertToNovelListResponse(novel *models.Novel) *models
 		RatingCount:   novel.RatingCount,
 		IsFeatured:    novel.IsFeatured,
 		IsFree:        novel.IsFree,
+		IsMature:      novel.IsMature,
 		Price:         novel.Price,
 		Tags:          novel.Tags,
 		CreatedAt:     novel.CreatedAt.Format(time.RFC3339),
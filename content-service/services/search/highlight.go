@@ -0,0 +1,69 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+	ellipsis       = "…"
+)
+
+// Highlight 用 <em></em> 标出 text 中命中查询词项的部分，其余内容做 HTML 转义。
+// maxRunes > 0 且原文更长时，截取第一个命中位置附近 maxRunes 个字符作为摘要，没有命中时取开头
+func Highlight(text string, terms map[string]bool, maxRunes int) string {
+	runes := []rune(text)
+	marked := make([]bool, len(runes))
+	first := -1
+	for _, token := range Tokenize(text, true) {
+		if !terms[token.Term] {
+			continue
+		}
+		for i := token.Start; i < token.End; i++ {
+			marked[i] = true
+		}
+		if first < 0 || token.Start < first {
+			first = token.Start
+		}
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		// 命中位置前保留四分之一的上下文
+		if first > 0 {
+			start = first - maxRunes/4
+			if start < 0 {
+				start = 0
+			}
+		}
+		end = start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxRunes
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && marked[j] == marked[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if marked[i] {
+			b.WriteString(highlightOpen + segment + highlightClose)
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
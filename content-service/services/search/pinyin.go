@@ -0,0 +1,55 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// pinyinArgs 不带声调、多音字取最常用的读音
+var pinyinArgs = pinyin.NewArgs()
+
+// Pinyin 返回文本的全拼和首字母，如“斗破苍穹”为 doupocangqiong 和 dpcq；
+// 字母和数字原样保留（转小写），其余字符忽略
+func Pinyin(text string) (full, initials string) {
+	var f, i strings.Builder
+	for _, r := range text {
+		r = normalizeRune(r)
+		switch {
+		case unicode.Is(unicode.Han, r):
+			pys := pinyin.SinglePinyin(r, pinyinArgs)
+			if len(pys) == 0 || pys[0] == "" {
+				continue
+			}
+			f.WriteString(pys[0])
+			i.WriteByte(pys[0][0])
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			f.WriteRune(r)
+			i.WriteRune(r)
+		}
+	}
+	return f.String(), i.String()
+}
+
+// Compact 规范化后只保留文字、字母和数字，用于忽略空格和标点的前缀匹配
+func Compact(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		r = normalizeRune(r)
+		if isCJK(r) || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// hasHan 是否包含汉字
+func hasHan(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
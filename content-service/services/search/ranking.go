@@ -0,0 +1,43 @@
+package search
+
+import (
+	"math"
+)
+
+// BM25K1 词频饱和参数，同一词项出现多次时得分增长逐渐放缓
+const BM25K1 = 1.2
+
+// Weights 排序参数
+type Weights struct {
+	Fields         map[string]float64 // 字段权重，未配置的字段按 1 计算
+	Views          float64            // 阅读量权重，按 log(1+阅读量) 计入
+	Rating         float64            // 评分权重，评分人数少时按比例打折
+	MinShouldMatch float64            // 文档至少要命中的查询词项比例
+}
+
+// 文本相关度为各词项的 IDF 乘以各字段权重与饱和词频 freq*(k1+1)/(freq+k1) 之积的和，
+// 由数据库按小说汇总，只取回得分最高的若干本
+
+// IDF 词项的逆文档频率，docFreq 为包含该词项的文档数
+func IDF(totalDocs, docFreq int64) float64 {
+	if totalDocs < docFreq {
+		totalDocs = docFreq
+	}
+	df := float64(docFreq)
+	return math.Log(1 + (float64(totalDocs)-df+0.5)/(df+0.5))
+}
+
+// RequiredMatches 文档至少要命中的词项数，不少于 1
+func RequiredMatches(terms int, minShouldMatch float64) int {
+	required := int(math.Ceil(float64(terms) * minShouldMatch))
+	if required < 1 {
+		required = 1
+	}
+	return required
+}
+
+// Blend 在文本相关度的基础上按阅读量和评分加权，评分人数达到 20 人后评分权重不再打折
+func Blend(textScore float64, views int64, rating float64, ratingCount int, w Weights) float64 {
+	confidence := math.Min(float64(ratingCount), 20) / 20
+	return textScore * (1 + w.Views*math.Log1p(float64(views)) + w.Rating*rating*confidence)
+}
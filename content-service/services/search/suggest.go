@@ -0,0 +1,211 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// 补全候选的类型
+const (
+	SuggestTitle   = "title"
+	SuggestAuthor  = "author"
+	SuggestKeyword = "keyword"
+)
+
+// maxQueryRunes 参与匹配的查询前缀长度上限，避免超长输入拖慢纠错
+const maxQueryRunes = 32
+
+// SuggestEntry 一个补全候选，Weight 越大越靠前
+type SuggestEntry struct {
+	Text    string
+	Kind    string
+	NovelID string // 书名候选对应的小说
+	Weight  float64
+	Mature  bool // 青少年模式下不展示
+}
+
+type suggestKey struct {
+	key   string
+	entry int
+}
+
+// Suggester 补全索引，构建后只读，可并发查询。每个候选按原文、全拼和首字母三种形式建立前缀键
+type Suggester struct {
+	entries []SuggestEntry
+	keys    []suggestKey          // 按 key 排序，前缀匹配用二分查找
+	byFirst map[rune][]suggestKey // 纠错时只比较首字符相同的键
+}
+
+func NewSuggester(entries []SuggestEntry) *Suggester {
+	s := &Suggester{entries: entries, byFirst: make(map[rune][]suggestKey)}
+	for i, entry := range entries {
+		compact := Compact(entry.Text)
+		full, initials := Pinyin(entry.Text)
+		seen := make(map[string]bool, 3)
+		for _, key := range []string{compact, full, initials} {
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			k := suggestKey{key: key, entry: i}
+			s.keys = append(s.keys, k)
+			first := []rune(key)[0]
+			s.byFirst[first] = append(s.byFirst[first], k)
+		}
+	}
+	sort.Slice(s.keys, func(i, j int) bool {
+		return s.keys[i].key < s.keys[j].key
+	})
+	return s
+}
+
+// Suggest 前缀匹配原文、全拼或首字母；输入含汉字时同时按拼音匹配，可纠正同音错字。
+// 精确前缀不足 limit 个时再按编辑距离纠错，结果先按距离、再按权重排序，同类同文本只保留一个
+func (s *Suggester) Suggest(query string, limit int, hideMature bool) []SuggestEntry {
+	q := Compact(query)
+	if q == "" || limit <= 0 {
+		return nil
+	}
+	if runes := []rune(q); len(runes) > maxQueryRunes {
+		q = string(runes[:maxQueryRunes])
+	}
+	// 按拼音匹配到的同音字视为一处错误，排在字面匹配之后
+	variants := []string{q}
+	if hasHan(q) {
+		if full, _ := Pinyin(q); full != "" {
+			variants = append(variants, full)
+		}
+	}
+
+	distance := make(map[int]int)
+	match := func(entry, d int) {
+		if hideMature && s.entries[entry].Mature {
+			return
+		}
+		if old, ok := distance[entry]; !ok || d < old {
+			distance[entry] = d
+		}
+	}
+
+	for penalty, v := range variants {
+		start := sort.Search(len(s.keys), func(i int) bool { return s.keys[i].key >= v })
+		for i := start; i < len(s.keys) && strings.HasPrefix(s.keys[i].key, v); i++ {
+			match(s.keys[i].entry, penalty)
+		}
+	}
+
+	if len(distance) < limit {
+		for penalty, v := range variants {
+			runes := []rune(v)
+			maxDist := typoBudget(len(runes))
+			if maxDist == 0 {
+				continue
+			}
+			for _, k := range s.byFirst[runes[0]] {
+				if _, ok := distance[k.entry]; ok {
+					continue
+				}
+				if d := prefixDistance(runes, []rune(k.key), maxDist); d <= maxDist {
+					match(k.entry, d+penalty)
+				}
+			}
+		}
+	}
+
+	matched := make([]int, 0, len(distance))
+	for entry := range distance {
+		matched = append(matched, entry)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if distance[a] != distance[b] {
+			return distance[a] < distance[b]
+		}
+		if s.entries[a].Weight != s.entries[b].Weight {
+			return s.entries[a].Weight > s.entries[b].Weight
+		}
+		return s.entries[a].Text < s.entries[b].Text
+	})
+
+	results := make([]SuggestEntry, 0, limit)
+	seen := make(map[string]bool)
+	for _, entry := range matched {
+		e := s.entries[entry]
+		if seen[e.Kind+"\x00"+e.Text] {
+			continue
+		}
+		seen[e.Kind+"\x00"+e.Text] = true
+		results = append(results, e)
+		if len(results) == limit {
+			break
+		}
+	}
+	return results
+}
+
+// typoBudget 允许的编辑次数：太短的输入不纠错，较长的输入允许两处错误
+func typoBudget(n int) int {
+	switch {
+	case n >= 7:
+		return 2
+	case n >= 3:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// prefixDistance query 与 key 的某个前缀之间的最小编辑距离（相邻字符交换算一次），
+// 超过 max 时提前结束并返回 max+1
+func prefixDistance(query, key []rune, max int) int {
+	if len(key) > len(query)+max {
+		key = key[:len(query)+max]
+	}
+	// rows[i][j] 为 query[:i] 与 key[:j] 的编辑距离
+	rows := make([][]int, len(query)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(key)+1)
+		rows[i][0] = i
+	}
+	for j := 0; j <= len(key); j++ {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(query); i++ {
+		rowMin := rows[i][0]
+		for j := 1; j <= len(key); j++ {
+			cost := 1
+			if query[i-1] == key[j-1] {
+				cost = 0
+			}
+			d := minInt(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && query[i-1] == key[j-2] && query[i-2] == key[j-1] {
+				d = minInt(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+		if rowMin > max {
+			return max + 1
+		}
+	}
+
+	best := max + 1
+	for _, d := range rows[len(query)] {
+		if d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
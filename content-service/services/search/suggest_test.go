@@ -0,0 +1,112 @@
+package search
+
+import (
+	"testing"
+)
+
+func testSuggester() *Suggester {
+	return NewSuggester([]SuggestEntry{
+		{Text: "斗破苍穹", Kind: SuggestTitle, NovelID: "n1", Weight: 1},
+		{Text: "斗罗大陆", Kind: SuggestTitle, NovelID: "n2", Weight: 0.8},
+		{Text: "天蚕土豆", Kind: SuggestAuthor, Weight: 0.9},
+		{Text: "盗墓笔记", Kind: SuggestTitle, NovelID: "n3", Weight: 0.7},
+		{Text: "夜色撩人", Kind: SuggestTitle, NovelID: "n4", Weight: 0.6, Mature: true},
+		{Text: "Harry Potter", Kind: SuggestTitle, NovelID: "n5", Weight: 0.5},
+	})
+}
+
+func suggestTexts(entries []SuggestEntry) []string {
+	texts := make([]string, len(entries))
+	for i, e := range entries {
+		texts[i] = e.Text
+	}
+	return texts
+}
+
+func TestSuggest(t *testing.T) {
+	s := testSuggester()
+	tests := []struct {
+		name       string
+		query      string
+		limit      int
+		hideMature bool
+		wantFirst  string // 为空表示不应有结果
+		wantCount  int    // 为 0 时不检查
+	}{
+		{name: "首字母", query: "dp", limit: 5, wantFirst: "斗破苍穹", wantCount: 1},
+		{name: "首字母匹配多个按权重排序", query: "d", limit: 5, wantFirst: "斗破苍穹", wantCount: 3},
+		{name: "全拼", query: "doupo", limit: 5, wantFirst: "斗破苍穹"},
+		{name: "原文前缀", query: "斗罗", limit: 5, wantFirst: "斗罗大陆"},
+		{name: "忽略空格和大小写", query: "harry pot", limit: 5, wantFirst: "Harry Potter"},
+		{name: "首字母错一个", query: "dpcp", limit: 5, wantFirst: "斗破苍穹"},
+		{name: "全拼错一个", query: "tiancan", limit: 5, wantFirst: "天蚕土豆"},
+		{name: "相邻字母交换", query: "dmbj", limit: 5, wantFirst: "盗墓笔记"},
+		{name: "同音错字", query: "斗坡", limit: 5, wantFirst: "斗破苍穹"},
+		{name: "太短不纠错", query: "xp", limit: 5},
+		{name: "错太多", query: "xyzw", limit: 5},
+		{name: "limit 截断", query: "d", limit: 1, wantFirst: "斗破苍穹", wantCount: 1},
+		{name: "青少年模式隐藏成人向", query: "ysl", limit: 5, hideMature: true},
+		{name: "非青少年模式展示成人向", query: "ysl", limit: 5, wantFirst: "夜色撩人"},
+		{name: "空查询", query: " ", limit: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Suggest(tt.query, tt.limit, tt.hideMature)
+			if tt.wantFirst == "" {
+				if len(got) != 0 {
+					t.Fatalf("Suggest(%q) = %v, want none", tt.query, suggestTexts(got))
+				}
+				return
+			}
+			if len(got) == 0 || got[0].Text != tt.wantFirst {
+				t.Fatalf("Suggest(%q) = %v, want %q first", tt.query, suggestTexts(got), tt.wantFirst)
+			}
+			if tt.wantCount > 0 && len(got) != tt.wantCount {
+				t.Errorf("Suggest(%q) = %v, want %d results", tt.query, suggestTexts(got), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestSuggestExactBeforeTypo(t *testing.T) {
+	s := NewSuggester([]SuggestEntry{
+		{Text: "dpca", Kind: SuggestKeyword, Weight: 1},
+		{Text: "dpcb", Kind: SuggestKeyword, Weight: 0.1},
+	})
+	got := s.Suggest("dpcb", 5, false)
+	if len(got) != 2 || got[0].Text != "dpcb" {
+		t.Fatalf("Suggest = %v, want exact match before the heavier typo match", suggestTexts(got))
+	}
+}
+
+func TestSuggestDeduplicates(t *testing.T) {
+	s := NewSuggester([]SuggestEntry{
+		{Text: "斗破苍穹", Kind: SuggestTitle, NovelID: "n1", Weight: 1},
+		{Text: "斗破苍穹", Kind: SuggestTitle, NovelID: "n9", Weight: 0.2},
+		{Text: "斗破苍穹", Kind: SuggestKeyword, Weight: 0.5},
+	})
+	got := s.Suggest("dp", 5, false)
+	if len(got) != 2 || got[0].NovelID != "n1" || got[1].Kind != SuggestKeyword {
+		t.Fatalf("Suggest = %+v, want one title and one keyword", got)
+	}
+}
+
+func TestPrefixDistance(t *testing.T) {
+	tests := []struct {
+		query, key string
+		max        int
+		want       int
+	}{
+		{query: "dpcq", key: "dpcq", max: 1, want: 0},
+		{query: "dpc", key: "dpcq", max: 1, want: 0},
+		{query: "dpcp", key: "dpcq", max: 1, want: 1},
+		{query: "pdcq", key: "dpcq", max: 1, want: 1},
+		{query: "dcq", key: "dpcq", max: 1, want: 1},
+		{query: "xyzw", key: "dpcq", max: 1, want: 2},
+	}
+	for _, tt := range tests {
+		if got := prefixDistance([]rune(tt.query), []rune(tt.key), tt.max); got != tt.want {
+			t.Errorf("prefixDistance(%q, %q, %d) = %d, want %d", tt.query, tt.key, tt.max, got, tt.want)
+		}
+	}
+}
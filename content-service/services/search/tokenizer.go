@@ -0,0 +1,98 @@
+package search
+
+import (
+	"unicode"
+)
+
+// 索引的字段
+const (
+	FieldTitle       = "title"
+	FieldAuthor      = "author"
+	FieldTag         = "tag"
+	FieldDescription = "description"
+)
+
+// maxWordRunes 英文单词、数字串超过该长度时截断，保证词项能放进索引列
+const maxWordRunes = 32
+
+// Token 分词结果，Start、End 为词项在原文中的字符（rune）下标，高亮时使用
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize 中日韩文字按相邻两字切分（二元组），unigrams 为 true 时同时输出单字，
+// 只有一个字的片段总是输出单字；字母和数字按连续片段成词并转为小写；其余字符视为分隔符
+func Tokenize(text string, unigrams bool) []Token {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = normalizeRune(r)
+	}
+
+	var tokens []Token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			j := i
+			for j < len(runes) && isCJK(runes[j]) {
+				j++
+			}
+			for k := i; k < j; k++ {
+				if unigrams || j-i == 1 {
+					tokens = append(tokens, Token{Term: string(runes[k]), Start: k, End: k + 1})
+				}
+				if k+1 < j {
+					tokens = append(tokens, Token{Term: string(runes[k : k+2]), Start: k, End: k + 2})
+				}
+			}
+			i = j
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && !isCJK(runes[j]) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+				j++
+			}
+			word := runes[i:j]
+			if len(word) > maxWordRunes {
+				word = word[:maxWordRunes]
+			}
+			tokens = append(tokens, Token{Term: string(word), Start: i, End: j})
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// QueryTerms 查询串的去重词项，顺序与出现顺序一致
+func QueryTerms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, token := range Tokenize(query, false) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+	return terms
+}
+
+// normalizeRune 全角转半角并转小写，一个字符只映射为一个字符，保证下标与原文对应
+func normalizeRune(r rune) rune {
+	switch {
+	case r == 0x3000:
+		r = ' '
+	case r >= 0xFF01 && r <= 0xFF5E:
+		r -= 0xFEE0
+	}
+	return unicode.ToLower(r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
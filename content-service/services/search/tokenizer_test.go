@@ -0,0 +1,125 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		unigrams bool
+		want     []Token
+	}{
+		{
+			name: "中文按二元组切分",
+			text: "斗破苍穹",
+			want: []Token{{"斗破", 0, 2}, {"破苍", 1, 3}, {"苍穹", 2, 4}},
+		},
+		{
+			name:     "同时输出单字",
+			text:     "斗破",
+			unigrams: true,
+			want:     []Token{{"斗", 0, 1}, {"斗破", 0, 2}, {"破", 1, 2}},
+		},
+		{
+			name: "单个汉字总是输出",
+			text: "我 的",
+			want: []Token{{"我", 0, 1}, {"的", 2, 3}},
+		},
+		{
+			name: "字母数字连续成词并转小写",
+			text: "Harry Potter 7",
+			want: []Token{{"harry", 0, 5}, {"potter", 6, 12}, {"7", 13, 14}},
+		},
+		{
+			name: "中英混排在文字边界切开",
+			text: "第1章abc",
+			want: []Token{{"第", 0, 1}, {"1", 1, 2}, {"章", 2, 3}, {"abc", 3, 6}},
+		},
+		{
+			name: "全角转半角，下标与原文一致",
+			text: "ＡＢＣ　１２",
+			want: []Token{{"abc", 0, 3}, {"12", 4, 6}},
+		},
+		{
+			name: "标点是分隔符",
+			text: "《凡人·修仙》",
+			want: []Token{{"凡人", 1, 3}, {"修仙", 4, 6}},
+		},
+		{
+			name: "空串",
+			text: "  ,. ",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text, tt.unigrams); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q, %v) = %v, want %v", tt.text, tt.unigrams, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeTruncatesLongWords(t *testing.T) {
+	long := strings.Repeat("a", maxWordRunes+10)
+	tokens := Tokenize(long, false)
+	if len(tokens) != 1 {
+		t.Fatalf("got %d tokens, want 1", len(tokens))
+	}
+	if len(tokens[0].Term) != maxWordRunes || tokens[0].End != len(long) {
+		t.Errorf("token = %+v, want term truncated to %d and end at %d", tokens[0], maxWordRunes, len(long))
+	}
+}
+
+func TestQueryTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "斗破苍穹", want: []string{"斗破", "破苍", "苍穹"}},
+		{query: "哈哈哈", want: []string{"哈哈"}},
+		{query: "Go go GO 语言", want: []string{"go", "语言"}},
+		{query: "！？", want: nil},
+	}
+	for _, tt := range tests {
+		if got := QueryTerms(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryTerms(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRequiredMatches(t *testing.T) {
+	tests := []struct {
+		terms          int
+		minShouldMatch float64
+		want           int
+	}{
+		{terms: 3, minShouldMatch: 0, want: 1},
+		{terms: 3, minShouldMatch: 0.5, want: 2},
+		{terms: 4, minShouldMatch: 0.75, want: 3},
+		{terms: 4, minShouldMatch: 1, want: 4},
+		{terms: 0, minShouldMatch: 1, want: 1},
+	}
+	for _, tt := range tests {
+		if got := RequiredMatches(tt.terms, tt.minShouldMatch); got != tt.want {
+			t.Errorf("RequiredMatches(%d, %v) = %d, want %d", tt.terms, tt.minShouldMatch, got, tt.want)
+		}
+	}
+}
+
+func TestIDF(t *testing.T) {
+	if rare, common := IDF(1000, 1), IDF(1000, 900); rare <= common {
+		t.Errorf("IDF of rare term %v <= common term %v", rare, common)
+	}
+	if got := IDF(10, 10); got <= 0 {
+		t.Errorf("IDF of term in every document = %v, want positive", got)
+	}
+	// 统计有延迟时文档数可能小于词项文档数，不能得到负值
+	if got := IDF(5, 8); got <= 0 {
+		t.Errorf("IDF with stale total = %v, want positive", got)
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"reading-microservices/content-service/models"
+	"reading-microservices/content-service/repositories"
+	"reading-microservices/content-service/services/search"
+	"sort"
+	"time"
+)
+
+// SearchConfig 搜索排序参数，对应配置文件中的 search 段
+type SearchConfig struct {
+	TitleBoost       float64 `mapstructure:"title_boost"`
+	AuthorBoost      float64 `mapstructure:"author_boost"`
+	TagBoost         float64 `mapstructure:"tag_boost"`
+	DescriptionBoost float64 `mapstructure:"description_boost"`
+	ViewsWeight      float64 `mapstructure:"views_weight"`
+	RatingWeight     float64 `mapstructure:"rating_weight"`
+	MinShouldMatch   float64 `mapstructure:"min_should_match"` // 至少命中的查询词项比例
+	MaxCandidates    int     `mapstructure:"max_candidates"`   // 按文本相关度取前若干本再做筛选和排序
+	SnippetLength    int     `mapstructure:"snippet_length"`   // 简介摘要的字符数
+}
+
+func DefaultSearchConfig() SearchConfig {
+	return SearchConfig{
+		TitleBoost:       3,
+		AuthorBoost:      2,
+		TagBoost:         1.5,
+		DescriptionBoost: 1,
+		ViewsWeight:      0.05,
+		RatingWeight:     0.05,
+		MinShouldMatch:   0.75,
+		MaxCandidates:    1000,
+		SnippetLength:    80,
+	}
+}
+
+// SearchHit 一条搜索结果
+type SearchHit struct {
+	Novel     models.Novel
+	Highlight *models.NovelHighlight
+}
+
+type SearchService interface {
+	Search(params *models.NovelSearchParams) ([]SearchHit, int64, error)
+	IndexNovel(novelID string) error
+	RemoveNovel(novelID string) error
+	Reindex() (*models.SearchReindexResponse, error)
+}
+
+// searchService 基于倒排索引的小说搜索：标题、作者、标签和简介分词后写入 search_postings，
+// 查询时按词项取出命中的小说计算相关度，再结合阅读量和评分排序
+type searchService struct {
+	repo    repositories.SearchRepository
+	content repositories.ContentRepository
+	cfg     SearchConfig
+	weights search.Weights
+}
+
+func NewSearchService(repo repositories.SearchRepository, content repositories.ContentRepository, cfg SearchConfig) SearchService {
+	return &searchService{
+		repo:    repo,
+		content: content,
+		cfg:     cfg,
+		weights: search.Weights{
+			Fields: map[string]float64{
+				search.FieldTitle:       cfg.TitleBoost,
+				search.FieldAuthor:      cfg.AuthorBoost,
+				search.FieldTag:         cfg.TagBoost,
+				search.FieldDescription: cfg.DescriptionBoost,
+			},
+			Views:          cfg.ViewsWeight,
+			Rating:         cfg.RatingWeight,
+			MinShouldMatch: cfg.MinShouldMatch,
+		},
+	}
+}
+
+type rankedNovel struct {
+	novel models.Novel
+	score float64
+}
+
+// Search 关键词搜索。未指定 order_by 时按相关度排序，指定时按对应字段排序、相关度相同的保持相关度顺序
+func (s *searchService) Search(params *models.NovelSearchParams) ([]SearchHit, int64, error) {
+	terms := search.QueryTerms(params.Keyword)
+	if len(terms) == 0 {
+		return []SearchHit{}, 0, nil
+	}
+
+	docFreqs, err := s.repo.GetDocFreqs(terms)
+	if err != nil {
+		return nil, 0, err
+	}
+	totalDocs, err := s.repo.CountDocuments()
+	if err != nil {
+		return nil, 0, err
+	}
+	idf := make(map[string]float64, len(docFreqs))
+	for term, df := range docFreqs {
+		idf[term] = search.IDF(totalDocs, df)
+	}
+	scored, err := s.repo.TopScores(repositories.ScoreQuery{
+		TermWeights: idf,
+		FieldBoosts: s.weights.Fields,
+		K1:          search.BM25K1,
+		MinMatched:  search.RequiredMatches(len(terms), s.weights.MinShouldMatch),
+		Limit:       s.cfg.MaxCandidates,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	textScores := make(map[string]float64, len(scored))
+	ids := make([]string, len(scored))
+	for i, doc := range scored {
+		textScores[doc.NovelID] = doc.Score
+		ids[i] = doc.NovelID
+	}
+
+	candidates, err := s.content.GetSearchCandidates(ids, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	ranked := make([]rankedNovel, len(candidates))
+	for i, novel := range candidates {
+		ranked[i] = rankedNovel{
+			novel: novel,
+			score: search.Blend(textScores[novel.ID], novel.ViewsCount, novel.Rating, novel.RatingCount, s.weights),
+		}
+	}
+	sortRanked(ranked, params.OrderBy)
+
+	total := int64(len(ranked))
+	start := (params.Page - 1) * params.Size
+	if start >= len(ranked) {
+		return []SearchHit{}, total, nil
+	}
+	end := start + params.Size
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	pageIDs := make([]string, 0, end-start)
+	for _, r := range ranked[start:end] {
+		pageIDs = append(pageIDs, r.novel.ID)
+	}
+
+	novels, err := s.content.GetNovelsByIDs(pageIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[string]models.Novel, len(novels))
+	for _, novel := range novels {
+		byID[novel.ID] = novel
+	}
+
+	termSet := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		termSet[term] = true
+	}
+	hits := make([]SearchHit, 0, len(pageIDs))
+	for _, id := range pageIDs {
+		novel, ok := byID[id]
+		if !ok {
+			// 查询期间被删除
+			continue
+		}
+		hits = append(hits, SearchHit{Novel: novel, Highlight: s.highlight(&novel, termSet)})
+	}
+	return hits, total, nil
+}
+
+func (s *searchService) highlight(novel *models.Novel, terms map[string]bool) *models.NovelHighlight {
+	h := &models.NovelHighlight{
+		Title:  search.Highlight(novel.Title, terms, 0),
+		Author: search.Highlight(novel.Author, terms, 0),
+	}
+	if novel.Description != nil {
+		h.Description = search.Highlight(*novel.Description, terms, s.cfg.SnippetLength)
+	}
+	return h
+}
+
+func sortRanked(ranked []rankedNovel, orderBy string) {
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].novel.ViewsCount > ranked[j].novel.ViewsCount
+	})
+
+	var less func(a, b *models.Novel) bool
+	switch orderBy {
+	case "views":
+		less = func(a, b *models.Novel) bool { return a.ViewsCount > b.ViewsCount }
+	case "rating":
+		less = func(a, b *models.Novel) bool { return a.Rating > b.Rating }
+	case "updated":
+		less = func(a, b *models.Novel) bool {
+			if a.LastUpdatedAt == nil || b.LastUpdatedAt == nil {
+				return a.LastUpdatedAt != nil && b.LastUpdatedAt == nil
+			}
+			return a.LastUpdatedAt.After(*b.LastUpdatedAt)
+		}
+	case "chapters":
+		less = func(a, b *models.Novel) bool { return a.TotalChapters > b.TotalChapters }
+	default:
+		return
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return less(&ranked[i].novel, &ranked[j].novel)
+	})
+}
+
+// IndexNovel 重建一本小说的索引，小说不存在时删除其索引
+func (s *searchService) IndexNovel(novelID string) error {
+	novel, err := s.content.GetNovelByID(novelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return s.repo.DeleteDocument(novelID)
+		}
+		return err
+	}
+
+	type key struct{ term, field string }
+	freqs := make(map[key]int)
+	add := func(field, text string, unigrams bool) {
+		for _, token := range search.Tokenize(text, unigrams) {
+			freqs[key{token.Term, field}]++
+		}
+	}
+	// 标题、作者和标签较短，额外索引单字以支持单字查询
+	add(search.FieldTitle, novel.Title, true)
+	add(search.FieldAuthor, novel.Author, true)
+	for _, tag := range novel.Tags {
+		add(search.FieldTag, tag.Name, true)
+	}
+	if novel.Description != nil {
+		add(search.FieldDescription, *novel.Description, false)
+	}
+
+	postings := make([]models.SearchPosting, 0, len(freqs))
+	for k, freq := range freqs {
+		postings = append(postings, models.SearchPosting{Term: k.term, NovelID: novel.ID, Field: k.field, Freq: freq})
+	}
+	return s.repo.ReplaceDocument(&models.SearchDocument{NovelID: novel.ID, IndexedAt: time.Now()}, postings)
+}
+
+func (s *searchService) RemoveNovel(novelID string) error {
+	return s.repo.DeleteDocument(novelID)
+}
+
+// Reindex 为没有索引或索引过期的小说补建索引，并清理已删除小说的索引。
+// 服务启动时在后台执行一次，弥补增量更新失败或绕过服务直接改库的情况
+func (s *searchService) Reindex() (*models.SearchReindexResponse, error) {
+	stale, err := s.repo.GetStaleNovelIDs()
+	if err != nil {
+		return nil, err
+	}
+	orphans, err := s.repo.GetOrphanDocumentIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.SearchReindexResponse{}
+	failed := 0
+	for _, id := range stale {
+		if err := s.IndexNovel(id); err != nil {
+			logrus.Warnf("Failed to index novel %s: %v", id, err)
+			failed++
+			continue
+		}
+		resp.Indexed++
+	}
+	for _, id := range orphans {
+		if err := s.repo.DeleteDocument(id); err != nil {
+			logrus.Warnf("Failed to remove search document %s: %v", id, err)
+			failed++
+			continue
+		}
+		resp.Removed++
+	}
+	if failed > 0 {
+		return resp, fmt.Errorf("%d search documents failed to update", failed)
+	}
+	return resp, nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"github.com/sirupsen/logrus"
+	"math"
+	"reading-microservices/content-service/models"
+	"reading-microservices/content-service/repositories"
+	"reading-microservices/content-service/services/search"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 不同类型候选的权重上限，同等热度下书名优先，其次作者，最后是热门搜索词
+const (
+	suggestTitleWeight   = 1.0
+	suggestAuthorWeight  = 0.9
+	suggestKeywordWeight = 0.8
+)
+
+// SuggestConfig 搜索补全参数，对应配置文件中的 suggest 段
+type SuggestConfig struct {
+	RefreshInterval  int `mapstructure:"refresh_interval"` // 重建补全索引的间隔（秒），新书和热门词在下次重建后出现
+	TrendingDays     int `mapstructure:"trending_days"`    // 热门搜索词统计最近几天
+	TrendingLimit    int `mapstructure:"trending_limit"`
+	TrendingMinUsers int `mapstructure:"trending_min_users"` // 搜索人数达到该值才算热门，避免个人输入被展示给其他人
+	HotCount         int `mapstructure:"hot_count"`          // 输入为空时返回的热门搜索词数量
+}
+
+func DefaultSuggestConfig() SuggestConfig {
+	return SuggestConfig{
+		RefreshInterval:  300,
+		TrendingDays:     7,
+		TrendingLimit:    50,
+		TrendingMinUsers: 3,
+		HotCount:         10,
+	}
+}
+
+type SuggestService interface {
+	Suggest(query string, limit int, hideMature bool) *models.SuggestResponse
+	Refresh(ctx context.Context) error
+	Start()
+}
+
+// suggestState 一次重建的结果，重建期间查询继续使用旧索引
+type suggestState struct {
+	suggester *search.Suggester
+	hot       []search.SuggestEntry
+}
+
+// suggestService 书名、作者和热门搜索词的补全索引常驻内存，定时从数据库和阅读服务重建
+type suggestService struct {
+	repo     repositories.ContentRepository
+	trending TrendingClient
+	cfg      SuggestConfig
+
+	mu       sync.RWMutex
+	state    *suggestState
+	keywords []TrendingKeyword // 最近一次成功获取的热门搜索词，阅读服务不可用时沿用
+}
+
+func NewSuggestService(repo repositories.ContentRepository, trending TrendingClient, cfg SuggestConfig) SuggestService {
+	return &suggestService{repo: repo, trending: trending, cfg: cfg}
+}
+
+// Suggest 查询为空时返回热门搜索词，否则返回补全候选；索引尚未建立时返回空结果
+func (s *suggestService) Suggest(query string, limit int, hideMature bool) *models.SuggestResponse {
+	if limit <= 0 {
+		limit = 10
+	}
+	resp := &models.SuggestResponse{Suggestions: []models.SuggestItem{}, Hot: []string{}}
+
+	s.mu.RLock()
+	state := s.state
+	s.mu.RUnlock()
+	if state == nil {
+		return resp
+	}
+
+	if strings.TrimSpace(query) == "" {
+		for _, entry := range state.hot {
+			if hideMature && entry.Mature {
+				continue
+			}
+			resp.Hot = append(resp.Hot, entry.Text)
+			if len(resp.Hot) == s.cfg.HotCount {
+				break
+			}
+		}
+		return resp
+	}
+
+	for _, entry := range state.suggester.Suggest(query, limit, hideMature) {
+		resp.Suggestions = append(resp.Suggestions, models.SuggestItem{
+			Text:    entry.Text,
+			Type:    entry.Kind,
+			NovelID: entry.NovelID,
+		})
+	}
+	return resp
+}
+
+// Refresh 重建补全索引。读取小说失败时保留旧索引；获取热门搜索词失败时沿用上一次的结果
+func (s *suggestService) Refresh(ctx context.Context) error {
+	novels, err := s.repo.GetSuggestSources()
+	if err != nil {
+		return err
+	}
+
+	keywords, err := s.trending.GetTrendingKeywords(ctx, s.cfg.TrendingDays, s.cfg.TrendingLimit, s.cfg.TrendingMinUsers)
+	s.mu.RLock()
+	previous := s.keywords
+	s.mu.RUnlock()
+	if err != nil {
+		logrus.Warnf("Failed to get trending keywords: %v", err)
+		keywords = previous
+	}
+
+	state := buildSuggestState(novels, keywords)
+	s.mu.Lock()
+	s.state = state
+	s.keywords = keywords
+	s.mu.Unlock()
+	return nil
+}
+
+// Start 在后台立即建立索引并定时重建，不阻塞启动
+func (s *suggestService) Start() {
+	interval := time.Duration(s.cfg.RefreshInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Refresh(context.Background()); err != nil {
+				logrus.Warnf("Failed to refresh search suggestions: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// buildSuggestState 各类候选的权重按该类中最热门的一项归一化。
+// 作者按全部作品的阅读量汇总，所有作品都是成人向时才在青少年模式下隐藏；
+// 与书名或作者相同的热门词只出现在热门列表中，补全时以书名、作者为准；
+// 对应不到书名或作者的热门词无法判断是否成人向，青少年模式下不展示
+func buildSuggestState(novels []models.Novel, keywords []TrendingKeyword) *suggestState {
+	type authorStats struct {
+		name   string
+		views  int64
+		mature bool
+	}
+	authors := make(map[string]*authorStats)
+	var authorOrder []string
+	var maxViews int64
+	for _, novel := range novels {
+		if novel.ViewsCount > maxViews {
+			maxViews = novel.ViewsCount
+		}
+		name := strings.TrimSpace(novel.Author)
+		if name == "" {
+			continue
+		}
+		a, ok := authors[name]
+		if !ok {
+			a = &authorStats{name: name, mature: true}
+			authors[name] = a
+			authorOrder = append(authorOrder, name)
+		}
+		a.views += novel.ViewsCount
+		a.mature = a.mature && novel.IsMature
+	}
+	var maxAuthorViews int64
+	for _, a := range authors {
+		if a.views > maxAuthorViews {
+			maxAuthorViews = a.views
+		}
+	}
+
+	entries := make([]search.SuggestEntry, 0, len(novels)+len(authors)+len(keywords))
+	// 成人向标记按规范化文本记录，同名的非成人向作品优先
+	mature := make(map[string]bool)
+	for _, novel := range novels {
+		title := strings.TrimSpace(novel.Title)
+		if title == "" {
+			continue
+		}
+		entries = append(entries, search.SuggestEntry{
+			Text:    title,
+			Kind:    search.SuggestTitle,
+			NovelID: novel.ID,
+			Weight:  suggestTitleWeight * normalizedPopularity(novel.ViewsCount, maxViews),
+			Mature:  novel.IsMature,
+		})
+		key := search.Compact(title)
+		if m, ok := mature[key]; !ok || m {
+			mature[key] = novel.IsMature
+		}
+	}
+	for _, name := range authorOrder {
+		a := authors[name]
+		entries = append(entries, search.SuggestEntry{
+			Text:   a.name,
+			Kind:   search.SuggestAuthor,
+			Weight: suggestAuthorWeight * normalizedPopularity(a.views, maxAuthorViews),
+			Mature: a.mature,
+		})
+		key := search.Compact(a.name)
+		if m, ok := mature[key]; !ok || m {
+			mature[key] = a.mature
+		}
+	}
+
+	var maxUsers int64
+	for _, k := range keywords {
+		if k.Users > maxUsers {
+			maxUsers = k.Users
+		}
+	}
+	hot := make([]search.SuggestEntry, 0, len(keywords))
+	for _, k := range keywords {
+		text := strings.TrimSpace(k.Keyword)
+		key := search.Compact(text)
+		if key == "" {
+			continue
+		}
+		m, known := mature[key]
+		entry := search.SuggestEntry{
+			Text:   text,
+			Kind:   search.SuggestKeyword,
+			Weight: suggestKeywordWeight * normalizedPopularity(k.Users, maxUsers),
+			Mature: m || !known,
+		}
+		hot = append(hot, entry)
+		if !known {
+			entries = append(entries, entry)
+		}
+	}
+
+	return &suggestState{suggester: search.NewSuggester(entries), hot: hot}
+}
+
+// normalizedPopularity 按对数缩放到 0～1
+func normalizedPopularity(value, max int64) float64 {
+	if value <= 0 || max <= 0 {
+		return 0
+	}
+	return math.Log1p(float64(value)) / math.Log1p(float64(max))
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+
+	"reading-microservices/content-service/models"
+)
+
+func TestBuildSuggestStateMatureKeywords(t *testing.T) {
+	novels := []models.Novel{
+		{ID: "n1", Title: "斗破苍穹", Author: "天蚕土豆", ViewsCount: 1000},
+		{ID: "n2", Title: "夜色撩人", Author: "某作者", ViewsCount: 10, IsMature: true},
+	}
+	keywords := []TrendingKeyword{
+		{Keyword: "斗破苍穹", Users: 50},
+		{Keyword: "夜色撩人", Users: 40},
+		{Keyword: "陌生的词", Users: 30},
+	}
+	state := buildSuggestState(novels, keywords)
+
+	tests := []struct {
+		keyword    string
+		wantMature bool
+	}{
+		{keyword: "斗破苍穹", wantMature: false},
+		{keyword: "夜色撩人", wantMature: true},
+		{keyword: "陌生的词", wantMature: true}, // 对应不到书名或作者，按成人向处理
+	}
+	for _, tt := range tests {
+		var found bool
+		for _, entry := range state.hot {
+			if entry.Text == tt.keyword {
+				found = true
+				if entry.Mature != tt.wantMature {
+					t.Errorf("hot %q Mature = %v, want %v", tt.keyword, entry.Mature, tt.wantMature)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("hot list missing %q", tt.keyword)
+		}
+	}
+
+	if got := state.suggester.Suggest("陌生", 5, true); len(got) != 0 {
+		t.Errorf("teen mode suggestions for unknown keyword = %+v, want none", got)
+	}
+	if got := state.suggester.Suggest("陌生", 5, false); len(got) != 1 {
+		t.Errorf("suggestions for unknown keyword = %+v, want one", got)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"reading-microservices/shared/utils"
+)
+
+// TrendingKeyword 阅读服务统计的热门搜索词
+type TrendingKeyword struct {
+	Keyword string `json:"keyword"`
+	Users   int64  `json:"users"`
+}
+
+// TrendingClient 从阅读服务的搜索历史获取热门搜索词
+type TrendingClient interface {
+	GetTrendingKeywords(ctx context.Context, days, limit, minUsers int) ([]TrendingKeyword, error)
+}
+
+// NewTrendingClient 未配置阅读服务地址时没有热门搜索词，token 为服务间凭证
+func NewTrendingClient(readingServiceURL, token string) TrendingClient {
+	if readingServiceURL == "" {
+		return noopTrendingClient{}
+	}
+	return &httpTrendingClient{
+		endpoint: strings.TrimRight(readingServiceURL, "/") + "/api/v1/internal/reading/search/trending",
+		client:   utils.NewInternalClient(token, 5*time.Second),
+	}
+}
+
+type httpTrendingClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (c *httpTrendingClient) GetTrendingKeywords(ctx context.Context, days, limit, minUsers int) ([]TrendingKeyword, error) {
+	query := url.Values{}
+	query.Set("days", strconv.Itoa(days))
+	query.Set("limit", strconv.Itoa(limit))
+	query.Set("min_users", strconv.Itoa(minUsers))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int               `json:"code"`
+		Message string            `json:"message"`
+		Data    []TrendingKeyword `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != utils.SUCCESS {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, result.Message)
+	}
+	return result.Data, nil
+}
+
+type noopTrendingClient struct{}
+
+func (noopTrendingClient) GetTrendingKeywords(ctx context.Context, days, limit, minUsers int) ([]TrendingKeyword, error) {
+	return nil, nil
+}
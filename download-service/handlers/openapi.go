@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"reading-microservices/download-service/models"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
+)
+
+// OpenAPIDocument 下载服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("download-service", "1.0.0")
+	return doc.AddRoutes(
+		openapi.Route{Method: "POST", Path: "/api/v1/download/tasks", Summary: "创建下载任务", Tag: "下载任务", Auth: true,
+			Body: models.CreateDownloadTaskRequest{}, Response: models.DownloadTaskResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/download/tasks", Summary: "下载任务列表", Tag: "下载任务", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.DownloadTaskResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/download/tasks/:id", Summary: "下载任务详情", Tag: "下载任务", Auth: true,
+			Response: models.DownloadTaskResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/download/tasks/:id", Summary: "更新下载任务", Tag: "下载任务", Auth: true,
+			Body: models.UpdateDownloadTaskRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/download/tasks/:id", Summary: "删除下载任务", Tag: "下载任务", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/download/tasks/:id/start", Summary: "开始下载", Tag: "下载任务", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/download/tasks/:id/pause", Summary: "暂停下载", Tag: "下载任务", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/download/tasks/:id/resume", Summary: "恢复下载", Tag: "下载任务", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/download/tasks/:id/file", Summary: "下载文件", Tag: "下载任务", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/download/stats", Summary: "下载统计", Tag: "下载任务", Auth: true,
+			Response: models.DownloadStatsResponse{}},
+
+		// 内部接口
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/download/users/:user_id/data", Summary: "导出个人数据", Tag: "内部接口",
+			Response: models.UserDataExport{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/internal/download/users/:user_id/data", Summary: "删除个人数据", Tag: "内部接口"},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/download/users/:user_id/merge", Summary: "合并账号数据", Tag: "内部接口",
+			Body: utils.MergeUserDataRequest{}, Response: map[string]utils.MergeCount{}},
+	)
+}
@@ -13,6 +13,8 @@ import (
 
 	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
 	"reading-microservices/download-service/handlers"
 	"reading-microservices/download-service/models"
 	"reading-microservices/download-service/repositories"
@@ -61,7 +63,7 @@ func main() {
 	downloadHandler := handlers.NewDownloadHandler(downloadService)
 
 	// 初始化路由
-	router := setupRouter(downloadHandler, cfg.JWT.Secret)
+	router := setupRouter(downloadHandler, cfg.JWT.NewVerifier(), cfg.Internal.Token)
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -118,7 +120,7 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(downloadHandler *handlers.DownloadHandler, jwtSecret string) *gin.Engine {
+func setupRouter(downloadHandler *handlers.DownloadHandler, verifier utils.TokenVerifier, internalToken string) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -129,9 +131,12 @@ func setupRouter(downloadHandler *handlers.DownloadHandler, jwtSecret string) *g
 	// 健康检查
 	router.GET("/health", downloadHandler.Health)
 
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
 	// API路由 - 需要认证
 	v1 := router.Group("/api/v1/download")
-	v1.Use(middleware.JWTAuth(jwtSecret))
+	v1.Use(middleware.JWTAuth(verifier))
 	{
 		// 下载任务管理
 		v1.POST("/tasks", downloadHandler.CreateDownloadTask)
@@ -150,5 +155,14 @@ func setupRouter(downloadHandler *handlers.DownloadHandler, jwtSecret string) *g
 		v1.GET("/stats", downloadHandler.GetDownloadStats)
 	}
 
+	// 内部API - 供用户服务导出和删除个人数据，不经网关暴露，需携带服务间凭证
+	internal := router.Group("/api/v1/internal/download")
+	internal.Use(middleware.InternalAuth(internalToken))
+	{
+		internal.GET("/users/:user_id/data", downloadHandler.ExportUserData)
+		internal.DELETE("/users/:user_id/data", downloadHandler.DeleteUserData)
+		internal.POST("/users/:user_id/merge", downloadHandler.MergeUserData)
+	}
+
 	return router
 }
\ No newline at end of file
@@ -87,4 +87,9 @@ const (
 	FormatTXT  = "txt"
 	FormatEPUB = "epub"
 	FormatPDF  = "pdf"
-)
\ No newline at end of file
+)
+
+// UserDataExport 个人数据导出，供用户服务汇总打包
+type UserDataExport struct {
+	DownloadTasks []DownloadTask `json:"download_tasks"`
+}
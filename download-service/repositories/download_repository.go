@@ -3,6 +3,7 @@ package repositories
 import (
 	"gorm.io/gorm"
 	"reading-microservices/download-service/models"
+	"reading-microservices/shared/utils"
 )
 
 type DownloadRepository struct {
@@ -38,4 +39,44 @@ func (r *DownloadRepository) FindByUserID(userID string, limit, offset int) ([]*
 
 func (r *DownloadRepository) Update(download *models.DownloadTask) error {
 	return r.db.Save(download).Error
-}
\ No newline at end of file
+}
+
+func (r *DownloadRepository) ExportUserData(userID string) (*models.UserDataExport, error) {
+	data := &models.UserDataExport{}
+	err := r.db.Preload("DownloadChapters").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&data.DownloadTasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// DeleteUserData 删除下载任务及其章节记录
+func (r *DownloadRepository) DeleteUserData(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		taskIDs := tx.Model(&models.DownloadTask{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Where("download_task_id IN (?)", taskIDs).Delete(&models.DownloadChapter{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.DownloadTask{}).Error
+	})
+}
+
+// MergeUserData 账号合并：下载任务全部转移，章节记录跟随任务
+func (r *DownloadRepository) MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	var count utils.MergeCount
+	if dryRun {
+		if err := r.db.Model(&models.DownloadTask{}).Where("user_id = ?", sourceID).Count(&count.Moved).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		res := r.db.Model(&models.DownloadTask{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		count.Moved = res.RowsAffected
+	}
+	return map[string]utils.MergeCount{"download_tasks": count}, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"reading-microservices/download-service/models"
 	"reading-microservices/download-service/repositories"
+	"reading-microservices/shared/utils"
 )
 
 type DownloadService struct {
@@ -29,4 +30,19 @@ func (s *DownloadService) GetDownloadsByUserID(userID string, limit, offset int)
 
 func (s *DownloadService) UpdateDownload(download *models.DownloadTask) error {
 	return s.downloadRepo.Update(download)
-}
\ No newline at end of file
+}
+
+// ExportUserData 导出个人数据
+func (s *DownloadService) ExportUserData(userID string) (*models.UserDataExport, error) {
+	return s.downloadRepo.ExportUserData(userID)
+}
+
+// DeleteUserData 账号注销时删除个人数据
+func (s *DownloadService) DeleteUserData(userID string) error {
+	return s.downloadRepo.DeleteUserData(userID)
+}
+
+// MergeUserData 账号合并时把数据转移到目标账号
+func (s *DownloadService) MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error) {
+	return s.downloadRepo.MergeUserData(sourceID, req.TargetUserID, req.DryRun)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"reading-microservices/notification-service/services"
+	"reading-microservices/shared/utils"
 )
 
 type NotificationHandler struct {
@@ -67,4 +68,38 @@ func (h *NotificationHandler) CreateBatchNotifications(c *gin.Context) {
 
 func (h *NotificationHandler) PushNotification(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "PushNotification not implemented"})
-}
\ No newline at end of file
+}
+
+// ExportUserData 导出个人数据（内部接口）
+func (h *NotificationHandler) ExportUserData(c *gin.Context) {
+	data, err := h.notificationService.ExportUserData(c.Param("user_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, data)
+}
+
+// DeleteUserData 删除个人数据（内部接口）
+func (h *NotificationHandler) DeleteUserData(c *gin.Context) {
+	if err := h.notificationService.DeleteUserData(c.Param("user_id")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.SuccessWithMessage(c, "User data deleted", nil)
+}
+
+// MergeUserData 合并账号数据（内部接口）
+func (h *NotificationHandler) MergeUserData(c *gin.Context) {
+	var req utils.MergeUserDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	result, err := h.notificationService.MergeUserData(c.Param("user_id"), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, result)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"reading-microservices/notification-service/models"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
+)
+
+// OpenAPIDocument 通知服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("notification-service", "1.0.0")
+	return doc.AddRoutes(
+		openapi.Route{Method: "GET", Path: "/api/v1/notification/", Summary: "通知列表", Tag: "通知", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.NotificationResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/notification/stats", Summary: "通知统计", Tag: "通知", Auth: true,
+			Response: models.NotificationStatsResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/notification/:id/read", Summary: "标记已读", Tag: "通知", Auth: true},
+		openapi.Route{Method: "PUT", Path: "/api/v1/notification/read-all", Summary: "全部已读", Tag: "通知", Auth: true},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/notification/:id", Summary: "删除通知", Tag: "通知", Auth: true},
+
+		openapi.Route{Method: "GET", Path: "/api/v1/notification/settings", Summary: "通知设置", Tag: "通知设置", Auth: true,
+			Response: []models.NotificationSettingResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/notification/settings", Summary: "更新通知设置", Tag: "通知设置", Auth: true,
+			Body: models.UpdateNotificationSettingRequest{}},
+
+		openapi.Route{Method: "POST", Path: "/api/v1/notification/push-token", Summary: "注册推送Token", Tag: "推送", Auth: true,
+			Body: models.RegisterPushTokenRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/notification/push-token/:device_id", Summary: "注销推送Token", Tag: "推送", Auth: true},
+
+		// 内部接口
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/notification/users/:user_id/data", Summary: "导出个人数据", Tag: "内部接口",
+			Response: models.UserDataExport{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/internal/notification/users/:user_id/data", Summary: "删除个人数据", Tag: "内部接口"},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/notification/users/:user_id/merge", Summary: "合并账号数据", Tag: "内部接口",
+			Body: utils.MergeUserDataRequest{}, Response: map[string]utils.MergeCount{}},
+	)
+}
@@ -13,6 +13,8 @@ import (
 
 	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
 	"reading-microservices/notification-service/handlers"
 	"reading-microservices/notification-service/models"
 	"reading-microservices/notification-service/repositories"
@@ -62,7 +64,7 @@ func main() {
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
 
 	// 初始化路由
-	router := setupRouter(notificationHandler, cfg.JWT.Secret)
+	router := setupRouter(notificationHandler, cfg.JWT.NewVerifier(), cfg.Internal.Token)
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -119,7 +121,7 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(notificationHandler *handlers.NotificationHandler, jwtSecret string) *gin.Engine {
+func setupRouter(notificationHandler *handlers.NotificationHandler, verifier utils.TokenVerifier, internalToken string) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -130,9 +132,12 @@ func setupRouter(notificationHandler *handlers.NotificationHandler, jwtSecret st
 	// 健康检查
 	router.GET("/health", notificationHandler.Health)
 
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
 	// API路由 - 需要认证
 	v1 := router.Group("/api/v1/notification")
-	v1.Use(middleware.JWTAuth(jwtSecret))
+	v1.Use(middleware.JWTAuth(verifier))
 	{
 		// 通知管理
 		v1.GET("/", notificationHandler.GetNotifications)
@@ -150,8 +155,9 @@ func setupRouter(notificationHandler *handlers.NotificationHandler, jwtSecret st
 		v1.DELETE("/push-token/:device_id", notificationHandler.UnregisterPushToken)
 	}
 
-	// 内部API - 供其他服务调用
+	// 内部API - 供其他服务调用，需携带服务间凭证
 	internal := router.Group("/api/v1/internal/notification")
+	internal.Use(middleware.InternalAuth(internalToken))
 	{
 		// 创建通知
 		internal.POST("/", notificationHandler.CreateNotification)
@@ -159,6 +165,11 @@ func setupRouter(notificationHandler *handlers.NotificationHandler, jwtSecret st
 
 		// 推送通知
 		internal.POST("/push", notificationHandler.PushNotification)
+
+		// 个人数据导出与注销
+		internal.GET("/users/:user_id/data", notificationHandler.ExportUserData)
+		internal.DELETE("/users/:user_id/data", notificationHandler.DeleteUserData)
+		internal.POST("/users/:user_id/merge", notificationHandler.MergeUserData)
 	}
 
 	return router
@@ -98,4 +98,11 @@ const (
 	SettingTypeRecommendation = "recommendation"
 	SettingTypeSystem        = "system_notice"
 	SettingTypeMarketing     = "marketing"
-)
\ No newline at end of file
+)
+
+// UserDataExport 个人数据导出，供用户服务汇总打包
+type UserDataExport struct {
+	Notifications []Notification        `json:"notifications"`
+	Settings      []NotificationSetting `json:"settings"`
+	PushTokens    []PushToken           `json:"push_tokens"`
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"gorm.io/gorm"
 	"reading-microservices/notification-service/models"
+	"reading-microservices/shared/utils"
 )
 
 type NotificationRepository struct {
@@ -42,4 +43,88 @@ func (r *NotificationRepository) Update(notification *models.Notification) error
 
 func (r *NotificationRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Notification{}, id).Error
-}
\ No newline at end of file
+}
+
+func (r *NotificationRepository) ExportUserData(userID string) (*models.UserDataExport, error) {
+	data := &models.UserDataExport{}
+	for _, dest := range []interface{}{&data.Notifications, &data.Settings, &data.PushTokens} {
+		if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// DeleteUserData 删除通知、通知设置和推送 token
+func (r *NotificationRepository) DeleteUserData(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&models.Notification{}, &models.NotificationSetting{}, &models.PushToken{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MergeUserData 账号合并：通知全部转移；通知设置以目标账号为准，目标账号没有的项才转移；
+// 推送 token 属于已下线的设备，直接删除
+func (r *NotificationRepository) MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	result := make(map[string]utils.MergeCount)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var notifications, tokens int64
+		if err := tx.Model(&models.Notification{}).Where("user_id = ?", sourceID).Count(&notifications).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.PushToken{}).Where("user_id = ?", sourceID).Count(&tokens).Error; err != nil {
+			return err
+		}
+		var targetTypes []string
+		if err := tx.Model(&models.NotificationSetting{}).Where("user_id = ?", targetID).Pluck("setting_type", &targetTypes).Error; err != nil {
+			return err
+		}
+		var settings []models.NotificationSetting
+		if err := tx.Where("user_id = ?", sourceID).Find(&settings).Error; err != nil {
+			return err
+		}
+		exists := make(map[string]bool, len(targetTypes))
+		for _, t := range targetTypes {
+			exists[t] = true
+		}
+		var moveIDs, deleteIDs []string
+		for _, setting := range settings {
+			if exists[setting.SettingType] {
+				deleteIDs = append(deleteIDs, setting.ID)
+			} else {
+				moveIDs = append(moveIDs, setting.ID)
+			}
+		}
+
+		result["notifications"] = utils.MergeCount{Moved: notifications}
+		result["settings"] = utils.MergeCount{Moved: int64(len(moveIDs)), Discarded: int64(len(deleteIDs))}
+		result["push_tokens"] = utils.MergeCount{Discarded: tokens}
+		if dryRun {
+			return nil
+		}
+
+		if err := tx.Model(&models.Notification{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", sourceID).Delete(&models.PushToken{}).Error; err != nil {
+			return err
+		}
+		if len(deleteIDs) > 0 {
+			if err := tx.Where("id IN ?", deleteIDs).Delete(&models.NotificationSetting{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(moveIDs) > 0 {
+			return tx.Model(&models.NotificationSetting{}).Where("id IN ?", moveIDs).Update("user_id", targetID).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
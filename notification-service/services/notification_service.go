@@ -3,6 +3,7 @@ package services
 import (
 	"reading-microservices/notification-service/models"
 	"reading-microservices/notification-service/repositories"
+	"reading-microservices/shared/utils"
 )
 
 type NotificationService struct {
@@ -35,4 +36,19 @@ func (s *NotificationService) MarkAsRead(id uint) error {
 
 func (s *NotificationService) DeleteNotification(id uint) error {
 	return s.notificationRepo.Delete(id)
-}
\ No newline at end of file
+}
+
+// ExportUserData 导出个人数据
+func (s *NotificationService) ExportUserData(userID string) (*models.UserDataExport, error) {
+	return s.notificationRepo.ExportUserData(userID)
+}
+
+// DeleteUserData 账号注销时删除个人数据
+func (s *NotificationService) DeleteUserData(userID string) error {
+	return s.notificationRepo.DeleteUserData(userID)
+}
+
+// MergeUserData 账号合并时把数据转移到目标账号
+func (s *NotificationService) MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error) {
+	return s.notificationRepo.MergeUserData(sourceID, req.TargetUserID, req.DryRun)
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"reading-microservices/payment-service/models"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
+)
+
+type userGiftQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=unused used expired"`
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+}
+
+type giftQuery struct {
+	Category string `form:"category"`
+	IsActive string `form:"is_active" binding:"omitempty,oneof=true false"`
+}
+
+// OpenAPIDocument 支付服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("payment-service", "1.0.0")
+	return doc.AddRoutes(
+		// VIP
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/vip", Summary: "开通VIP", Tag: "VIP", Auth: true,
+			Body: models.CreateVipMembershipRequest{}, Response: models.VipMembershipResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/vip/status", Summary: "VIP状态", Tag: "VIP", Auth: true,
+			Response: models.VipMembershipResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/vip/history", Summary: "VIP历史", Tag: "VIP", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.VipMembershipResponse{}, Paged: true},
+
+		// 积分
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/points/earn", Summary: "获得积分", Tag: "积分", Auth: true,
+			Body: models.EarnPointsRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/points/spend", Summary: "消费积分", Tag: "积分", Auth: true,
+			Body: models.SpendPointsRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/points/history", Summary: "积分明细", Tag: "积分", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.PointsRecordResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/points/stats", Summary: "积分统计", Tag: "积分", Auth: true,
+			Response: models.PointsStatsResponse{}},
+
+		// 阅读币
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/coins/earn", Summary: "获得阅读币", Tag: "阅读币", Auth: true,
+			Body: models.EarnCoinsRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/coins/spend", Summary: "消费阅读币", Tag: "阅读币", Auth: true,
+			Body: models.SpendCoinsRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/coins/history", Summary: "阅读币明细", Tag: "阅读币", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.CoinsRecordResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/coins/stats", Summary: "阅读币统计", Tag: "阅读币", Auth: true,
+			Response: models.CoinsStatsResponse{}},
+
+		// 签到
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/checkin", Summary: "每日签到", Tag: "签到", Auth: true,
+			Response: models.CheckinResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/checkin/status", Summary: "签到状态", Tag: "签到", Auth: true,
+			Response: models.CheckinStatusResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/checkin/history", Summary: "签到历史", Tag: "签到", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.CheckinResponse{}, Paged: true},
+
+		// 礼品与兑换
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/gifts", Summary: "我的礼品", Tag: "礼品", Auth: true,
+			Query: userGiftQuery{}, Response: []models.UserGiftResponse{}, Paged: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/gifts/:id/use", Summary: "使用礼品", Tag: "礼品", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/payment/redeem", Summary: "兑换码兑换", Tag: "礼品", Auth: true,
+			Body: models.RedeemCodeRequest{}, Response: models.UserGiftResponse{}},
+
+		// 钱包
+		openapi.Route{Method: "GET", Path: "/api/v1/payment/wallet", Summary: "钱包", Tag: "钱包", Auth: true,
+			Response: models.WalletResponse{}},
+
+		// 礼品管理
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/payment/gifts", Summary: "创建礼品", Tag: "礼品管理", Auth: true,
+			Body: models.CreateGiftRequest{}, Response: models.GiftResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/admin/payment/gifts", Summary: "礼品列表", Tag: "礼品管理", Auth: true,
+			Query: giftQuery{}, Response: []models.GiftResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/admin/payment/gifts/:id", Summary: "更新礼品", Tag: "礼品管理", Auth: true,
+			Body: models.CreateGiftRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/admin/payment/gifts/:id", Summary: "删除礼品", Tag: "礼品管理", Auth: true},
+
+		// 内部接口
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/payment/users/:user_id/data", Summary: "导出个人数据", Tag: "内部接口",
+			Response: models.UserDataExport{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/internal/payment/users/:user_id/data", Summary: "删除个人数据", Tag: "内部接口"},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/payment/users/:user_id/merge", Summary: "合并账号数据", Tag: "内部接口",
+			Body: utils.MergeUserDataRequest{}, Response: map[string]utils.MergeCount{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/payment/rewards", Summary: "发放奖励", Tag: "内部接口",
+			Body: models.GrantRewardRequest{}},
+	)
+}
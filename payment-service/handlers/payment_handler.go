@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"reading-microservices/payment-service/models"
 	"reading-microservices/payment-service/services"
+	"reading-microservices/shared/utils"
 )
 
 type PaymentHandler struct {
@@ -23,7 +26,18 @@ func (h *PaymentHandler) Health(c *gin.Context) {
 
 // VIP Management
 func (h *PaymentHandler) CreateVipMembership(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "CreateVipMembership not implemented"})
+	var req models.CreateVipMembershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	membership, err := h.paymentService.CreateVipMembership(c.GetString("user_id"), &req)
+	if err != nil {
+		h.handlePurchaseError(c, err)
+		return
+	}
+	utils.Success(c, membership)
 }
 
 func (h *PaymentHandler) GetVipStatus(c *gin.Context) {
@@ -57,7 +71,17 @@ func (h *PaymentHandler) EarnCoins(c *gin.Context) {
 }
 
 func (h *PaymentHandler) SpendCoins(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "SpendCoins not implemented"})
+	var req models.SpendCoinsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.paymentService.SpendCoins(c.GetString("user_id"), &req); err != nil {
+		h.handlePurchaseError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Coins spent successfully", nil)
 }
 
 func (h *PaymentHandler) GetCoinsHistory(c *gin.Context) {
@@ -119,4 +143,62 @@ func (h *PaymentHandler) UpdateGift(c *gin.Context) {
 
 func (h *PaymentHandler) DeleteGift(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "DeleteGift not implemented"})
-}
\ No newline at end of file
+}
+
+// User Data（内部接口，供用户服务导出和注销时调用）
+func (h *PaymentHandler) ExportUserData(c *gin.Context) {
+	data, err := h.paymentService.ExportUserData(c.Param("user_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, data)
+}
+
+// GrantReward 内部接口：其他服务发放奖励，按 source 和 related_id 去重
+func (h *PaymentHandler) GrantReward(c *gin.Context) {
+	var req models.GrantRewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	duplicate, err := h.paymentService.GrantReward(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, gin.H{"duplicate": duplicate})
+}
+
+func (h *PaymentHandler) DeleteUserData(c *gin.Context) {
+	if err := h.paymentService.DeleteUserData(c.Param("user_id")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.SuccessWithMessage(c, "User data deleted", nil)
+}
+
+// MergeUserData 账号合并时把数据转移到目标账号，返回各类数据的转移和丢弃条数
+func (h *PaymentHandler) MergeUserData(c *gin.Context) {
+	var req utils.MergeUserDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	result, err := h.paymentService.MergeUserData(c.Param("user_id"), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, result)
+}
+
+// handlePurchaseError 青少年模式禁止购买时返回 403
+func (h *PaymentHandler) handlePurchaseError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrPurchaseRestricted) {
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+		return
+	}
+	utils.Error(c, utils.ERROR, err.Error())
+}
@@ -4,15 +4,20 @@ import (
 	"fmt"
 	"log"
 	"context"
+	"time"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/userclient"
+	"reading-microservices/shared/utils"
 	"reading-microservices/payment-service/handlers"
 	"reading-microservices/payment-service/models"
 	"reading-microservices/payment-service/repositories"
@@ -60,13 +65,15 @@ func main() {
 	paymentRepo := repositories.NewPaymentRepository(db)
 
 	// 初始化Service
-	paymentService := services.NewPaymentService(paymentRepo)
+	// 购买前向用户服务确认用户不在青少年模式
+	users := userclient.New(viper.GetString("user_service_url"), cfg.Internal.Token, 5*time.Second)
+	paymentService := services.NewPaymentService(paymentRepo, users)
 
 	// 初始化Handler
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 
 	// 初始化路由
-	router := setupRouter(paymentHandler, cfg.JWT.Secret)
+	router := setupRouter(paymentHandler, cfg.JWT.NewVerifier(), cfg.Internal.Token)
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -123,7 +130,7 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(paymentHandler *handlers.PaymentHandler, jwtSecret string) *gin.Engine {
+func setupRouter(paymentHandler *handlers.PaymentHandler, verifier utils.TokenVerifier, internalToken string) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -134,9 +141,12 @@ func setupRouter(paymentHandler *handlers.PaymentHandler, jwtSecret string) *gin
 	// 健康检查
 	router.GET("/health", paymentHandler.Health)
 
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
 	// API路由 - 需要认证
 	v1 := router.Group("/api/v1/payment")
-	v1.Use(middleware.JWTAuth(jwtSecret))
+	v1.Use(middleware.JWTAuth(verifier))
 	{
 		// VIP管理
 		v1.POST("/vip", paymentHandler.CreateVipMembership)
@@ -173,7 +183,7 @@ func setupRouter(paymentHandler *handlers.PaymentHandler, jwtSecret string) *gin
 
 	// 管理接口
 	admin := router.Group("/api/v1/admin/payment")
-	admin.Use(middleware.JWTAuth(jwtSecret))
+	admin.Use(middleware.JWTAuth(verifier))
 	{
 		// 礼品管理
 		admin.POST("/gifts", paymentHandler.CreateGift)
@@ -182,5 +192,15 @@ func setupRouter(paymentHandler *handlers.PaymentHandler, jwtSecret string) *gin
 		admin.DELETE("/gifts/:id", paymentHandler.DeleteGift)
 	}
 
+	// 内部API - 供用户服务导出和删除个人数据、发放奖励，不经网关暴露，需携带服务间凭证
+	internal := router.Group("/api/v1/internal/payment")
+	internal.Use(middleware.InternalAuth(internalToken))
+	{
+		internal.GET("/users/:user_id/data", paymentHandler.ExportUserData)
+		internal.DELETE("/users/:user_id/data", paymentHandler.DeleteUserData)
+		internal.POST("/users/:user_id/merge", paymentHandler.MergeUserData)
+		internal.POST("/rewards", paymentHandler.GrantReward)
+	}
+
 	return router
 }
\ No newline at end of file
@@ -47,6 +47,17 @@ type EarnCoinsRequest struct {
 	RelatedType *string `json:"related_type"`
 }
 
+// GrantRewardRequest 其他服务发放的奖励（如邀请奖励），同一 source 和 related_id 只发放一次
+type GrantRewardRequest struct {
+	UserID      string  `json:"user_id" binding:"required"`
+	Points      int     `json:"points" binding:"min=0"`
+	Coins       int     `json:"coins" binding:"min=0"`
+	Source      string  `json:"source" binding:"required"`
+	RelatedID   string  `json:"related_id" binding:"required"`
+	RelatedType *string `json:"related_type"`
+	Description *string `json:"description"`
+}
+
 type SpendCoinsRequest struct {
 	Coins       int     `json:"coins" binding:"required,gt=0"`
 	Source      string  `json:"source" binding:"required"`
@@ -164,4 +175,14 @@ type CoinsStatsResponse struct {
 	CurrentBalance  int `json:"current_balance"`
 	ThisMonthEarned int `json:"this_month_earned"`
 	ThisMonthSpent  int `json:"this_month_spent"`
-}
\ No newline at end of file
+}
+
+// UserDataExport 个人数据导出，供用户服务汇总打包
+type UserDataExport struct {
+	Wallet         *WalletResponse `json:"wallet"`
+	VipMemberships []VipMembership `json:"vip_memberships"`
+	PointsRecords  []PointsRecord  `json:"points_records"`
+	CoinsRecords   []CoinsRecord   `json:"coins_records"`
+	CheckinRecords []CheckinRecord `json:"checkin_records"`
+	UserGifts      []UserGift      `json:"user_gifts"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 	"gorm.io/gorm"
 	"reading-microservices/payment-service/models"
+	"reading-microservices/shared/utils"
 )
 
 type PaymentRepository interface {
@@ -26,6 +27,10 @@ type PaymentRepository interface {
 	GetUserCoinsBalance(userID string) (int, error)
 	GetCoinsStats(userID string) (*models.CoinsStatsResponse, error)
 
+	// Rewards
+	HasReward(userID, source, relatedID string) (bool, error)
+	CreateRewardRecords(points *models.PointsRecord, coins *models.CoinsRecord) error
+
 	// Checkin
 	CreateCheckinRecord(record *models.CheckinRecord) error
 	GetLastCheckinRecord(userID string) (*models.CheckinRecord, error)
@@ -54,6 +59,11 @@ type PaymentRepository interface {
 	// Wallet
 	GetUserWallet(userID string) (*models.WalletResponse, error)
 	UpdateUserBalance(userID string, pointsDelta, coinsDelta int) error
+
+	// User Data
+	ExportUserData(userID string) (*models.UserDataExport, error)
+	DeleteUserData(userID string) error
+	MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error)
 }
 
 type paymentRepository struct {
@@ -448,8 +458,230 @@ func (r *paymentRepository) GetUserWallet(userID string) (*models.WalletResponse
 	return wallet, nil
 }
 
+// HasReward 是否已按 source 和 related_id 发放过积分或阅读币
+func (r *paymentRepository) HasReward(userID, source, relatedID string) (bool, error) {
+	for _, model := range []interface{}{&models.PointsRecord{}, &models.CoinsRecord{}} {
+		var count int64
+		err := r.db.Model(model).
+			Where("user_id = ? AND source = ? AND related_id = ?", userID, source, relatedID).
+			Count(&count).Error
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CreateRewardRecords 同一事务写入积分和阅读币记录，为空的跳过
+func (r *paymentRepository) CreateRewardRecords(points *models.PointsRecord, coins *models.CoinsRecord) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if points != nil {
+			if err := tx.Create(points).Error; err != nil {
+				return err
+			}
+		}
+		if coins != nil {
+			return tx.Create(coins).Error
+		}
+		return nil
+	})
+}
+
 func (r *paymentRepository) UpdateUserBalance(userID string, pointsDelta, coinsDelta int) error {
 	// 这里应该与用户服务同步更新用户表中的余额
 	// 由于这是微服务架构，实际实现中可能需要通过消息队列或API调用来同步
 	return nil
-}
\ No newline at end of file
+}
+
+// User Data
+func (r *paymentRepository) ExportUserData(userID string) (*models.UserDataExport, error) {
+	data := &models.UserDataExport{}
+	queries := []interface{}{
+		&data.VipMemberships,
+		&data.PointsRecords,
+		&data.CoinsRecords,
+		&data.CheckinRecords,
+	}
+	for _, dest := range queries {
+		if err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := r.db.Preload("Gift").Where("user_id = ?", userID).Order("created_at DESC").Find(&data.UserGifts).Error; err != nil {
+		return nil, err
+	}
+
+	wallet, err := r.GetUserWallet(userID)
+	if err != nil {
+		return nil, err
+	}
+	data.Wallet = wallet
+	return data, nil
+}
+
+// DeleteUserData 删除积分、签到和礼品记录；会员和阅读币流水需留档对账，改挂到占位用户
+func (r *paymentRepository) DeleteUserData(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&models.PointsRecord{},
+			&models.CheckinRecord{},
+			&models.UserGift{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, model := range []interface{}{
+			&models.VipMembership{},
+			&models.CoinsRecord{},
+		} {
+			if err := tx.Model(model).Where("user_id = ?", userID).Update("user_id", utils.DeletedUserID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.RedeemCode{}).
+			Where("used_by = ?", userID).
+			Update("used_by", utils.DeletedUserID).Error
+	})
+}
+
+// MergeUserData 账号合并：积分、阅读币流水和礼品全部转移，余额随流水合并；同一天的签到只保留一条。
+// 两个账号都有生效中的会员时，保留到期较晚的一条，另一条的剩余时长加到它上面
+func (r *paymentRepository) MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	result := make(map[string]utils.MergeCount)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		moved := []struct {
+			name  string
+			model interface{}
+		}{
+			{"vip_memberships", &models.VipMembership{}},
+			{"points_records", &models.PointsRecord{}},
+			{"coins_records", &models.CoinsRecord{}},
+			{"user_gifts", &models.UserGift{}},
+		}
+		for _, m := range moved {
+			count, err := moveAll(tx, m.model, "user_id", sourceID, targetID, dryRun)
+			if err != nil {
+				return err
+			}
+			result[m.name] = count
+		}
+		count, err := moveAll(tx, &models.RedeemCode{}, "used_by", sourceID, targetID, dryRun)
+		if err != nil {
+			return err
+		}
+		result["redeem_codes"] = count
+
+		count, err = mergeByKey(tx, &models.CheckinRecord{}, "DATE_FORMAT(checkin_date, '%Y-%m-%d')", "created_at", sourceID, targetID, dryRun)
+		if err != nil {
+			return err
+		}
+		result["checkin_records"] = count
+
+		if dryRun {
+			return nil
+		}
+		return mergeActiveVip(tx, targetID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeActiveVip 合并后只保留一条生效中的会员，其余的剩余时长累加到到期最晚的一条上
+func mergeActiveVip(tx *gorm.DB, userID string) error {
+	now := time.Now()
+	var active []models.VipMembership
+	if err := tx.Where("user_id = ? AND is_active = true AND end_date > ?", userID, now).
+		Order("end_date DESC").
+		Find(&active).Error; err != nil {
+		return err
+	}
+	if len(active) < 2 {
+		return nil
+	}
+
+	kept := active[0]
+	ids := make([]string, 0, len(active)-1)
+	for _, m := range active[1:] {
+		kept.EndDate = kept.EndDate.Add(m.EndDate.Sub(now))
+		ids = append(ids, m.ID)
+	}
+	if err := tx.Model(&models.VipMembership{}).Where("id IN ?", ids).Update("is_active", false).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.VipMembership{}).Where("id = ?", kept.ID).Update("end_date", kept.EndDate).Error
+}
+
+// mergeRow 合并时比较的行，MergeKey 相同视为同一条数据
+type mergeRow struct {
+	ID       string
+	UserID   string
+	MergeKey string
+	MergeAt  time.Time
+}
+
+// mergeByKey 把 sourceID 的行转给 targetID；与目标账号 key 相同的行只保留 at 最新的一条，其余删除
+func mergeByKey(tx *gorm.DB, model interface{}, keyExpr, atExpr, sourceID, targetID string, dryRun bool) (utils.MergeCount, error) {
+	var count utils.MergeCount
+	var rows []mergeRow
+	if err := tx.Model(model).
+		Select("id, user_id, "+keyExpr+" AS merge_key, "+atExpr+" AS merge_at").
+		Where("user_id IN ?", []string{sourceID, targetID}).
+		Scan(&rows).Error; err != nil {
+		return count, err
+	}
+
+	latest := make(map[string]mergeRow)
+	conflict := make(map[string]bool)
+	for _, row := range rows {
+		if row.UserID == targetID {
+			conflict[row.MergeKey] = true
+		}
+		if current, ok := latest[row.MergeKey]; !ok || row.MergeAt.After(current.MergeAt) {
+			latest[row.MergeKey] = row
+		}
+	}
+
+	var moveIDs, deleteIDs []string
+	for _, row := range rows {
+		switch {
+		case conflict[row.MergeKey] && latest[row.MergeKey].ID != row.ID:
+			deleteIDs = append(deleteIDs, row.ID)
+		case row.UserID == sourceID:
+			moveIDs = append(moveIDs, row.ID)
+		}
+	}
+	count.Moved = int64(len(moveIDs))
+	count.Discarded = int64(len(deleteIDs))
+	if dryRun {
+		return count, nil
+	}
+
+	if len(deleteIDs) > 0 {
+		if err := tx.Where("id IN ?", deleteIDs).Delete(model).Error; err != nil {
+			return count, err
+		}
+	}
+	if len(moveIDs) > 0 {
+		if err := tx.Model(model).Where("id IN ?", moveIDs).Update("user_id", targetID).Error; err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func moveAll(tx *gorm.DB, model interface{}, column, sourceID, targetID string, dryRun bool) (utils.MergeCount, error) {
+	var count utils.MergeCount
+	if dryRun {
+		err := tx.Model(model).Where(column+" = ?", sourceID).Count(&count.Moved).Error
+		return count, err
+	}
+	res := tx.Model(model).Where(column+" = ?", sourceID).Update(column, targetID)
+	count.Moved = res.RowsAffected
+	return count, res.Error
+}
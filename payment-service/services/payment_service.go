@@ -1,14 +1,20 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"time"
 	"fmt"
 	"gorm.io/gorm"
 	"reading-microservices/payment-service/models"
 	"reading-microservices/payment-service/repositories"
+	"reading-microservices/shared/userclient"
+	"reading-microservices/shared/utils"
 )
 
+// ErrPurchaseRestricted 青少年模式下不能购买
+var ErrPurchaseRestricted = errors.New("purchases are not allowed in teen mode")
+
 type PaymentService interface {
 	// VIP Management
 	CreateVipMembership(userID string, req *models.CreateVipMembershipRequest) (*models.VipMembershipResponse, error)
@@ -27,6 +33,9 @@ type PaymentService interface {
 	GetCoinsHistory(userID string, page, size int) ([]models.CoinsRecordResponse, int64, error)
 	GetCoinsStats(userID string) (*models.CoinsStatsResponse, error)
 
+	// Rewards
+	GrantReward(req *models.GrantRewardRequest) (bool, error)
+
 	// Checkin System
 	DailyCheckin(userID string) (*models.CheckinResponse, error)
 	GetCheckinStatus(userID string) (*models.CheckinStatusResponse, error)
@@ -47,18 +56,28 @@ type PaymentService interface {
 
 	// Wallet
 	GetUserWallet(userID string) (*models.WalletResponse, error)
+
+	// User Data
+	ExportUserData(userID string) (*models.UserDataExport, error)
+	DeleteUserData(userID string) error
+	MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error)
 }
 
 type paymentService struct {
-	repo repositories.PaymentRepository
+	repo  repositories.PaymentRepository
+	users *userclient.Client
 }
 
-func NewPaymentService(repo repositories.PaymentRepository) PaymentService {
-	return &paymentService{repo: repo}
+func NewPaymentService(repo repositories.PaymentRepository, users *userclient.Client) PaymentService {
+	return &paymentService{repo: repo, users: users}
 }
 
 // VIP Management
 func (s *paymentService) CreateVipMembership(userID string, req *models.CreateVipMembershipRequest) (*models.VipMembershipResponse, error) {
+	if err := s.checkPurchaseAllowed(userID); err != nil {
+		return nil, err
+	}
+
 	startDate := time.Now()
 	endDate := startDate.AddDate(0, req.Duration, 0)
 
@@ -187,6 +206,10 @@ func (s *paymentService) EarnCoins(userID string, req *models.EarnCoinsRequest)
 }
 
 func (s *paymentService) SpendCoins(userID string, req *models.SpendCoinsRequest) error {
+	if err := s.checkPurchaseAllowed(userID); err != nil {
+		return err
+	}
+
 	// 检查余额是否足够
 	balance, err := s.repo.GetUserCoinsBalance(userID)
 	if err != nil {
@@ -211,6 +234,46 @@ func (s *paymentService) SpendCoins(userID string, req *models.SpendCoinsRequest
 	return s.repo.CreateCoinsRecord(record)
 }
 
+// GrantReward 发放积分和阅读币，重复请求不会重复发放；返回是否为重复请求
+func (s *paymentService) GrantReward(req *models.GrantRewardRequest) (bool, error) {
+	granted, err := s.repo.HasReward(req.UserID, req.Source, req.RelatedID)
+	if err != nil {
+		return false, err
+	}
+	if granted {
+		return true, nil
+	}
+
+	now := time.Now()
+	var points *models.PointsRecord
+	if req.Points > 0 {
+		points = &models.PointsRecord{
+			UserID:      req.UserID,
+			Points:      req.Points,
+			PointsType:  "earn",
+			Source:      req.Source,
+			Description: req.Description,
+			RelatedID:   &req.RelatedID,
+			RelatedType: req.RelatedType,
+			CreatedAt:   now,
+		}
+	}
+	var coins *models.CoinsRecord
+	if req.Coins > 0 {
+		coins = &models.CoinsRecord{
+			UserID:      req.UserID,
+			Coins:       req.Coins,
+			CoinsType:   "earn",
+			Source:      req.Source,
+			Description: req.Description,
+			RelatedID:   &req.RelatedID,
+			RelatedType: req.RelatedType,
+			CreatedAt:   now,
+		}
+	}
+	return false, s.repo.CreateRewardRecords(points, coins)
+}
+
 func (s *paymentService) GetCoinsHistory(userID string, page, size int) ([]models.CoinsRecordResponse, int64, error) {
 	records, total, err := s.repo.GetUserCoinsRecords(userID, page, size)
 	if err != nil {
@@ -513,7 +576,32 @@ func (s *paymentService) GetUserWallet(userID string) (*models.WalletResponse, e
 	return s.repo.GetUserWallet(userID)
 }
 
+// User Data
+func (s *paymentService) ExportUserData(userID string) (*models.UserDataExport, error) {
+	return s.repo.ExportUserData(userID)
+}
+
+func (s *paymentService) DeleteUserData(userID string) error {
+	return s.repo.DeleteUserData(userID)
+}
+
+func (s *paymentService) MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error) {
+	return s.repo.MergeUserData(sourceID, req.TargetUserID, req.DryRun)
+}
+
 // Helper methods
+// checkPurchaseAllowed 青少年模式下禁止购买；用户服务不可用时同样拒绝，避免未成年人在故障期间消费
+func (s *paymentService) checkPurchaseAllowed(userID string) error {
+	mode, err := s.users.GetTeenMode(context.Background(), userID)
+	if err != nil {
+		return fmt.Errorf("check teen mode: %w", err)
+	}
+	if mode.Enabled {
+		return ErrPurchaseRestricted
+	}
+	return nil
+}
+
 func (s *paymentService) calculateCheckinRewards(consecutiveDays int) (int, int) {
 	basePoints := 10
 	baseCoins := 5
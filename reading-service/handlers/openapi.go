@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"reading-microservices/reading-service/models"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
+)
+
+// bookshelfQuery 书架查询参数（handler 中直接读取 query）
+type bookshelfQuery struct {
+	ShelfType string `form:"shelf_type" binding:"omitempty,oneof=reading favorite download"`
+	Page      int    `form:"page"`
+	Size      int    `form:"size"`
+}
+
+type shelfTypeQuery struct {
+	ShelfType string `form:"shelf_type" binding:"required,oneof=reading favorite download"`
+}
+
+// OpenAPIDocument 阅读服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("reading-service", "1.0.0")
+	return doc.AddRoutes(
+		// 阅读进度
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/progress", Summary: "更新阅读进度", Tag: "阅读进度", Auth: true,
+			Body: models.UpdateReadingProgressRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/progress/:novel_id/:chapter_id", Summary: "获取阅读进度", Tag: "阅读进度", Auth: true,
+			Response: models.ReadingRecordResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/history", Summary: "阅读历史", Tag: "阅读进度", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.ReadingRecordResponse{}, Paged: true},
+
+		// 书架
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/bookshelf", Summary: "加入书架", Tag: "书架", Auth: true,
+			Body: models.AddToBookshelfRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/reading/bookshelf/:novel_id", Summary: "移出书架", Tag: "书架", Auth: true,
+			Query: shelfTypeQuery{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/bookshelf", Summary: "获取书架", Tag: "书架", Auth: true,
+			Query: bookshelfQuery{}, Response: []models.BookshelfResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/bookshelf/stats", Summary: "书架统计", Tag: "书架", Auth: true,
+			Response: models.BookshelfStatsResponse{}},
+
+		// 收藏
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/favorites/:novel_id", Summary: "收藏小说", Tag: "收藏", Auth: true},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/reading/favorites/:novel_id", Summary: "取消收藏", Tag: "收藏", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/favorites", Summary: "收藏列表", Tag: "收藏", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.BookshelfResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/favorites/:novel_id/status", Summary: "收藏状态", Tag: "收藏", Auth: true},
+
+		// 评论
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/comments", Summary: "发表评论", Tag: "评论", Auth: true,
+			Body: models.CreateCommentRequest{}, Response: models.CommentResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/reading/comments/:id", Summary: "修改评论", Tag: "评论", Auth: true,
+			Body: models.UpdateCommentRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/reading/comments/:id", Summary: "删除评论", Tag: "评论", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/comments/user", Summary: "我的评论", Tag: "评论", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.CommentResponse{}, Paged: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/comments/:id/like", Summary: "点赞评论", Tag: "评论", Auth: true},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/reading/comments/:id/like", Summary: "取消点赞", Tag: "评论", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/public/novels/:novel_id/comments", Summary: "小说评论", Tag: "评论",
+			Query: openapi.PageQuery{}, Response: []models.CommentResponse{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/public/chapters/:chapter_id/comments", Summary: "章节评论", Tag: "评论",
+			Query: openapi.PageQuery{}, Response: []models.CommentResponse{}, Paged: true},
+
+		// 搜索历史
+		openapi.Route{Method: "POST", Path: "/api/v1/reading/search/history", Summary: "添加搜索历史", Tag: "搜索历史", Auth: true,
+			Body: models.AddSearchHistoryRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/search/history", Summary: "获取搜索历史", Tag: "搜索历史", Auth: true,
+			Response: []models.SearchHistory{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/reading/search/history", Summary: "清空搜索历史", Tag: "搜索历史", Auth: true},
+
+		// 统计
+		openapi.Route{Method: "GET", Path: "/api/v1/reading/stats", Summary: "阅读统计", Tag: "统计", Auth: true,
+			Response: models.ReadingStatsResponse{}},
+
+		// 内部接口
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/reading/users/:user_id/data", Summary: "导出个人数据", Tag: "内部接口",
+			Response: models.UserDataExport{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/internal/reading/users/:user_id/data", Summary: "删除个人数据", Tag: "内部接口"},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/reading/users/:user_id/merge", Summary: "合并账号数据", Tag: "内部接口",
+			Body: utils.MergeUserDataRequest{}, Response: map[string]utils.MergeCount{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/reading/users/:user_id/bookshelf", Summary: "用户书架", Tag: "内部接口",
+			Query: openapi.PageQuery{}, Response: []models.BookshelfResponse{}, Paged: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/reading/activity", Summary: "用户动态", Tag: "内部接口",
+			Body: models.UserActivityRequest{}, Response: []models.ActivityItem{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/reading/search/trending", Summary: "热门搜索词", Tag: "内部接口",
+			Query: models.TrendingKeywordsQuery{}, Response: []models.TrendingKeyword{}},
+	)
+}
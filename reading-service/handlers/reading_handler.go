@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 	"github.com/gin-gonic/gin"
 	"reading-microservices/shared/utils"
@@ -33,6 +34,10 @@ func (h *ReadingHandler) UpdateReadingProgress(c *gin.Context) {
 	}
 
 	if err := h.readingService.UpdateReadingProgress(userID, &req); err != nil {
+		if errors.Is(err, services.ErrReadingRestricted) {
+			utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+			return
+		}
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -424,12 +429,7 @@ func (h *ReadingHandler) AddSearchHistory(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Keyword     string `json:"keyword" binding:"required"`
-		SearchType  string `json:"search_type"`
-		ResultCount int    `json:"result_count"`
-	}
-
+	var req models.AddSearchHistoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
 		return
@@ -491,6 +491,90 @@ func (h *ReadingHandler) GetReadingStats(c *gin.Context) {
 	utils.Success(c, stats)
 }
 
+// User Data Handlers（内部接口，供用户服务导出和注销时调用）
+func (h *ReadingHandler) ExportUserData(c *gin.Context) {
+	data, err := h.readingService.ExportUserData(c.Param("user_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, data)
+}
+
+func (h *ReadingHandler) DeleteUserData(c *gin.Context) {
+	if err := h.readingService.DeleteUserData(c.Param("user_id")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "User data deleted", nil)
+}
+
+// MergeUserData 账号合并时把数据转移到目标账号，返回各类数据的转移和丢弃条数
+func (h *ReadingHandler) MergeUserData(c *gin.Context) {
+	var req utils.MergeUserDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	result, err := h.readingService.MergeUserData(c.Param("user_id"), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// Public profile Handlers（内部接口，用户服务检查隐私设置后调用）
+func (h *ReadingHandler) GetUserBookshelf(c *gin.Context) {
+	shelfType := c.Query("shelf_type")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+
+	bookshelf, total, err := h.readingService.GetBookshelf(c.Param("user_id"), shelfType, page, size)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.PageSuccess(c, bookshelf, total, page, size)
+}
+
+func (h *ReadingHandler) GetTrendingKeywords(c *gin.Context) {
+	var query models.TrendingKeywordsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	keywords, err := h.readingService.GetTrendingKeywords(&query)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, keywords)
+}
+
+func (h *ReadingHandler) GetUserActivity(c *gin.Context) {
+	var req models.UserActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	items, err := h.readingService.GetUserActivity(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, items)
+}
+
 // Health check
 func (h *ReadingHandler) Health(c *gin.Context) {
 	c.JSON(200, gin.H{
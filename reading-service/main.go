@@ -6,10 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"log"
+	"time"
 
 	"reading-microservices/reading-service/handlers"
 	"reading-microservices/reading-service/models"
@@ -17,6 +19,9 @@ import (
 	"reading-microservices/reading-service/services"
 	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/userclient"
+	"reading-microservices/shared/utils"
 )
 
 func main() {
@@ -58,13 +63,19 @@ func main() {
 	readingRepo := repositories.NewReadingRepository(db)
 
 	// 初始化Service
-	readingService := services.NewReadingService(readingRepo)
+	// 阅读和评论经验由用户服务记录，评论作者信息从用户服务批量查询；地址未配置时都跳过
+	userServiceURL := viper.GetString("user_service_url")
+	readingService := services.NewReadingService(
+		readingRepo,
+		services.NewExperienceClient(userServiceURL, cfg.Internal.Token),
+		userclient.New(userServiceURL, cfg.Internal.Token, 5*time.Second),
+	)
 
 	// 初始化Handler
 	readingHandler := handlers.NewReadingHandler(readingService)
 
 	// 初始化路由
-	router := setupRouter(readingHandler, cfg.JWT.Secret)
+	router := setupRouter(readingHandler, cfg.JWT.NewVerifier(), cfg.Internal.Token)
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -121,7 +132,7 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(readingHandler *handlers.ReadingHandler, jwtSecret string) *gin.Engine {
+func setupRouter(readingHandler *handlers.ReadingHandler, verifier utils.TokenVerifier, internalToken string) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -132,9 +143,12 @@ func setupRouter(readingHandler *handlers.ReadingHandler, jwtSecret string) *gin
 	// 健康检查
 	router.GET("/health", readingHandler.Health)
 
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
 	// API路由 - 需要认证
 	v1 := router.Group("/api/v1/reading")
-	v1.Use(middleware.JWTAuth(jwtSecret))
+	v1.Use(middleware.JWTAuth(verifier))
 	{
 		// 阅读进度
 		v1.POST("/progress", readingHandler.UpdateReadingProgress)
@@ -179,5 +193,17 @@ func setupRouter(readingHandler *handlers.ReadingHandler, jwtSecret string) *gin
 		public.GET("/chapters/:chapter_id/comments", readingHandler.GetChapterComments)
 	}
 
+	// 内部API - 供用户服务导出和删除个人数据、展示公开主页和关注动态，不经网关暴露，需携带服务间凭证
+	internal := router.Group("/api/v1/internal/reading")
+	internal.Use(middleware.InternalAuth(internalToken))
+	{
+		internal.GET("/users/:user_id/data", readingHandler.ExportUserData)
+		internal.DELETE("/users/:user_id/data", readingHandler.DeleteUserData)
+		internal.POST("/users/:user_id/merge", readingHandler.MergeUserData)
+		internal.GET("/users/:user_id/bookshelf", readingHandler.GetUserBookshelf)
+		internal.POST("/activity", readingHandler.GetUserActivity)
+		internal.GET("/search/trending", readingHandler.GetTrendingKeywords)
+	}
+
 	return router
 }
@@ -3,6 +3,7 @@ package models
 import (
 	"crypto/rand"
 	"fmt"
+	"time"
 )
 
 func generateUUID() string {
@@ -21,7 +22,7 @@ type UpdateReadingProgressRequest struct {
 	ChapterNumber   int     `json:"chapter_number" binding:"required"`
 	ReadingPosition int     `json:"reading_position"`
 	ReadingProgress float64 `json:"reading_progress"`
-	ReadingTime     int     `json:"reading_time"`
+	ReadingTime     int     `json:"reading_time"` // 本次阅读秒数，青少年模式据此累计当天时长
 }
 
 type AddToBookshelfRequest struct {
@@ -42,6 +43,12 @@ type UpdateCommentRequest struct {
 	Rating  *int   `json:"rating" binding:"omitempty,min=1,max=5"`
 }
 
+type AddSearchHistoryRequest struct {
+	Keyword     string `json:"keyword" binding:"required"`
+	SearchType  string `json:"search_type"`
+	ResultCount int    `json:"result_count"`
+}
+
 type SearchRequest struct {
 	Keyword    string `form:"keyword" binding:"required"`
 	SearchType string `form:"search_type" binding:"omitempty,oneof=novel author tag"`
@@ -100,10 +107,13 @@ type NovelInfo struct {
 }
 
 type UserInfo struct {
-	ID        string  `json:"id"`
-	Username  string  `json:"username"`
-	Nickname  *string `json:"nickname"`
-	AvatarURL *string `json:"avatar_url"`
+	ID               string            `json:"id"`
+	Username         string            `json:"username"`
+	Nickname         *string           `json:"nickname"`
+	AvatarURL        *string           `json:"avatar_url"`
+	AvatarThumbnails map[string]string `json:"avatar_thumbnails,omitempty"`
+	Level            *int              `json:"level,omitempty"`
+	VipLevel         string            `json:"vip_level"`
 }
 
 type ReadingStatsResponse struct {
@@ -120,4 +130,44 @@ type BookshelfStatsResponse struct {
 	Favorite  int `json:"favorite"`  // 收藏数量
 	Download  int `json:"download"`  // 下载数量
 	Archived  int `json:"archived"`  // 归档数量
-}
\ No newline at end of file
+}
+
+// UserDataExport 个人数据导出，供用户服务汇总打包
+type UserDataExport struct {
+	ReadingRecords []ReadingRecord `json:"reading_records"`
+	Bookshelf      []Bookshelf     `json:"bookshelf"`
+	Favorites      []Favorite      `json:"favorites"`
+	Comments       []Comment       `json:"comments"`
+	SearchHistory  []SearchHistory `json:"search_history"`
+}
+
+// UserActivityRequest 查询多个用户的动态（内部接口）
+type UserActivityRequest struct {
+	UserIDs []string   `json:"user_ids" binding:"required,min=1,max=500"`
+	Before  *time.Time `json:"before"` // 只返回此时间之前的动态，用于翻页
+	Limit   int        `json:"limit"`
+}
+
+// TrendingKeywordsQuery 热门搜索词查询参数（内部接口）
+type TrendingKeywordsQuery struct {
+	Days     int `form:"days" binding:"omitempty,min=1,max=30"`   // 统计最近几天，默认 7
+	Limit    int `form:"limit" binding:"omitempty,min=1,max=200"` // 默认 50
+	MinUsers int `form:"min_users" binding:"omitempty,min=1"`     // 至少有多少用户搜过，默认 3
+}
+
+// TrendingKeyword 热门搜索词，只统计有搜索结果的关键词
+type TrendingKeyword struct {
+	Keyword string `json:"keyword"`
+	Users   int64  `json:"users"`
+}
+
+// ActivityItem 用户动态：review 为书评，finished 为读完一本书
+type ActivityItem struct {
+	Type      string    `json:"type"`
+	UserID    string    `json:"user_id"`
+	NovelID   string    `json:"novel_id"`
+	CommentID string    `json:"comment_id,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	Rating    *int      `json:"rating,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
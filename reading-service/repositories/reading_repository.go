@@ -3,6 +3,7 @@ package repositories
 import (
 	"gorm.io/gorm"
 	"reading-microservices/reading-service/models"
+	"reading-microservices/shared/utils"
 	"time"
 )
 
@@ -41,9 +42,19 @@ type ReadingRepository interface {
 	AddSearchHistory(history *models.SearchHistory) error
 	GetUserSearchHistory(userID string, limit int) ([]models.SearchHistory, error)
 	ClearUserSearchHistory(userID string) error
+	GetTrendingKeywords(since time.Time, minUsers, limit int) ([]models.TrendingKeyword, error)
 
 	// Statistics
 	GetReadingStats(userID string) (*models.ReadingStatsResponse, error)
+
+	// User Data
+	ExportUserData(userID string) (*models.UserDataExport, error)
+	DeleteUserData(userID string) error
+	MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error)
+
+	// Activity
+	GetUserReviews(userIDs []string, before time.Time, limit int) ([]models.Comment, error)
+	GetFinishedBooks(userIDs []string, before time.Time, limit int) ([]models.Bookshelf, error)
 }
 
 type readingRepository struct {
@@ -71,7 +82,12 @@ func (r *readingRepository) CreateOrUpdateReadingRecord(record *models.ReadingRe
 		existing.ReadingProgress = record.ReadingProgress
 		existing.ReadingTime += record.ReadingTime
 		existing.LastReadAt = record.LastReadAt
-		return r.db.Save(&existing).Error
+		if err := r.db.Save(&existing).Error; err != nil {
+			return err
+		}
+		// 回填累计后的记录，调用方据此计算阅读时长
+		*record = existing
+		return nil
 	}
 }
 
@@ -381,6 +397,21 @@ func (r *readingRepository) ClearUserSearchHistory(userID string) error {
 	return r.db.Where("user_id = ?", userID).Delete(&models.SearchHistory{}).Error
 }
 
+// GetTrendingKeywords 按搜索人数统计 since 之后的热门关键词。每个用户的同一关键词只保留一条记录，
+// 所以一个用户反复搜索不会刷高排名
+func (r *readingRepository) GetTrendingKeywords(since time.Time, minUsers, limit int) ([]models.TrendingKeyword, error) {
+	var keywords []models.TrendingKeyword
+	err := r.db.Model(&models.SearchHistory{}).
+		Select("TRIM(keyword) AS keyword, COUNT(DISTINCT user_id) AS users").
+		Where("created_at >= ? AND result_count > 0", since).
+		Group("TRIM(keyword)").
+		Having("COUNT(DISTINCT user_id) >= ?", minUsers).
+		Order("users DESC").
+		Limit(limit).
+		Scan(&keywords).Error
+	return keywords, err
+}
+
 // Statistics
 func (r *readingRepository) GetReadingStats(userID string) (*models.ReadingStatsResponse, error) {
 	stats := &models.ReadingStatsResponse{}
@@ -439,3 +470,178 @@ func (r *readingRepository) calculateReadingStreak(userID string) int {
 
 	return streak
 }
+
+// User Data
+func (r *readingRepository) ExportUserData(userID string) (*models.UserDataExport, error) {
+	data := &models.UserDataExport{}
+	queries := []struct {
+		dest  interface{}
+		order string
+	}{
+		{&data.ReadingRecords, "last_read_at DESC"},
+		{&data.Bookshelf, "added_at DESC"},
+		{&data.Favorites, "created_at DESC"},
+		{&data.Comments, "created_at DESC"},
+		{&data.SearchHistory, "created_at DESC"},
+	}
+	for _, q := range queries {
+		if err := r.db.Where("user_id = ?", userID).Order(q.order).Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// DeleteUserData 删除阅读记录、书架、收藏和搜索历史；评论保留内容，改挂到占位用户
+func (r *readingRepository) DeleteUserData(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&models.ReadingRecord{},
+			&models.Bookshelf{},
+			&models.Favorite{},
+			&models.SearchHistory{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Comment{}).
+			Where("user_id = ?", userID).
+			Update("user_id", utils.DeletedUserID).Error
+	})
+}
+
+// MergeUserData 账号合并：阅读记录、书架和收藏与目标账号重复时保留最近的一条，评论和搜索历史全部转移
+func (r *readingRepository) MergeUserData(sourceID, targetID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	result := make(map[string]utils.MergeCount)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		keyed := []struct {
+			name  string
+			model interface{}
+			key   string
+			at    string
+		}{
+			{"reading_records", &models.ReadingRecord{}, "CONCAT(novel_id, ':', chapter_id)", "last_read_at"},
+			{"bookshelf", &models.Bookshelf{}, "CONCAT(novel_id, ':', shelf_type)", "COALESCE(last_read_at, added_at)"},
+			{"favorites", &models.Favorite{}, "novel_id", "created_at"},
+		}
+		for _, m := range keyed {
+			count, err := mergeByKey(tx, m.model, m.key, m.at, sourceID, targetID, dryRun)
+			if err != nil {
+				return err
+			}
+			result[m.name] = count
+		}
+
+		moved := []struct {
+			name  string
+			model interface{}
+		}{
+			{"comments", &models.Comment{}},
+			{"search_history", &models.SearchHistory{}},
+		}
+		for _, m := range moved {
+			count, err := moveAll(tx, m.model, sourceID, targetID, dryRun)
+			if err != nil {
+				return err
+			}
+			result[m.name] = count
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeRow 合并时比较的行，MergeKey 相同视为同一条数据
+type mergeRow struct {
+	ID       string
+	UserID   string
+	MergeKey string
+	MergeAt  time.Time
+}
+
+// mergeByKey 把 sourceID 的行转给 targetID；与目标账号 key 相同的行只保留 at 最新的一条，其余删除
+func mergeByKey(tx *gorm.DB, model interface{}, keyExpr, atExpr, sourceID, targetID string, dryRun bool) (utils.MergeCount, error) {
+	var count utils.MergeCount
+	var rows []mergeRow
+	if err := tx.Model(model).
+		Select("id, user_id, "+keyExpr+" AS merge_key, "+atExpr+" AS merge_at").
+		Where("user_id IN ?", []string{sourceID, targetID}).
+		Scan(&rows).Error; err != nil {
+		return count, err
+	}
+
+	latest := make(map[string]mergeRow)
+	conflict := make(map[string]bool)
+	for _, row := range rows {
+		if row.UserID == targetID {
+			conflict[row.MergeKey] = true
+		}
+		if current, ok := latest[row.MergeKey]; !ok || row.MergeAt.After(current.MergeAt) {
+			latest[row.MergeKey] = row
+		}
+	}
+
+	var moveIDs, deleteIDs []string
+	for _, row := range rows {
+		switch {
+		case conflict[row.MergeKey] && latest[row.MergeKey].ID != row.ID:
+			deleteIDs = append(deleteIDs, row.ID)
+		case row.UserID == sourceID:
+			moveIDs = append(moveIDs, row.ID)
+		}
+	}
+	count.Moved = int64(len(moveIDs))
+	count.Discarded = int64(len(deleteIDs))
+	if dryRun {
+		return count, nil
+	}
+
+	if len(deleteIDs) > 0 {
+		if err := tx.Where("id IN ?", deleteIDs).Delete(model).Error; err != nil {
+			return count, err
+		}
+	}
+	if len(moveIDs) > 0 {
+		if err := tx.Model(model).Where("id IN ?", moveIDs).Update("user_id", targetID).Error; err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+func moveAll(tx *gorm.DB, model interface{}, sourceID, targetID string, dryRun bool) (utils.MergeCount, error) {
+	var count utils.MergeCount
+	if dryRun {
+		err := tx.Model(model).Where("user_id = ?", sourceID).Count(&count.Moved).Error
+		return count, err
+	}
+	res := tx.Model(model).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	count.Moved = res.RowsAffected
+	return count, res.Error
+}
+
+// GetUserReviews 带评分的顶层评论视为书评
+func (r *readingRepository) GetUserReviews(userIDs []string, before time.Time, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.Where("user_id IN ? AND parent_id IS NULL AND rating IS NOT NULL AND is_deleted = ? AND created_at < ?",
+		userIDs, false, before).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// GetFinishedBooks 在读书架中进度达到 100% 的书
+func (r *readingRepository) GetFinishedBooks(userIDs []string, before time.Time, limit int) ([]models.Bookshelf, error) {
+	var items []models.Bookshelf
+	err := r.db.Where("user_id IN ? AND shelf_type = 'reading' AND reading_progress >= 100 AND last_read_at IS NOT NULL AND last_read_at < ?",
+		userIDs, before).
+		Order("last_read_at DESC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
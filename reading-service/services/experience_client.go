@@ -0,0 +1,83 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"reading-microservices/shared/utils"
+)
+
+// 经验事件，与用户服务 progression.rules 中的事件对应
+const (
+	XPEventReadingTime     = "reading_time"
+	XPEventChapterFinished = "chapter_finished"
+	XPEventComment         = "comment"
+)
+
+// ExperienceClient 通知用户服务给用户记经验
+type ExperienceClient interface {
+	Award(userID, event, eventID string, amount int)
+}
+
+// NewExperienceClient 未配置用户服务地址时不记经验，token 为服务间凭证
+func NewExperienceClient(userServiceURL, token string) ExperienceClient {
+	if userServiceURL == "" {
+		return noopExperienceClient{}
+	}
+	return &httpExperienceClient{
+		endpoint: strings.TrimRight(userServiceURL, "/") + "/api/v1/internal/user/experience",
+		client:   utils.NewInternalClient(token, 5*time.Second),
+	}
+}
+
+type httpExperienceClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// Award 异步调用，失败只记日志，不影响阅读和评论；event_id 保证重试不会重复记
+func (c *httpExperienceClient) Award(userID, event, eventID string, amount int) {
+	go func() {
+		if err := c.award(userID, event, eventID, amount); err != nil {
+			logrus.Warnf("Failed to award experience %s/%s to user %s: %v", event, eventID, userID, err)
+		}
+	}()
+}
+
+func (c *httpExperienceClient) award(userID, event, eventID string, amount int) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"user_id":  userID,
+		"event":    event,
+		"event_id": eventID,
+		"amount":   amount,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != utils.SUCCESS {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, result.Message)
+	}
+	return nil
+}
+
+type noopExperienceClient struct{}
+
+func (noopExperienceClient) Award(userID, event, eventID string, amount int) {}
@@ -1,12 +1,22 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reading-microservices/reading-service/models"
 	"reading-microservices/reading-service/repositories"
+	"reading-microservices/shared/userclient"
+	"reading-microservices/shared/utils"
+	"sort"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
+// ErrReadingRestricted 青少年模式下今日阅读时长已用完或处于宵禁时段
+var ErrReadingRestricted = errors.New("reading is restricted by teen mode")
+
 type ReadingService interface {
 	// Reading Progress
 	UpdateReadingProgress(userID string, req *models.UpdateReadingProgressRequest) error
@@ -39,17 +49,28 @@ type ReadingService interface {
 	AddSearchHistory(userID, keyword, searchType string, resultCount int) error
 	GetSearchHistory(userID string) ([]models.SearchHistory, error)
 	ClearSearchHistory(userID string) error
+	GetTrendingKeywords(query *models.TrendingKeywordsQuery) ([]models.TrendingKeyword, error)
 
 	// Statistics
 	GetReadingStats(userID string) (*models.ReadingStatsResponse, error)
+
+	// User Data
+	ExportUserData(userID string) (*models.UserDataExport, error)
+	DeleteUserData(userID string) error
+	MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error)
+
+	// Activity
+	GetUserActivity(req *models.UserActivityRequest) ([]models.ActivityItem, error)
 }
 
 type readingService struct {
-	repo repositories.ReadingRepository
+	repo  repositories.ReadingRepository
+	xp    ExperienceClient
+	users *userclient.Client
 }
 
-func NewReadingService(repo repositories.ReadingRepository) ReadingService {
-	return &readingService{repo: repo}
+func NewReadingService(repo repositories.ReadingRepository, xp ExperienceClient, users *userclient.Client) ReadingService {
+	return &readingService{repo: repo, xp: xp, users: users}
 }
 
 // Reading Progress
@@ -69,9 +90,30 @@ func (s *readingService) UpdateReadingProgress(userID string, req *models.Update
 	if err := s.repo.CreateOrUpdateReadingRecord(record); err != nil {
 		return err
 	}
+	s.awardReadingExperience(userID, record, req)
 
 	// 更新书架进度
-	return s.repo.UpdateBookshelfProgress(userID, req.NovelID, req.ReadingProgress)
+	if err := s.repo.UpdateBookshelfProgress(userID, req.NovelID, req.ReadingProgress); err != nil {
+		return err
+	}
+	return s.checkTeenMode(userID, req.ReadingTime)
+}
+
+// checkTeenMode 向用户服务上报本次阅读时长；进度照常保存，超出青少年模式限制时返回错误，客户端应停止阅读。
+// 用户服务不可用时放行
+func (s *readingService) checkTeenMode(userID string, seconds int) error {
+	if seconds < 0 {
+		seconds = 0
+	}
+	mode, err := s.users.RecordReading(context.Background(), userID, seconds)
+	if err != nil {
+		logrus.Warnf("Failed to check teen mode for user %s: %v", userID, err)
+		return nil
+	}
+	if !mode.ReadingAllowed {
+		return fmt.Errorf("%w: %s", ErrReadingRestricted, mode.Reason)
+	}
+	return nil
 }
 
 func (s *readingService) GetReadingProgress(userID, novelID, chapterID string) (*models.ReadingRecordResponse, error) {
@@ -184,8 +226,11 @@ func (s *readingService) CreateComment(userID string, req *models.CreateCommentR
 	if err := s.repo.CreateComment(comment); err != nil {
 		return nil, err
 	}
+	s.xp.Award(userID, XPEventComment, comment.ID, 1)
 
-	return s.convertToCommentResponse(comment), nil
+	responses := []models.CommentResponse{*s.convertToCommentResponse(comment)}
+	s.fillCommentUsers(responses)
+	return &responses[0], nil
 }
 
 func (s *readingService) UpdateComment(userID, commentID string, req *models.UpdateCommentRequest) error {
@@ -238,6 +283,7 @@ func (s *readingService) GetNovelComments(novelID string, page, size int) ([]mod
 			responses[i].Replies = replies
 		}
 	}
+	s.fillCommentUsers(responses)
 
 	return responses, total, nil
 }
@@ -260,6 +306,7 @@ func (s *readingService) GetChapterComments(chapterID string, page, size int) ([
 			responses[i].Replies = replies
 		}
 	}
+	s.fillCommentUsers(responses)
 
 	return responses, total, nil
 }
@@ -274,6 +321,7 @@ func (s *readingService) GetUserComments(userID string, page, size int) ([]model
 	for i, comment := range comments {
 		responses[i] = *s.convertToCommentResponse(&comment)
 	}
+	s.fillCommentUsers(responses)
 
 	return responses, total, nil
 }
@@ -313,12 +361,108 @@ func (s *readingService) ClearSearchHistory(userID string) error {
 	return s.repo.ClearUserSearchHistory(userID)
 }
 
+// GetTrendingKeywords 最近有搜索结果的热门关键词，供内容服务做搜索补全
+func (s *readingService) GetTrendingKeywords(query *models.TrendingKeywordsQuery) ([]models.TrendingKeyword, error) {
+	if query.Days <= 0 {
+		query.Days = 7
+	}
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+	if query.MinUsers <= 0 {
+		query.MinUsers = 3
+	}
+	since := time.Now().AddDate(0, 0, -query.Days)
+	return s.repo.GetTrendingKeywords(since, query.MinUsers, query.Limit)
+}
+
 // Statistics
 func (s *readingService) GetReadingStats(userID string) (*models.ReadingStatsResponse, error) {
 	return s.repo.GetReadingStats(userID)
 }
 
+// User Data
+func (s *readingService) ExportUserData(userID string) (*models.UserDataExport, error) {
+	return s.repo.ExportUserData(userID)
+}
+
+func (s *readingService) DeleteUserData(userID string) error {
+	return s.repo.DeleteUserData(userID)
+}
+
+func (s *readingService) MergeUserData(sourceID string, req *utils.MergeUserDataRequest) (map[string]utils.MergeCount, error) {
+	return s.repo.MergeUserData(sourceID, req.TargetUserID, req.DryRun)
+}
+
+// Activity
+
+// GetUserActivity 多个用户的书评和读完的书，按时间倒序合并，供用户服务生成关注动态
+func (s *readingService) GetUserActivity(req *models.UserActivityRequest) ([]models.ActivityItem, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	before := time.Now()
+	if req.Before != nil {
+		before = *req.Before
+	}
+
+	reviews, err := s.repo.GetUserReviews(req.UserIDs, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	finished, err := s.repo.GetFinishedBooks(req.UserIDs, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.ActivityItem, 0, len(reviews)+len(finished))
+	for _, review := range reviews {
+		items = append(items, models.ActivityItem{
+			Type:      "review",
+			UserID:    review.UserID,
+			NovelID:   review.NovelID,
+			CommentID: review.ID,
+			Content:   review.Content,
+			Rating:    review.Rating,
+			CreatedAt: review.CreatedAt,
+		})
+	}
+	for _, book := range finished {
+		items = append(items, models.ActivityItem{
+			Type:      "finished",
+			UserID:    book.UserID,
+			NovelID:   book.NovelID,
+			CreatedAt: *book.LastReadAt,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.After(items[j].CreatedAt) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
 // Helper methods
+
+// awardReadingExperience 按阅读分钟数和读完章节记经验。
+// 阅读时长以累计满一分钟为单位，event_id 带上累计分钟数，重复上报不会重复记
+func (s *readingService) awardReadingExperience(userID string, record *models.ReadingRecord, req *models.UpdateReadingProgressRequest) {
+	if req.ReadingTime > 0 {
+		before := (record.ReadingTime - req.ReadingTime) / 60
+		after := record.ReadingTime / 60
+		if after > before {
+			s.xp.Award(userID, XPEventReadingTime, fmt.Sprintf("%s:%d", record.ID, after), after-before)
+		}
+	}
+	if req.ReadingProgress >= 100 {
+		s.xp.Award(userID, XPEventChapterFinished, req.ChapterID, 1)
+	}
+}
+
 func (s *readingService) convertToReadingRecordResponse(record *models.ReadingRecord) *models.ReadingRecordResponse {
 	return &models.ReadingRecordResponse{
 		ID:              record.ID,
@@ -365,3 +509,44 @@ func (s *readingService) convertToCommentResponse(comment *models.Comment) *mode
 		UpdatedAt:  comment.UpdatedAt.Format(time.RFC3339),
 	}
 }
+
+// fillCommentUsers 批量查询评论及回复作者的公开信息；用户服务不可用时评论照常返回，只是不带 user
+func (s *readingService) fillCommentUsers(responses []models.CommentResponse) {
+	var ids []string
+	for _, r := range responses {
+		ids = append(ids, r.UserID)
+		for _, reply := range r.Replies {
+			ids = append(ids, reply.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	users, err := s.users.GetUsers(context.Background(), ids)
+	if err != nil {
+		logrus.Warnf("Failed to load comment users: %v", err)
+		return
+	}
+	for i := range responses {
+		responses[i].User = toUserInfo(users[responses[i].UserID])
+		for j := range responses[i].Replies {
+			responses[i].Replies[j].User = toUserInfo(users[responses[i].Replies[j].UserID])
+		}
+	}
+}
+
+func toUserInfo(user *userclient.User) *models.UserInfo {
+	if user == nil {
+		return nil
+	}
+	return &models.UserInfo{
+		ID:               user.ID,
+		Username:         user.Username,
+		Nickname:         user.Nickname,
+		AvatarURL:        user.AvatarURL,
+		AvatarThumbnails: user.AvatarThumbnails,
+		Level:            user.Level,
+		VipLevel:         user.VipLevel,
+	}
+}
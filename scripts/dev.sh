@@ -34,6 +34,9 @@ echo "✅ Redis is ready"
 
 echo "🎉 Infrastructure is ready! You can now start your services manually:"
 echo ""
+echo "Export in every terminal first (use the same values for all services):"
+echo "  export INTERNAL_TOKEN=<random secret> SIGNING_KEY_ENCRYPTION_KEY=<random secret>"
+echo ""
 echo "Terminal 1 - User Service:"
 echo "  cd user-service && go run main.go"
 echo ""
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/spf13/viper"
+	"reading-microservices/shared/utils"
 )
 
 type Config struct {
@@ -11,12 +14,19 @@ type Config struct {
 	Redis    RedisConfig    `mapstructure:"redis"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Consul   ConsulConfig   `mapstructure:"consul"`
+	Internal InternalConfig `mapstructure:"internal"`
+}
+
+// InternalConfig 服务间调用 /api/v1/internal 接口的凭证，所有服务配置相同的 token
+type InternalConfig struct {
+	Token string `mapstructure:"token"`
 }
 
 type ServerConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
-	Name string `mapstructure:"name"`
+	Host           string   `mapstructure:"host"`
+	Port           int      `mapstructure:"port"`
+	Name           string   `mapstructure:"name"`
+	TrustedProxies []string `mapstructure:"trusted_proxies"` // 只信任这些地址或网段转发的 X-Forwarded-For，为空时以连接地址作为客户端 IP
 }
 
 type DatabaseConfig struct {
@@ -36,8 +46,15 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	Secret    string `mapstructure:"secret"`
-	ExpiresIn int    `mapstructure:"expires_in"`
+	Secret      string `mapstructure:"secret"` // 未配置 jwks_url 时使用的共享密钥
+	ExpiresIn   int    `mapstructure:"expires_in"`
+	JWKSURL     string `mapstructure:"jwks_url"`     // 用户服务发布的公钥地址
+	JWKSRefresh int    `mapstructure:"jwks_refresh"` // 公钥缓存刷新间隔（秒）
+}
+
+// NewVerifier 按配置创建 token 验证器：配置了 jwks_url 时用用户服务公钥验证，不再需要共享密钥
+func (j JWTConfig) NewVerifier() utils.TokenVerifier {
+	return utils.NewTokenVerifier(j.JWKSURL, j.Secret, time.Duration(j.JWKSRefresh)*time.Second)
 }
 
 type ConsulConfig struct {
@@ -56,6 +73,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	// 绑定环境变量到配置键
 	viper.BindEnv("server.host", "SERVER_HOST")
 	viper.BindEnv("server.port", "SERVER_PORT")
+	viper.BindEnv("server.trusted_proxies", "SERVER_TRUSTED_PROXIES")
 	viper.BindEnv("database.host", "DATABASE_HOST")
 	viper.BindEnv("database.port", "DATABASE_PORT")
 	viper.BindEnv("database.username", "DATABASE_USERNAME")
@@ -65,6 +83,8 @@ func LoadConfig(configPath string) (*Config, error) {
 	viper.BindEnv("redis.port", "REDIS_PORT")
 	viper.BindEnv("redis.password", "REDIS_PASSWORD")
 	viper.BindEnv("jwt.secret", "JWT_SECRET")
+	viper.BindEnv("jwt.jwks_url", "JWT_JWKS_URL")
+	viper.BindEnv("internal.token", "INTERNAL_TOKEN")
 	viper.BindEnv("consul.host", "CONSUL_HOST")
 	viper.BindEnv("consul.port", "CONSUL_PORT")
 
@@ -1,13 +1,14 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"reading-microservices/shared/utils"
 	"strings"
 )
 
-func JWTAuth(secret string) gin.HandlerFunc {
+func JWTAuth(verifier utils.TokenVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.GetHeader("Authorization")
 		if token == "" {
@@ -21,7 +22,7 @@ func JWTAuth(secret string) gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		claims, err := utils.ParseToken(token, secret)
+		claims, err := verifier.VerifyToken(token)
 		if err != nil {
 			utils.Error(c, utils.ERROR_UNAUTHORIZED, "Invalid token")
 			c.Abort()
@@ -30,6 +31,39 @@ func JWTAuth(secret string) gin.HandlerFunc {
 
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 带了有效 token 时设置用户信息，未带或无效时按未登录继续处理
+func OptionalJWTAuth(verifier utils.TokenVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if len(token) > 7 && strings.ToUpper(token[:7]) == "BEARER " {
+			token = token[7:]
+		}
+		if token != "" {
+			if claims, err := verifier.VerifyToken(token); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("username", claims.Username)
+				c.Set("role", claims.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
+// InternalAuth 内部接口只接受携带服务间凭证的请求；未配置 token 时拒绝所有请求，
+// 避免服务端口暴露在外时任何人都能调用发放奖励、删除数据等接口
+func InternalAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		got := c.GetHeader(utils.InternalTokenHeader)
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			utils.ErrorWithCode(c, utils.ERROR_FORBIDDEN)
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,122 @@
+package openapi
+
+import (
+	"strings"
+)
+
+// Route 描述一个接口，由各服务在 handlers/openapi.go 中维护
+type Route struct {
+	Method   string
+	Path     string // gin 风格路径，如 /api/v1/content/novels/:novel_id
+	Summary  string
+	Tag      string
+	Auth     bool
+	Body     interface{} // JSON 请求体类型
+	Query    interface{} // 查询参数类型（form 标签）
+	Response interface{} // 响应 data 字段的类型
+	Paged    bool        // 响应是否为分页结构
+}
+
+// AddRoutes 把路由追加到文档
+func (d *Document) AddRoutes(routes ...Route) *Document {
+	for _, r := range routes {
+		d.AddRoute(r)
+	}
+	return d
+}
+
+// AddRoute 把单个路由转换为 Operation 并追加到文档
+func (d *Document) AddRoute(r Route) {
+	path, pathParams := convertPath(r.Path)
+	method := strings.ToLower(r.Method)
+
+	op := &Operation{
+		Summary:     r.Summary,
+		OperationID: operationID(method, path),
+		Parameters:  pathParams,
+		Responses: map[string]*Response{
+			"200": {
+				Description: "OK",
+				Content:     map[string]MediaType{"application/json": {Schema: d.envelope(r.Response, r.Paged)}},
+			},
+		},
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+		d.addTag(r.Tag)
+	}
+	if r.Auth {
+		op.Security = []map[string][]string{{"BearerAuth": {}}}
+	}
+	if r.Query != nil {
+		op.Parameters = append(op.Parameters, d.QueryParameters(r.Query)...)
+	}
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: d.SchemaOf(r.Body)}},
+		}
+	}
+
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	(*item)[method] = op
+}
+
+func (d *Document) addTag(name string) {
+	for _, t := range d.Tags {
+		if t.Name == name {
+			return
+		}
+	}
+	d.Tags = append(d.Tags, Tag{Name: name})
+}
+
+// envelope 对应 shared/utils.Response 与 PageResponse 的统一响应结构
+func (d *Document) envelope(data interface{}, paged bool) *Schema {
+	s := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer"},
+			"message": {Type: "string"},
+		},
+		Required: []string{"code", "message"},
+	}
+	if data != nil {
+		s.Properties["data"] = d.SchemaOf(data)
+	}
+	if paged {
+		s.Properties["total"] = &Schema{Type: "integer", Format: "int64"}
+		s.Properties["page"] = &Schema{Type: "integer"}
+		s.Properties["size"] = &Schema{Type: "integer"}
+	}
+	return s
+}
+
+// convertPath 把 gin 路径参数 :id / *path 转换为 {id}
+func convertPath(ginPath string) (string, []Parameter) {
+	segments := strings.Split(ginPath, "/")
+	var params []Parameter
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			name := seg[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(method, path string) string {
+	replacer := strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_")
+	return method + strings.TrimRight(replacer.Replace(path), "_")
+}
+
+// PageQuery 通用分页参数
+type PageQuery struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
+}
@@ -0,0 +1,82 @@
+package openapi
+
+// Merge 把 src 合并进 dst，namespace 非空时给 src 的组件名加前缀，避免不同服务的同名 DTO 冲突
+func Merge(dst, src *Document, namespace string) {
+	if src == nil {
+		return
+	}
+	if dst.Paths == nil {
+		dst.Paths = make(map[string]*PathItem)
+	}
+	if dst.Components == nil {
+		dst.Components = &Components{}
+	}
+	if dst.Components.Schemas == nil {
+		dst.Components.Schemas = make(map[string]*Schema)
+	}
+
+	rename := func(name string) string {
+		if namespace == "" {
+			return name
+		}
+		return namespace + "." + name
+	}
+
+	if src.Components != nil {
+		for name, schema := range src.Components.Schemas {
+			renameRefs(schema, rename)
+			dst.Components.Schemas[rename(name)] = schema
+		}
+		for name, scheme := range src.Components.SecuritySchemes {
+			if dst.Components.SecuritySchemes == nil {
+				dst.Components.SecuritySchemes = make(map[string]*SecurityScheme)
+			}
+			if _, ok := dst.Components.SecuritySchemes[name]; !ok {
+				dst.Components.SecuritySchemes[name] = scheme
+			}
+		}
+	}
+
+	for path, item := range src.Paths {
+		target, ok := dst.Paths[path]
+		if !ok {
+			target = &PathItem{}
+			dst.Paths[path] = target
+		}
+		for method, op := range *item {
+			for i := range op.Parameters {
+				renameRefs(op.Parameters[i].Schema, rename)
+			}
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					renameRefs(media.Schema, rename)
+				}
+			}
+			for _, resp := range op.Responses {
+				for _, media := range resp.Content {
+					renameRefs(media.Schema, rename)
+				}
+			}
+			(*target)[method] = op
+		}
+	}
+
+	for _, tag := range src.Tags {
+		dst.addTag(tag.Name)
+	}
+}
+
+func renameRefs(s *Schema, rename func(string) string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		s.Ref = refPrefix + rename(s.Ref[len(refPrefix):])
+		return
+	}
+	for _, prop := range s.Properties {
+		renameRefs(prop, rename)
+	}
+	renameRefs(s.Items, rename)
+	renameRefs(s.AdditionalProperties, rename)
+}
@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf 根据 Go 类型生成 schema，具名结构体注册到 components 并返回 $ref
+// 字段名取自 json 标签，校验规则取自 gin 的 binding 标签
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return d.schemaOfType(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOfType(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct:
+		s = d.structRef(t)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		s = &Schema{Type: "string", Format: "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: d.schemaOfType(t.Elem())}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: d.schemaOfType(t.Elem())}
+	case t.Kind() == reflect.Interface:
+		s = &Schema{}
+	default:
+		s = primitiveSchema(t.Kind())
+	}
+
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+func primitiveSchema(kind reflect.Kind) *Schema {
+	switch kind {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+func (d *Document) structRef(t reflect.Type) *Schema {
+	name := t.Name()
+	if name == "" {
+		return d.structSchema(t)
+	}
+	if _, ok := d.Components.Schemas[name]; !ok {
+		// 先占位，避免递归类型（如 Comment.Replies）死循环
+		d.Components.Schemas[name] = &Schema{Type: "object"}
+		d.Components.Schemas[name] = d.structSchema(t)
+	}
+	return &Schema{Ref: refPrefix + name}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.collectFields(t, s)
+	return s
+}
+
+func (d *Document) collectFields(t reflect.Type, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		name := strings.Split(jsonTag, ",")[0]
+
+		// 匿名嵌入的结构体字段平铺到父对象
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.collectFields(ft, s)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := d.schemaOfType(field.Type)
+		if applyBinding(prop, field.Tag.Get("binding")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyBinding 把 binding 标签转换为 schema 约束，返回字段是否必填
+func applyBinding(s *Schema, tag string) bool {
+	if tag == "" || s.Ref != "" {
+		return strings.Contains(tag, "required")
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value := rule, ""
+		if idx := strings.Index(rule, "="); idx >= 0 {
+			key, value = rule[:idx], rule[idx+1:]
+		}
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				s.Enum = append(s.Enum, enumValue(s.Type, option))
+			}
+		case "min", "gte":
+			setLowerBound(s, value, false)
+		case "gt":
+			setLowerBound(s, value, true)
+		case "max", "lte":
+			setUpperBound(s, value, false)
+		case "lt":
+			setUpperBound(s, value, true)
+		case "len":
+			setLowerBound(s, value, false)
+			setUpperBound(s, value, false)
+		}
+	}
+	return required
+}
+
+func enumValue(schemaType, option string) interface{} {
+	switch schemaType {
+	case "integer":
+		if n, err := strconv.ParseInt(option, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(option, 64); err == nil {
+			return f
+		}
+	}
+	return option
+}
+
+func setLowerBound(s *Schema, value string, exclusive bool) {
+	switch s.Type {
+	case "string":
+		if n, err := strconv.Atoi(value); err == nil {
+			s.MinLength = &n
+		}
+	case "array":
+		if n, err := strconv.Atoi(value); err == nil {
+			s.MinItems = &n
+		}
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			s.Minimum = &f
+			s.ExclusiveMinimum = exclusive
+		}
+	}
+}
+
+func setUpperBound(s *Schema, value string, exclusive bool) {
+	switch s.Type {
+	case "string":
+		if n, err := strconv.Atoi(value); err == nil {
+			s.MaxLength = &n
+		}
+	case "array":
+		if n, err := strconv.Atoi(value); err == nil {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			s.Maximum = &f
+			s.ExclusiveMaximum = exclusive
+		}
+	}
+}
+
+// QueryParameters 根据 form 标签生成查询参数
+func (d *Document) QueryParameters(v interface{}) []Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := d.schemaOfType(field.Type)
+		required := applyBinding(schema, field.Tag.Get("binding"))
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
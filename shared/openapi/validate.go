@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// Validator 根据文档校验请求参数与请求体
+type Validator struct {
+	doc    *Document
+	routes []compiledRoute
+}
+
+type compiledRoute struct {
+	method   string
+	segments []string
+	literals int
+	op       *Operation
+}
+
+// NewValidator 预编译文档中的路径模板
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{doc: doc}
+	for path, item := range doc.Paths {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		literals := 0
+		for _, seg := range segments {
+			if !isTemplate(seg) {
+				literals++
+			}
+		}
+		for method, op := range *item {
+			v.routes = append(v.routes, compiledRoute{
+				method:   strings.ToUpper(method),
+				segments: segments,
+				literals: literals,
+				op:       op,
+			})
+		}
+	}
+	// 字面量越多越具体，优先匹配（/novels/search 优先于 /novels/{novel_id}）
+	sort.SliceStable(v.routes, func(i, j int) bool {
+		return v.routes[i].literals > v.routes[j].literals
+	})
+	return v
+}
+
+func isTemplate(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+// Match 查找请求对应的 Operation，未在文档中描述时返回 nil
+func (v *Validator) Match(method, path string) *Operation {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range v.routes {
+		if route.method != method || len(route.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, seg := range route.segments {
+			if !isTemplate(seg) && seg != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route.op
+		}
+	}
+	return nil
+}
+
+// ValidateRequest 校验查询参数和 JSON 请求体，返回所有错误信息
+func (v *Validator) ValidateRequest(r *http.Request, body []byte) []string {
+	op := v.Match(r.Method, r.URL.Path)
+	if op == nil {
+		return nil
+	}
+
+	var errs []string
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		raw, ok := query[param.Name]
+		if !ok || len(raw) == 0 || raw[0] == "" {
+			if param.Required {
+				errs = append(errs, fmt.Sprintf("query parameter %q is required", param.Name))
+			}
+			continue
+		}
+		errs = append(errs, v.validateScalar("query."+param.Name, raw[0], param.Schema)...)
+	}
+
+	if op.RequestBody == nil {
+		return errs
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return errs
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		if op.RequestBody.Required {
+			errs = append(errs, "request body is required")
+		}
+		return errs
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return append(errs, "request body is not valid JSON: "+err.Error())
+	}
+	return append(errs, v.validateValue("body", value, media.Schema)...)
+}
+
+func (v *Validator) validateScalar(field, raw string, schema *Schema) []string {
+	schema = v.doc.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	var value interface{} = raw
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return []string{fmt.Sprintf("%s must be an integer", field)}
+		}
+		value = float64(n)
+	case "number":
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return []string{fmt.Sprintf("%s must be a number", field)}
+		}
+		value = f
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []string{fmt.Sprintf("%s must be a boolean", field)}
+		}
+		value = b
+	}
+	return v.validateValue(field, value, schema)
+}
+
+func (v *Validator) validateValue(field string, value interface{}, schema *Schema) []string {
+	schema = v.doc.Resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		return nil // 与 gin binding 一致，null 视为未提供
+	}
+
+	var errs []string
+	switch schema.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s must be an object", field)}
+		}
+		for _, name := range schema.Required {
+			if val, ok := obj[name]; !ok || val == nil {
+				errs = append(errs, fmt.Sprintf("%s.%s is required", field, name))
+			}
+		}
+		for name, val := range obj {
+			if prop, ok := schema.Properties[name]; ok {
+				errs = append(errs, v.validateValue(field+"."+name, val, prop)...)
+			} else if schema.AdditionalProperties != nil {
+				errs = append(errs, v.validateValue(field+"."+name, val, schema.AdditionalProperties)...)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s must be an array", field)}
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			errs = append(errs, fmt.Sprintf("%s must contain at least %d items", field, *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			errs = append(errs, fmt.Sprintf("%s must contain at most %d items", field, *schema.MaxItems))
+		}
+		for i, item := range items {
+			errs = append(errs, v.validateValue(fmt.Sprintf("%s[%d]", field, i), item, schema.Items)...)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be a string", field)}
+		}
+		length := len([]rune(str))
+		if schema.MinLength != nil && length < *schema.MinLength {
+			errs = append(errs, fmt.Sprintf("%s must be at least %d characters", field, *schema.MinLength))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			errs = append(errs, fmt.Sprintf("%s must be at most %d characters", field, *schema.MaxLength))
+		}
+		if schema.Format == "email" && str != "" && !emailPattern.MatchString(str) {
+			errs = append(errs, fmt.Sprintf("%s must be a valid email", field))
+		}
+	case "integer", "number":
+		num, ok := value.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s must be a %s", field, schema.Type)}
+		}
+		if schema.Type == "integer" && num != float64(int64(num)) {
+			errs = append(errs, fmt.Sprintf("%s must be an integer", field))
+		}
+		if schema.Minimum != nil {
+			if num < *schema.Minimum || (schema.ExclusiveMinimum && num == *schema.Minimum) {
+				errs = append(errs, fmt.Sprintf("%s is below the minimum %v", field, *schema.Minimum))
+			}
+		}
+		if schema.Maximum != nil {
+			if num > *schema.Maximum || (schema.ExclusiveMaximum && num == *schema.Maximum) {
+				errs = append(errs, fmt.Sprintf("%s exceeds the maximum %v", field, *schema.Maximum))
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s must be a boolean", field)}
+		}
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		errs = append(errs, fmt.Sprintf("%s must be one of %v", field, schema.Enum))
+	}
+	return errs
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"reading-microservices/shared/utils"
+)
+
+// MaxBatchSize 用户服务单次批量查询的上限，超过时客户端自动分批
+const MaxBatchSize = 500
+
+// User 用户公开信息，用于评论、排行榜等列表展示
+type User struct {
+	ID               string            `json:"id"`
+	Username         string            `json:"username"`
+	Nickname         *string           `json:"nickname"`
+	AvatarURL        *string           `json:"avatar_url"`
+	AvatarThumbnails map[string]string `json:"avatar_thumbnails,omitempty"` // 边长到头像缩略图地址
+	Level            *int              `json:"level,omitempty"`             // 用户设置等级不公开时为空
+	VipLevel         string            `json:"vip_level"`                   // none、vip 或 svip，已过期为 none
+}
+
+// Client 调用用户服务的内部批量查询接口
+type Client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// New baseURL 为空时不发请求，所有查询返回空结果，便于本地单独运行某个服务；token 为服务间凭证
+func New(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  utils.NewInternalClient(token, timeout),
+	}
+}
+
+// GetUsers 按 ID 批量查询，自动去重和分批；不存在或已注销的用户不在结果中
+func (c *Client) GetUsers(ctx context.Context, ids []string) (map[string]*User, error) {
+	users := make(map[string]*User, len(ids))
+	if c.baseURL == "" {
+		return users, nil
+	}
+
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+
+	for start := 0; start < len(unique); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(unique) {
+			end = len(unique)
+		}
+		batch, err := c.fetch(ctx, unique[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range batch {
+			users[user.ID] = user
+		}
+	}
+	return users, nil
+}
+
+func (c *Client) fetch(ctx context.Context, ids []string) ([]*User, error) {
+	var users []*User
+	err := c.call(ctx, http.MethodPost, "/api/v1/internal/user/users/batch", map[string][]string{"ids": ids}, &users)
+	return users, err
+}
+
+// TeenMode 青少年模式限制
+type TeenMode struct {
+	Enabled          bool   `json:"enabled"`
+	ReadingAllowed   bool   `json:"reading_allowed"`
+	Reason           string `json:"reason,omitempty"` // daily_limit 或 curfew
+	RemainingMinutes int    `json:"remaining_minutes"`
+}
+
+// GetTeenMode 查询用户是否处于青少年模式，开启时应禁止购买、屏蔽成人向内容
+func (c *Client) GetTeenMode(ctx context.Context, userID string) (*TeenMode, error) {
+	if c.baseURL == "" {
+		return &TeenMode{ReadingAllowed: true}, nil
+	}
+	var mode TeenMode
+	if err := c.call(ctx, http.MethodGet, "/api/v1/internal/user/users/"+url.PathEscape(userID)+"/teen-mode", nil, &mode); err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
+
+// RecordReading 上报本次阅读秒数，返回是否还能继续阅读（每日时长和宵禁）
+func (c *Client) RecordReading(ctx context.Context, userID string, seconds int) (*TeenMode, error) {
+	if c.baseURL == "" {
+		return &TeenMode{ReadingAllowed: true}, nil
+	}
+	body := map[string]interface{}{"user_id": userID, "seconds": seconds}
+	var mode TeenMode
+	if err := c.call(ctx, http.MethodPost, "/api/v1/internal/user/teen-mode/reading", body, &mode); err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
+
+// call 调用用户服务内部接口，data 解析到 out
+func (c *Client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("user service: status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != 0 {
+		return fmt.Errorf("user service: %s", result.Message)
+	}
+	return json.Unmarshal(result.Data, out)
+}
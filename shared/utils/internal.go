@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"net/http"
+	"time"
+)
+
+// InternalTokenHeader 调用其他服务 /api/v1/internal 接口时携带的服务间凭证
+const InternalTokenHeader = "X-Internal-Token"
+
+// internalTransport 为每个请求加上服务间凭证
+type internalTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *internalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(InternalTokenHeader, t.token)
+	return t.base.RoundTrip(req)
+}
+
+// NewInternalClient 调用内部接口的 HTTP 客户端，请求自动携带 token
+func NewInternalClient(token string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &internalTransport{token: token, base: http.DefaultTransport},
+	}
+}
@@ -0,0 +1,398 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+	AlgHS256 = "HS256"
+)
+
+var (
+	ErrUnknownKey      = errors.New("unknown signing key")
+	ErrUnsupportedAlg  = errors.New("unsupported signing algorithm")
+	ErrMissingKeyID    = errors.New("token has no key id")
+	ErrAlgKeyMismatch  = errors.New("token algorithm does not match key")
+	ErrLegacyToken     = errors.New("legacy token is no longer accepted")
+	errNoVerifyingKeys = errors.New("no verifying keys configured")
+)
+
+// ------------------- 签名 -------------------
+
+// SigningKey 签名私钥，kid 写入 token 头，验证方据此在 JWKS 中找到公钥
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// GenerateSigningKey 生成 RS256（RSA 2048）或 EdDSA（Ed25519）密钥
+func GenerateSigningKey(alg string) (*SigningKey, error) {
+	var signer crypto.Signer
+	switch alg {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlg, alg)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &SigningKey{
+		ID:         time.Now().UTC().Format("20060102") + "-" + hex.EncodeToString(id),
+		Algorithm:  alg,
+		PrivateKey: signer,
+	}, nil
+}
+
+// MarshalPrivateKey 私钥编码为 PKCS#8 PEM，便于持久化
+func (k *SigningKey) MarshalPrivateKey() (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParseSigningKey 从 PKCS#8 PEM 还原签名私钥
+func ParseSigningKey(id, alg, privatePEM string) (*SigningKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok || signingMethod(alg) == nil || !keyMatchesAlg(signer.Public(), alg) {
+		return nil, ErrAlgKeyMismatch
+	}
+	return &SigningKey{ID: id, Algorithm: alg, PrivateKey: signer}, nil
+}
+
+// SignToken 用指定私钥签发 token，头部带 kid
+func SignToken(claims *Claims, key *SigningKey) (string, error) {
+	method := signingMethod(key.Algorithm)
+	if method == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedAlg, key.Algorithm)
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
+
+func keyMatchesAlg(key crypto.PublicKey, alg string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return alg == AlgRS256
+	case ed25519.PublicKey:
+		return alg == AlgEdDSA
+	}
+	return false
+}
+
+// ------------------- JWKS -------------------
+
+// JWK 单个公钥（RFC 7517），RSA 用 n/e，Ed25519 用 crv/x
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS 公钥集合，由用户服务发布
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWK 签名私钥对应的公开 JWK
+func (k *SigningKey) PublicJWK() JWK {
+	jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+	switch pub := k.PrivateKey.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return jwk
+}
+
+// PublicKey 解析 JWK 中的公钥
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %s", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", j.Kty)
+}
+
+// ------------------- 验证 -------------------
+
+// TokenVerifier 验证 token 并返回 claims
+type TokenVerifier interface {
+	VerifyToken(token string) (*Claims, error)
+}
+
+// KeyResolver 按 kid 查找验证公钥及其算法
+type KeyResolver interface {
+	PublicKey(kid string) (crypto.PublicKey, string, error)
+}
+
+// KeyVerifier 带 kid 的 token 用 resolver 中的公钥验证；
+// 配置了 secret 时兼容迁移前不带 kid 的 HS256 token，可用 LimitLegacy 限定接受哪些旧 token
+type KeyVerifier struct {
+	resolver KeyResolver
+	secret   []byte
+
+	legacyLimited bool
+	legacyBefore  time.Time
+	legacyMaxAge  time.Duration
+}
+
+func NewKeyVerifier(resolver KeyResolver, legacySecret string) *KeyVerifier {
+	v := &KeyVerifier{resolver: resolver}
+	if legacySecret != "" {
+		v.secret = []byte(legacySecret)
+	}
+	return v
+}
+
+// LimitLegacy 只接受 issuedBefore 之前签发、签发不超过 maxAge 且不带角色的 HS256 token。
+// 共享密钥泄露后任何人都能签发 token，切换时间之后的和带角色的一律拒绝，最后一批旧 token 过期后自然不再可用
+func (v *KeyVerifier) LimitLegacy(issuedBefore time.Time, maxAge time.Duration) {
+	v.legacyLimited = true
+	v.legacyBefore = issuedBefore
+	v.legacyMaxAge = maxAge
+}
+
+// NewTokenVerifier 配置了 JWKS 地址时只接受用户服务公钥签名的 token，
+// 否则退回共享密钥（未迁移的部署）
+func NewTokenVerifier(jwksURL, secret string, refresh time.Duration) TokenVerifier {
+	if jwksURL == "" {
+		return NewKeyVerifier(nil, secret)
+	}
+	return NewKeyVerifier(NewJWKSClient(jwksURL, refresh), "")
+}
+
+func (v *KeyVerifier) VerifyToken(tokenString string) (*Claims, error) {
+	methods := []string{AlgRS256, AlgEdDSA}
+	if v.secret != nil {
+		methods = append(methods, AlgHS256)
+	}
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, v.keyFunc, jwt.WithValidMethods(methods))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if token.Method.Alg() == AlgHS256 && !v.legacyAllowed(claims) {
+		return nil, ErrLegacyToken
+	}
+	return claims, nil
+}
+
+func (v *KeyVerifier) legacyAllowed(claims *Claims) bool {
+	if !v.legacyLimited {
+		return true
+	}
+	if claims.Role != "" || claims.IssuedAt == nil {
+		return false
+	}
+	issuedAt := claims.IssuedAt.Time
+	return issuedAt.Before(v.legacyBefore) && time.Since(issuedAt) <= v.legacyMaxAge
+}
+
+func (v *KeyVerifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if v.secret == nil || token.Method.Alg() != AlgHS256 {
+			return nil, ErrMissingKeyID
+		}
+		return v.secret, nil
+	}
+	if v.resolver == nil {
+		return nil, errNoVerifyingKeys
+	}
+	key, alg, err := v.resolver.PublicKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if alg != token.Method.Alg() {
+		return nil, ErrAlgKeyMismatch
+	}
+	return key, nil
+}
+
+// ------------------- JWKS 客户端 -------------------
+
+// 遇到未知 kid 时最短的重新拉取间隔，防止伪造 kid 的请求打满用户服务
+const jwksMinRefetch = 10 * time.Second
+
+type cachedKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// JWKSClient 缓存用户服务发布的公钥，定期刷新；遇到未知 kid（密钥轮换）时提前刷新。
+// 刷新失败时继续使用已缓存的公钥
+type JWKSClient struct {
+	url     string
+	client  *http.Client
+	refresh time.Duration
+
+	mu          sync.RWMutex
+	keys        map[string]cachedKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	fetchMu     sync.Mutex
+}
+
+func NewJWKSClient(url string, refresh time.Duration) *JWKSClient {
+	if refresh <= 0 {
+		refresh = 10 * time.Minute
+	}
+	return &JWKSClient{
+		url:     url,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		refresh: refresh,
+		keys:    map[string]cachedKey{},
+	}
+}
+
+func (c *JWKSClient) PublicKey(kid string) (crypto.PublicKey, string, error) {
+	c.mu.RLock()
+	cached, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.refresh
+	c.mu.RUnlock()
+	if ok && !stale {
+		return cached.key, cached.alg, nil
+	}
+
+	if err := c.fetch(); err != nil && !ok {
+		return nil, "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if cached, ok := c.keys[kid]; ok {
+		return cached.key, cached.alg, nil
+	}
+	return nil, "", ErrUnknownKey
+}
+
+func (c *JWKSClient) fetch() error {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	// 等锁期间可能已被其他请求刷新
+	c.mu.RLock()
+	recent := time.Since(c.lastAttempt) < jwksMinRefetch
+	c.mu.RUnlock()
+	if recent {
+		return nil
+	}
+
+	c.mu.Lock()
+	c.lastAttempt = time.Now()
+	c.mu.Unlock()
+
+	resp, err := c.client.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: status %d", resp.StatusCode)
+	}
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := make(map[string]cachedKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil || !keyMatchesAlg(key, jwk.Alg) {
+			continue
+		}
+		keys[jwk.Kid] = cachedKey{key: key, alg: jwk.Alg}
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
@@ -11,37 +11,52 @@ import (
 type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"` // 普通用户为空
 	Random   string `json:"rand"` // 确保唯一性
 	jwt.RegisteredClaims
 }
 
 // 添加随机后缀确保token唯一性
 func GenerateToken(userID, username, secret string, expiresIn int) (string, error) {
+	return GenerateTokenWithRole(userID, username, "", secret, expiresIn)
+}
+
+// GenerateTokenWithRole 签发带角色的 token，网关据此拦截管理接口
+func GenerateTokenWithRole(userID, username, role, secret string, expiresIn int) (string, error) {
+	claims, err := NewClaims(userID, username, role, expiresIn)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// NewClaims 生成 claims，带随机字段保证同一秒内签发的 token 也不相同
+func NewClaims(userID, username, role string, expiresIn int) (*Claims, error) {
 	// 生成随机后缀
 	randomBytes := make([]byte, 8)
 	if _, err := rand.Read(randomBytes); err != nil {
-		return "", err
+		return nil, err
 	}
-	randomSuffix := hex.EncodeToString(randomBytes)
 
-	claims := &Claims{
+	now := time.Now()
+	return &Claims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiresIn) * time.Second)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(expiresIn) * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-		Random: randomSuffix, // 添加随机字段
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+		Random: hex.EncodeToString(randomBytes), // 添加随机字段
+	}, nil
 }
 
 func ParseToken(token string, secret string) (*Claims, error) {
 	tokenClaims, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{AlgHS256}))
 
 	if tokenClaims != nil {
 		if claims, ok := tokenClaims.Claims.(*Claims); ok && tokenClaims.Valid {
@@ -63,5 +78,5 @@ func RefreshToken(token string, secret string, expiresIn int) (string, error) {
 		return "", errors.New("token doesn't need refresh")
 	}
 
-	return GenerateToken(claims.UserID, claims.Username, secret, expiresIn)
+	return GenerateTokenWithRole(claims.UserID, claims.Username, claims.Role, secret, expiresIn)
 }
@@ -0,0 +1,18 @@
+package utils
+
+// DeletedUserID 账号注销后需要保留的内容（如评论）改挂到这个占位用户，
+// 各服务展示时按“已注销用户”处理
+const DeletedUserID = "00000000-0000-0000-0000-000000000000"
+
+// MergeUserDataRequest 账号合并时用户服务调用各服务的内部接口，把路径中用户的数据转移到 TargetUserID；
+// DryRun 为 true 时只统计不修改。重复调用是安全的，已转移的数据不会再计入
+type MergeUserDataRequest struct {
+	TargetUserID string `json:"target_user_id" binding:"required"`
+	DryRun       bool   `json:"dry_run"`
+}
+
+// MergeCount 某类数据转入目标账号的条数，以及与目标账号已有数据重复而丢弃的条数
+type MergeCount struct {
+	Moved     int64 `json:"moved"`
+	Discarded int64 `json:"discarded"`
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"reading-microservices/user-service/models"
+)
+
+// LocalUserCache 进程内 LRU，挡在 Redis 前面；其他实例的修改靠 Redis 失效通知清除，有效期很短作为兜底
+type LocalUserCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type localEntry struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+func NewLocalUserCache(size int, ttl time.Duration) *LocalUserCache {
+	return &LocalUserCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get 返回副本，调用方修改后不会影响缓存
+func (c *LocalUserCache) Get(userID string) (*models.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[userID]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*localEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.ll.Remove(elem)
+		delete(c.items, userID)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	user := entry.user
+	return &user, true
+}
+
+func (c *LocalUserCache) Set(user *models.User) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := &localEntry{user: *user, expiresAt: time.Now().Add(c.ttl)}
+	if elem, ok := c.items[user.ID]; ok {
+		elem.Value = entry
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[user.ID] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*localEntry).user.ID)
+	}
+}
+
+func (c *LocalUserCache) Remove(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[userID]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, userID)
+	}
+}
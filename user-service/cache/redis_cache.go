@@ -12,12 +12,27 @@ import (
 type UserCache interface {
 	GetUser(ctx context.Context, userID string) (*models.User, error)
 	SetUser(ctx context.Context, user *models.User, expiration time.Duration) error
+	GetUsers(ctx context.Context, userIDs []string) (map[string]*models.User, error)
+	SetUsers(ctx context.Context, users []*models.User, expiration time.Duration) error
 	InvalidateUser(ctx context.Context, userID string) error
+	PublishUserInvalidation(ctx context.Context, userID string) error
+	SubscribeUserInvalidation(ctx context.Context, handler func(userID string))
 	GetSession(ctx context.Context, token string) (*models.UserSession, error)
 	SetSession(ctx context.Context, session *models.UserSession, expiration time.Duration) error
-	IncrementLoginAttempts(ctx context.Context, username string) (int64, error)
+	IncrementLoginAttempts(ctx context.Context, username string, window time.Duration) (int64, error)
 	GetLoginAttempts(ctx context.Context, username string) (int64, error)
 	ResetLoginAttempts(ctx context.Context, username string) error
+	SetLoginLock(ctx context.Context, key string, duration time.Duration) error
+	GetLoginLock(ctx context.Context, key string) (time.Duration, error)
+	ClearLoginLock(ctx context.Context, key string) error
+	AddReadingTime(ctx context.Context, userID, day string, seconds int, expiration time.Duration) (int64, error)
+	GetReadingTime(ctx context.Context, userID, day string) (int64, error)
+}
+
+// cachedUser 缓存时保留 PasswordHash（模型上为 json:"-"），修改密码、注销等校验需要它
+type cachedUser struct {
+	*models.User
+	PasswordHash string `json:"password_hash"`
 }
 
 type redisUserCache struct {
@@ -39,27 +54,97 @@ func (c *redisUserCache) GetUser(ctx context.Context, userID string) (*models.Us
 		return nil, err
 	}
 
-	var user models.User
-	if err := json.Unmarshal(data, &user); err != nil {
+	cached := cachedUser{User: &models.User{}}
+	if err := json.Unmarshal(data, &cached); err != nil {
 		return nil, err
 	}
-	return &user, nil
+	cached.User.PasswordHash = cached.PasswordHash
+	return cached.User, nil
 }
 
 func (c *redisUserCache) SetUser(ctx context.Context, user *models.User, expiration time.Duration) error {
 	key := fmt.Sprintf("%suser:%s", c.prefix, user.ID)
-	data, err := json.Marshal(user)
+	data, err := json.Marshal(cachedUser{User: user, PasswordHash: user.PasswordHash})
 	if err != nil {
 		return err
 	}
 	return c.client.Set(ctx, key, data, expiration).Err()
 }
 
+// GetUsers 批量读取，未缓存的用户不在结果中
+func (c *redisUserCache) GetUsers(ctx context.Context, userIDs []string) (map[string]*models.User, error) {
+	users := make(map[string]*models.User, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	keys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		keys[i] = fmt.Sprintf("%suser:%s", c.prefix, id)
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		cached := cachedUser{User: &models.User{}}
+		if err := json.Unmarshal([]byte(data), &cached); err != nil {
+			continue
+		}
+		cached.User.PasswordHash = cached.PasswordHash
+		users[cached.User.ID] = cached.User
+	}
+	return users, nil
+}
+
+// SetUsers 批量写入，一次往返
+func (c *redisUserCache) SetUsers(ctx context.Context, users []*models.User, expiration time.Duration) error {
+	if len(users) == 0 {
+		return nil
+	}
+	pipe := c.client.Pipeline()
+	for _, user := range users {
+		data, err := json.Marshal(cachedUser{User: user, PasswordHash: user.PasswordHash})
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, fmt.Sprintf("%suser:%s", c.prefix, user.ID), data, expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (c *redisUserCache) InvalidateUser(ctx context.Context, userID string) error {
 	key := fmt.Sprintf("%suser:%s", c.prefix, userID)
 	return c.client.Del(ctx, key).Err()
 }
 
+// PublishUserInvalidation 通知所有实例清除进程内缓存中的该用户
+func (c *redisUserCache) PublishUserInvalidation(ctx context.Context, userID string) error {
+	return c.client.Publish(ctx, c.prefix+"user_invalidation", userID).Err()
+}
+
+// SubscribeUserInvalidation 阻塞接收失效通知，直到 ctx 结束
+func (c *redisUserCache) SubscribeUserInvalidation(ctx context.Context, handler func(userID string)) {
+	pubsub := c.client.Subscribe(ctx, c.prefix+"user_invalidation")
+	defer pubsub.Close()
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			handler(msg.Payload)
+		}
+	}
+}
+
 func (c *redisUserCache) GetSession(ctx context.Context, token string) (*models.UserSession, error) {
 	key := fmt.Sprintf("%ssession:%s", c.prefix, token)
 	data, err := c.client.Get(ctx, key).Bytes()
@@ -83,17 +168,71 @@ func (c *redisUserCache) SetSession(ctx context.Context, session *models.UserSes
 	return c.client.Set(ctx, key, data, expiration).Err()
 }
 
-func (c *redisUserCache) IncrementLoginAttempts(ctx context.Context, username string) (int64, error) {
+// IncrementLoginAttempts 失败次数加一，计数窗口从第一次失败开始计算
+func (c *redisUserCache) IncrementLoginAttempts(ctx context.Context, username string, window time.Duration) (int64, error) {
 	key := fmt.Sprintf("%slogin_attempts:%s", c.prefix, username)
-	return c.client.Incr(ctx, key).Result()
+	count, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		c.client.Expire(ctx, key, window)
+	}
+	return count, nil
 }
 
 func (c *redisUserCache) GetLoginAttempts(ctx context.Context, username string) (int64, error) {
 	key := fmt.Sprintf("%slogin_attempts:%s", c.prefix, username)
-	return c.client.Get(ctx, key).Int64()
+	count, err := c.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
 }
 
 func (c *redisUserCache) ResetLoginAttempts(ctx context.Context, username string) error {
 	key := fmt.Sprintf("%slogin_attempts:%s", c.prefix, username)
 	return c.client.Del(ctx, key).Err()
 }
+
+func (c *redisUserCache) SetLoginLock(ctx context.Context, key string, duration time.Duration) error {
+	return c.client.Set(ctx, fmt.Sprintf("%slogin_lock:%s", c.prefix, key), 1, duration).Err()
+}
+
+// GetLoginLock 返回锁定剩余时间，未锁定返回 0
+func (c *redisUserCache) GetLoginLock(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.TTL(ctx, fmt.Sprintf("%slogin_lock:%s", c.prefix, key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (c *redisUserCache) ClearLoginLock(ctx context.Context, key string) error {
+	return c.client.Del(ctx, fmt.Sprintf("%slogin_lock:%s", c.prefix, key)).Err()
+}
+
+// AddReadingTime 累加用户某天的阅读秒数，返回累加后的总数
+func (c *redisUserCache) AddReadingTime(ctx context.Context, userID, day string, seconds int, expiration time.Duration) (int64, error) {
+	key := fmt.Sprintf("%sreading_time:%s:%s", c.prefix, userID, day)
+	total, err := c.client.IncrBy(ctx, key, int64(seconds)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if total == int64(seconds) {
+		c.client.Expire(ctx, key, expiration)
+	}
+	return total, nil
+}
+
+func (c *redisUserCache) GetReadingTime(ctx context.Context, userID, day string) (int64, error) {
+	key := fmt.Sprintf("%sreading_time:%s:%s", c.prefix, userID, day)
+	total, err := c.client.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return total, err
+}
@@ -0,0 +1,498 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	sharedConfig "reading-microservices/shared/config"
+)
+
+// Config 用户服务配置，在公共配置基础上扩展用户服务独有的配置项
+type Config struct {
+	sharedConfig.Config `mapstructure:",squash"`
+	OAuth               OAuthConfig        `mapstructure:"oauth"`
+	Session             SessionConfig      `mapstructure:"session"`
+	LoginGuard          LoginGuardConfig   `mapstructure:"login_guard"`
+	Verification        VerificationConfig `mapstructure:"verification"`
+	TwoFactor           TwoFactorConfig    `mapstructure:"two_factor"`
+	LoginAlert          LoginAlertConfig   `mapstructure:"login_alert"`
+	AccountData         AccountDataConfig  `mapstructure:"account_data"`
+	Signing             SigningConfig      `mapstructure:"signing"`
+	UserCache           UserCacheConfig    `mapstructure:"user_cache"`
+	Progression         ProgressionConfig  `mapstructure:"progression"`
+	Social              SocialConfig       `mapstructure:"social"`
+	Avatar              AvatarConfig       `mapstructure:"avatar"`
+	Password            PasswordConfig     `mapstructure:"password"`
+	TeenMode            TeenModeConfig     `mapstructure:"teen_mode"`
+	Referral            ReferralConfig     `mapstructure:"referral"`
+}
+
+// ReferralConfig 邀请注册：被邀请人完成里程碑后通过支付服务给双方发放积分和阅读币
+type ReferralConfig struct {
+	CodeLength        int                  `mapstructure:"code_length"`         // 邀请码长度
+	IPWindow          int                  `mapstructure:"ip_window"`           // 同 IP 检查的时间窗口（秒）
+	MaxPerIP          int                  `mapstructure:"max_per_ip"`          // 窗口内同一 IP 最多计入几个被邀请人
+	PaymentServiceURL string               `mapstructure:"payment_service_url"` // 发放奖励
+	RequestTimeout    int                  `mapstructure:"request_timeout"`     // 调用支付服务的超时（秒）
+	Rewards           []ReferralRewardRule `mapstructure:"rewards"`
+}
+
+// ReferralRewardRule 被邀请人达到里程碑时双方获得的奖励
+type ReferralRewardRule struct {
+	Milestone     string `mapstructure:"milestone"` // registered、verified（邮箱或手机号验证）或 level
+	Level         int    `mapstructure:"level"`     // milestone 为 level 时需要达到的等级
+	InviterPoints int    `mapstructure:"inviter_points"`
+	InviterCoins  int    `mapstructure:"inviter_coins"`
+	InviteePoints int    `mapstructure:"invitee_points"`
+	InviteeCoins  int    `mapstructure:"invitee_coins"`
+}
+
+// TeenModeConfig 青少年模式：限制每日阅读时长和宵禁时段，禁止购买，屏蔽成人向内容
+type TeenModeConfig struct {
+	AdultAge        int    `mapstructure:"adult_age"`         // 按生日未满该年龄自动开启，且不能关闭
+	DailyMinutes    int    `mapstructure:"daily_minutes"`     // 监护人未设置时的每日阅读时长（分钟）
+	MaxDailyMinutes int    `mapstructure:"max_daily_minutes"` // 监护人最多可设置的每日阅读时长（分钟）
+	CurfewStart     string `mapstructure:"curfew_start"`      // 宵禁开始时间 HH:MM
+	CurfewEnd       string `mapstructure:"curfew_end"`        // 宵禁结束时间 HH:MM，可跨零点
+	Timezone        string `mapstructure:"timezone"`          // 计算当天阅读时长和宵禁的时区
+	PinMaxAttempts  int    `mapstructure:"pin_max_attempts"`  // 监护密码连续输错几次后锁定
+	PinLockDuration int    `mapstructure:"pin_lock_duration"` // 监护密码锁定时长（秒）
+}
+
+// PasswordConfig 密码策略与哈希算法，策略只在设置新密码时检查
+type PasswordConfig struct {
+	MinLength           int                `mapstructure:"min_length"`            // 最少字符数
+	MaxLength           int                `mapstructure:"max_length"`            // 最多字符数，bcrypt 另有 72 字节上限
+	MinClasses          int                `mapstructure:"min_classes"`           // 至少包含小写、大写、数字、符号中的几类
+	AllowUsername       bool               `mapstructure:"allow_username"`        // 是否允许密码包含用户名
+	CommonPasswordsFile string             `mapstructure:"common_passwords_file"` // 常见/泄露密码列表，每行一个，与内置列表合并
+	Hash                PasswordHashConfig `mapstructure:"hash"`
+}
+
+// PasswordHashConfig 新密码使用的哈希算法；旧算法或旧参数的哈希在登录成功后自动升级
+type PasswordHashConfig struct {
+	Algorithm     string       `mapstructure:"algorithm"` // argon2id 或 bcrypt
+	BcryptCost    int          `mapstructure:"bcrypt_cost"`
+	Argon2        Argon2Config `mapstructure:"argon2"`
+	MaxConcurrent int          `mapstructure:"max_concurrent"` // 同时进行的哈希计算数，argon2id 的峰值内存为该值乘以 memory；0 为 CPU 核数
+}
+
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"`      // 内存（KiB）
+	Iterations  uint32 `mapstructure:"iterations"`  // 迭代次数
+	Parallelism uint8  `mapstructure:"parallelism"` // 并行度
+	SaltLength  uint32 `mapstructure:"salt_length"` // 盐长度（字节）
+	KeyLength   uint32 `mapstructure:"key_length"`  // 输出长度（字节）
+}
+
+// AvatarConfig 头像上传
+type AvatarConfig struct {
+	MaxSize      int           `mapstructure:"max_size"`      // 上传文件大小上限（字节）
+	MaxDimension int           `mapstructure:"max_dimension"` // 原图宽高上限（像素），防止解码超大图片耗尽内存
+	Sizes        []int         `mapstructure:"sizes"`         // 生成的正方形缩略图边长（像素）
+	Quality      int           `mapstructure:"quality"`       // JPEG 质量 1-100
+	Storage      StorageConfig `mapstructure:"storage"`
+}
+
+// StorageConfig 文件存储
+type StorageConfig struct {
+	Driver string             `mapstructure:"driver"` // local 或 s3
+	Local  LocalStorageConfig `mapstructure:"local"`
+	S3     S3Config           `mapstructure:"s3"`
+}
+
+type LocalStorageConfig struct {
+	Dir       string `mapstructure:"dir"`
+	PublicURL string `mapstructure:"public_url"` // 文件对外地址前缀，本服务在 /files 下提供
+}
+
+// S3Config 兼容 S3 的对象存储，本地联调可用 MinIO
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	PublicURL string `mapstructure:"public_url"` // CDN 或桶的公开地址，为空时使用 <endpoint>/<bucket>
+}
+
+// SocialConfig 关注与公开主页
+type SocialConfig struct {
+	ReadingServiceURL string `mapstructure:"reading_service_url"` // 书架和动态来自阅读服务
+	RequestTimeout    int    `mapstructure:"request_timeout"`     // 调用阅读服务的超时（秒）
+	FeedFollowing     int    `mapstructure:"feed_following"`      // 动态最多取最近关注的多少人
+}
+
+// ProgressionConfig 经验与等级
+type ProgressionConfig struct {
+	Rules  map[string]XPRule `mapstructure:"rules"`  // 按事件配置，未配置的事件不加经验
+	Levels []LevelConfig     `mapstructure:"levels"` // 按所需经验从低到高
+}
+
+// XPRule 单个事件的经验规则
+type XPRule struct {
+	XP       int `mapstructure:"xp"`        // 每单位获得的经验，如阅读时长按分钟
+	DailyCap int `mapstructure:"daily_cap"` // 每天从该事件最多获得的经验，0 表示不限
+}
+
+// LevelConfig 等级门槛和特权
+type LevelConfig struct {
+	Level int      `mapstructure:"level"`
+	XP    int      `mapstructure:"xp"` // 达到该等级所需的累计经验
+	Title string   `mapstructure:"title"`
+	Perks []string `mapstructure:"perks"` // 达到该等级新解锁的特权
+}
+
+// UserCacheConfig 用户查询缓存
+type UserCacheConfig struct {
+	TTL       int `mapstructure:"ttl"`        // Redis 缓存有效期（秒）
+	LocalSize int `mapstructure:"local_size"` // 进程内 LRU 最多缓存的用户数，0 表示不启用
+	LocalTTL  int `mapstructure:"local_ttl"`  // 进程内缓存有效期（秒），失效通知丢失时最多延迟这么久可见
+}
+
+// SigningConfig token 签名密钥与轮换
+type SigningConfig struct {
+	Algorithm        string `mapstructure:"algorithm"`         // RS256 或 EdDSA
+	RotationInterval int    `mapstructure:"rotation_interval"` // 签名密钥使用多久后轮换（秒）
+	Overlap          int    `mapstructure:"overlap"`           // 轮换后旧公钥继续发布的时长（秒），不短于 refresh token 有效期
+	CheckInterval    int    `mapstructure:"check_interval"`    // 检查轮换、同步其他实例密钥的间隔（秒）
+	KeyEncryptionKey string `mapstructure:"key_encryption_key"` // 加密数据库中签名私钥的密钥，只用于签名私钥，必须配置
+	LegacyCutover    string `mapstructure:"legacy_cutover"`    // 改用非对称密钥的时间（RFC3339），之前签发的 HS256 token 在 refresh token 有效期内仍可用；为空时不接受 HS256 token
+}
+
+// AccountDataConfig 个人数据导出、账号注销与合并
+type AccountDataConfig struct {
+	ExportDir         string            `mapstructure:"export_dir"`          // 导出压缩包存放目录
+	ExportTTL         int               `mapstructure:"export_ttl"`          // 导出文件保留时长（秒），过期删除
+	DeletionGraceDays int               `mapstructure:"deletion_grace_days"` // 注销冷静期（天），期间可撤销
+	WorkerInterval    int               `mapstructure:"worker_interval"`     // 后台任务检查间隔（秒）
+	RequestTimeout    int               `mapstructure:"request_timeout"`     // 调用其他服务的超时（秒）
+	Services          map[string]string `mapstructure:"services"`            // 持有用户数据的服务及地址，导出、注销和合并时逐一调用
+}
+
+// LoginAlertConfig 登录地点解析和新设备/新地区提醒
+type LoginAlertConfig struct {
+	GeoIPDatabase   string `mapstructure:"geoip_database"`   // GeoIP2/GeoLite2 City 数据库文件，为空时不解析位置
+	GeoIPLanguage   string `mapstructure:"geoip_language"`   // 地名语言，默认 zh-CN
+	Notifier        string `mapstructure:"notifier"`         // http（通知服务）或 log
+	NotificationURL string `mapstructure:"notification_url"` // 通知服务地址
+}
+
+// TwoFactorConfig 两步验证（TOTP）配置
+type TwoFactorConfig struct {
+	Issuer              string   `mapstructure:"issuer"`                // 验证器 App 中显示的服务名
+	ChallengeTTL        int      `mapstructure:"challenge_ttl"`         // 登录第二步的有效期（秒）
+	MaxAttempts         int      `mapstructure:"max_attempts"`          // 登录第二步允许输错的次数
+	RecoveryCodes       int      `mapstructure:"recovery_codes"`        // 每次生成的恢复码数量
+	RequiredCoinBalance int      `mapstructure:"required_coin_balance"` // 阅读币余额达到该值必须开启，0 表示不要求
+	RequiredVIPLevels   []string `mapstructure:"required_vip_levels"`   // 这些 VIP 等级必须开启
+}
+
+// VerificationConfig 邮箱/手机验证码配置
+type VerificationConfig struct {
+	CodeLength     int         `mapstructure:"code_length"`
+	CodeTTL        int         `mapstructure:"code_ttl"`        // 验证码有效期（秒）
+	MaxAttempts    int         `mapstructure:"max_attempts"`    // 单个验证码允许输错的次数
+	ResendInterval int         `mapstructure:"resend_interval"` // 同一目标两次发送的最小间隔（秒）
+	DailyLimit     int         `mapstructure:"daily_limit"`     // 同一目标每天最多发送次数
+	EmailLinkURL   string      `mapstructure:"email_link_url"`  // 邮件中验证链接的前端地址
+	ResetLinkURL   string      `mapstructure:"reset_link_url"`  // 重置密码邮件中的前端地址
+	IPHourlyLimit  int         `mapstructure:"ip_hourly_limit"` // 同一 IP 每小时最多请求重置密码的次数
+	Email          EmailConfig `mapstructure:"email"`
+	SMS            SMSConfig   `mapstructure:"sms"`
+}
+
+type EmailConfig struct {
+	Driver  string     `mapstructure:"driver"` // smtp 或 log
+	LogFile string     `mapstructure:"log_file"`
+	SMTP    SMTPConfig `mapstructure:"smtp"`
+}
+
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+type SMSConfig struct {
+	Driver  string        `mapstructure:"driver"` // http 或 log
+	LogFile string        `mapstructure:"log_file"`
+	HTTP    SMSHTTPConfig `mapstructure:"http"`
+}
+
+type SMSHTTPConfig struct {
+	URL      string `mapstructure:"url"`
+	APIKey   string `mapstructure:"api_key"`
+	SignName string `mapstructure:"sign_name"`
+}
+
+// LoginGuardConfig 登录防暴力破解配置
+type LoginGuardConfig struct {
+	Window           int `mapstructure:"window"`             // 失败计数窗口（秒）
+	CaptchaAfter     int `mapstructure:"captcha_after"`      // 账号失败几次后要求验证码
+	DelayAfter       int `mapstructure:"delay_after"`        // 账号失败几次后开始退避
+	BaseDelay        int `mapstructure:"base_delay"`         // 首次退避时长（秒），之后每次翻倍
+	MaxDelay         int `mapstructure:"max_delay"`          // 退避上限（秒）
+	AccountLockAfter int `mapstructure:"account_lock_after"` // 账号失败几次后锁定
+	IPLockAfter      int `mapstructure:"ip_lock_after"`      // 同一 IP 失败几次后锁定
+	LockDuration     int `mapstructure:"lock_duration"`      // 锁定时长（秒）
+}
+
+// SessionConfig 会话配置
+type SessionConfig struct {
+	MaxDevices map[string]int `mapstructure:"max_devices"` // 各 VIP 等级允许同时在线的设备数
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	TokenEncryptionKey string                    `mapstructure:"token_encryption_key"` // 第三方 token 加密密钥
+	StateTTL           int                       `mapstructure:"state_ttl"`            // state 有效期（秒）
+	Providers          map[string]ProviderConfig `mapstructure:"providers"`
+	AllowFake          bool                      `mapstructure:"allow_fake"`     // 开发环境开关，未开启时配置了假平台会拒绝启动
+	FakeProviders      []string                  `mapstructure:"fake_providers"` // 本地联调时以假平台启用的平台，覆盖 providers 中的配置
+}
+
+// ProviderConfig 单个第三方平台配置
+type ProviderConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Mode         string `mapstructure:"mode"` // live 或 fake（本地联调，需开启 allow_fake）
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURI  string `mapstructure:"redirect_uri"`
+
+	// Apple 专用：client_secret 由私钥签发
+	TeamID         string `mapstructure:"team_id"`
+	KeyID          string `mapstructure:"key_id"`
+	PrivateKeyPath string `mapstructure:"private_key_path"`
+}
+
+func LoadConfig(configPath string) (*Config, error) {
+	if _, err := sharedConfig.LoadConfig(configPath); err != nil {
+		return nil, err
+	}
+
+	viper.BindEnv("oauth.token_encryption_key", "OAUTH_TOKEN_ENCRYPTION_KEY")
+	viper.BindEnv("signing.key_encryption_key", "SIGNING_KEY_ENCRYPTION_KEY")
+	viper.BindEnv("signing.legacy_cutover", "SIGNING_LEGACY_CUTOVER")
+	viper.BindEnv("oauth.allow_fake", "OAUTH_ALLOW_FAKE")
+	viper.BindEnv("oauth.fake_providers", "OAUTH_FAKE_PROVIDERS")
+	viper.BindEnv("verification.email.smtp.password", "SMTP_PASSWORD")
+	viper.BindEnv("verification.sms.http.api_key", "SMS_API_KEY")
+	viper.BindEnv("avatar.storage.driver", "AVATAR_STORAGE_DRIVER")
+	viper.BindEnv("avatar.storage.s3.endpoint", "AVATAR_S3_ENDPOINT")
+	viper.BindEnv("avatar.storage.s3.access_key", "AVATAR_S3_ACCESS_KEY")
+	viper.BindEnv("avatar.storage.s3.secret_key", "AVATAR_S3_SECRET_KEY")
+	viper.BindEnv("avatar.storage.s3.public_url", "AVATAR_S3_PUBLIC_URL")
+	viper.BindEnv("referral.payment_service_url", "PAYMENT_SERVICE_URL")
+	viper.BindEnv("social.reading_service_url", "READING_SERVICE_URL")
+	viper.BindEnv("login_alert.notification_url", "NOTIFICATION_SERVICE_URL")
+	viper.BindEnv("account_data.services.reading", "READING_SERVICE_URL")
+	viper.BindEnv("account_data.services.payment", "PAYMENT_SERVICE_URL")
+	viper.BindEnv("account_data.services.notification", "NOTIFICATION_SERVICE_URL")
+	viper.BindEnv("account_data.services.download", "DOWNLOAD_SERVICE_URL")
+
+	var cfg Config
+	if err := viper.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+	}
+
+	if cfg.OAuth.StateTTL <= 0 {
+		cfg.OAuth.StateTTL = 600
+	}
+	if cfg.LoginGuard.Window <= 0 {
+		cfg.LoginGuard = LoginGuardConfig{
+			Window:           900,
+			CaptchaAfter:     3,
+			DelayAfter:       3,
+			BaseDelay:        1,
+			MaxDelay:         30,
+			AccountLockAfter: 10,
+			IPLockAfter:      50,
+			LockDuration:     900,
+		}
+	}
+	if cfg.Verification.CodeLength <= 0 {
+		cfg.Verification.CodeLength = 6
+	}
+	if cfg.Verification.CodeTTL <= 0 {
+		cfg.Verification.CodeTTL = 600
+	}
+	if cfg.Verification.MaxAttempts <= 0 {
+		cfg.Verification.MaxAttempts = 5
+	}
+	if cfg.Verification.IPHourlyLimit <= 0 {
+		cfg.Verification.IPHourlyLimit = 20
+	}
+	if cfg.TwoFactor.Issuer == "" {
+		cfg.TwoFactor.Issuer = "Reading"
+	}
+	if cfg.TwoFactor.ChallengeTTL <= 0 {
+		cfg.TwoFactor.ChallengeTTL = 300
+	}
+	if cfg.TwoFactor.MaxAttempts <= 0 {
+		cfg.TwoFactor.MaxAttempts = 5
+	}
+	if cfg.TwoFactor.RecoveryCodes <= 0 {
+		cfg.TwoFactor.RecoveryCodes = 10
+	}
+	if cfg.AccountData.ExportDir == "" {
+		cfg.AccountData.ExportDir = "./data/exports"
+	}
+	if cfg.AccountData.ExportTTL <= 0 {
+		cfg.AccountData.ExportTTL = 604800
+	}
+	if cfg.AccountData.DeletionGraceDays <= 0 {
+		cfg.AccountData.DeletionGraceDays = 15
+	}
+	if cfg.AccountData.WorkerInterval <= 0 {
+		cfg.AccountData.WorkerInterval = 300
+	}
+	if cfg.AccountData.RequestTimeout <= 0 {
+		cfg.AccountData.RequestTimeout = 30
+	}
+	if cfg.Signing.Algorithm == "" {
+		cfg.Signing.Algorithm = "RS256"
+	}
+	if cfg.Signing.RotationInterval <= 0 {
+		cfg.Signing.RotationInterval = 2592000
+	}
+	if cfg.Signing.Overlap <= 0 {
+		cfg.Signing.Overlap = 691200
+	}
+	if cfg.Signing.CheckInterval <= 0 {
+		cfg.Signing.CheckInterval = 600
+	}
+	if cfg.UserCache.TTL <= 0 {
+		cfg.UserCache.TTL = 600
+	}
+	if cfg.UserCache.LocalTTL <= 0 {
+		cfg.UserCache.LocalTTL = 10
+	}
+	if len(cfg.Progression.Rules) == 0 {
+		cfg.Progression.Rules = map[string]XPRule{
+			"reading_time":     {XP: 1, DailyCap: 120},
+			"chapter_finished": {XP: 5, DailyCap: 100},
+			"comment":          {XP: 10, DailyCap: 50},
+			"daily_checkin":    {XP: 20},
+		}
+	}
+	if len(cfg.Progression.Levels) == 0 {
+		cfg.Progression.Levels = []LevelConfig{
+			{Level: 1, XP: 0, Title: "书童"},
+			{Level: 2, XP: 100, Title: "书生"},
+			{Level: 3, XP: 300, Title: "秀才"},
+			{Level: 4, XP: 800, Title: "举人"},
+			{Level: 5, XP: 2000, Title: "进士"},
+		}
+	}
+	if cfg.Social.ReadingServiceURL == "" {
+		cfg.Social.ReadingServiceURL = "http://localhost:8083"
+	}
+	if cfg.Social.RequestTimeout <= 0 {
+		cfg.Social.RequestTimeout = 5
+	}
+	if cfg.Social.FeedFollowing <= 0 {
+		cfg.Social.FeedFollowing = 500
+	}
+	if cfg.Avatar.MaxSize <= 0 {
+		cfg.Avatar.MaxSize = 5 << 20
+	}
+	if cfg.Avatar.MaxDimension <= 0 {
+		cfg.Avatar.MaxDimension = 6000
+	}
+	if len(cfg.Avatar.Sizes) == 0 {
+		cfg.Avatar.Sizes = []int{48, 96, 256, 512}
+	}
+	if cfg.Avatar.Quality <= 0 || cfg.Avatar.Quality > 100 {
+		cfg.Avatar.Quality = 85
+	}
+	if cfg.Avatar.Storage.Local.Dir == "" {
+		cfg.Avatar.Storage.Local.Dir = "./data/files"
+	}
+	if cfg.Avatar.Storage.Local.PublicURL == "" {
+		cfg.Avatar.Storage.Local.PublicURL = "http://localhost:8080/files"
+	}
+	if cfg.Password.MinLength <= 0 {
+		cfg.Password.MinLength = 8
+	}
+	if cfg.Password.MaxLength <= 0 {
+		cfg.Password.MaxLength = 128
+	}
+	if cfg.Password.MinClasses <= 0 {
+		cfg.Password.MinClasses = 2
+	}
+	if cfg.Password.Hash.Algorithm == "" {
+		cfg.Password.Hash.Algorithm = "argon2id"
+	}
+	if cfg.Password.Hash.BcryptCost <= 0 {
+		cfg.Password.Hash.BcryptCost = 12
+	}
+	if cfg.Password.Hash.Argon2.Memory == 0 {
+		cfg.Password.Hash.Argon2.Memory = 64 * 1024
+	}
+	if cfg.Password.Hash.Argon2.Iterations == 0 {
+		cfg.Password.Hash.Argon2.Iterations = 3
+	}
+	if cfg.Password.Hash.Argon2.Parallelism == 0 {
+		cfg.Password.Hash.Argon2.Parallelism = 2
+	}
+	if cfg.Password.Hash.Argon2.SaltLength == 0 {
+		cfg.Password.Hash.Argon2.SaltLength = 16
+	}
+	if cfg.Password.Hash.Argon2.KeyLength == 0 {
+		cfg.Password.Hash.Argon2.KeyLength = 32
+	}
+	if cfg.TeenMode.AdultAge <= 0 {
+		cfg.TeenMode.AdultAge = 18
+	}
+	if cfg.TeenMode.DailyMinutes <= 0 {
+		cfg.TeenMode.DailyMinutes = 40
+	}
+	if cfg.TeenMode.MaxDailyMinutes <= 0 {
+		cfg.TeenMode.MaxDailyMinutes = 120
+	}
+	if cfg.TeenMode.CurfewStart == "" {
+		cfg.TeenMode.CurfewStart = "22:00"
+	}
+	if cfg.TeenMode.CurfewEnd == "" {
+		cfg.TeenMode.CurfewEnd = "06:00"
+	}
+	if cfg.TeenMode.Timezone == "" {
+		cfg.TeenMode.Timezone = "Asia/Shanghai"
+	}
+	if cfg.TeenMode.PinMaxAttempts <= 0 {
+		cfg.TeenMode.PinMaxAttempts = 5
+	}
+	if cfg.TeenMode.PinLockDuration <= 0 {
+		cfg.TeenMode.PinLockDuration = 900
+	}
+	if cfg.Referral.CodeLength <= 0 {
+		cfg.Referral.CodeLength = 8
+	}
+	if cfg.Referral.IPWindow <= 0 {
+		cfg.Referral.IPWindow = 86400
+	}
+	if cfg.Referral.MaxPerIP <= 0 {
+		cfg.Referral.MaxPerIP = 3
+	}
+	if cfg.Referral.PaymentServiceURL == "" {
+		cfg.Referral.PaymentServiceURL = "http://localhost:8084"
+	}
+	if cfg.Referral.RequestTimeout <= 0 {
+		cfg.Referral.RequestTimeout = 5
+	}
+	if cfg.Referral.Rewards == nil {
+		cfg.Referral.Rewards = []ReferralRewardRule{
+			{Milestone: "registered", InviteePoints: 100},
+			{Milestone: "verified", InviterPoints: 200, InviteeCoins: 50},
+			{Milestone: "level", Level: 3, InviterCoins: 100},
+		}
+	}
+	if len(cfg.Session.MaxDevices) == 0 {
+		cfg.Session.MaxDevices = map[string]int{"none": 2, "vip": 3, "svip": 5}
+	}
+
+	return &cfg, nil
+}
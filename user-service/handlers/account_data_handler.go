@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+)
+
+type AccountDataHandler struct {
+	accountDataService services.AccountDataServiceInterface
+}
+
+func NewAccountDataHandler(accountDataService services.AccountDataServiceInterface) *AccountDataHandler {
+	return &AccountDataHandler{
+		accountDataService: accountDataService,
+	}
+}
+
+// RequestExport 发起个人数据导出
+// @Summary 导出个人数据
+// @Description 异步汇总各服务中的个人数据，完成后通过任务 ID 下载压缩包
+// @Tags 个人数据
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.DataExportJob}
+// @Router /user/data-export [post]
+func (h *AccountDataHandler) RequestExport(c *gin.Context) {
+	job, err := h.accountDataService.RequestExport(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, job)
+}
+
+// ExportStatus 导出任务状态
+// @Summary 导出任务状态
+// @Tags 个人数据
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "导出任务ID"
+// @Success 200 {object} utils.Response{data=models.DataExportJob}
+// @Router /user/data-export/{id} [get]
+func (h *AccountDataHandler) ExportStatus(c *gin.Context) {
+	job, err := h.accountDataService.ExportStatus(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, job)
+}
+
+// DownloadExport 下载导出文件
+// @Summary 下载导出文件
+// @Tags 个人数据
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param id path string true "导出任务ID"
+// @Success 200 {file} file
+// @Router /user/data-export/{id}/file [get]
+func (h *AccountDataHandler) DownloadExport(c *gin.Context) {
+	path, err := h.accountDataService.ExportFile(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.FileAttachment(path, "personal-data-"+c.Param("id")+".zip")
+}
+
+// RequestDeletion 申请注销账号
+// @Summary 申请注销账号
+// @Description 冷静期结束后删除各服务中的个人数据并匿名化账号，冷静期内可撤销
+// @Tags 个人数据
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.RequestAccountDeletionRequest true "密码和注销原因"
+// @Success 200 {object} utils.Response{data=models.AccountDeletionRequest}
+// @Router /user/account/deletion [post]
+func (h *AccountDataHandler) RequestDeletion(c *gin.Context) {
+	var req models.RequestAccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	deletion, err := h.accountDataService.RequestDeletion(c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, deletion)
+}
+
+// DeletionStatus 注销申请状态
+// @Summary 注销申请状态
+// @Tags 个人数据
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.AccountDeletionRequest}
+// @Router /user/account/deletion [get]
+func (h *AccountDataHandler) DeletionStatus(c *gin.Context) {
+	deletion, err := h.accountDataService.DeletionStatus(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, deletion)
+}
+
+// CancelDeletion 撤销注销申请
+// @Summary 撤销注销申请
+// @Tags 个人数据
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response
+// @Router /user/account/deletion [delete]
+func (h *AccountDataHandler) CancelDeletion(c *gin.Context) {
+	if err := h.accountDataService.CancelDeletion(c.GetString("user_id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Account deletion cancelled", nil)
+}
+
+func (h *AccountDataHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrExportNotFound), errors.Is(err, services.ErrNoDeletionRequest):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrExportNotReady), errors.Is(err, services.ErrExportExpired),
+		errors.Is(err, services.ErrDeletionPending), errors.Is(err, services.ErrDeletionPassword):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	case errors.Is(err, services.ErrAccountDeactivated):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
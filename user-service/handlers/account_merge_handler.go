@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	authServices "reading-microservices/user-service/services/auth"
+)
+
+type AccountMergeHandler struct {
+	accountMergeService services.AccountMergeServiceInterface
+}
+
+func NewAccountMergeHandler(accountMergeService services.AccountMergeServiceInterface) *AccountMergeHandler {
+	return &AccountMergeHandler{
+		accountMergeService: accountMergeService,
+	}
+}
+
+// Preview 账号合并预览
+// @Summary 账号合并预览
+// @Description 先登录要合并掉的副账号，用其 access token 预览各服务将转入当前账号和因重复而丢弃的数据条数，不修改数据
+// @Tags 个人数据
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.MergeAccountRequest true "副账号 token"
+// @Success 200 {object} utils.Response{data=models.AccountMergePreview}
+// @Router /user/account/merge/preview [post]
+func (h *AccountMergeHandler) Preview(c *gin.Context) {
+	var req models.MergeAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	preview, err := h.accountMergeService.Preview(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, preview)
+}
+
+// SendCode 发送账号合并验证码
+// @Summary 发送账号合并验证码
+// @Description 向副账号已验证的邮箱（优先）或手机号发送验证码，用于确认合并；副账号没有已验证的联系方式时改用其密码
+// @Tags 个人数据
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.SendMergeCodeRequest true "副账号 token"
+// @Success 200 {object} utils.Response{data=models.SendCodeResponse}
+// @Router /user/account/merge/code [post]
+func (h *AccountMergeHandler) SendCode(c *gin.Context) {
+	var req models.SendMergeCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	resp, err := h.accountMergeService.SendCode(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Merge 合并账号
+// @Summary 合并账号
+// @Description 把副账号的第三方绑定、阅读记录、书架、评论、积分和阅读币流水、会员等转入当前账号，完成后注销副账号。需要副账号的密码或合并验证码，失败时可原样重试
+// @Tags 个人数据
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.MergeAccountRequest true "副账号 token，以及副账号密码或验证码"
+// @Success 200 {object} utils.Response{data=models.AccountMerge}
+// @Router /user/account/merge [post]
+func (h *AccountMergeHandler) Merge(c *gin.Context) {
+	var req models.MergeAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.IPAddress = c.ClientIP()
+
+	merge, err := h.accountMergeService.Merge(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, merge)
+}
+
+func (h *AccountMergeHandler) handleError(c *gin.Context, err error) {
+	var loginErr *authServices.LoginError
+	switch {
+	case errors.As(err, &loginErr):
+		respondLoginError(c, loginErr)
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrMergeTokenInvalid), errors.Is(err, services.ErrMergeProofInvalid):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	case errors.Is(err, services.ErrMergeSameAccount), errors.Is(err, services.ErrMergePlatformConflict),
+		errors.Is(err, services.ErrMergeProofRequired), errors.Is(err, services.ErrMergeNoContact),
+		errors.Is(err, authServices.ErrCodeTooManyTries):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
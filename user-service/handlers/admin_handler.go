@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	"reading-microservices/user-service/services/password"
+)
+
+type AdminHandler struct {
+	adminService services.AdminServiceInterface
+}
+
+func NewAdminHandler(adminService services.AdminServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// SearchUsers 用户列表
+// @Summary 用户列表
+// @Description 按用户 ID、用户名、昵称、邮箱、手机号搜索，可按状态、角色和 VIP 等级筛选
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param keyword query string false "关键字"
+// @Param status query string false "active、banned 或 disabled"
+// @Param role query string false "user、moderator 或 admin"
+// @Param vip_level query string false "none、vip 或 svip"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.AdminUserItem}
+// @Router /admin/users [get]
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	var query models.AdminUserQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Size <= 0 {
+		query.Size = 20
+	}
+	if query.Size > 100 {
+		query.Size = 100
+	}
+
+	items, total, err := h.adminService.SearchUsers(&query)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.PageSuccess(c, items, total, query.Page, query.Size)
+}
+
+// GetUser 用户详情
+// @Summary 用户详情
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response{data=models.AdminUserDetail}
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	detail, err := h.adminService.GetUser(c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, detail)
+}
+
+// ListSessions 用户在线设备
+// @Summary 用户在线设备
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response{data=[]models.DeviceInfo}
+// @Router /admin/users/{id}/sessions [get]
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	devices, err := h.adminService.ListSessions(c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, devices)
+}
+
+// LoginHistory 用户登录记录
+// @Summary 用户登录记录
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.LoginHistoryItem}
+// @Router /admin/users/{id}/login-history [get]
+func (h *AdminHandler) LoginHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if size > 100 {
+		size = 100
+	}
+
+	items, total, err := h.adminService.LoginHistory(c.Param("id"), page, size)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.PageSuccess(c, items, total, page, size)
+}
+
+// BanUser 封禁用户
+// @Summary 封禁用户
+// @Description 封禁后立即下线所有设备，duration 为 0 时永久封禁
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param request body models.AdminBanRequest true "原因和时长"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/ban [post]
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	var req models.AdminBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.adminService.BanUser(c.GetString("user_id"), c.GetString("role"), c.Param("id"), &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "User banned", nil)
+}
+
+// UnbanUser 解除封禁
+// @Summary 解除封禁
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/unban [post]
+func (h *AdminHandler) UnbanUser(c *gin.Context) {
+	if err := h.adminService.UnbanUser(c.GetString("user_id"), c.GetString("role"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "User unbanned", nil)
+}
+
+// ForceLogout 强制下线
+// @Summary 强制下线
+// @Tags 用户管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/logout [post]
+func (h *AdminHandler) ForceLogout(c *gin.Context) {
+	if err := h.adminService.ForceLogout(c.GetString("user_id"), c.GetString("role"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "All sessions revoked", nil)
+}
+
+// ResetPassword 重置密码
+// @Summary 重置密码
+// @Description 未指定新密码时生成临时密码并返回，重置后下线所有设备
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param request body models.AdminResetPasswordRequest false "新密码"
+// @Success 200 {object} utils.Response{data=models.AdminResetPasswordResponse}
+// @Router /admin/users/{id}/reset-password [post]
+func (h *AdminHandler) ResetPassword(c *gin.Context) {
+	var req models.AdminResetPasswordRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+			return
+		}
+	}
+
+	resp, err := h.adminService.ResetPassword(c.GetString("user_id"), c.GetString("role"), c.Param("id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// UpdateRole 调整角色
+// @Summary 调整角色
+// @Description 角色变更后下线所有设备，重新登录生效
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Param request body models.AdminUpdateRoleRequest true "角色"
+// @Success 200 {object} utils.Response
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateRole(c *gin.Context) {
+	var req models.AdminUpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.adminService.UpdateRole(c.GetString("user_id"), c.GetString("role"), c.Param("id"), req.Role); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Role updated", nil)
+}
+
+func (h *AdminHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrAdminSelf), errors.Is(err, services.ErrAdminInsufficient):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	case errors.Is(err, password.ErrWeakPassword):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
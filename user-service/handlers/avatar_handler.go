@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"image"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	"reading-microservices/user-service/services/imaging"
+)
+
+type AvatarHandler struct {
+	avatarService services.AvatarServiceInterface
+}
+
+func NewAvatarHandler(avatarService services.AvatarServiceInterface) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+	}
+}
+
+// Upload 上传头像
+// @Summary 上传头像
+// @Description multipart 表单，avatar 为图片文件（jpeg、png、gif）；可选 crop_x、crop_y、crop_size 指定裁剪区域，不传时取中间的正方形
+// @Tags 用户信息
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param avatar formData file true "头像图片"
+// @Param crop_x formData int false "裁剪区域左上角 x"
+// @Param crop_y formData int false "裁剪区域左上角 y"
+// @Param crop_size formData int false "裁剪区域边长"
+// @Success 200 {object} utils.Response{data=models.AvatarResponse}
+// @Router /user/avatar [post]
+func (h *AvatarHandler) Upload(c *gin.Context) {
+	maxSize := int64(h.avatarService.MaxSize())
+	// 表单其他字段和 multipart 边界另留 1MB
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	var crop models.AvatarCropRequest
+	if err := c.ShouldBind(&crop); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	var rect *image.Rectangle
+	switch {
+	case crop.X != nil && crop.Y != nil && crop.Size != nil:
+		r := image.Rect(*crop.X, *crop.Y, *crop.X+*crop.Size, *crop.Y+*crop.Size)
+		rect = &r
+	case crop.X != nil || crop.Y != nil || crop.Size != nil:
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, "crop_x, crop_y and crop_size must be given together")
+		return
+	}
+
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if file.Size > maxSize {
+		h.handleError(c, services.ErrAvatarTooLarge)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	resp, err := h.avatarService.Upload(c.Request.Context(), c.GetString("user_id"), data, rect)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Remove 删除头像
+// @Summary 删除头像
+// @Description 删除已上传的头像文件，恢复默认头像
+// @Tags 用户信息
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response
+// @Router /user/avatar [delete]
+func (h *AvatarHandler) Remove(c *gin.Context) {
+	if err := h.avatarService.Remove(c.Request.Context(), c.GetString("user_id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Avatar removed", nil)
+}
+
+func (h *AvatarHandler) handleError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr), errors.Is(err, services.ErrAvatarTooLarge):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, services.ErrAvatarTooLarge.Error())
+	case errors.Is(err, http.ErrMissingFile), errors.Is(err, http.ErrNotMultipart):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, "avatar file is required")
+	case errors.Is(err, imaging.ErrUnsupportedFormat), errors.Is(err, imaging.ErrImageTooLarge), errors.Is(err, imaging.ErrInvalidCrop):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
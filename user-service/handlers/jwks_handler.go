@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+)
+
+// KeySet 提供当前发布的 token 验证公钥
+type KeySet interface {
+	JWKS() utils.JWKS
+}
+
+type JWKSHandler struct {
+	keys KeySet
+}
+
+func NewJWKSHandler(keys KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// JWKS token 验证公钥
+// @Summary token 验证公钥
+// @Description 按 RFC 7517 输出 JWKS，网关和各服务据 token 头中的 kid 选取公钥；轮换后旧公钥仍会保留一段时间
+// @Tags 用户认证
+// @Produce json
+// @Success 200 {object} utils.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	// 验证方按 jwks_refresh 缓存，遇到未知 kid 会主动刷新，这里只做短时缓存
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keys.JWKS())
+}
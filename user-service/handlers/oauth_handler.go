@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	"reading-microservices/user-service/services/oauth"
+)
+
+type OAuthHandler struct {
+	oauthService services.OAuthServiceInterface
+}
+
+func NewOAuthHandler(oauthService services.OAuthServiceInterface) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// Providers 已启用的第三方平台
+// @Summary 第三方平台列表
+// @Tags 第三方登录
+// @Produce json
+// @Success 200 {object} utils.Response{data=[]string}
+// @Router /auth/oauth/providers [get]
+func (h *OAuthHandler) Providers(c *gin.Context) {
+	utils.Success(c, h.oauthService.Providers())
+}
+
+// Authorize 获取第三方登录授权地址
+// @Summary 第三方登录授权地址
+// @Tags 第三方登录
+// @Produce json
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Success 200 {object} utils.Response{data=models.OAuthAuthorizeResponse}
+// @Router /auth/oauth/{provider}/authorize [get]
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	resp, err := h.oauthService.Authorize(c.Request.Context(), c.Param("provider"), "")
+	if err != nil {
+		h.handleError(c, err, utils.ERROR)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Login 第三方登录
+// @Summary 第三方登录
+// @Description 使用授权码登录，首次登录自动注册
+// @Tags 第三方登录
+// @Accept json
+// @Produce json
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Param request body models.OAuthLoginRequest true "授权码"
+// @Success 200 {object} utils.Response{data=models.LoginResponse}
+// @Router /auth/oauth/{provider}/login [post]
+func (h *OAuthHandler) Login(c *gin.Context) {
+	var req models.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.Platform = getClientPlatform(c)
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.oauthService.Login(c.Request.Context(), c.Param("provider"), &req)
+	if err != nil {
+		h.handleError(c, err, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// LinkAuthorize 获取绑定第三方账号的授权地址
+// @Summary 绑定授权地址
+// @Tags 第三方登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Success 200 {object} utils.Response{data=models.OAuthAuthorizeResponse}
+// @Router /user/oauth/{provider}/authorize [get]
+func (h *OAuthHandler) LinkAuthorize(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	resp, err := h.oauthService.Authorize(c.Request.Context(), c.Param("provider"), userID)
+	if err != nil {
+		h.handleError(c, err, utils.ERROR)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Link 绑定第三方账号
+// @Summary 绑定第三方账号
+// @Tags 第三方登录
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Param request body models.OAuthLinkRequest true "授权码"
+// @Success 200 {object} utils.Response{data=models.ThirdPartyAccountInfo}
+// @Router /user/oauth/{provider}/link [post]
+func (h *OAuthHandler) Link(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	var req models.OAuthLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	account, err := h.oauthService.Link(c.Request.Context(), userID, c.Param("provider"), &req)
+	if err != nil {
+		h.handleError(c, err, utils.ERROR)
+		return
+	}
+	utils.Success(c, account)
+}
+
+// Unlink 解绑第三方账号
+// @Summary 解绑第三方账号
+// @Tags 第三方登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Success 200 {object} utils.Response
+// @Router /user/oauth/{provider} [delete]
+func (h *OAuthHandler) Unlink(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	if err := h.oauthService.Unlink(userID, c.Param("provider")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.SuccessWithMessage(c, "Unlinked successfully", nil)
+}
+
+// SetPrimary 设置主账号
+// @Summary 设置主第三方账号
+// @Tags 第三方登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "平台 wechat/qq/weibo/apple"
+// @Success 200 {object} utils.Response
+// @Router /user/oauth/{provider}/primary [put]
+func (h *OAuthHandler) SetPrimary(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	if err := h.oauthService.SetPrimary(userID, c.Param("provider")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.SuccessWithMessage(c, "Primary account updated", nil)
+}
+
+// ListAccounts 已绑定的第三方账号
+// @Summary 已绑定的第三方账号
+// @Tags 第三方登录
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]models.ThirdPartyAccountInfo}
+// @Router /user/oauth/accounts [get]
+func (h *OAuthHandler) ListAccounts(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	accounts, err := h.oauthService.ListAccounts(userID)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, accounts)
+}
+
+// handleError 平台不存在和 state 无效单独返回错误码，其余使用 fallback
+func (h *OAuthHandler) handleError(c *gin.Context, err error, fallback int) {
+	switch {
+	case errors.Is(err, oauth.ErrProviderNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, oauth.ErrInvalidState):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, fallback, err.Error())
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"reading-microservices/shared/openapi"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services/sender"
+)
+
+// OpenAPIDocument 用户服务接口文档，新增路由时同步维护
+func OpenAPIDocument() *openapi.Document {
+	doc := openapi.NewDocument("user-service", "1.0.0")
+	return doc.AddRoutes(
+		// 用户认证
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/register", Summary: "用户注册", Tag: "用户认证",
+			Body: models.RegisterRequest{}, Response: models.LoginResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/login", Summary: "用户登录", Tag: "用户认证",
+			Body: models.LoginRequest{}, Response: models.LoginResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/refresh", Summary: "刷新Token", Tag: "用户认证", Auth: true,
+			Response: models.LoginResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/validate", Summary: "验证Token", Tag: "用户认证", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/password/forgot", Summary: "忘记密码", Tag: "用户认证",
+			Body: models.ForgotPasswordRequest{}, Response: models.SendCodeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/password/reset", Summary: "重置密码", Tag: "用户认证",
+			Body: models.ResetPasswordRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/sms/send", Summary: "发送短信登录验证码", Tag: "用户认证",
+			Body: models.SendSMSCodeRequest{}, Response: models.SendCodeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/sms/login", Summary: "短信验证码登录", Tag: "用户认证",
+			Body: models.SMSLoginRequest{}, Response: models.LoginResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/2fa/verify", Summary: "两步验证登录", Tag: "用户认证",
+			Body: models.TwoFactorLoginRequest{}, Response: models.LoginResponse{}},
+
+		// 用户信息
+		openapi.Route{Method: "GET", Path: "/api/v1/user/profile", Summary: "获取用户信息", Tag: "用户信息", Auth: true,
+			Response: models.UserInfo{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/user/profile", Summary: "更新用户信息", Tag: "用户信息", Auth: true,
+			Body: models.UpdateProfileRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/avatar", Summary: "上传头像", Tag: "用户信息", Auth: true,
+			Response: models.AvatarResponse{}}, // multipart 表单：avatar 文件，可选 crop_x、crop_y、crop_size
+		openapi.Route{Method: "DELETE", Path: "/api/v1/user/avatar", Summary: "删除头像", Tag: "用户信息", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/change-password", Summary: "修改密码", Tag: "用户信息", Auth: true,
+			Body: models.ChangePasswordRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/login-history", Summary: "登录记录", Tag: "用户信息", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.LoginHistoryItem{}, Paged: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/logout", Summary: "用户登出", Tag: "用户认证", Auth: true},
+
+		// 设备管理
+		openapi.Route{Method: "GET", Path: "/api/v1/user/devices", Summary: "在线设备列表", Tag: "设备管理", Auth: true,
+			Response: []models.DeviceInfo{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/user/devices/:device_id", Summary: "下线指定设备", Tag: "设备管理", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/devices/revoke-others", Summary: "下线其他设备", Tag: "设备管理", Auth: true},
+
+		// 第三方登录
+		openapi.Route{Method: "GET", Path: "/api/v1/auth/oauth/providers", Summary: "第三方平台列表", Tag: "第三方登录",
+			Response: []string{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/auth/oauth/:provider/authorize", Summary: "第三方登录授权地址", Tag: "第三方登录",
+			Response: models.OAuthAuthorizeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/auth/oauth/:provider/login", Summary: "第三方登录", Tag: "第三方登录",
+			Body: models.OAuthLoginRequest{}, Response: models.LoginResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/oauth/accounts", Summary: "已绑定的第三方账号", Tag: "第三方登录", Auth: true,
+			Response: []models.ThirdPartyAccountInfo{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/oauth/:provider/authorize", Summary: "绑定授权地址", Tag: "第三方登录", Auth: true,
+			Response: models.OAuthAuthorizeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/oauth/:provider/link", Summary: "绑定第三方账号", Tag: "第三方登录", Auth: true,
+			Body: models.OAuthLinkRequest{}, Response: models.ThirdPartyAccountInfo{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/user/oauth/:provider/primary", Summary: "设置主第三方账号", Tag: "第三方登录", Auth: true},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/user/oauth/:provider", Summary: "解绑第三方账号", Tag: "第三方登录", Auth: true},
+
+		// 账号验证
+		openapi.Route{Method: "POST", Path: "/api/v1/user/verify/email/send", Summary: "发送邮箱验证码", Tag: "账号验证", Auth: true,
+			Response: models.SendCodeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/verify/email/confirm", Summary: "确认邮箱验证码", Tag: "账号验证", Auth: true,
+			Body: models.ConfirmCodeRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/verify/phone/send", Summary: "发送手机验证码", Tag: "账号验证", Auth: true,
+			Response: models.SendCodeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/verify/phone/confirm", Summary: "确认手机验证码", Tag: "账号验证", Auth: true,
+			Body: models.ConfirmCodeRequest{}},
+
+		// 两步验证
+		openapi.Route{Method: "GET", Path: "/api/v1/user/2fa", Summary: "两步验证状态", Tag: "两步验证", Auth: true,
+			Response: models.TwoFactorStatus{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/2fa/setup", Summary: "设置两步验证", Tag: "两步验证", Auth: true,
+			Response: models.TwoFactorSetupResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/2fa/enable", Summary: "开启两步验证", Tag: "两步验证", Auth: true,
+			Body: models.ConfirmCodeRequest{}, Response: models.RecoveryCodesResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/2fa/disable", Summary: "关闭两步验证", Tag: "两步验证", Auth: true,
+			Body: models.ConfirmCodeRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/2fa/recovery-codes", Summary: "重新生成恢复码", Tag: "两步验证", Auth: true,
+			Body: models.ConfirmCodeRequest{}, Response: models.RecoveryCodesResponse{}},
+
+		// 个人数据
+		openapi.Route{Method: "POST", Path: "/api/v1/user/data-export", Summary: "导出个人数据", Tag: "个人数据", Auth: true,
+			Response: models.DataExportJob{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/data-export/:id", Summary: "导出任务状态", Tag: "个人数据", Auth: true,
+			Response: models.DataExportJob{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/data-export/:id/file", Summary: "下载导出文件", Tag: "个人数据", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/account/deletion", Summary: "申请注销账号", Tag: "个人数据", Auth: true,
+			Body: models.RequestAccountDeletionRequest{}, Response: models.AccountDeletionRequest{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/account/deletion", Summary: "注销申请状态", Tag: "个人数据", Auth: true,
+			Response: models.AccountDeletionRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/user/account/deletion", Summary: "撤销注销申请", Tag: "个人数据", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/account/merge/preview", Summary: "账号合并预览", Tag: "个人数据", Auth: true,
+			Body: models.MergeAccountRequest{}, Response: models.AccountMergePreview{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/account/merge/code", Summary: "发送账号合并验证码", Tag: "个人数据", Auth: true,
+			Body: models.SendMergeCodeRequest{}, Response: models.SendCodeResponse{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/account/merge", Summary: "合并账号", Tag: "个人数据", Auth: true,
+			Body: models.MergeAccountRequest{}, Response: models.AccountMerge{}},
+
+		// 等级
+		openapi.Route{Method: "POST", Path: "/api/v1/user/checkin", Summary: "每日签到", Tag: "等级", Auth: true,
+			Response: models.AwardExperienceResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/level", Summary: "等级进度", Tag: "等级", Auth: true,
+			Response: models.LevelProgress{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/levels", Summary: "等级列表", Tag: "等级", Auth: true,
+			Response: []models.LevelInfo{}},
+
+		// 社交
+		openapi.Route{Method: "GET", Path: "/api/v1/users/:id", Summary: "公开主页", Tag: "社交",
+			Response: models.PublicProfile{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/users/:id/follow", Summary: "关注用户", Tag: "社交", Auth: true},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/users/:id/follow", Summary: "取消关注", Tag: "社交", Auth: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/users/:id/followers", Summary: "粉丝列表", Tag: "社交",
+			Query: openapi.PageQuery{}, Response: []models.FollowUserItem{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/users/:id/following", Summary: "关注列表", Tag: "社交",
+			Query: openapi.PageQuery{}, Response: []models.FollowUserItem{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/users/:id/bookshelf", Summary: "公开书架", Tag: "社交",
+			Query: openapi.PageQuery{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/users/:id/activity", Summary: "用户动态", Tag: "社交",
+			Query: models.FeedQuery{}, Response: models.FeedResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/feed", Summary: "关注动态", Tag: "社交", Auth: true,
+			Query: models.FeedQuery{}, Response: models.FeedResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/privacy", Summary: "隐私设置", Tag: "社交", Auth: true,
+			Response: models.UserPrivacy{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/user/privacy", Summary: "修改隐私设置", Tag: "社交", Auth: true,
+			Body: models.UpdatePrivacyRequest{}, Response: models.UserPrivacy{}},
+
+		// 青少年模式
+		openapi.Route{Method: "GET", Path: "/api/v1/user/teen-mode", Summary: "青少年模式状态", Tag: "青少年模式", Auth: true,
+			Response: models.TeenModeStatus{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/teen-mode/enable", Summary: "开启青少年模式", Tag: "青少年模式", Auth: true,
+			Body: models.EnableTeenModeRequest{}, Response: models.TeenModeStatus{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/user/teen-mode", Summary: "修改青少年模式设置", Tag: "青少年模式", Auth: true,
+			Body: models.UpdateTeenModeRequest{}, Response: models.TeenModeStatus{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/user/teen-mode/disable", Summary: "关闭青少年模式", Tag: "青少年模式", Auth: true,
+			Body: models.TeenModePinRequest{}},
+
+		// 邀请
+		openapi.Route{Method: "GET", Path: "/api/v1/user/referral", Summary: "我的邀请", Tag: "邀请", Auth: true,
+			Response: models.ReferralSummary{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/user/referral/invitees", Summary: "我邀请的用户", Tag: "邀请", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.InviteeItem{}, Paged: true},
+
+		// 阅读偏好
+		openapi.Route{Method: "GET", Path: "/api/v1/user/preferences", Summary: "阅读偏好", Tag: "阅读偏好", Auth: true,
+			Query: models.PreferencesQuery{}, Response: models.PreferencesResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/user/preferences", Summary: "修改阅读偏好", Tag: "阅读偏好", Auth: true,
+			Body: models.UpdatePreferencesRequest{}, Response: models.PreferencesResponse{}},
+
+		// 用户管理
+		openapi.Route{Method: "GET", Path: "/api/v1/admin/users", Summary: "用户列表", Tag: "用户管理", Auth: true,
+			Query: models.AdminUserQuery{}, Response: []models.AdminUserItem{}, Paged: true},
+		openapi.Route{Method: "GET", Path: "/api/v1/admin/users/:id", Summary: "用户详情", Tag: "用户管理", Auth: true,
+			Response: models.AdminUserDetail{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/admin/users/:id/sessions", Summary: "用户在线设备", Tag: "用户管理", Auth: true,
+			Response: []models.DeviceInfo{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/admin/users/:id/login-history", Summary: "用户登录记录", Tag: "用户管理", Auth: true,
+			Query: openapi.PageQuery{}, Response: []models.LoginHistoryItem{}, Paged: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/users/:id/ban", Summary: "封禁用户", Tag: "用户管理", Auth: true,
+			Body: models.AdminBanRequest{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/users/:id/unban", Summary: "解除封禁", Tag: "用户管理", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/users/:id/logout", Summary: "强制下线", Tag: "用户管理", Auth: true},
+		openapi.Route{Method: "POST", Path: "/api/v1/admin/users/:id/reset-password", Summary: "重置密码", Tag: "用户管理", Auth: true,
+			Body: models.AdminResetPasswordRequest{}, Response: models.AdminResetPasswordResponse{}},
+		openapi.Route{Method: "PUT", Path: "/api/v1/admin/users/:id/role", Summary: "调整角色", Tag: "用户管理", Auth: true,
+			Body: models.AdminUpdateRoleRequest{}},
+
+		// 内部接口
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/user/login-unlock", Summary: "解除登录锁定", Tag: "内部接口",
+			Body: models.UnlockLoginRequest{}},
+		openapi.Route{Method: "DELETE", Path: "/api/v1/internal/user/users/:user_id/cache", Summary: "清除用户缓存", Tag: "内部接口"},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/user/users/batch", Summary: "批量查询用户", Tag: "内部接口",
+			Body: models.BatchUsersRequest{}, Response: []models.UserPublicInfo{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/user/experience", Summary: "记经验", Tag: "内部接口",
+			Body: models.AwardExperienceRequest{}, Response: models.AwardExperienceResponse{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/user/users/:user_id/teen-mode", Summary: "查询青少年模式限制", Tag: "内部接口",
+			Response: models.TeenModeStatus{}},
+		openapi.Route{Method: "POST", Path: "/api/v1/internal/user/teen-mode/reading", Summary: "上报阅读时长", Tag: "内部接口",
+			Body: models.RecordTeenReadingRequest{}, Response: models.TeenModeStatus{}},
+		openapi.Route{Method: "GET", Path: "/api/v1/internal/user/sms-outbox/:phone", Summary: "测试短信收件箱", Tag: "内部接口",
+			Response: sender.Message{}},
+	)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/password"
+)
+
+type PasswordResetHandler struct {
+	passwordResetService services.PasswordResetServiceInterface
+}
+
+func NewPasswordResetHandler(passwordResetService services.PasswordResetServiceInterface) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetService: passwordResetService,
+	}
+}
+
+// ForgotPassword 忘记密码，发送重置验证码
+// @Summary 忘记密码
+// @Description 向邮箱或手机号发送重置验证码，账号不存在时同样返回成功
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "邮箱或手机号"
+// @Success 200 {object} utils.Response{data=models.SendCodeResponse}
+// @Router /auth/password/forgot [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.Platform = getClientPlatform(c)
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.passwordResetService.ForgotPassword(c.Request.Context(), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// ResetPassword 使用验证码重置密码
+// @Summary 重置密码
+// @Description 重置成功后所有设备需要重新登录
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "重置信息"
+// @Success 200 {object} utils.Response
+// @Router /auth/password/reset [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.Platform = getClientPlatform(c)
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	if err := h.passwordResetService.ResetPassword(c.Request.Context(), &req); err != nil {
+		if errors.Is(err, services.ErrResetInvalid) || errors.Is(err, auth.ErrCodeTooManyTries) ||
+			errors.Is(err, password.ErrWeakPassword) {
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+			return
+		}
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.SuccessWithMessage(c, "Password reset successfully", nil)
+}
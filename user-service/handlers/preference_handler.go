@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+)
+
+type PreferenceHandler struct {
+	preferenceService services.PreferenceServiceInterface
+}
+
+func NewPreferenceHandler(preferenceService services.PreferenceServiceInterface) *PreferenceHandler {
+	return &PreferenceHandler{
+		preferenceService: preferenceService,
+	}
+}
+
+// Get 阅读偏好
+// @Summary 阅读偏好
+// @Description 账号级设置、指定设备的覆盖项和合并后的生效设置，登录响应中也会返回本设备的偏好
+// @Tags 阅读偏好
+// @Produce json
+// @Security ApiKeyAuth
+// @Param device_id query string false "设备 ID"
+// @Success 200 {object} utils.Response{data=models.PreferencesResponse}
+// @Router /user/preferences [get]
+func (h *PreferenceHandler) Get(c *gin.Context) {
+	var query models.PreferencesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	prefs, err := h.preferenceService.Get(c.GetString("user_id"), query.DeviceID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, prefs)
+}
+
+// Update 修改阅读偏好
+// @Summary 修改阅读偏好
+// @Description 只提交修改的项；带 device_id 时修改该设备的覆盖项。服务端已有更晚写入的项不会被覆盖，列在 ignored 中
+// @Tags 阅读偏好
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.UpdatePreferencesRequest true "修改的设置"
+// @Success 200 {object} utils.Response{data=models.PreferencesResponse}
+// @Router /user/preferences [put]
+func (h *PreferenceHandler) Update(c *gin.Context) {
+	var req models.UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	prefs, err := h.preferenceService.Update(c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, prefs)
+}
+
+func (h *PreferenceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrPreferencesSchemaUnsupported):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
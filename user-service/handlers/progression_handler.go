@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+)
+
+type ProgressionHandler struct {
+	progressionService services.ProgressionServiceInterface
+}
+
+func NewProgressionHandler(progressionService services.ProgressionServiceInterface) *ProgressionHandler {
+	return &ProgressionHandler{
+		progressionService: progressionService,
+	}
+}
+
+// CheckIn 每日签到
+// @Summary 每日签到
+// @Description 每天一次，获得签到经验
+// @Tags 等级
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.AwardExperienceResponse}
+// @Router /user/checkin [post]
+func (h *ProgressionHandler) CheckIn(c *gin.Context) {
+	resp, err := h.progressionService.CheckIn(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Progress 等级进度
+// @Summary 等级进度
+// @Description 当前等级、距下一级所需经验、已解锁特权和今日各事件经验（含每日上限）
+// @Tags 等级
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.LevelProgress}
+// @Router /user/level [get]
+func (h *ProgressionHandler) Progress(c *gin.Context) {
+	progress, err := h.progressionService.Progress(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, progress)
+}
+
+// Levels 等级列表
+// @Summary 等级列表
+// @Tags 等级
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]models.LevelInfo}
+// @Router /user/levels [get]
+func (h *ProgressionHandler) Levels(c *gin.Context) {
+	utils.Success(c, h.progressionService.Levels())
+}
+
+// AwardExperience 记经验（内部接口）
+// @Summary 记经验
+// @Description 阅读、评论等服务在事件发生后调用，event_id 为幂等键，重复提交只计一次
+// @Tags 内部接口
+// @Accept json
+// @Produce json
+// @Param request body models.AwardExperienceRequest true "用户、事件和幂等键"
+// @Success 200 {object} utils.Response{data=models.AwardExperienceResponse}
+// @Router /internal/user/experience [post]
+func (h *ProgressionHandler) AwardExperience(c *gin.Context) {
+	var req models.AwardExperienceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	resp, err := h.progressionService.Award(&req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+func (h *ProgressionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrUnknownXPEvent), errors.Is(err, services.ErrAlreadyCheckedIn):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/services"
+)
+
+type ReferralHandler struct {
+	referralService services.ReferralServiceInterface
+}
+
+func NewReferralHandler(referralService services.ReferralServiceInterface) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+	}
+}
+
+// Summary 我的邀请
+// @Summary 我的邀请
+// @Description 邀请码（首次查看时生成）、邀请人数和获得的积分、阅读币
+// @Tags 邀请
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.ReferralSummary}
+// @Router /user/referral [get]
+func (h *ReferralHandler) Summary(c *gin.Context) {
+	summary, err := h.referralService.Summary(c.GetString("user_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, summary)
+}
+
+// Invitees 我邀请的用户
+// @Summary 我邀请的用户
+// @Description 按注册时间倒序，含邀请是否有效和因每个人获得的奖励
+// @Tags 邀请
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.InviteeItem}
+// @Router /user/referral/invitees [get]
+func (h *ReferralHandler) Invitees(c *gin.Context) {
+	page, size := pageParams(c)
+	items, total, err := h.referralService.Invitees(c.GetString("user_id"), page, size)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.PageSuccess(c, items, total, page, size)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/sender"
+)
+
+type SMSLoginHandler struct {
+	smsLoginService services.SMSLoginServiceInterface
+	outbox          *sender.FakeSender // 仅在短信 driver 为 fake 时非空
+}
+
+func NewSMSLoginHandler(smsLoginService services.SMSLoginServiceInterface, outbox *sender.FakeSender) *SMSLoginHandler {
+	return &SMSLoginHandler{
+		smsLoginService: smsLoginService,
+		outbox:          outbox,
+	}
+}
+
+// SendCode 发送登录验证码
+// @Summary 发送短信登录验证码
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body models.SendSMSCodeRequest true "手机号"
+// @Success 200 {object} utils.Response{data=models.SendCodeResponse}
+// @Router /auth/sms/send [post]
+func (h *SMSLoginHandler) SendCode(c *gin.Context) {
+	var req models.SendSMSCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.IPAddress = c.ClientIP()
+
+	resp, err := h.smsLoginService.SendLoginCode(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPhone) {
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+			return
+		}
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Login 短信验证码登录
+// @Summary 短信验证码登录
+// @Description 手机号未注册时自动创建账号
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body models.SMSLoginRequest true "手机号和验证码"
+// @Success 200 {object} utils.Response{data=models.LoginResponse}
+// @Router /auth/sms/login [post]
+func (h *SMSLoginHandler) Login(c *gin.Context) {
+	var req models.SMSLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
+	resp, err := h.smsLoginService.Login(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPhone):
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		case errors.Is(err, auth.ErrCodeInvalid),
+			errors.Is(err, auth.ErrCodeExpired),
+			errors.Is(err, auth.ErrCodeTooManyTries):
+			utils.Error(c, utils.ERROR_UNAUTHORIZED, err.Error())
+		default:
+			utils.Error(c, utils.ERROR, err.Error())
+		}
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Outbox 查看假短信网关最近发给某个手机号的短信（内部接口，仅测试环境可用）
+// @Summary 测试短信收件箱
+// @Tags 内部接口
+// @Produce json
+// @Param phone path string true "手机号"
+// @Success 200 {object} utils.Response{data=sender.Message}
+// @Router /internal/user/sms-outbox/{phone} [get]
+func (h *SMSLoginHandler) Outbox(c *gin.Context) {
+	if h.outbox == nil {
+		utils.Error(c, utils.ERROR_NOT_FOUND, "fake sms gateway is not enabled")
+		return
+	}
+	msg, ok := h.outbox.Last(c.Param("phone"))
+	if !ok {
+		utils.Error(c, utils.ERROR_NOT_FOUND, "no message")
+		return
+	}
+	utils.Success(c, msg)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+)
+
+type SocialHandler struct {
+	socialService services.SocialServiceInterface
+}
+
+func NewSocialHandler(socialService services.SocialServiceInterface) *SocialHandler {
+	return &SocialHandler{
+		socialService: socialService,
+	}
+}
+
+// PublicProfile 公开主页
+// @Summary 公开主页
+// @Description 他人可见的资料、关注数和粉丝数，按对方的隐私设置隐藏部分字段；登录后返回关注状态
+// @Tags 社交
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response{data=models.PublicProfile}
+// @Router /users/{id} [get]
+func (h *SocialHandler) PublicProfile(c *gin.Context) {
+	profile, err := h.socialService.PublicProfile(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, profile)
+}
+
+// Follow 关注
+// @Summary 关注用户
+// @Tags 社交
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /users/{id}/follow [post]
+func (h *SocialHandler) Follow(c *gin.Context) {
+	if err := h.socialService.Follow(c.GetString("user_id"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Followed", nil)
+}
+
+// Unfollow 取消关注
+// @Summary 取消关注
+// @Tags 社交
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /users/{id}/follow [delete]
+func (h *SocialHandler) Unfollow(c *gin.Context) {
+	if err := h.socialService.Unfollow(c.GetString("user_id"), c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Unfollowed", nil)
+}
+
+// Followers 粉丝列表
+// @Summary 粉丝列表
+// @Tags 社交
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.FollowUserItem}
+// @Router /users/{id}/followers [get]
+func (h *SocialHandler) Followers(c *gin.Context) {
+	page, size := pageParams(c)
+	items, total, err := h.socialService.Followers(c.GetString("user_id"), c.Param("id"), page, size)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.PageSuccess(c, items, total, page, size)
+}
+
+// Following 关注列表
+// @Summary 关注列表
+// @Tags 社交
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.FollowUserItem}
+// @Router /users/{id}/following [get]
+func (h *SocialHandler) Following(c *gin.Context) {
+	page, size := pageParams(c)
+	items, total, err := h.socialService.Following(c.GetString("user_id"), c.Param("id"), page, size)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.PageSuccess(c, items, total, page, size)
+}
+
+// Bookshelf 公开书架
+// @Summary 公开书架
+// @Tags 社交
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param shelf_type query string false "书架类型"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse
+// @Router /users/{id}/bookshelf [get]
+func (h *SocialHandler) Bookshelf(c *gin.Context) {
+	page, size := pageParams(c)
+	books, total, err := h.socialService.Bookshelf(c.Request.Context(), c.GetString("user_id"), c.Param("id"), c.Query("shelf_type"), page, size)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.PageSuccess(c, books, total, page, size)
+}
+
+// Activity 用户动态
+// @Summary 用户动态
+// @Description 该用户的书评和读完的书，按时间倒序
+// @Tags 社交
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param before query string false "上一页返回的 next_before（RFC3339）"
+// @Param limit query int false "条数，默认 20，最多 50"
+// @Success 200 {object} utils.Response{data=models.FeedResponse}
+// @Router /users/{id}/activity [get]
+func (h *SocialHandler) Activity(c *gin.Context) {
+	before, limit, ok := feedParams(c)
+	if !ok {
+		return
+	}
+	feed, err := h.socialService.Activity(c.Request.Context(), c.GetString("user_id"), c.Param("id"), before, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, feed)
+}
+
+// Feed 关注动态
+// @Summary 关注动态
+// @Description 关注的人的书评和读完的书，按时间倒序
+// @Tags 社交
+// @Produce json
+// @Security ApiKeyAuth
+// @Param before query string false "上一页返回的 next_before（RFC3339）"
+// @Param limit query int false "条数，默认 20，最多 50"
+// @Success 200 {object} utils.Response{data=models.FeedResponse}
+// @Router /user/feed [get]
+func (h *SocialHandler) Feed(c *gin.Context) {
+	before, limit, ok := feedParams(c)
+	if !ok {
+		return
+	}
+	feed, err := h.socialService.Feed(c.Request.Context(), c.GetString("user_id"), before, limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, feed)
+}
+
+// GetPrivacy 隐私设置
+// @Summary 隐私设置
+// @Description 公开主页各项资料的可见范围：public 或 private；书架和动态默认仅自己可见
+// @Tags 社交
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.UserPrivacy}
+// @Router /user/privacy [get]
+func (h *SocialHandler) GetPrivacy(c *gin.Context) {
+	privacy, err := h.socialService.GetPrivacy(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, privacy)
+}
+
+// UpdatePrivacy 修改隐私设置
+// @Summary 修改隐私设置
+// @Description 只修改传入的项
+// @Tags 社交
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.UpdatePrivacyRequest true "各项可见范围"
+// @Success 200 {object} utils.Response{data=models.UserPrivacy}
+// @Router /user/privacy [put]
+func (h *SocialHandler) UpdatePrivacy(c *gin.Context) {
+	var req models.UpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	privacy, err := h.socialService.UpdatePrivacy(c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, privacy)
+}
+
+func (h *SocialHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrProfilePrivate):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	case errors.Is(err, services.ErrFollowSelf):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
+
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if size > 100 {
+		size = 100
+	}
+	return page, size
+}
+
+// feedParams 解析动态翻页参数，格式错误时直接返回 400
+func feedParams(c *gin.Context) (*time.Time, int, bool) {
+	var query models.FeedQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return nil, 0, false
+	}
+	if query.Before == "" {
+		return nil, query.Limit, true
+	}
+	before, err := time.Parse(time.RFC3339, query.Before)
+	if err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, "invalid before, expected RFC3339 time")
+		return nil, 0, false
+	}
+	return &before, query.Limit, true
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+)
+
+type TeenModeHandler struct {
+	teenModeService services.TeenModeServiceInterface
+}
+
+func NewTeenModeHandler(teenModeService services.TeenModeServiceInterface) *TeenModeHandler {
+	return &TeenModeHandler{
+		teenModeService: teenModeService,
+	}
+}
+
+// Status 青少年模式状态
+// @Summary 青少年模式状态
+// @Description 是否开启、来源（未成年自动开启或监护人开启）、今日已读和剩余时长、宵禁时段
+// @Tags 青少年模式
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.TeenModeStatus}
+// @Router /user/teen-mode [get]
+func (h *TeenModeHandler) Status(c *gin.Context) {
+	status, err := h.teenModeService.Status(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, status)
+}
+
+// Enable 开启青少年模式
+// @Summary 开启青少年模式
+// @Description 监护人设置 4-6 位数字监护密码并开启；未成年用户已自动开启，可用此接口设置监护密码和限制
+// @Tags 青少年模式
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.EnableTeenModeRequest true "监护密码和限制"
+// @Success 200 {object} utils.Response{data=models.TeenModeStatus}
+// @Router /user/teen-mode/enable [post]
+func (h *TeenModeHandler) Enable(c *gin.Context) {
+	var req models.EnableTeenModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	status, err := h.teenModeService.Enable(c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, status)
+}
+
+// Update 修改青少年模式设置
+// @Summary 修改青少年模式设置
+// @Description 修改每日阅读时长、宵禁时段或监护密码，需要当前监护密码
+// @Tags 青少年模式
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.UpdateTeenModeRequest true "监护密码和新设置"
+// @Success 200 {object} utils.Response{data=models.TeenModeStatus}
+// @Router /user/teen-mode [put]
+func (h *TeenModeHandler) Update(c *gin.Context) {
+	var req models.UpdateTeenModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	status, err := h.teenModeService.Update(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, status)
+}
+
+// Disable 关闭青少年模式
+// @Summary 关闭青少年模式
+// @Description 需要监护密码，未成年用户不能关闭
+// @Tags 青少年模式
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.TeenModePinRequest true "监护密码"
+// @Success 200 {object} utils.Response
+// @Router /user/teen-mode/disable [post]
+func (h *TeenModeHandler) Disable(c *gin.Context) {
+	var req models.TeenModePinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.teenModeService.Disable(c.Request.Context(), c.GetString("user_id"), &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Teen mode disabled", nil)
+}
+
+// GetUserStatus 查询用户的青少年模式限制（内部接口）
+// @Summary 查询青少年模式限制
+// @Description 支付服务购买前、内容服务过滤成人向作品前调用
+// @Tags 内部接口
+// @Produce json
+// @Param user_id path string true "用户 ID"
+// @Success 200 {object} utils.Response{data=models.TeenModeStatus}
+// @Router /internal/user/users/{user_id}/teen-mode [get]
+func (h *TeenModeHandler) GetUserStatus(c *gin.Context) {
+	status, err := h.teenModeService.Status(c.Request.Context(), c.Param("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, status)
+}
+
+// RecordReading 上报阅读时长（内部接口）
+// @Summary 上报阅读时长
+// @Description 阅读服务更新进度时调用，返回是否还能继续阅读
+// @Tags 内部接口
+// @Accept json
+// @Produce json
+// @Param request body models.RecordTeenReadingRequest true "用户和本次阅读秒数"
+// @Success 200 {object} utils.Response{data=models.TeenModeStatus}
+// @Router /internal/user/teen-mode/reading [post]
+func (h *TeenModeHandler) RecordReading(c *gin.Context) {
+	var req models.RecordTeenReadingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	status, err := h.teenModeService.RecordReading(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, status)
+}
+
+func (h *TeenModeHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+	case errors.Is(err, services.ErrTeenPinInvalid), errors.Is(err, services.ErrTeenPinLocked),
+		errors.Is(err, services.ErrTeenModeRequired):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	case errors.Is(err, services.ErrTeenModeActive), errors.Is(err, services.ErrTeenModeInactive),
+		errors.Is(err, services.ErrTeenPinNotSet), errors.Is(err, services.ErrTeenLimitTooHigh):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
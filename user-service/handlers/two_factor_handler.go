@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	authServices "reading-microservices/user-service/services/auth"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorServiceInterface
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorServiceInterface) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Status 两步验证状态
+// @Summary 两步验证状态
+// @Tags 两步验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.TwoFactorStatus}
+// @Router /user/2fa [get]
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	status, err := h.twoFactorService.Status(c.GetString("user_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+	utils.Success(c, status)
+}
+
+// Setup 生成 TOTP 密钥
+// @Summary 设置两步验证
+// @Description 返回密钥和 otpauth 地址，用验证器 App 扫码后调用 enable 确认
+// @Tags 两步验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.TwoFactorSetupResponse}
+// @Router /user/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	resp, err := h.twoFactorService.Setup(c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Enable 确认动态码并开启两步验证
+// @Summary 开启两步验证
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.ConfirmCodeRequest true "动态码"
+// @Success 200 {object} utils.Response{data=models.RecoveryCodesResponse}
+// @Router /user/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	var req models.ConfirmCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	resp, err := h.twoFactorService.Enable(c.Request.Context(), c.GetString("user_id"), req.Code)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// Disable 关闭两步验证
+// @Summary 关闭两步验证
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.ConfirmCodeRequest true "动态码或恢复码"
+// @Success 200 {object} utils.Response
+// @Router /user/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	var req models.ConfirmCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), c.GetString("user_id"), req.Code); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Tags 两步验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.ConfirmCodeRequest true "动态码"
+// @Success 200 {object} utils.Response{data=models.RecoveryCodesResponse}
+// @Router /user/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.ConfirmCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	resp, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("user_id"), req.Code)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// VerifyLogin 登录第二步
+// @Summary 两步验证登录
+// @Description 密码、短信或第三方登录返回 two_factor_required 时，用挑战 token 和动态码完成登录
+// @Tags 用户认证
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "挑战 token 和动态码"
+// @Success 200 {object} utils.Response{data=models.LoginResponse}
+// @Router /auth/2fa/verify [post]
+func (h *TwoFactorHandler) VerifyLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+	req.IPAddress = c.ClientIP()
+
+	resp, err := h.twoFactorService.VerifyLogin(c.Request.Context(), &req)
+	if err != nil {
+		var loginErr *authServices.LoginError
+		if errors.As(err, &loginErr) {
+			respondLoginError(c, loginErr)
+			return
+		}
+		utils.Error(c, utils.ERROR_UNAUTHORIZED, err.Error())
+		return
+	}
+	utils.Success(c, resp)
+}
+
+func (h *TwoFactorHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
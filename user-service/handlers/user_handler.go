@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"reading-microservices/shared/utils"
 	"reading-microservices/user-service/models"
 	"reading-microservices/user-service/services"
+	authServices "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/password"
 )
 
 type UserHandler struct {
@@ -38,8 +42,15 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	response, err := h.userService.Register(&req)
 	if err != nil {
+		if errors.Is(err, password.ErrWeakPassword) || errors.Is(err, services.ErrReferralCodeInvalid) {
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+			return
+		}
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -66,11 +77,18 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 获取客户端IP
+	// 获取客户端平台、IP 和 UA
 	req.Platform = getClientPlatform(c)
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
 
 	response, err := h.userService.Login(&req)
 	if err != nil {
+		var loginErr *authServices.LoginError
+		if errors.As(err, &loginErr) {
+			respondLoginError(c, loginErr)
+			return
+		}
 		utils.Error(c, utils.ERROR_UNAUTHORIZED, err.Error())
 		return
 	}
@@ -167,6 +185,10 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.userService.ChangePassword(userID, &req); err != nil {
+		if errors.Is(err, password.ErrWeakPassword) {
+			utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+			return
+		}
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -176,7 +198,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 
 // RefreshToken 刷新token
 // @Summary 刷新Token
-// @Description 使用旧Token刷新获取新Token
+// @Description 使用 refresh token 换取新的 access token 和 refresh token，旧 refresh token 随即失效
 // @Tags 用户认证
 // @Accept json
 // @Produce json
@@ -192,7 +214,7 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	newToken, err := h.userService.RefreshToken(token)
+	newToken, err := h.userService.RefreshToken(token, c.ClientIP(), c.Request.UserAgent())
 	if err != nil {
 		utils.Error(c, utils.ERROR_UNAUTHORIZED, err.Error())
 		return
@@ -230,6 +252,110 @@ func (h *UserHandler) Logout(c *gin.Context) {
 	utils.SuccessWithMessage(c, "Logout successfully", nil)
 }
 
+// LoginHistory 登录记录
+// @Summary 登录记录
+// @Description 最近的登录、失败和安全事件，含登录地点和新设备/新地区标记
+// @Tags 用户信息
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} utils.PageResponse{data=[]models.LoginHistoryItem}
+// @Router /user/login-history [get]
+func (h *UserHandler) LoginHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "20"))
+	if size > 100 {
+		size = 100
+	}
+
+	items, total, err := h.userService.LoginHistory(userID, page, size)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.PageSuccess(c, items, total, page, size)
+}
+
+// ListDevices 在线设备列表
+// @Summary 在线设备列表
+// @Description 当前账号已登录的设备
+// @Tags 设备管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=[]models.DeviceInfo}
+// @Failure 401 {object} utils.Response
+// @Router /user/devices [get]
+func (h *UserHandler) ListDevices(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	devices, err := h.userService.ListDevices(userID, extractTokenFromHeader(c))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, devices)
+}
+
+// RevokeDevice 下线指定设备
+// @Summary 下线指定设备
+// @Tags 设备管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Param device_id path string true "设备ID"
+// @Success 200 {object} utils.Response
+// @Failure 404 {object} utils.Response
+// @Router /user/devices/{device_id} [delete]
+func (h *UserHandler) RevokeDevice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	if err := h.userService.RevokeDevice(userID, c.Param("device_id")); err != nil {
+		utils.Error(c, utils.ERROR_NOT_FOUND, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Device revoked successfully", nil)
+}
+
+// RevokeOtherDevices 下线其他设备
+// @Summary 下线其他设备
+// @Description 保留当前设备，其余设备全部下线
+// @Tags 设备管理
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response
+// @Router /user/devices/revoke-others [post]
+func (h *UserHandler) RevokeOtherDevices(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorWithCode(c, utils.ERROR_UNAUTHORIZED)
+		return
+	}
+
+	if err := h.userService.RevokeOtherDevices(userID, extractTokenFromHeader(c)); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Other devices revoked successfully", nil)
+}
+
 // ValidateToken 验证token
 // @Summary 验证Token
 // @Description 验证Token的有效性
@@ -276,6 +402,87 @@ func (h *UserHandler) Health(c *gin.Context) {
 	})
 }
 
+// UnlockLogin 解除登录锁定（内部接口）
+// @Summary 解除登录锁定
+// @Tags 内部接口
+// @Accept json
+// @Produce json
+// @Param request body models.UnlockLoginRequest true "用户名"
+// @Success 200 {object} utils.Response
+// @Router /internal/user/login-unlock [post]
+func (h *UserHandler) UnlockLogin(c *gin.Context) {
+	var req models.UnlockLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.userService.UnlockLogin(req.Username); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Login unlocked successfully", nil)
+}
+
+// InvalidateUserCache 清除用户缓存（内部接口）
+// @Summary 清除用户缓存
+// @Description 其他服务修改余额、VIP 等用户字段后调用，下次查询重新读库
+// @Tags 内部接口
+// @Produce json
+// @Param user_id path string true "用户ID"
+// @Success 200 {object} utils.Response
+// @Router /internal/user/users/{user_id}/cache [delete]
+func (h *UserHandler) InvalidateUserCache(c *gin.Context) {
+	if err := h.userService.InvalidateUserCache(c.Param("user_id")); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "User cache invalidated", nil)
+}
+
+// BatchGetUsers 批量查询用户（内部接口）
+// @Summary 批量查询用户
+// @Description 评论、排行榜等列表展示用，一次最多 500 个 ID，经过用户缓存；不存在或已注销的用户不返回
+// @Tags 内部接口
+// @Accept json
+// @Produce json
+// @Param request body models.BatchUsersRequest true "用户ID列表"
+// @Success 200 {object} utils.Response{data=[]models.UserPublicInfo}
+// @Router /internal/user/users/batch [post]
+func (h *UserHandler) BatchGetUsers(c *gin.Context) {
+	var req models.BatchUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	users, err := h.userService.BatchGetUsers(req.IDs)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, users)
+}
+
+// respondLoginError 登录失败时带上验证码、锁定和重试信息
+func respondLoginError(c *gin.Context, loginErr *authServices.LoginError) {
+	if loginErr.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(loginErr.RetryAfter))
+	}
+	c.JSON(http.StatusOK, utils.Response{
+		Code:    utils.ERROR_UNAUTHORIZED,
+		Message: loginErr.Message,
+		Data: gin.H{
+			"captcha_required": loginErr.CaptchaRequired,
+			"locked":           loginErr.Locked,
+			"retry_after":      loginErr.RetryAfter,
+		},
+	})
+}
+
 // extractTokenFromHeader 从请求头中提取token
 func extractTokenFromHeader(c *gin.Context) string {
 	token := c.GetHeader("Authorization")
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/services"
+	auth "reading-microservices/user-service/services/auth"
+)
+
+type VerificationHandler struct {
+	verificationService services.VerificationServiceInterface
+}
+
+func NewVerificationHandler(verificationService services.VerificationServiceInterface) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+// SendEmailCode 发送邮箱验证码
+// @Summary 发送邮箱验证码
+// @Tags 账号验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.SendCodeResponse}
+// @Router /user/verify/email/send [post]
+func (h *VerificationHandler) SendEmailCode(c *gin.Context) {
+	resp, err := h.verificationService.SendEmailCode(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// ConfirmEmail 确认邮箱验证码
+// @Summary 确认邮箱验证码
+// @Tags 账号验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.ConfirmCodeRequest true "验证码"
+// @Success 200 {object} utils.Response
+// @Router /user/verify/email/confirm [post]
+func (h *VerificationHandler) ConfirmEmail(c *gin.Context) {
+	var req models.ConfirmCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.verificationService.ConfirmEmail(c.Request.Context(), c.GetString("user_id"), req.Code); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Email verified successfully", nil)
+}
+
+// SendPhoneCode 发送手机验证码
+// @Summary 发送手机验证码
+// @Tags 账号验证
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} utils.Response{data=models.SendCodeResponse}
+// @Router /user/verify/phone/send [post]
+func (h *VerificationHandler) SendPhoneCode(c *gin.Context) {
+	resp, err := h.verificationService.SendPhoneCode(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// ConfirmPhone 确认手机验证码
+// @Summary 确认手机验证码
+// @Tags 账号验证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.ConfirmCodeRequest true "验证码"
+// @Success 200 {object} utils.Response
+// @Router /user/verify/phone/confirm [post]
+func (h *VerificationHandler) ConfirmPhone(c *gin.Context) {
+	var req models.ConfirmCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+		return
+	}
+
+	if err := h.verificationService.ConfirmPhone(c.Request.Context(), c.GetString("user_id"), req.Code); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	utils.SuccessWithMessage(c, "Phone verified successfully", nil)
+}
+
+func (h *VerificationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, auth.ErrCodeInvalid),
+		errors.Is(err, auth.ErrCodeExpired),
+		errors.Is(err, auth.ErrCodeTooManyTries):
+		utils.Error(c, utils.ERROR_INVALID_PARAMS, err.Error())
+	default:
+		utils.Error(c, utils.ERROR, err.Error())
+	}
+}
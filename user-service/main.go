@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -10,18 +11,35 @@ import (
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"log"
-	"reading-microservices/shared/config"
 	"reading-microservices/shared/middleware"
+	"reading-microservices/shared/openapi"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/cache"
+	"reading-microservices/user-service/config"
 	"reading-microservices/user-service/handlers"
 	middleware2 "reading-microservices/user-service/middleware"
 	"reading-microservices/user-service/models"
 	"reading-microservices/user-service/repositories"
 	"reading-microservices/user-service/services"
+	"reading-microservices/user-service/services/accountdata"
 	authServices "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/geoip"
+	"reading-microservices/user-service/services/notifier"
+	"reading-microservices/user-service/services/oauth"
+	"reading-microservices/user-service/services/password"
+	"reading-microservices/user-service/services/referral"
+	"reading-microservices/user-service/services/sender"
+	"reading-microservices/user-service/services/social"
+	"reading-microservices/user-service/services/storage"
 	"time"
 )
 
 func main() {
+	// 初始化第一个管理员：user-service -bootstrap-admin <用户名>，设置后退出，不启动服务。
+	// 角色只能通过命令行或已有管理员调整，不提供网络接口
+	bootstrapAdmin := flag.String("bootstrap-admin", "", "grant the admin role to this username and exit")
+	flag.Parse()
+
 	// 加载配置
 	cfg, err := config.LoadConfig(".")
 	if err != nil {
@@ -44,48 +62,280 @@ func main() {
 		log.Fatal("Failed to connect redis:", err)
 	}
 
+	// 取消“仅粉丝可见”之前先把已有的该设置改为仅自己可见，否则收窄枚举时迁移失败
+	if err := migratePrivacyFollowers(db); err != nil {
+		log.Fatal("Failed to migrate privacy settings:", err)
+	}
+
 	// 自动迁移
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.ThirdPartyAccount{},
 		&models.UserSession{},
 		&models.LoginLog{},
+		&models.UserTwoFactor{},
+		&models.UserRecoveryCode{},
+		&models.DataExportJob{},
+		&models.AccountDeletionRequest{},
+		&models.AccountMerge{},
+		&models.AdminActionLog{},
+		&models.JWTSigningKey{},
+		&models.ExperienceLog{},
+		&models.UserFollow{},
+		&models.UserPrivacy{},
+		&models.TeenMode{},
+		&models.ReferralCode{},
+		&models.Referral{},
+		&models.ReferralReward{},
+		&models.UserPreference{},
 	); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	// 初始化Repository
-	userRepo := repositories.NewUserRepository(db)
+	// 初始化Repository，用户查询经过进程内 LRU 和 Redis 两级缓存
+	userCache := cache.NewRedisUserCache(rdb)
+	userRepo := repositories.NewCachedUserRepository(
+		repositories.NewUserRepository(db),
+		userCache,
+		cache.NewLocalUserCache(cfg.UserCache.LocalSize, time.Duration(cfg.UserCache.LocalTTL)*time.Second),
+		time.Duration(cfg.UserCache.TTL)*time.Second,
+	)
+	go userRepo.ListenInvalidations(context.Background())
+
+	// 配置双 token 的过期时间
+	accessExpiresIn := 7200    // 例如：7200 (2小时)
+	refreshExpiresIn := 604800 // 例如：604800 (7天)
+
+	// 第三方 token 和 TOTP 密钥使用同一把加密密钥
+	tokenCipher, err := oauth.NewTokenCipher(cfg.OAuth.TokenEncryptionKey)
+	if err != nil {
+		log.Fatal("Failed to init oauth token cipher:", err)
+	}
 
-	// 初始化 AuthManager
-	// 初始化 AuthManager（不再需要默认过期时间，因为我们会分别设置）
-	authManager := authServices.NewAuthManager(cfg.JWT.Secret)
+	// 签名私钥使用专用的加密密钥，没有默认值
+	if cfg.Signing.KeyEncryptionKey == "" {
+		log.Fatal("Missing signing key encryption key, set SIGNING_KEY_ENCRYPTION_KEY")
+	}
+	signingCipher, err := oauth.NewTokenCipher(cfg.Signing.KeyEncryptionKey)
+	if err != nil {
+		log.Fatal("Failed to init signing key cipher:", err)
+	}
+
+	// 初始化签名密钥，旧公钥的发布时长不能短于 refresh token 有效期
+	if cfg.Signing.Overlap < refreshExpiresIn {
+		log.Printf("warning: signing overlap %ds is shorter than refresh token lifetime, using %ds", cfg.Signing.Overlap, refreshExpiresIn)
+		cfg.Signing.Overlap = refreshExpiresIn
+	}
+	keyManager := authServices.NewKeyManager(userRepo, signingCipher, tokenCipher, cfg.Signing)
+	if err := keyManager.Init(); err != nil {
+		log.Fatal("Failed to init signing keys:", err)
+	}
+	go keyManager.Run(context.Background())
+
+	// 初始化密码哈希和密码策略
+	passwordHasher, err := password.NewHasher(cfg.Password.Hash)
+	if err != nil {
+		log.Fatal("Failed to init password hasher:", err)
+	}
+	passwordPolicy, err := password.NewPolicy(cfg.Password)
+	if err != nil {
+		log.Fatal("Failed to load password policy:", err)
+	}
+
+	// 初始化 AuthManager，secret 只用于验证切换前签发的 token
+	var legacyCutover time.Time
+	if cfg.Signing.LegacyCutover != "" {
+		legacyCutover, err = time.Parse(time.RFC3339, cfg.Signing.LegacyCutover)
+		if err != nil {
+			log.Fatal("Invalid signing legacy cutover:", err)
+		}
+	}
+	authManager := authServices.NewAuthManager(keyManager, cfg.JWT.Secret, legacyCutover, time.Duration(refreshExpiresIn)*time.Second, passwordHasher, passwordPolicy)
 
 	// 初始化 SessionManager
 	sessionManager := authServices.NewSessionManager(userRepo, rdb)
 
-	// 初始化 LoginLogger
-	loginLogger := authServices.NewLoginLogger(userRepo)
+	// 初始化 LoginLogger（登录地点解析 + 新设备/新地区提醒）
+	securityNotifier, err := notifier.NewSecurityNotifier(cfg.LoginAlert.Notifier, cfg.LoginAlert.NotificationURL, cfg.Internal.Token)
+	if err != nil {
+		log.Fatal("Failed to init security notifier:", err)
+	}
+	locator := geoip.NewLocator(cfg.LoginAlert.GeoIPDatabase, cfg.LoginAlert.GeoIPLanguage)
+	loginLogger := authServices.NewLoginLogger(userRepo, locator, securityNotifier)
+
+	// 初始化登录防护（失败计数存 Redis）
+	loginGuard := authServices.NewLoginGuard(userCache, cfg.LoginGuard)
 
-	// 配置双 token 的过期时间
-	accessExpiresIn := 7200    // 例如：7200 (2小时)
-	refreshExpiresIn := 604800 // 例如：604800 (7天)
+	// 初始化邀请注册，奖励通过支付服务发放
+	referralService := services.NewReferralService(
+		userRepo,
+		referral.NewPaymentClient(cfg.Referral.PaymentServiceURL, cfg.Internal.Token, time.Duration(cfg.Referral.RequestTimeout)*time.Second),
+		cfg.Referral,
+	)
+
+	// 初始化阅读偏好，登录时随响应返回
+	preferenceService := services.NewPreferenceService(userRepo)
 
 	// 初始化 UserService，传入双 token 的过期时间配置
-	var userService services.UserServiceInterface
-	userService = services.NewUserService(
+	userService := services.NewUserService(
 		userRepo,
 		authManager,
 		sessionManager,
 		loginLogger,
+		loginGuard,
 		accessExpiresIn,
 		refreshExpiresIn,
+		cfg.Session.MaxDevices,
+		referralService,
+		preferenceService,
+	)
+
+	// 初始化第三方登录
+	oauthRegistry, err := oauth.NewRegistry(cfg.OAuth)
+	if err != nil {
+		log.Fatal("Failed to init oauth providers:", err)
+	}
+	oauthService := services.NewOAuthService(
+		userRepo,
+		userService,
+		oauthRegistry,
+		oauth.NewStateStore(rdb, time.Duration(cfg.OAuth.StateTTL)*time.Second),
+		tokenCipher,
+	)
+
+	// 初始化邮箱和手机验证
+	emailSender, err := sender.NewEmailSender(cfg.Verification.Email)
+	if err != nil {
+		log.Fatal("Failed to init email sender:", err)
+	}
+	smsSender, err := sender.NewSMSSender(cfg.Verification.SMS)
+	if err != nil {
+		log.Fatal("Failed to init sms sender:", err)
+	}
+	codeManager := authServices.NewCodeManager(rdb, cfg.Verification)
+	verificationService := services.NewVerificationService(
+		userRepo,
+		codeManager,
+		emailSender,
+		smsSender,
+		cfg.Verification,
+		referralService,
+	)
+	passwordResetService := services.NewPasswordResetService(
+		userRepo,
+		authManager,
+		sessionManager,
+		loginLogger,
+		loginGuard,
+		codeManager,
+		emailSender,
+		smsSender,
+		cfg.Verification,
+	)
+
+	smsLoginService := services.NewSMSLoginService(userRepo, userService, codeManager, smsSender, cfg.Verification)
+	fakeSMS, _ := smsSender.(*sender.FakeSender)
+
+	// 初始化两步验证，TOTP 密钥与第三方 token 使用同一把加密密钥
+	// 管理员和运营账号必须开启两步验证
+	twoFactorPolicy := authServices.NewTwoFactorPolicy(cfg.TwoFactor)
+	twoFactorPolicy.AddRule(func(user *models.User) bool {
+		return user.Role == "admin" || user.Role == "moderator"
+	})
+	twoFactorService := services.NewTwoFactorService(
+		userRepo,
+		userService,
+		authServices.NewTwoFactorStore(rdb, cfg.TwoFactor),
+		twoFactorPolicy,
+		tokenCipher,
+		cfg.TwoFactor,
+	)
+	userService.SetTwoFactor(twoFactorService)
+
+	// 初始化头像上传，文件存本地目录或兼容 S3 的对象存储
+	avatarStorage, err := storage.NewStorage(cfg.Avatar.Storage)
+	if err != nil {
+		log.Fatal("Failed to init avatar storage:", err)
+	}
+	avatarService := services.NewAvatarService(userRepo, avatarStorage, cfg.Avatar)
+
+	// 初始化个人数据导出与账号注销，后台定期执行到期的注销申请
+	dataClients := accountdata.NewServiceClients(cfg.AccountData.Services, cfg.Internal.Token, time.Duration(cfg.AccountData.RequestTimeout)*time.Second)
+	accountDataService := services.NewAccountDataService(userRepo, authManager, sessionManager, avatarService, dataClients, cfg.AccountData)
+	go accountDataService.Run(context.Background())
+
+	// 初始化账号合并，和数据导出、注销共用各服务的内部接口地址
+	accountMergeService := services.NewAccountMergeService(
+		userRepo,
+		authManager,
+		sessionManager,
+		loginGuard,
+		codeManager,
+		emailSender,
+		smsSender,
+		avatarService,
+		dataClients,
+		cfg.Verification,
 	)
+
+	// 初始化管理后台
+	adminService := services.NewAdminService(userRepo, userService, authManager, sessionManager, loginGuard)
+	if *bootstrapAdmin != "" {
+		if err := adminService.SetRoleByUsername(*bootstrapAdmin, "admin"); err != nil {
+			log.Fatal("Failed to bootstrap admin:", err)
+		}
+		log.Printf("granted admin role to %s", *bootstrapAdmin)
+		return
+	}
+
+	// 初始化经验与等级
+	progressionService := services.NewProgressionService(userRepo, cfg.Progression, referralService)
+
+	// 初始化关注与公开主页，书架和动态来自阅读服务
+	socialService := services.NewSocialService(
+		userRepo,
+		social.NewReadingClient(cfg.Social.ReadingServiceURL, cfg.Internal.Token, time.Duration(cfg.Social.RequestTimeout)*time.Second),
+		cfg.Social,
+	)
+
+	// 初始化青少年模式，阅读时长计数存 Redis
+	teenModeService := services.NewTeenModeService(userRepo, userCache, authManager, cfg.TeenMode)
+	userService.SetTeenMode(teenModeService)
+
 	// 初始化 Handler
-	userHandler := handlers.NewUserHandler(userService)
+	handlerSet := routeHandlers{
+		user:          handlers.NewUserHandler(userService),
+		oauth:         handlers.NewOAuthHandler(oauthService),
+		verification:  handlers.NewVerificationHandler(verificationService),
+		passwordReset: handlers.NewPasswordResetHandler(passwordResetService),
+		smsLogin:      handlers.NewSMSLoginHandler(smsLoginService, fakeSMS),
+		twoFactor:     handlers.NewTwoFactorHandler(twoFactorService),
+		accountData:   handlers.NewAccountDataHandler(accountDataService),
+		accountMerge:  handlers.NewAccountMergeHandler(accountMergeService),
+		admin:         handlers.NewAdminHandler(adminService),
+		progression:   handlers.NewProgressionHandler(progressionService),
+		social:        handlers.NewSocialHandler(socialService),
+		avatar:        handlers.NewAvatarHandler(avatarService),
+		teenMode:      handlers.NewTeenModeHandler(teenModeService),
+		referral:      handlers.NewReferralHandler(referralService),
+		preference:    handlers.NewPreferenceHandler(preferenceService),
+		jwks:          handlers.NewJWKSHandler(keyManager),
+	}
 
 	// 初始化路由
-	router := setupRouter(userHandler, cfg.JWT.Secret, rdb)
+	router := setupRouter(handlerSet, adminService.Role, authManager, rdb, cfg.Internal.Token)
+	// 登录锁定、验证码限流和邀请同 IP 检查依赖客户端 IP，只信任网关转发的 X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
+	}
+
+	// 本地存储时由本服务提供上传的文件，文件名带内容哈希，可长期缓存
+	if cfg.Avatar.Storage.Driver == "" || cfg.Avatar.Storage.Driver == "local" {
+		files := router.Group("/files", func(c *gin.Context) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		})
+		files.Static("/", cfg.Avatar.Storage.Local.Dir)
+	}
 
 	// 启动服务器
 	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -126,6 +376,22 @@ func initDB(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// migratePrivacyFollowers 把已保存的 followers 设置改为 private
+func migratePrivacyFollowers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.UserPrivacy{}) {
+		return nil
+	}
+	for _, column := range []string{"bio", "gender", "birth_date", "level", "bookshelf", "follows", "activity"} {
+		err := db.Model(&models.UserPrivacy{}).
+			Where(column+" = ?", "followers").
+			Update(column, models.VisibilityPrivate).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func initRedis(cfg *config.Config) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
@@ -146,7 +412,27 @@ func initRedis(cfg *config.Config) (*redis.Client, error) {
 	return rdb, nil
 }
 
-func setupRouter(userHandler *handlers.UserHandler, jwtSecret string, rdb *redis.Client) *gin.Engine {
+// routeHandlers setupRouter 注册路由用到的全部 handler
+type routeHandlers struct {
+	user          *handlers.UserHandler
+	oauth         *handlers.OAuthHandler
+	verification  *handlers.VerificationHandler
+	passwordReset *handlers.PasswordResetHandler
+	smsLogin      *handlers.SMSLoginHandler
+	twoFactor     *handlers.TwoFactorHandler
+	accountData   *handlers.AccountDataHandler
+	accountMerge  *handlers.AccountMergeHandler
+	admin         *handlers.AdminHandler
+	progression   *handlers.ProgressionHandler
+	social        *handlers.SocialHandler
+	avatar        *handlers.AvatarHandler
+	teenMode      *handlers.TeenModeHandler
+	referral      *handlers.ReferralHandler
+	preference    *handlers.PreferenceHandler
+	jwks          *handlers.JWKSHandler
+}
+
+func setupRouter(h routeHandlers, roleLookup middleware2.RoleLookup, verifier utils.TokenVerifier, rdb *redis.Client, internalToken string) *gin.Engine {
 	router := gin.Default()
 
 	// 中间件
@@ -157,8 +443,14 @@ func setupRouter(userHandler *handlers.UserHandler, jwtSecret string, rdb *redis
 	router.Use(gin.Recovery())
 
 	// 健康检查
-	router.GET("/health", userHandler.Health)
-	router.GET("/healthz", userHandler.Health) // 添加另一个健康检查端点
+	router.GET("/health", h.user.Health)
+	router.GET("/healthz", h.user.Health) // 添加另一个健康检查端点
+
+	// 接口文档（由网关聚合）
+	router.GET("/openapi.json", openapi.Handler(handlers.OpenAPIDocument()))
+
+	// token 验证公钥，供网关和其他服务验证 token
+	router.GET("/.well-known/jwks.json", h.jwks.JWKS)
 
 	// API 路由
 	api := router.Group("/api")
@@ -169,21 +461,140 @@ func setupRouter(userHandler *handlers.UserHandler, jwtSecret string, rdb *redis
 			auth := v1.Group("/auth")
 			auth.Use(middleware2.RateLimit(rdb, 10, time.Minute)) // 登录注册更高
 			{
-				auth.POST("/register", userHandler.Register)
-				auth.POST("/login", userHandler.Login)
-				auth.POST("/refresh", userHandler.RefreshToken)
-				auth.POST("/validate", userHandler.ValidateToken)
+				auth.POST("/register", h.user.Register)
+				auth.POST("/login", h.user.Login)
+				auth.POST("/refresh", h.user.RefreshToken)
+				auth.POST("/validate", h.user.ValidateToken)
+				auth.POST("/password/forgot", h.passwordReset.ForgotPassword)
+				auth.POST("/password/reset", h.passwordReset.ResetPassword)
+				auth.POST("/sms/send", h.smsLogin.SendCode)
+				auth.POST("/sms/login", h.smsLogin.Login)
+				auth.POST("/2fa/verify", h.twoFactor.VerifyLogin)
+
+				// 第三方登录
+				auth.GET("/oauth/providers", h.oauth.Providers)
+				auth.GET("/oauth/:provider/authorize", h.oauth.Authorize)
+				auth.POST("/oauth/:provider/login", h.oauth.Login)
 			}
 
 			// 需要认证的用户接口
 			user := v1.Group("/user")
-			user.Use(middleware.JWTAuth(jwtSecret))
+			user.Use(middleware.JWTAuth(verifier))
 			user.Use(middleware2.RateLimit(rdb, 100, time.Minute)) // 增加限制到每分钟100次
 			{
-				user.GET("/profile", userHandler.GetProfile)
-				user.PUT("/profile", userHandler.UpdateProfile)
-				user.POST("/change-password", userHandler.ChangePassword)
-				user.POST("/logout", userHandler.Logout)
+				user.GET("/profile", h.user.GetProfile)
+				user.PUT("/profile", h.user.UpdateProfile)
+				user.POST("/avatar", h.avatar.Upload)
+				user.DELETE("/avatar", h.avatar.Remove)
+				user.POST("/change-password", h.user.ChangePassword)
+				user.POST("/logout", h.user.Logout)
+
+				// 设备管理
+				user.GET("/login-history", h.user.LoginHistory)
+				user.GET("/devices", h.user.ListDevices)
+				user.DELETE("/devices/:device_id", h.user.RevokeDevice)
+				user.POST("/devices/revoke-others", h.user.RevokeOtherDevices)
+
+				// 第三方账号绑定
+				user.GET("/oauth/accounts", h.oauth.ListAccounts)
+				user.GET("/oauth/:provider/authorize", h.oauth.LinkAuthorize)
+				user.POST("/oauth/:provider/link", h.oauth.Link)
+				user.PUT("/oauth/:provider/primary", h.oauth.SetPrimary)
+				user.DELETE("/oauth/:provider", h.oauth.Unlink)
+
+				// 邮箱和手机验证
+				user.POST("/verify/email/send", h.verification.SendEmailCode)
+				user.POST("/verify/email/confirm", h.verification.ConfirmEmail)
+				user.POST("/verify/phone/send", h.verification.SendPhoneCode)
+				user.POST("/verify/phone/confirm", h.verification.ConfirmPhone)
+
+				// 两步验证
+				user.GET("/2fa", h.twoFactor.Status)
+				user.POST("/2fa/setup", h.twoFactor.Setup)
+				user.POST("/2fa/enable", h.twoFactor.Enable)
+				user.POST("/2fa/disable", h.twoFactor.Disable)
+				user.POST("/2fa/recovery-codes", h.twoFactor.RegenerateRecoveryCodes)
+
+				// 个人数据导出与账号注销
+				user.POST("/data-export", h.accountData.RequestExport)
+				user.GET("/data-export/:id", h.accountData.ExportStatus)
+				user.GET("/data-export/:id/file", h.accountData.DownloadExport)
+				user.POST("/account/deletion", h.accountData.RequestDeletion)
+				user.GET("/account/deletion", h.accountData.DeletionStatus)
+				user.DELETE("/account/deletion", h.accountData.CancelDeletion)
+				user.POST("/account/merge/preview", h.accountMerge.Preview)
+				user.POST("/account/merge/code", h.accountMerge.SendCode)
+				user.POST("/account/merge", h.accountMerge.Merge)
+
+				// 经验与等级
+				user.POST("/checkin", h.progression.CheckIn)
+				user.GET("/level", h.progression.Progress)
+				user.GET("/levels", h.progression.Levels)
+
+				// 关注动态与隐私设置
+				user.GET("/feed", h.social.Feed)
+				user.GET("/privacy", h.social.GetPrivacy)
+				user.PUT("/privacy", h.social.UpdatePrivacy)
+
+				// 青少年模式
+				user.GET("/teen-mode", h.teenMode.Status)
+				user.PUT("/teen-mode", h.teenMode.Update)
+				user.POST("/teen-mode/enable", h.teenMode.Enable)
+				user.POST("/teen-mode/disable", h.teenMode.Disable)
+
+				// 邀请
+				user.GET("/referral", h.referral.Summary)
+				user.GET("/referral/invitees", h.referral.Invitees)
+
+				// 阅读偏好
+				user.GET("/preferences", h.preference.Get)
+				user.PUT("/preferences", h.preference.Update)
+			}
+
+			// 公开主页：未登录也可访问，登录后按关注关系判断隐私可见范围
+			users := v1.Group("/users")
+			users.Use(middleware.OptionalJWTAuth(verifier))
+			users.Use(middleware2.RateLimit(rdb, 100, time.Minute))
+			{
+				users.GET("/:id", h.social.PublicProfile)
+				users.GET("/:id/followers", h.social.Followers)
+				users.GET("/:id/following", h.social.Following)
+				users.GET("/:id/bookshelf", h.social.Bookshelf)
+				users.GET("/:id/activity", h.social.Activity)
+				users.POST("/:id/follow", middleware.JWTAuth(verifier), h.social.Follow)
+				users.DELETE("/:id/follow", middleware.JWTAuth(verifier), h.social.Unfollow)
+			}
+
+			// 管理接口：运营和管理员可查询、封禁、强制下线，重置密码和调整角色仅管理员
+			admin := v1.Group("/admin/users")
+			admin.Use(middleware.JWTAuth(verifier))
+			admin.Use(middleware2.RequireRole(roleLookup, "admin", "moderator"))
+			{
+				admin.GET("", h.admin.SearchUsers)
+				admin.GET("/:id", h.admin.GetUser)
+				admin.GET("/:id/sessions", h.admin.ListSessions)
+				admin.GET("/:id/login-history", h.admin.LoginHistory)
+				admin.POST("/:id/ban", h.admin.BanUser)
+				admin.POST("/:id/unban", h.admin.UnbanUser)
+				admin.POST("/:id/logout", h.admin.ForceLogout)
+
+				adminOnly := admin.Group("")
+				adminOnly.Use(middleware2.RequireRole(roleLookup, "admin"))
+				adminOnly.POST("/:id/reset-password", h.admin.ResetPassword)
+				adminOnly.PUT("/:id/role", h.admin.UpdateRole)
+			}
+
+			// 内部API - 供其他服务和运维调用，不经网关暴露，需携带服务间凭证
+			internal := v1.Group("/internal/user")
+			internal.Use(middleware.InternalAuth(internalToken))
+			{
+				internal.POST("/login-unlock", h.user.UnlockLogin)
+				internal.DELETE("/users/:user_id/cache", h.user.InvalidateUserCache)
+				internal.POST("/users/batch", h.user.BatchGetUsers)
+				internal.POST("/experience", h.progression.AwardExperience)
+				internal.GET("/users/:user_id/teen-mode", h.teenMode.GetUserStatus)
+				internal.POST("/teen-mode/reading", h.teenMode.RecordReading)
+				internal.GET("/sms-outbox/:phone", h.smsLogin.Outbox) // 仅短信 driver 为 fake 时可用
 			}
 		}
 	}
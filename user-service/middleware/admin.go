@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"reading-microservices/shared/utils"
+)
+
+// RoleLookup 查询用户当前角色
+type RoleLookup func(userID string) (string, error)
+
+// RequireRole 只允许指定角色访问。角色每次从数据库读取，降级或封禁立即生效，不依赖 token 中的角色。
+// lookup 返回的错误原样提示给客户端（如需要先开启两步验证），不能包含内部错误信息
+func RequireRole(lookup RoleLookup, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, err := lookup(c.GetString("user_id"))
+		if err != nil {
+			utils.Error(c, utils.ERROR_FORBIDDEN, err.Error())
+			c.Abort()
+			return
+		}
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Set("role", role)
+				c.Next()
+				return
+			}
+		}
+		utils.ErrorWithCode(c, utils.ERROR_FORBIDDEN)
+		c.Abort()
+	}
+}
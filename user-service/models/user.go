@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+
 	"gorm.io/gorm"
 	"time"
 )
@@ -12,6 +14,8 @@ type User struct {
 	Phone               *string    `gorm:"type:varchar(20);uniqueIndex" json:"phone"`
 	PasswordHash        string     `gorm:"type:varchar(255);not null" json:"-"`
 	AvatarURL           *string    `gorm:"type:varchar(500)" json:"avatar_url"`
+	AvatarKey           *string    `gorm:"type:varchar(200)" json:"avatar_key,omitempty"`                // 上传头像的存储 key 前缀，更换时据此删除旧文件
+	AvatarThumbnails    Thumbnails `gorm:"type:text;serializer:json" json:"avatar_thumbnails,omitempty"` // 各尺寸缩略图地址
 	Nickname            *string    `gorm:"type:varchar(50)" json:"nickname"`
 	Bio                 *string    `gorm:"type:text" json:"bio"`
 	Gender              string     `gorm:"type:enum('male','female','other');default:'other'" json:"gender"`
@@ -21,13 +25,17 @@ type User struct {
 	ReadingCoins        int        `gorm:"default:0" json:"reading_coins"`
 	VipLevel            string     `gorm:"type:enum('none','vip','svip');default:'none'" json:"vip_level"`
 	VipExpiresAt        *time.Time `json:"vip_expires_at"`
-	LoginType           string     `gorm:"type:enum('password','wechat','qq','weibo','apple');default:'password'" json:"login_type"`
+	LoginType           string     `gorm:"type:enum('password','sms','wechat','qq','weibo','apple');default:'password'" json:"login_type"`
 	IsPhoneVerified     bool       `gorm:"default:false" json:"is_phone_verified"`
 	IsEmailVerified     bool       `gorm:"default:false" json:"is_email_verified"`
 	PhoneVerifiedAt     *time.Time `json:"phone_verified_at"`
 	EmailVerifiedAt     *time.Time `json:"email_verified_at"`
 	LastThirdPartyLogin *time.Time `json:"last_third_party_login"`
 	IsActive            bool       `gorm:"default:true" json:"is_active"`
+	Role                string     `gorm:"type:enum('user','moderator','admin');default:'user'" json:"role"`
+	BanReason           *string    `gorm:"type:varchar(500)" json:"ban_reason,omitempty"`
+	BannedAt            *time.Time `json:"banned_at,omitempty"`
+	BannedUntil         *time.Time `json:"banned_until,omitempty"` // 为空且 BannedAt 非空表示永久封禁
 	LastLoginAt         *time.Time `json:"last_login_at"`
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
@@ -38,6 +46,9 @@ type User struct {
 	LoginLogs          []LoginLog          `gorm:"foreignKey:UserID" json:"login_logs,omitempty"`
 }
 
+// Thumbnails 缩略图边长（像素）到地址
+type Thumbnails map[string]string
+
 type ThirdPartyAccount struct {
 	ID               string     `gorm:"type:varchar(36);primarykey" json:"id"`
 	UserID           string     `gorm:"type:varchar(36);not null;index" json:"user_id"`
@@ -61,19 +72,22 @@ type ThirdPartyAccount struct {
 }
 
 type UserSession struct {
-	ID             string    `gorm:"primaryKey" json:"id"`
-	UserID         string    `gorm:"index;not null" json:"user_id"`
-	SessionToken   string    `gorm:"type:varchar(512);uniqueIndex;not null" json:"session_token"` // 现在是 refresh token
-	SessionType    string    `gorm:"not null;default:'refresh'" json:"session_type"`              // refresh 或 access（历史兼容）
-	Platform       string    `gorm:"not null" json:"platform"`
-	DeviceID       *string   `gorm:"index" json:"device_id"`
-	IPAddress      *string   `json:"ip_address"`
-	UserAgent      *string   `json:"user_agent"`
-	ExpiresAt      time.Time `gorm:"not null" json:"expires_at"`
-	LastActivityAt time.Time `gorm:"not null" json:"last_activity_at"`
-	IsActive       bool      `gorm:"default:true" json:"is_active"`
-	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID             string     `gorm:"primaryKey" json:"id"`
+	UserID         string     `gorm:"index;not null" json:"user_id"`
+	SessionToken   string     `gorm:"type:varchar(512);uniqueIndex;not null" json:"session_token"` // 现在是 refresh token
+	SessionType    string     `gorm:"not null;default:'refresh'" json:"session_type"`              // refresh 或 access（历史兼容）
+	FamilyID       string     `gorm:"type:varchar(36);index" json:"family_id"`                     // 同一次登录轮换出的 refresh token 属于同一族
+	RotatedAt      *time.Time `json:"rotated_at"`                                                  // 已被轮换的时间，再次使用即视为重放
+	ReplacedByID   *string    `gorm:"type:varchar(36)" json:"replaced_by_id"`
+	Platform       string     `gorm:"not null" json:"platform"`
+	DeviceID       *string    `gorm:"index" json:"device_id"`
+	IPAddress      *string    `json:"ip_address"`
+	UserAgent      *string    `json:"user_agent"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	LastActivityAt time.Time  `gorm:"not null" json:"last_activity_at"`
+	IsActive       bool       `gorm:"default:true" json:"is_active"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// 关联字段
 	AccessToken     string `gorm:"-" json:"access_token"`      // 仅用于响应，不存数据库
@@ -83,19 +97,237 @@ type UserSession struct {
 type LoginLog struct {
 	ID            string    `gorm:"type:varchar(36);primarykey" json:"id"`
 	UserID        string    `gorm:"type:varchar(36);not null;index" json:"user_id"`
-	LoginType     string    `gorm:"type:enum('password','wechat','qq','weibo','apple');not null" json:"login_type"`
+	LoginType     string    `gorm:"type:enum('password','sms','wechat','qq','weibo','apple');not null" json:"login_type"`
 	Platform      string    `gorm:"type:enum('ios','android','web','h5');not null" json:"platform"`
 	DeviceID      *string   `gorm:"type:varchar(100)" json:"device_id"`
 	DeviceInfo    *string   `gorm:"type:text" json:"device_info"`
 	IPAddress     *string   `gorm:"type:varchar(45)" json:"ip_address"`
 	UserAgent     *string   `gorm:"type:text" json:"user_agent"`
 	Location      *string   `gorm:"type:varchar(200)" json:"location"`
+	EventType     string    `gorm:"type:varchar(30);default:'login'" json:"event_type"` // login 或安全事件，如 refresh_token_reuse
+	IsNewDevice   bool      `gorm:"default:false" json:"is_new_device"`                 // 首次在该设备登录
+	IsNewLocation bool      `gorm:"default:false" json:"is_new_location"`               // 首次在该地区登录
 	IsSuccess     bool      `gorm:"default:true" json:"is_success"`
 	FailureReason *string   `gorm:"type:varchar(200)" json:"failure_reason"`
 	SessionID     *string   `gorm:"type:varchar(100)" json:"session_id"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+// UserTwoFactor 两步验证（TOTP），每个用户一条，确认前 IsEnabled 为 false
+type UserTwoFactor struct {
+	UserID    string     `gorm:"type:varchar(36);primarykey" json:"user_id"`
+	Secret    *string    `gorm:"type:text" json:"-"` // 加密后的 TOTP 密钥
+	IsEnabled bool       `gorm:"default:false" json:"is_enabled"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// UserRecoveryCode 两步验证恢复码，只存哈希，用过即作废
+type UserRecoveryCode struct {
+	ID        string     `gorm:"type:varchar(36);primarykey" json:"id"`
+	UserID    string     `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AdminActionLog 管理员对用户的操作记录
+type AdminActionLog struct {
+	ID           string    `gorm:"type:varchar(36);primarykey" json:"id"`
+	AdminID      string    `gorm:"type:varchar(36);not null;index" json:"admin_id"`
+	TargetUserID string    `gorm:"type:varchar(36);not null;index" json:"target_user_id"`
+	Action       string    `gorm:"type:varchar(30);not null" json:"action"` // ban、unban、logout、reset_password、set_role
+	Detail       *string   `gorm:"type:varchar(500)" json:"detail"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DataExportJob 个人数据导出任务，完成后生成压缩包供下载
+type DataExportJob struct {
+	ID           string     `gorm:"type:varchar(36);primarykey" json:"id"`
+	UserID       string     `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	Status       string     `gorm:"type:enum('pending','running','completed','failed');default:'pending'" json:"status"`
+	FilePath     *string    `gorm:"type:varchar(500)" json:"-"`
+	FileSize     int64      `gorm:"default:0" json:"file_size"`
+	ErrorMessage *string    `gorm:"type:varchar(500)" json:"error_message"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expires_at"` // 文件过期后删除
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// AccountDeletionRequest 账号注销申请，冷静期结束后由后台任务执行
+type AccountDeletionRequest struct {
+	ID          string     `gorm:"type:varchar(36);primarykey" json:"id"`
+	UserID      string     `gorm:"type:varchar(36);not null;index" json:"user_id"`
+	Status      string     `gorm:"type:enum('pending','completed','cancelled');default:'pending';index" json:"status"`
+	Reason      *string    `gorm:"type:varchar(500)" json:"reason"`
+	ScheduledAt time.Time  `gorm:"not null;index" json:"scheduled_at"` // 冷静期结束时间
+	Attempts    int        `gorm:"default:0" json:"attempts"`          // 执行失败会在下一轮重试
+	LastError   *string    `gorm:"type:varchar(500)" json:"last_error"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// AccountMerge 账号合并记录：副账号的数据转入主账号后注销副账号；失败的合并可重新发起，已转移的数据不会重复计入
+type AccountMerge struct {
+	ID              string                     `gorm:"type:varchar(36);primarykey" json:"id"`
+	PrimaryUserID   string                     `gorm:"type:varchar(36);not null;index" json:"primary_user_id"`
+	SecondaryUserID string                     `gorm:"type:varchar(36);not null;index" json:"secondary_user_id"`
+	Status          string                     `gorm:"type:enum('running','completed','failed');default:'running';index" json:"status"`
+	Summary         map[string]json.RawMessage `gorm:"type:text;serializer:json" json:"summary"` // 各服务转移和丢弃的条数，user 为本服务
+	LastError       *string                    `gorm:"type:varchar(500)" json:"last_error"`
+	CompletedAt     *time.Time                 `json:"completed_at"`
+	CreatedAt       time.Time                  `json:"created_at"`
+	UpdatedAt       time.Time                  `json:"updated_at"`
+}
+
+// ExperienceLog 经验获得记录，同一用户、事件和 event_id 只记一次，调用方重试不会重复加经验
+type ExperienceLog struct {
+	ID        string    `gorm:"type:varchar(36);primarykey" json:"id"`
+	UserID    string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_user_event;index:idx_user_created" json:"user_id"`
+	Event     string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_user_event" json:"event"`
+	EventID   string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_user_event" json:"event_id"`
+	Amount    int       `gorm:"default:1" json:"amount"`
+	XP        int       `gorm:"default:0" json:"xp"` // 实际获得的经验，达到每日上限后为 0
+	CreatedAt time.Time `gorm:"index:idx_user_created" json:"created_at"`
+}
+
+// UserFollow 关注关系，FollowerID 关注了 FolloweeID
+type UserFollow struct {
+	ID         string    `gorm:"type:varchar(36);primarykey" json:"id"`
+	FollowerID string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_follower_followee;index:idx_follower_created" json:"follower_id"`
+	FolloweeID string    `gorm:"type:varchar(36);not null;uniqueIndex:idx_follower_followee;index:idx_followee_created" json:"followee_id"`
+	CreatedAt  time.Time `gorm:"index:idx_follower_created;index:idx_followee_created" json:"created_at"`
+}
+
+// 公开主页各项资料的可见范围
+const (
+	VisibilityPublic  = "public"
+	VisibilityPrivate = "private"
+)
+
+// UserPrivacy 公开主页的隐私设置，每项资料单独设置可见范围；没有记录时使用 NewUserPrivacy 的默认值
+type UserPrivacy struct {
+	UserID    string    `gorm:"type:varchar(36);primarykey" json:"-"`
+	Bio       string    `gorm:"type:enum('public','private');default:'public'" json:"bio"`
+	Gender    string    `gorm:"type:enum('public','private');default:'private'" json:"gender"`
+	BirthDate string    `gorm:"type:enum('public','private');default:'private'" json:"birth_date"`
+	Level     string    `gorm:"type:enum('public','private');default:'public'" json:"level"`
+	Bookshelf string    `gorm:"type:enum('public','private');default:'private'" json:"bookshelf"`
+	Follows   string    `gorm:"type:enum('public','private');default:'public'" json:"follows"`   // 关注和粉丝列表
+	Activity  string    `gorm:"type:enum('public','private');default:'private'" json:"activity"` // 书评和读完的书，private 时也不出现在粉丝的动态里
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewUserPrivacy 默认隐私设置：性别、生日、书架和动态不公开，其余公开。
+// 没有保存过设置的老用户同样使用这组默认值
+func NewUserPrivacy(userID string) *UserPrivacy {
+	return &UserPrivacy{
+		UserID:    userID,
+		Bio:       VisibilityPublic,
+		Gender:    VisibilityPrivate,
+		BirthDate: VisibilityPrivate,
+		Level:     VisibilityPublic,
+		Bookshelf: VisibilityPrivate,
+		Follows:   VisibilityPublic,
+		Activity:  VisibilityPrivate,
+	}
+}
+
+// TeenMode 监护人设置的青少年模式；未成年用户首次检查时自动保存为开启
+type TeenMode struct {
+	UserID       string    `gorm:"type:varchar(36);primarykey" json:"-"`
+	Enabled      bool      `gorm:"default:false" json:"enabled"` // 监护人主动开启，或未成年时自动开启
+	PinHash      string    `gorm:"type:varchar(255)" json:"-"`   // 监护密码，修改设置和关闭时需要
+	DailyMinutes int       `gorm:"default:0" json:"daily_minutes"`
+	CurfewStart  string    `gorm:"type:varchar(5)" json:"curfew_start"`
+	CurfewEnd    string    `gorm:"type:varchar(5)" json:"curfew_end"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PreferencesSchemaVersion 阅读偏好的 schema 版本，字段含义变化时递增，客户端据此迁移本地设置
+const PreferencesSchemaVersion = 1
+
+// ReadingPreferences 阅读设置，未设置的项使用客户端默认值
+type ReadingPreferences struct {
+	FontSize          *int     `json:"font_size,omitempty" binding:"omitempty,min=10,max=48"`
+	FontFamily        *string  `json:"font_family,omitempty" binding:"omitempty,max=50"`
+	LineSpacing       *float64 `json:"line_spacing,omitempty" binding:"omitempty,min=1,max=3"`
+	Theme             *string  `json:"theme,omitempty" binding:"omitempty,oneof=light dark sepia green"`
+	PageTurnMode      *string  `json:"page_turn_mode,omitempty" binding:"omitempty,oneof=slide curl scroll none"`
+	ChineseConversion *string  `json:"chinese_conversion,omitempty" binding:"omitempty,oneof=none simplified traditional"` // 繁简转换
+	Brightness        *int     `json:"brightness,omitempty" binding:"omitempty,min=0,max=100"`
+	KeepScreenOn      *bool    `json:"keep_screen_on,omitempty"`
+}
+
+// UserPreference 阅读偏好文档；DeviceID 为空是账号级设置，非空是该设备覆盖账号设置的项
+type UserPreference struct {
+	UserID         string               `gorm:"type:varchar(36);primarykey" json:"-"`
+	DeviceID       string               `gorm:"type:varchar(100);primarykey" json:"device_id,omitempty"`
+	SchemaVersion  int                  `gorm:"not null;default:1" json:"schema_version"`
+	Settings       ReadingPreferences   `gorm:"type:text;serializer:json" json:"settings"`
+	FieldUpdatedAt map[string]time.Time `gorm:"type:text;serializer:json" json:"field_updated_at"` // 各项最后写入时间，按项合并冲突
+	UpdatedAt      time.Time            `json:"updated_at"`
+}
+
+// ReferralCode 用户的邀请码，首次查看时生成
+type ReferralCode struct {
+	UserID    string    `gorm:"type:varchar(36);primarykey" json:"-"`
+	Code      string    `gorm:"type:varchar(16);not null;uniqueIndex" json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 邀请关系状态，rejected 的邀请不发放奖励
+const (
+	ReferralStatusValid    = "valid"
+	ReferralStatusRejected = "rejected"
+)
+
+// Referral 邀请关系，每个被邀请人只有一条
+type Referral struct {
+	ID           string    `gorm:"type:varchar(36);primarykey" json:"id"`
+	InviterID    string    `gorm:"type:varchar(36);not null;index:idx_inviter_created" json:"inviter_id"`
+	InviteeID    string    `gorm:"type:varchar(36);not null;uniqueIndex" json:"invitee_id"`
+	Code         string    `gorm:"type:varchar(16);not null" json:"code"`
+	DeviceID     *string   `gorm:"type:varchar(100);index" json:"-"`
+	IPAddress    *string   `gorm:"type:varchar(45)" json:"-"`
+	Status       string    `gorm:"type:enum('valid','rejected');default:'valid'" json:"status"`
+	RejectReason *string   `gorm:"type:varchar(30)" json:"reject_reason"` // same_device 或 same_ip
+	CreatedAt    time.Time `gorm:"index:idx_inviter_created" json:"created_at"`
+}
+
+// 奖励发放状态，pending 的奖励在被邀请人下次达到里程碑时重试
+const (
+	ReferralRewardPending = "pending"
+	ReferralRewardGranted = "granted"
+)
+
+// ReferralReward 一条邀请关系在某个里程碑给邀请人或被邀请人的奖励
+type ReferralReward struct {
+	ID         string     `gorm:"type:varchar(36);primarykey" json:"id"`
+	ReferralID string     `gorm:"type:varchar(36);not null;uniqueIndex:idx_referral_user_milestone" json:"referral_id"`
+	UserID     string     `gorm:"type:varchar(36);not null;uniqueIndex:idx_referral_user_milestone;index" json:"user_id"`
+	Milestone  string     `gorm:"type:varchar(30);not null;uniqueIndex:idx_referral_user_milestone" json:"milestone"` // 如 registered、verified、level_3
+	Points     int        `gorm:"default:0" json:"points"`
+	Coins      int        `gorm:"default:0" json:"coins"`
+	Status     string     `gorm:"type:enum('pending','granted');default:'pending'" json:"status"`
+	GrantedAt  *time.Time `json:"granted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// JWTSigningKey token 签名密钥，私钥加密存储；轮换后旧密钥继续发布一段时间，直到用它签发的 token 全部过期
+type JWTSigningKey struct {
+	ID         string     `gorm:"type:varchar(64);primarykey" json:"id"` // 即 token 头中的 kid
+	Algorithm  string     `gorm:"type:varchar(10);not null" json:"algorithm"`
+	PrivateKey string     `gorm:"type:text;not null" json:"-"`
+	RetiredAt  *time.Time `json:"retired_at"`              // 停止签发的时间，为空表示当前签名密钥
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at"` // 从 JWKS 移除的时间
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
 // BeforeCreate 钩子函数
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
@@ -115,6 +347,10 @@ func (s *UserSession) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == "" {
 		s.ID = generateUUID()
 	}
+	// 新登录以首个 refresh session 的 ID 作为族 ID
+	if s.FamilyID == "" {
+		s.FamilyID = s.ID
+	}
 	return nil
 }
 
@@ -124,3 +360,66 @@ func (l *LoginLog) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func (r *UserRecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = generateUUID()
+	}
+	return nil
+}
+
+func (j *DataExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == "" {
+		j.ID = generateUUID()
+	}
+	return nil
+}
+
+func (d *AccountDeletionRequest) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = generateUUID()
+	}
+	return nil
+}
+
+func (m *AccountMerge) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = generateUUID()
+	}
+	return nil
+}
+
+func (a *AdminActionLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = generateUUID()
+	}
+	return nil
+}
+
+func (e *ExperienceLog) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == "" {
+		e.ID = generateUUID()
+	}
+	return nil
+}
+
+func (f *UserFollow) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = generateUUID()
+	}
+	return nil
+}
+
+func (r *Referral) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = generateUUID()
+	}
+	return nil
+}
+
+func (r *ReferralReward) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = generateUUID()
+	}
+	return nil
+}
@@ -2,7 +2,9 @@ package models
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
 func generateUUID() string {
@@ -15,22 +17,30 @@ func generateUUID() string {
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-	Platform string `json:"platform" binding:"required,oneof=ios android web h5"`
-	DeviceID string `json:"device_id"`
+	Username  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	Platform  string `json:"platform" binding:"required,oneof=ios android web h5"`
+	DeviceID  string `json:"device_id"`
+	IPAddress string `json:"-"` // 由 handler 填充
+	UserAgent string `json:"-"`
 }
 
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6"`
-	Email    string `json:"email" binding:"omitempty,email"`
-	Phone    string `json:"phone" binding:"omitempty"`
-	Platform string `json:"platform" binding:"required,oneof=ios android web h5"`
-	DeviceID string `json:"device_id"`
+	Username  string `json:"username" binding:"required,min=3,max=50"`
+	Password  string `json:"password" binding:"required"` // 强度要求见 password 配置
+	Email     string `json:"email" binding:"omitempty,email"`
+	Phone     string `json:"phone" binding:"omitempty"`
+	Platform  string `json:"platform" binding:"required,oneof=ios android web h5"`
+	DeviceID  string `json:"device_id"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+
+	ReferralCode string `json:"referral_code" binding:"omitempty,max=16"` // 邀请人的邀请码
 }
 
 type UpdateProfileRequest struct {
+	Email     *string `json:"email" binding:"omitempty,email"` // 修改后需要重新验证
+	Phone     *string `json:"phone"`                           // 修改后需要重新验证
 	Nickname  *string `json:"nickname"`
 	Bio       *string `json:"bio"`
 	Gender    *string `json:"gender" binding:"omitempty,oneof=male female other"`
@@ -40,7 +50,11 @@ type UpdateProfileRequest struct {
 
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"` // 强度要求见 password 配置
+}
+
+type UnlockLoginRequest struct {
+	Username string `json:"username" binding:"required"`
 }
 
 type LoginResponse struct {
@@ -49,21 +63,462 @@ type LoginResponse struct {
 	AccessExpiresIn  int       `json:"access_expires_in"`
 	RefreshExpiresIn int       `json:"refresh_expires_in"`
 	User             *UserInfo `json:"user"`
+	DeviceID         string    `json:"device_id"`             // 客户端未传时由服务端生成，后续登录请带上
+	IsNewUser        bool      `json:"is_new_user,omitempty"` // 第三方首次登录自动注册
+
+	// 两步验证：TwoFactorRequired 为 true 时不含 token，需带 TwoFactorToken 调用 /auth/2fa/verify
+	TwoFactorRequired      bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken         string `json:"two_factor_token,omitempty"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"` // 账号策略要求开启但尚未开启
+
+	Preferences *PreferencesResponse `json:"preferences,omitempty"` // 本设备的阅读偏好
 }
 
 type UserInfo struct {
-	ID               string  `json:"id"`
-	Username         string  `json:"username"`
-	Email            *string `json:"email"`
-	Phone            *string `json:"phone"`
-	AvatarURL        *string `json:"avatar_url"`
-	Nickname         *string `json:"nickname"`
-	Bio              *string `json:"bio"`
-	Gender           string  `json:"gender"`
-	Level            int     `json:"level"`
-	ExperiencePoints int     `json:"experience_points"`
-	ReadingCoins     int     `json:"reading_coins"`
-	VipLevel         string  `json:"vip_level"`
-	IsPhoneVerified  bool    `json:"is_phone_verified"`
-	IsEmailVerified  bool    `json:"is_email_verified"`
+	ID               string     `json:"id"`
+	Username         string     `json:"username"`
+	Email            *string    `json:"email"`
+	Phone            *string    `json:"phone"`
+	AvatarURL        *string    `json:"avatar_url"`
+	AvatarThumbnails Thumbnails `json:"avatar_thumbnails,omitempty"`
+	Nickname         *string    `json:"nickname"`
+	Bio              *string    `json:"bio"`
+	Gender           string     `json:"gender"`
+	Level            int        `json:"level"`
+	ExperiencePoints int        `json:"experience_points"`
+	ReadingCoins     int        `json:"reading_coins"`
+	VipLevel         string     `json:"vip_level"`
+	IsPhoneVerified  bool       `json:"is_phone_verified"`
+	IsEmailVerified  bool       `json:"is_email_verified"`
+}
+
+// AvatarCropRequest 上传头像时的裁剪区域（原图摆正后的像素坐标），不传时取中间的正方形
+type AvatarCropRequest struct {
+	X    *int `form:"crop_x" binding:"omitempty,min=0"`
+	Y    *int `form:"crop_y" binding:"omitempty,min=0"`
+	Size *int `form:"crop_size" binding:"omitempty,min=1"`
+}
+
+type AvatarResponse struct {
+	AvatarURL  string     `json:"avatar_url"` // 最大尺寸
+	Thumbnails Thumbnails `json:"thumbnails"` // 边长到地址，如 "96": "..."
+}
+
+type BatchUsersRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// UserPublicInfo 批量查询返回的用户公开信息，与 shared/userclient.User 对应
+type UserPublicInfo struct {
+	ID               string     `json:"id"`
+	Username         string     `json:"username"`
+	Nickname         *string    `json:"nickname"`
+	AvatarURL        *string    `json:"avatar_url"`
+	AvatarThumbnails Thumbnails `json:"avatar_thumbnails,omitempty"`
+	Level            *int       `json:"level,omitempty"` // 隐私设置中等级不公开时为空
+	VipLevel         string     `json:"vip_level"`       // 已过期为 none
+}
+
+type OAuthAuthorizeResponse struct {
+	Platform     string `json:"platform"`
+	AuthorizeURL string `json:"authorize_url"`
+	State        string `json:"state"`
+}
+
+type OAuthLoginRequest struct {
+	Code      string `json:"code" binding:"required"`
+	State     string `json:"state" binding:"required"`
+	Platform  string `json:"platform"`
+	DeviceID  string `json:"device_id"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type OAuthLinkRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type ThirdPartyAccountInfo struct {
+	ID               string     `json:"id"`
+	Platform         string     `json:"platform"`
+	PlatformNickname *string    `json:"platform_nickname"`
+	PlatformAvatar   *string    `json:"platform_avatar"`
+	IsPrimary        bool       `json:"is_primary"`
+	LastLoginAt      *time.Time `json:"last_login_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+type DeviceInfo struct {
+	SessionID      string    `json:"session_id"`
+	DeviceID       string    `json:"device_id"`
+	Platform       string    `json:"platform"`
+	IPAddress      *string   `json:"ip_address"`
+	UserAgent      *string   `json:"user_agent"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	IsCurrent      bool      `json:"is_current"`
+}
+
+type LoginHistoryItem struct {
+	ID            string    `json:"id"`
+	LoginType     string    `json:"login_type"`
+	EventType     string    `json:"event_type"`
+	Platform      string    `json:"platform"`
+	DeviceID      *string   `json:"device_id"`
+	IPAddress     *string   `json:"ip_address"`
+	UserAgent     *string   `json:"user_agent"`
+	Location      *string   `json:"location"`
+	IsSuccess     bool      `json:"is_success"`
+	FailureReason *string   `json:"failure_reason"`
+	IsNewDevice   bool      `json:"is_new_device"`
+	IsNewLocation bool      `json:"is_new_location"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type ConfirmCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type SendCodeResponse struct {
+	Target      string `json:"target"` // 脱敏后的邮箱或手机号
+	ExpiresIn   int    `json:"expires_in"`
+	ResendAfter int    `json:"resend_after"`
+}
+
+type ForgotPasswordRequest struct {
+	Account   string `json:"account" binding:"required"` // 邮箱或手机号
+	Platform  string `json:"-"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type ResetPasswordRequest struct {
+	Account     string `json:"account" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // 强度要求见 password 配置
+	Platform    string `json:"-"`
+	IPAddress   string `json:"-"`
+	UserAgent   string `json:"-"`
+}
+
+type SendSMSCodeRequest struct {
+	Phone     string `json:"phone" binding:"required"`
+	IPAddress string `json:"-"`
+}
+
+type SMSLoginRequest struct {
+	Phone     string `json:"phone" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+	Platform  string `json:"platform" binding:"required,oneof=ios android web h5"`
+	DeviceID  string `json:"device_id"`
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // 策略要求必须开启，开启后不能关闭
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`      // 无法扫码时手动输入
+	OTPAuthURI string `json:"otpauth_uri"` // 客户端生成二维码
+}
+
+type RecoveryCodesResponse struct {
+	Codes []string `json:"codes"` // 只展示一次
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // 动态码或恢复码
+	IPAddress      string `json:"-"`
+}
+
+type RequestAccountDeletionRequest struct {
+	Password string `json:"password"` // 密码注册的账号必填
+	Reason   string `json:"reason" binding:"max=500"`
+}
+
+type AdminUserQuery struct {
+	Keyword  string `form:"keyword"` // 用户 ID、用户名、昵称、邮箱或手机号
+	Status   string `form:"status" binding:"omitempty,oneof=active banned disabled"`
+	Role     string `form:"role" binding:"omitempty,oneof=user moderator admin"`
+	VipLevel string `form:"vip_level" binding:"omitempty,oneof=none vip svip"`
+	Page     int    `form:"page"`
+	Size     int    `form:"size"`
+}
+
+type AdminUserItem struct {
+	ID          string     `json:"id"`
+	Username    string     `json:"username"`
+	Nickname    *string    `json:"nickname"`
+	Email       *string    `json:"email"`
+	Phone       *string    `json:"phone"`
+	Role        string     `json:"role"`
+	VipLevel    string     `json:"vip_level"`
+	LoginType   string     `json:"login_type"`
+	IsActive    bool       `json:"is_active"`
+	IsBanned    bool       `json:"is_banned"`
+	BannedUntil *time.Time `json:"banned_until"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type AdminUserDetail struct {
+	UserInfo
+	Role             string           `json:"role"`
+	LoginType        string           `json:"login_type"`
+	IsActive         bool             `json:"is_active"`
+	IsBanned         bool             `json:"is_banned"`
+	BanReason        *string          `json:"ban_reason"`
+	BannedAt         *time.Time       `json:"banned_at"`
+	BannedUntil      *time.Time       `json:"banned_until"`
+	TwoFactorEnabled bool             `json:"two_factor_enabled"`
+	LastLoginAt      *time.Time       `json:"last_login_at"`
+	CreatedAt        time.Time        `json:"created_at"`
+	RecentActions    []AdminActionLog `json:"recent_actions"` // 最近的管理操作
+}
+
+type AdminBanRequest struct {
+	Reason   string `json:"reason" binding:"required,max=500"`
+	Duration int    `json:"duration" binding:"min=0"` // 封禁时长（小时），0 表示永久
+}
+
+type AdminResetPasswordRequest struct {
+	NewPassword string `json:"new_password"` // 为空时生成临时密码，指定时同样检查强度
+}
+
+type AdminResetPasswordResponse struct {
+	TemporaryPassword string `json:"temporary_password,omitempty"` // 只返回一次
+}
+
+type AdminUpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+type AwardExperienceRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	Event   string `json:"event" binding:"required"`    // reading_time、chapter_finished、comment、daily_checkin
+	EventID string `json:"event_id" binding:"required"` // 幂等键，同一事件重复提交只计一次
+	Amount  int    `json:"amount" binding:"min=0"`      // 单位数量，如阅读分钟数，默认 1
+}
+
+type AwardExperienceResponse struct {
+	Awarded          int  `json:"awarded"`   // 本次获得的经验
+	Capped           bool `json:"capped"`    // 受每日上限影响少于规则值
+	Duplicate        bool `json:"duplicate"` // 该事件已记过
+	ExperiencePoints int  `json:"experience_points"`
+	Level            int  `json:"level"`
+	LeveledUp        bool `json:"leveled_up"`
+}
+
+type LevelInfo struct {
+	Level int      `json:"level"`
+	XP    int      `json:"xp"` // 达到该等级所需的累计经验
+	Title string   `json:"title"`
+	Perks []string `json:"perks"`
+}
+
+type DailyExperience struct {
+	Event    string `json:"event"`
+	Earned   int    `json:"earned"`
+	DailyCap int    `json:"daily_cap"` // 0 表示不限
+}
+
+type LevelProgress struct {
+	Level            int               `json:"level"`
+	Title            string            `json:"title"`
+	ExperiencePoints int               `json:"experience_points"`
+	CurrentLevelXP   int               `json:"current_level_xp"`
+	NextLevelXP      int               `json:"next_level_xp"` // 已满级时为 0
+	XPToNext         int               `json:"xp_to_next"`
+	Progress         float64           `json:"progress"`   // 当前等级内的进度 0-1，满级为 1
+	Perks            []string          `json:"perks"`      // 已解锁的特权
+	NextPerks        []string          `json:"next_perks"` // 下一级解锁的特权
+	Today            []DailyExperience `json:"today"`
+	CheckedInToday   bool              `json:"checked_in_today"`
+}
+
+// PublicProfile 他人可见的公开主页，按隐私设置隐藏的字段不返回
+type PublicProfile struct {
+	ID               string     `json:"id"`
+	Username         string     `json:"username"`
+	Nickname         *string    `json:"nickname"`
+	AvatarURL        *string    `json:"avatar_url"`
+	AvatarThumbnails Thumbnails `json:"avatar_thumbnails,omitempty"`
+	Bio              *string    `json:"bio,omitempty"`
+	Gender           string     `json:"gender,omitempty"`
+	BirthDate        *time.Time `json:"birth_date,omitempty"`
+	Level            *int       `json:"level,omitempty"`
+	FollowersCount   int64      `json:"followers_count"`
+	FollowingCount   int64      `json:"following_count"`
+	IsFollowing      bool       `json:"is_following"`   // 当前登录用户是否已关注
+	IsFollowedBy     bool       `json:"is_followed_by"` // 是否关注了当前登录用户
+	IsSelf           bool       `json:"is_self"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// UserSummary 列表和动态中展示的用户信息
+type UserSummary struct {
+	ID               string     `json:"id"`
+	Username         string     `json:"username"`
+	Nickname         *string    `json:"nickname"`
+	AvatarURL        *string    `json:"avatar_url"`
+	AvatarThumbnails Thumbnails `json:"avatar_thumbnails,omitempty"`
+}
+
+type FollowUserItem struct {
+	UserSummary
+	IsFollowing bool      `json:"is_following"` // 当前登录用户是否已关注
+	FollowedAt  time.Time `json:"followed_at"`
+}
+
+type UpdatePrivacyRequest struct {
+	Bio       *string `json:"bio" binding:"omitempty,oneof=public private"`
+	Gender    *string `json:"gender" binding:"omitempty,oneof=public private"`
+	BirthDate *string `json:"birth_date" binding:"omitempty,oneof=public private"`
+	Level     *string `json:"level" binding:"omitempty,oneof=public private"`
+	Bookshelf *string `json:"bookshelf" binding:"omitempty,oneof=public private"`
+	Follows   *string `json:"follows" binding:"omitempty,oneof=public private"`
+	Activity  *string `json:"activity" binding:"omitempty,oneof=public private"`
+}
+
+// ActivityItem 阅读服务返回的用户动态
+type ActivityItem struct {
+	Type      string    `json:"type"` // review（书评）或 finished（读完一本书）
+	UserID    string    `json:"user_id"`
+	NovelID   string    `json:"novel_id"`
+	CommentID string    `json:"comment_id,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	Rating    *int      `json:"rating,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedQuery struct {
+	Before string `form:"before"` // 上一页返回的 next_before（RFC3339）
+	Limit  int    `form:"limit"`  // 默认 20，最多 50
+}
+
+type FeedItem struct {
+	ActivityItem
+	User *UserSummary `json:"user"`
+}
+
+type FeedResponse struct {
+	Items      []*FeedItem `json:"items"`
+	NextBefore *time.Time  `json:"next_before"` // 下一页请求带上 before，为空表示没有更多
+}
+
+// 青少年模式受限原因
+const (
+	TeenReasonDailyLimit = "daily_limit" // 今日阅读时长已用完
+	TeenReasonCurfew     = "curfew"      // 处于宵禁时段
+)
+
+// EnableTeenModeRequest 监护人开启青少年模式并设置监护密码，未填的设置使用默认值
+type EnableTeenModeRequest struct {
+	Pin          string  `json:"pin" binding:"required,numeric,min=4,max=6"`
+	DailyMinutes *int    `json:"daily_minutes" binding:"omitempty,min=1"`
+	CurfewStart  *string `json:"curfew_start" binding:"omitempty,datetime=15:04"`
+	CurfewEnd    *string `json:"curfew_end" binding:"omitempty,datetime=15:04"` // 与开始时间相同表示不设宵禁
+}
+
+// UpdateTeenModeRequest 修改青少年模式设置，需要当前监护密码
+type UpdateTeenModeRequest struct {
+	Pin          string  `json:"pin" binding:"required"`
+	NewPin       *string `json:"new_pin" binding:"omitempty,numeric,min=4,max=6"`
+	DailyMinutes *int    `json:"daily_minutes" binding:"omitempty,min=1"`
+	CurfewStart  *string `json:"curfew_start" binding:"omitempty,datetime=15:04"`
+	CurfewEnd    *string `json:"curfew_end" binding:"omitempty,datetime=15:04"`
+}
+
+type TeenModePinRequest struct {
+	Pin string `json:"pin" binding:"required"`
+}
+
+// TeenModeStatus 青少年模式状态，阅读、支付、内容服务据此限制
+type TeenModeStatus struct {
+	Enabled          bool   `json:"enabled"`
+	Source           string `json:"source,omitempty"` // age（未成年自动开启）或 guardian（监护人开启）
+	HasPin           bool   `json:"has_pin"`
+	DailyMinutes     int    `json:"daily_minutes,omitempty"`
+	UsedMinutes      int    `json:"used_minutes"`
+	RemainingMinutes int    `json:"remaining_minutes,omitempty"`
+	CurfewStart      string `json:"curfew_start,omitempty"`
+	CurfewEnd        string `json:"curfew_end,omitempty"`
+	ReadingAllowed   bool   `json:"reading_allowed"`
+	Reason           string `json:"reason,omitempty"` // 不能阅读的原因
+}
+
+// RecordTeenReadingRequest 阅读服务上报阅读时长
+type RecordTeenReadingRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	Seconds int    `json:"seconds" binding:"min=0"`
+}
+
+// ReferralSummary 我的邀请码和邀请收益
+type ReferralSummary struct {
+	Code          string `json:"code"`
+	InvitedCount  int64  `json:"invited_count"`
+	ValidCount    int64  `json:"valid_count"` // 通过防刷检查、可获得奖励的人数
+	EarnedPoints  int    `json:"earned_points"`
+	EarnedCoins   int    `json:"earned_coins"`
+	PendingPoints int    `json:"pending_points"` // 已达成但尚未到账
+	PendingCoins  int    `json:"pending_coins"`
+}
+
+// InviteeItem 我邀请的用户和因其获得的奖励
+type InviteeItem struct {
+	UserSummary
+	Status       string           `json:"status"`                  // valid 或 rejected
+	RejectReason *string          `json:"reject_reason,omitempty"` // same_device 或 same_ip
+	Rewards      []ReferralReward `json:"rewards"`
+	InvitedAt    time.Time        `json:"invited_at"`
+}
+
+type PreferencesQuery struct {
+	DeviceID string `form:"device_id" binding:"max=100"` // 为空时只返回账号级设置
+}
+
+// UpdatePreferencesRequest 部分更新阅读偏好，只提交修改过的项
+type UpdatePreferencesRequest struct {
+	DeviceID      string             `json:"device_id" binding:"max=100"` // 为空修改账号级设置，非空修改该设备的覆盖项
+	SchemaVersion int                `json:"schema_version" binding:"omitempty,min=1"`
+	Settings      ReadingPreferences `json:"settings"`
+	Reset         []string           `json:"reset" binding:"omitempty,dive,oneof=font_size font_family line_spacing theme page_turn_mode chinese_conversion brightness keep_screen_on"` // 恢复默认的项，设备覆盖项恢复为跟随账号设置
+	UpdatedAt     *time.Time         `json:"updated_at"`                                                                                                                                // 客户端修改时间，早于服务端该项最后写入时间的修改不生效；为空或晚于服务端当前时间时取服务端时间
+}
+
+// PreferencesResponse 账号级设置、设备覆盖项和合并后的生效设置
+type PreferencesResponse struct {
+	SchemaVersion int                `json:"schema_version"`
+	Account       *UserPreference    `json:"account"`
+	Device        *UserPreference    `json:"device,omitempty"`
+	Effective     ReadingPreferences `json:"effective"`         // 设备覆盖项优先
+	Ignored       []string           `json:"ignored,omitempty"` // 服务端已有更新的写入而未生效的项，客户端应采用 Effective
+}
+
+// MergeAccountRequest 把另一个账号合并到当前账号；secondary_token 为登录副账号得到的 access token。
+// 预览只需要 token，执行合并时还需要副账号的密码或发到副账号的验证码，二选一
+type MergeAccountRequest struct {
+	SecondaryToken string `json:"secondary_token" binding:"required"`
+	Password       string `json:"password"`
+	Code           string `json:"code"`
+	IPAddress      string `json:"-"`
+}
+
+// SendMergeCodeRequest 向副账号已验证的邮箱或手机号发送合并验证码
+type SendMergeCodeRequest struct {
+	SecondaryToken string `json:"secondary_token" binding:"required"`
+}
+
+// AccountMergePreview 合并预览，不修改数据
+type AccountMergePreview struct {
+	Primary   UserSummary                `json:"primary"`
+	Secondary UserSummary                `json:"secondary"`
+	Summary   map[string]json.RawMessage `json:"summary"` // 各服务将转移和因与主账号重复而丢弃的条数，user 为本服务
 }
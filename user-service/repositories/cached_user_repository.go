@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/cache"
+	"reading-microservices/user-service/models"
+)
+
+// CachedUserRepository 用户查询走 进程内 LRU -> Redis -> MySQL 的读穿缓存，
+// 同一用户的并发回源合并为一次；修改用户的方法写库后清除两级缓存，并通知其他实例清除进程内缓存
+type CachedUserRepository struct {
+	UserRepository
+	cache cache.UserCache
+	local *cache.LocalUserCache
+	group cache.Group
+	ttl   time.Duration
+
+	// 每次失效加一；回源期间发生过失效则不回填，避免旧数据覆盖刚清除的缓存
+	generation atomic.Uint64
+}
+
+func NewCachedUserRepository(repo UserRepository, userCache cache.UserCache, local *cache.LocalUserCache, ttl time.Duration) *CachedUserRepository {
+	return &CachedUserRepository{
+		UserRepository: repo,
+		cache:          userCache,
+		local:          local,
+		ttl:            ttl,
+	}
+}
+
+func (r *CachedUserRepository) GetByID(id string) (*models.User, error) {
+	if user, ok := r.local.Get(id); ok {
+		return user, nil
+	}
+
+	val, err := r.group.Do(id, func() (interface{}, error) {
+		generation := r.generation.Load()
+		ctx := context.Background()
+
+		user, err := r.cache.GetUser(ctx, id)
+		if err == nil {
+			if r.generation.Load() == generation {
+				r.local.Set(user)
+			}
+			return user, nil
+		}
+		if err != redis.Nil {
+			log.Printf("warning: failed to read user cache %s: %v", id, err)
+		}
+
+		user, err = r.UserRepository.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if r.generation.Load() == generation {
+			if err := r.cache.SetUser(ctx, user, r.ttl); err != nil {
+				log.Printf("warning: failed to write user cache %s: %v", id, err)
+			}
+			r.local.Set(user)
+		}
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 并发调用共享同一结果，各自返回副本
+	user := *val.(*models.User)
+	return &user, nil
+}
+
+// GetByIDs 批量查询：先查进程内缓存，未命中的用一次 MGET 查 Redis，仍未命中的一次查库并回填。
+// 结果按 ids 顺序，重复和不存在的 ID 跳过
+func (r *CachedUserRepository) GetByIDs(ids []string) ([]models.User, error) {
+	found := make(map[string]*models.User, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if _, ok := found[id]; ok {
+			continue
+		}
+		if user, ok := r.local.Get(id); ok {
+			found[id] = user
+			continue
+		}
+		found[id] = nil
+		missing = append(missing, id)
+	}
+
+	if len(missing) > 0 {
+		generation := r.generation.Load()
+		ctx := context.Background()
+
+		cached, err := r.cache.GetUsers(ctx, missing)
+		if err != nil {
+			log.Printf("warning: failed to read user cache for %d users: %v", len(missing), err)
+			cached = map[string]*models.User{}
+		}
+		var fromDB []string
+		for _, id := range missing {
+			if user, ok := cached[id]; ok {
+				found[id] = user
+			} else {
+				fromDB = append(fromDB, id)
+			}
+		}
+
+		var loaded []*models.User
+		if len(fromDB) > 0 {
+			users, err := r.UserRepository.GetByIDs(fromDB)
+			if err != nil {
+				return nil, err
+			}
+			for i := range users {
+				found[users[i].ID] = &users[i]
+				loaded = append(loaded, &users[i])
+			}
+		}
+
+		if r.generation.Load() == generation {
+			if err := r.cache.SetUsers(ctx, loaded, r.ttl); err != nil {
+				log.Printf("warning: failed to write user cache for %d users: %v", len(loaded), err)
+			}
+			for _, id := range missing {
+				if user := found[id]; user != nil {
+					r.local.Set(user)
+				}
+			}
+		}
+	}
+
+	users := make([]models.User, 0, len(found))
+	for _, id := range ids {
+		if user := found[id]; user != nil {
+			users = append(users, *user)
+			found[id] = nil
+		}
+	}
+	return users, nil
+}
+
+func (r *CachedUserRepository) Update(user *models.User) error {
+	err := r.UserRepository.Update(user)
+	r.InvalidateUser(user.ID)
+	return err
+}
+
+func (r *CachedUserRepository) UpdateLastLogin(userID string) error {
+	err := r.UserRepository.UpdateLastLogin(userID)
+	r.InvalidateUser(userID)
+	return err
+}
+
+func (r *CachedUserRepository) UpdatePasswordHash(userID, oldHash, newHash string) error {
+	err := r.UserRepository.UpdatePasswordHash(userID, oldHash, newHash)
+	r.InvalidateUser(userID)
+	return err
+}
+
+func (r *CachedUserRepository) AnonymizeUser(userID, username, passwordHash string) error {
+	err := r.UserRepository.AnonymizeUser(userID, username, passwordHash)
+	r.InvalidateUser(userID)
+	return err
+}
+
+func (r *CachedUserRepository) MergeUsers(secondaryID, primaryID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	result, err := r.UserRepository.MergeUsers(secondaryID, primaryID, dryRun)
+	if !dryRun {
+		r.InvalidateUser(secondaryID)
+		r.InvalidateUser(primaryID)
+	}
+	return result, err
+}
+
+func (r *CachedUserRepository) AwardExperience(log *models.ExperienceLog, dailyCap int, dayStart time.Time) (bool, error) {
+	duplicate, err := r.UserRepository.AwardExperience(log, dailyCap, dayStart)
+	if err == nil && !duplicate && log.XP > 0 {
+		r.InvalidateUser(log.UserID)
+	}
+	return duplicate, err
+}
+
+func (r *CachedUserRepository) RaiseLevel(userID string, level int) error {
+	err := r.UserRepository.RaiseLevel(userID, level)
+	r.InvalidateUser(userID)
+	return err
+}
+
+// InvalidateUser 清除该用户的缓存，其他服务修改余额、VIP 后也通过内部接口调用
+func (r *CachedUserRepository) InvalidateUser(userID string) error {
+	r.generation.Add(1)
+	r.local.Remove(userID)
+
+	ctx := context.Background()
+	if err := r.cache.InvalidateUser(ctx, userID); err != nil {
+		log.Printf("warning: failed to invalidate user cache %s: %v", userID, err)
+		return err
+	}
+	if err := r.cache.PublishUserInvalidation(ctx, userID); err != nil {
+		log.Printf("warning: failed to publish user cache invalidation %s: %v", userID, err)
+	}
+	return nil
+}
+
+// ListenInvalidations 接收其他实例的失效通知，清除本实例的进程内缓存
+func (r *CachedUserRepository) ListenInvalidations(ctx context.Context) {
+	r.cache.SubscribeUserInvalidation(ctx, func(userID string) {
+		r.generation.Add(1)
+		r.local.Remove(userID)
+	})
+}
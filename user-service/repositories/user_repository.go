@@ -2,9 +2,12 @@ package repositories
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reading-microservices/shared/utils"
 	"reading-microservices/user-service/models"
 )
 
@@ -16,15 +19,98 @@ type UserRepository interface {
 	GetByPhone(phone string) (*models.User, error)
 	Update(user *models.User) error
 	UpdateLastLogin(userID string) error
+	UpdatePasswordHash(userID, oldHash, newHash string) error
 	CreateSession(session *models.UserSession) error
 	GetActiveSession(token string) (*models.UserSession, error)
 	InvalidateSession(token string) error
 	InvalidateAllUserSessions(userID string) error // ⭐ 新增
+	InvalidateSessionByID(id string) error
+	GetActiveUserSessions(userID string) ([]models.UserSession, error)
 	CreateLoginLog(log *models.LoginLog) error
 	GetThirdPartyAccount(platform, platformUserID string) (*models.ThirdPartyAccount, error)
 	CreateThirdPartyAccount(account *models.ThirdPartyAccount) error
 	LinkThirdPartyAccount(userID string, account *models.ThirdPartyAccount) error
-	UpdateRefreshSession(refreshToken, newAccessToken string, lastActivityAt time.Time) error
+	CreateUserWithThirdPartyAccount(user *models.User, account *models.ThirdPartyAccount) error
+	GetUserThirdPartyAccounts(userID string) ([]models.ThirdPartyAccount, error)
+	GetUserThirdPartyAccount(userID, platform string) (*models.ThirdPartyAccount, error)
+	UpdateThirdPartyAccount(account *models.ThirdPartyAccount) error
+	DeleteThirdPartyAccount(id string) error
+	SetPrimaryThirdPartyAccount(userID, accountID string) error
+	GetSessionByToken(token string) (*models.UserSession, error)
+	RotateSession(oldID string, newSession *models.UserSession) error
+	InvalidateSessionFamily(familyID string) error
+	GetTwoFactor(userID string) (*models.UserTwoFactor, error)
+	SaveTwoFactor(twoFactor *models.UserTwoFactor) error
+	DeleteTwoFactor(userID string) error
+	ReplaceRecoveryCodes(userID string, codes []models.UserRecoveryCode) error
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID string) (int64, error)
+	GetLoginHistory(userID string, page, size int) ([]models.LoginLog, int64, error)
+	HasSuccessfulLogin(userID string) (bool, error)
+	HasLoginFromDevice(userID, deviceID string) (bool, error)
+	HasLoginFromLocation(userID, location string) (bool, error)
+	HasLoginFromIP(userID, ip string, since time.Time) (bool, error)
+	GetAllLoginLogs(userID string) ([]models.LoginLog, error)
+	CreateExportJob(job *models.DataExportJob) error
+	UpdateExportJob(job *models.DataExportJob) error
+	GetExportJob(userID, jobID string) (*models.DataExportJob, error)
+	GetActiveExportJob(userID string) (*models.DataExportJob, error)
+	GetExpiredExportJobs(before time.Time) ([]models.DataExportJob, error)
+	GetUserExportJobs(userID string) ([]models.DataExportJob, error)
+	DeleteExportJob(id string) error
+	CreateDeletionRequest(req *models.AccountDeletionRequest) error
+	UpdateDeletionRequest(req *models.AccountDeletionRequest) error
+	GetPendingDeletionRequest(userID string) (*models.AccountDeletionRequest, error)
+	CreateAccountMerge(merge *models.AccountMerge) error
+	UpdateAccountMerge(merge *models.AccountMerge) error
+	MergeUsers(secondaryID, primaryID string, dryRun bool) (map[string]utils.MergeCount, error)
+	GetDueDeletionRequests(before time.Time, limit int) ([]models.AccountDeletionRequest, error)
+	AnonymizeUser(userID, username, passwordHash string) error
+	SearchUsers(query *models.AdminUserQuery) ([]models.User, int64, error)
+	CreateAdminActionLog(log *models.AdminActionLog) error
+	GetAdminActionLogs(targetUserID string, limit int) ([]models.AdminActionLog, error)
+	CreateSigningKey(key *models.JWTSigningKey) error
+	GetValidSigningKeys(now time.Time) ([]models.JWTSigningKey, error)
+	RetireSigningKeys(exceptID string, retiredAt, expiresAt time.Time) error
+	DeleteExpiredSigningKeys(before time.Time) error
+	InvalidateUser(userID string) error
+	AwardExperience(log *models.ExperienceLog, dailyCap int, dayStart time.Time) (bool, error)
+	RaiseLevel(userID string, level int) error
+	GetDailyExperience(userID string, dayStart time.Time) (map[string]int, error)
+	HasExperienceEvent(userID, event, eventID string) (bool, error)
+	GetAllExperienceLogs(userID string) ([]models.ExperienceLog, error)
+	GetByIDs(ids []string) ([]models.User, error)
+	Follow(followerID, followeeID string) error
+	Unfollow(followerID, followeeID string) error
+	IsFollowing(followerID, followeeID string) (bool, error)
+	GetFollowingAmong(followerID string, followeeIDs []string) (map[string]bool, error)
+	CountFollows(userID string) (followers, following int64, err error)
+	GetFollowers(userID string, page, size int) ([]models.UserFollow, int64, error)
+	GetFollowing(userID string, page, size int) ([]models.UserFollow, int64, error)
+	GetFollowingIDs(userID string, limit int) ([]string, error)
+	GetAllFollowing(userID string) ([]models.UserFollow, error)
+	GetPrivacy(userID string) (*models.UserPrivacy, error)
+	GetPrivacies(userIDs []string) (map[string]*models.UserPrivacy, error)
+	SavePrivacy(privacy *models.UserPrivacy) error
+	GetTeenMode(userID string) (*models.TeenMode, error)
+	SaveTeenMode(mode *models.TeenMode) error
+	GetPreferences(userID string, deviceIDs []string) ([]models.UserPreference, error)
+	GetAllPreferences(userID string) ([]models.UserPreference, error)
+	UpdatePreference(userID, deviceID string, fn func(pref *models.UserPreference) error) (*models.UserPreference, error)
+	GetReferralCode(userID string) (*models.ReferralCode, error)
+	GetReferralCodeByCode(code string) (*models.ReferralCode, error)
+	CreateReferralCode(code *models.ReferralCode) error
+	CreateReferral(referral *models.Referral) error
+	GetReferralByInvitee(inviteeID string) (*models.Referral, error)
+	HasReferralFromDevice(deviceID string) (bool, error)
+	CountReferralsFromIP(inviterID, ip string, since time.Time) (int64, error)
+	CountReferrals(inviterID string) (total, valid int64, err error)
+	GetReferrals(inviterID string, page, size int) ([]models.Referral, int64, error)
+	GetAllReferrals(inviterID string) ([]models.Referral, error)
+	CreateReferralRewards(rewards []models.ReferralReward) error
+	GetReferralRewards(referralID string) ([]models.ReferralReward, error)
+	GetUserReferralRewards(userID string, referralIDs []string) ([]models.ReferralReward, error)
+	MarkReferralRewardGranted(id string, grantedAt time.Time) error
 }
 
 type userRepository struct {
@@ -79,11 +165,23 @@ func (r *userRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// InvalidateUser 未启用缓存时无需处理，见 CachedUserRepository
+func (r *userRepository) InvalidateUser(userID string) error {
+	return nil
+}
+
 func (r *userRepository) UpdateLastLogin(userID string) error {
 	now := time.Now()
 	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("last_login_at", now).Error
 }
 
+// UpdatePasswordHash 仅当哈希仍是 oldHash 时更新，期间密码被修改则什么都不做
+func (r *userRepository) UpdatePasswordHash(userID, oldHash, newHash string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND password_hash = ?", userID, oldHash).
+		Update("password_hash", newHash).Error
+}
+
 func (r *userRepository) CreateSession(session *models.UserSession) error {
 	return r.db.Create(session).Error
 }
@@ -110,8 +208,24 @@ func (r *userRepository) InvalidateAllUserSessions(userID string) error {
 		Update("is_active", false).Error
 }
 
+func (r *userRepository) InvalidateSessionByID(id string) error {
+	return r.db.Model(&models.UserSession{}).
+		Where("id = ?", id).
+		Update("is_active", false).Error
+}
+
+// GetActiveUserSessions 按最后活动时间倒序返回有效的 refresh session
+func (r *userRepository) GetActiveUserSessions(userID string) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := r.db.Where("user_id = ? AND session_type = ? AND is_active = ? AND expires_at > ?",
+		userID, "refresh", true, time.Now()).
+		Order("last_activity_at DESC").Find(&sessions).Error
+	return sessions, err
+}
+
 func (r *userRepository) CreateLoginLog(log *models.LoginLog) error {
-	return r.db.Create(log).Error
+	// is_success 的数据库默认值为 true，Select("*") 保证失败记录的 false 能写入
+	return r.db.Select("*").Create(log).Error
 }
 
 func (r *userRepository) GetThirdPartyAccount(platform, platformUserID string) (*models.ThirdPartyAccount, error) {
@@ -133,22 +247,964 @@ func (r *userRepository) LinkThirdPartyAccount(userID string, account *models.Th
 	return r.db.Create(account).Error
 }
 
-// UpdateRefreshSession 更新 refresh session
-func (r *userRepository) UpdateRefreshSession(refreshToken, newAccessToken string, lastActivityAt time.Time) error {
-	result := r.db.Model(&models.UserSession{}).
-		Where("session_token = ? AND session_type = ?", refreshToken, "refresh").
-		Updates(map[string]interface{}{
-			"last_activity_at": lastActivityAt,
-			"updated_at":       time.Now(),
-		})
+// CreateUserWithThirdPartyAccount 第三方首次登录时同时创建用户和绑定关系
+func (r *userRepository) CreateUserWithThirdPartyAccount(user *models.User, account *models.ThirdPartyAccount) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		account.UserID = user.ID
+		return tx.Create(account).Error
+	})
+}
 
+func (r *userRepository) GetUserThirdPartyAccounts(userID string) ([]models.ThirdPartyAccount, error) {
+	var accounts []models.ThirdPartyAccount
+	err := r.db.Where("user_id = ? AND is_active = ?", userID, true).
+		Order("created_at ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *userRepository) GetUserThirdPartyAccount(userID, platform string) (*models.ThirdPartyAccount, error) {
+	var account models.ThirdPartyAccount
+	err := r.db.Where("user_id = ? AND platform = ? AND is_active = ?",
+		userID, platform, true).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (r *userRepository) UpdateThirdPartyAccount(account *models.ThirdPartyAccount) error {
+	return r.db.Save(account).Error
+}
+
+// DeleteThirdPartyAccount 解绑时直接删除，便于该第三方账号以后重新绑定
+func (r *userRepository) DeleteThirdPartyAccount(id string) error {
+	return r.db.Delete(&models.ThirdPartyAccount{}, "id = ?", id).Error
+}
+
+// SetPrimaryThirdPartyAccount 设置主账号，同一用户只有一个主账号
+func (r *userRepository) SetPrimaryThirdPartyAccount(userID, accountID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ThirdPartyAccount{}).
+			Where("user_id = ? AND id <> ?", userID, accountID).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.ThirdPartyAccount{}).
+			Where("user_id = ? AND id = ? AND is_active = ?", userID, accountID, true).
+			Update("is_primary", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetSessionByToken 按 token 查询会话，不区分是否有效（用于重放检测）
+func (r *userRepository) GetSessionByToken(token string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := r.db.Where("session_token = ?", token).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RotateSession 在同一个事务中把旧 refresh session 标记为已轮换并创建新会话。
+// 条件更新保证同一个 refresh token 只能轮换一次，已被轮换时返回 gorm.ErrRecordNotFound；
+// 创建失败时旧会话保持有效，客户端可以用原 token 重试
+func (r *userRepository) RotateSession(oldID string, newSession *models.UserSession) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.UserSession{}).
+			Where("id = ? AND is_active = ?", oldID, true).
+			Updates(map[string]interface{}{
+				"is_active":        false,
+				"rotated_at":       now,
+				"last_activity_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(newSession).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("id = ?", oldID).
+			Update("replaced_by_id", newSession.ID).Error
+	})
+}
+
+func (r *userRepository) InvalidateSessionFamily(familyID string) error {
+	return r.db.Model(&models.UserSession{}).
+		Where("family_id = ? AND is_active = ?", familyID, true).
+		Update("is_active", false).Error
+}
+
+func (r *userRepository) GetTwoFactor(userID string) (*models.UserTwoFactor, error) {
+	var twoFactor models.UserTwoFactor
+	err := r.db.Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *userRepository) SaveTwoFactor(twoFactor *models.UserTwoFactor) error {
+	return r.db.Save(twoFactor).Error
+}
+
+// DeleteTwoFactor 关闭两步验证，同时删除恢复码
+func (r *userRepository) DeleteTwoFactor(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserTwoFactor{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes 重新生成恢复码，旧的全部作废
+func (r *userRepository) ReplaceRecoveryCodes(userID string, codes []models.UserRecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode 条件更新保证同一个恢复码只能用一次
+func (r *userRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	result := r.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
 	if result.Error != nil {
-		return result.Error
+		return false, result.Error
 	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) CountRecoveryCodes(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetLoginHistory 登录记录，按时间倒序
+func (r *userRepository) GetLoginHistory(userID string, page, size int) ([]models.LoginLog, int64, error) {
+	var logs []models.LoginLog
+	var total int64
 
-	if result.RowsAffected == 0 {
-		return errors.New("refresh session not found")
+	query := r.db.Model(&models.LoginLog{}).Where("user_id = ?", userID)
+
+	query.Count(&total)
+
+	if page <= 0 {
+		page = 1
 	}
+	if size <= 0 {
+		size = 20
+	}
+	offset := (page - 1) * size
 
-	return nil
+	err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&logs).Error
+	return logs, total, err
+}
+
+func (r *userRepository) HasSuccessfulLogin(userID string) (bool, error) {
+	return r.loginExists(r.db.Where("user_id = ?", userID))
+}
+
+func (r *userRepository) HasLoginFromDevice(userID, deviceID string) (bool, error) {
+	return r.loginExists(r.db.Where("user_id = ? AND device_id = ?", userID, deviceID))
+}
+
+func (r *userRepository) HasLoginFromLocation(userID, location string) (bool, error) {
+	return r.loginExists(r.db.Where("user_id = ? AND location = ?", userID, location))
+}
+
+// HasLoginFromIP since 之后是否有从该 IP 的成功登录
+func (r *userRepository) HasLoginFromIP(userID, ip string, since time.Time) (bool, error) {
+	return r.loginExists(r.db.Where("user_id = ? AND ip_address = ? AND created_at >= ?", userID, ip, since))
+}
+
+// loginExists 是否存在满足条件的成功登录记录
+func (r *userRepository) loginExists(query *gorm.DB) (bool, error) {
+	var count int64
+	err := query.Model(&models.LoginLog{}).
+		Where("event_type = ? AND is_success = ?", "login", true).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetAllLoginLogs 全部登录记录，用于个人数据导出
+func (r *userRepository) GetAllLoginLogs(userID string) ([]models.LoginLog, error) {
+	var logs []models.LoginLog
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&logs).Error
+	return logs, err
+}
+
+func (r *userRepository) CreateExportJob(job *models.DataExportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *userRepository) UpdateExportJob(job *models.DataExportJob) error {
+	return r.db.Save(job).Error
+}
+
+func (r *userRepository) GetExportJob(userID, jobID string) (*models.DataExportJob, error) {
+	var job models.DataExportJob
+	err := r.db.Where("id = ? AND user_id = ?", jobID, userID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetActiveExportJob 尚未结束的导出任务
+func (r *userRepository) GetActiveExportJob(userID string) (*models.DataExportJob, error) {
+	var job models.DataExportJob
+	err := r.db.Where("user_id = ? AND status IN ?", userID, []string{"pending", "running"}).
+		Order("created_at DESC").
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *userRepository) GetExpiredExportJobs(before time.Time) ([]models.DataExportJob, error) {
+	var jobs []models.DataExportJob
+	err := r.db.Where("expires_at IS NOT NULL AND expires_at < ?", before).Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *userRepository) GetUserExportJobs(userID string) ([]models.DataExportJob, error) {
+	var jobs []models.DataExportJob
+	err := r.db.Where("user_id = ?", userID).Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *userRepository) DeleteExportJob(id string) error {
+	return r.db.Where("id = ?", id).Delete(&models.DataExportJob{}).Error
+}
+
+func (r *userRepository) CreateDeletionRequest(req *models.AccountDeletionRequest) error {
+	return r.db.Create(req).Error
+}
+
+func (r *userRepository) UpdateDeletionRequest(req *models.AccountDeletionRequest) error {
+	return r.db.Save(req).Error
+}
+
+func (r *userRepository) GetPendingDeletionRequest(userID string) (*models.AccountDeletionRequest, error) {
+	var req models.AccountDeletionRequest
+	err := r.db.Where("user_id = ? AND status = ?", userID, "pending").First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetDueDeletionRequests 冷静期已结束、等待执行的注销申请
+func (r *userRepository) GetDueDeletionRequests(before time.Time, limit int) ([]models.AccountDeletionRequest, error) {
+	var reqs []models.AccountDeletionRequest
+	err := r.db.Where("status = ? AND scheduled_at <= ?", "pending", before).
+		Order("scheduled_at ASC").
+		Limit(limit).
+		Find(&reqs).Error
+	return reqs, err
+}
+
+// AnonymizeUser 注销账号：抹去个人资料，删除第三方绑定、两步验证、登录记录和关注关系。
+// 用户行保留，避免其他服务中引用该 ID 的数据出现悬空
+func (r *userRepository) AnonymizeUser(userID, username, passwordHash string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":               username,
+			"email":                  nil,
+			"phone":                  nil,
+			"password_hash":          passwordHash,
+			"avatar_url":             nil,
+			"avatar_key":             nil,
+			"avatar_thumbnails":      nil,
+			"nickname":               nil,
+			"bio":                    nil,
+			"gender":                 "other",
+			"birth_date":             nil,
+			"is_phone_verified":      false,
+			"is_email_verified":      false,
+			"phone_verified_at":      nil,
+			"email_verified_at":      nil,
+			"last_third_party_login": nil,
+			"is_active":              false,
+		}).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.ThirdPartyAccount{},
+			&models.UserRecoveryCode{},
+			&models.UserTwoFactor{},
+			&models.LoginLog{},
+			&models.ExperienceLog{},
+			&models.UserPrivacy{},
+			&models.TeenMode{},
+			&models.ReferralCode{},
+			&models.UserPreference{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		// 邀请关系保留给邀请人查看，清除被邀请人的设备和 IP
+		err = tx.Model(&models.Referral{}).Where("invitee_id = ?", userID).
+			Updates(map[string]interface{}{"device_id": nil, "ip_address": nil}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("follower_id = ? OR followee_id = ?", userID, userID).Delete(&models.UserFollow{}).Error
+	})
+}
+
+func (r *userRepository) CreateAccountMerge(merge *models.AccountMerge) error {
+	return r.db.Create(merge).Error
+}
+
+func (r *userRepository) UpdateAccountMerge(merge *models.AccountMerge) error {
+	return r.db.Save(merge).Error
+}
+
+// MergeUsers 账号合并中本服务的部分：第三方绑定转到主账号；关注关系去重后转移，不保留主副账号之间的互相关注；
+// 主账号没有邮箱或手机号时改用副账号的，已有时丢弃副账号的
+func (r *userRepository) MergeUsers(secondaryID, primaryID string, dryRun bool) (map[string]utils.MergeCount, error) {
+	result := make(map[string]utils.MergeCount)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var accounts int64
+		if err := tx.Model(&models.ThirdPartyAccount{}).Where("user_id = ?", secondaryID).Count(&accounts).Error; err != nil {
+			return err
+		}
+		result["third_party_accounts"] = utils.MergeCount{Moved: accounts}
+
+		var follows, primaryFollows []models.UserFollow
+		if err := tx.Where("follower_id = ? OR followee_id = ?", secondaryID, secondaryID).Find(&follows).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("follower_id = ? OR followee_id = ?", primaryID, primaryID).Find(&primaryFollows).Error; err != nil {
+			return err
+		}
+		following := make(map[string]bool)
+		followers := make(map[string]bool)
+		for _, f := range primaryFollows {
+			if f.FollowerID == primaryID {
+				following[f.FolloweeID] = true
+			} else {
+				followers[f.FollowerID] = true
+			}
+		}
+		var moveFollowing, moveFollowers, deleteIDs []string
+		var followingCount, followersCount utils.MergeCount
+		for _, f := range follows {
+			if f.FollowerID == secondaryID {
+				if f.FolloweeID == primaryID || following[f.FolloweeID] {
+					deleteIDs = append(deleteIDs, f.ID)
+					followingCount.Discarded++
+					continue
+				}
+				following[f.FolloweeID] = true
+				moveFollowing = append(moveFollowing, f.ID)
+				followingCount.Moved++
+			} else {
+				if f.FollowerID == primaryID || followers[f.FollowerID] {
+					deleteIDs = append(deleteIDs, f.ID)
+					followersCount.Discarded++
+					continue
+				}
+				followers[f.FollowerID] = true
+				moveFollowers = append(moveFollowers, f.ID)
+				followersCount.Moved++
+			}
+		}
+		result["following"] = followingCount
+		result["followers"] = followersCount
+
+		var primary, secondary models.User
+		if err := tx.Where("id = ?", primaryID).First(&primary).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", secondaryID).First(&secondary).Error; err != nil {
+			return err
+		}
+		contact := make(map[string]interface{})
+		var email, phone utils.MergeCount
+		if secondary.Email != nil {
+			if primary.Email == nil {
+				email.Moved = 1
+				contact["email"] = secondary.Email
+				contact["is_email_verified"] = secondary.IsEmailVerified
+				contact["email_verified_at"] = secondary.EmailVerifiedAt
+			} else {
+				email.Discarded = 1
+			}
+		}
+		if secondary.Phone != nil {
+			if primary.Phone == nil {
+				phone.Moved = 1
+				contact["phone"] = secondary.Phone
+				contact["is_phone_verified"] = secondary.IsPhoneVerified
+				contact["phone_verified_at"] = secondary.PhoneVerifiedAt
+			} else {
+				phone.Discarded = 1
+			}
+		}
+		result["email"] = email
+		result["phone"] = phone
+
+		if dryRun {
+			return nil
+		}
+
+		err := tx.Model(&models.ThirdPartyAccount{}).Where("user_id = ?", secondaryID).
+			Updates(map[string]interface{}{"user_id": primaryID, "is_primary": false}).Error
+		if err != nil {
+			return err
+		}
+		if len(deleteIDs) > 0 {
+			if err := tx.Where("id IN ?", deleteIDs).Delete(&models.UserFollow{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(moveFollowing) > 0 {
+			if err := tx.Model(&models.UserFollow{}).Where("id IN ?", moveFollowing).Update("follower_id", primaryID).Error; err != nil {
+				return err
+			}
+		}
+		if len(moveFollowers) > 0 {
+			if err := tx.Model(&models.UserFollow{}).Where("id IN ?", moveFollowers).Update("followee_id", primaryID).Error; err != nil {
+				return err
+			}
+		}
+		if len(contact) > 0 {
+			// 邮箱和手机号有唯一索引，先从副账号上清除
+			err := tx.Model(&models.User{}).Where("id = ?", secondaryID).
+				Updates(map[string]interface{}{"email": nil, "phone": nil}).Error
+			if err != nil {
+				return err
+			}
+			return tx.Model(&models.User{}).Where("id = ?", primaryID).Updates(contact).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SearchUsers 管理后台按关键字和状态筛选用户
+func (r *userRepository) SearchUsers(q *models.AdminUserQuery) ([]models.User, int64, error) {
+	var users []models.User
+	var total int64
+
+	query := r.db.Model(&models.User{})
+	if keyword := strings.TrimSpace(q.Keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where("id = ? OR username LIKE ? OR nickname LIKE ? OR email LIKE ? OR phone LIKE ?",
+			keyword, like, like, like, like)
+	}
+	now := time.Now()
+	switch q.Status {
+	case "active":
+		query = query.Where("is_active = ? AND (banned_at IS NULL OR banned_until <= ?)", true, now)
+	case "banned":
+		query = query.Where("banned_at IS NOT NULL AND (banned_until IS NULL OR banned_until > ?)", now)
+	case "disabled":
+		query = query.Where("is_active = ?", false)
+	}
+	if q.Role != "" {
+		query = query.Where("role = ?", q.Role)
+	}
+	if q.VipLevel != "" {
+		query = query.Where("vip_level = ?", q.VipLevel)
+	}
+
+	query.Count(&total)
+
+	page, size := q.Page, q.Size
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+	offset := (page - 1) * size
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&users).Error
+	return users, total, err
+}
+
+func (r *userRepository) CreateAdminActionLog(log *models.AdminActionLog) error {
+	return r.db.Create(log).Error
+}
+
+func (r *userRepository) GetAdminActionLogs(targetUserID string, limit int) ([]models.AdminActionLog, error) {
+	var logs []models.AdminActionLog
+	err := r.db.Where("target_user_id = ?", targetUserID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+func (r *userRepository) CreateSigningKey(key *models.JWTSigningKey) error {
+	return r.db.Create(key).Error
+}
+
+// GetValidSigningKeys 尚未过期的签名密钥，最新的在前
+func (r *userRepository) GetValidSigningKeys(now time.Time) ([]models.JWTSigningKey, error) {
+	var keys []models.JWTSigningKey
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// RetireSigningKeys 除指定密钥外，其余仍在签发的密钥全部停用
+func (r *userRepository) RetireSigningKeys(exceptID string, retiredAt, expiresAt time.Time) error {
+	return r.db.Model(&models.JWTSigningKey{}).
+		Where("retired_at IS NULL AND id <> ?", exceptID).
+		Updates(map[string]interface{}{
+			"retired_at": retiredAt,
+			"expires_at": expiresAt,
+		}).Error
+}
+
+func (r *userRepository) DeleteExpiredSigningKeys(before time.Time) error {
+	return r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", before).
+		Delete(&models.JWTSigningKey{}).Error
+}
+
+// AwardExperience 记一次经验并累加到用户。锁定用户行保证每日上限在并发下准确；
+// 该事件已记过时返回 true，log 填充为原记录
+func (r *userRepository) AwardExperience(log *models.ExperienceLog, dailyCap int, dayStart time.Time) (bool, error) {
+	duplicate := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ? AND is_active = ?", log.UserID, true).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		var existing models.ExperienceLog
+		err := tx.Where("user_id = ? AND event = ? AND event_id = ?", log.UserID, log.Event, log.EventID).
+			First(&existing).Error
+		if err == nil {
+			duplicate = true
+			*log = existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if dailyCap > 0 {
+			var earned int
+			if err := tx.Model(&models.ExperienceLog{}).
+				Select("COALESCE(SUM(xp), 0)").
+				Where("user_id = ? AND event = ? AND created_at >= ?", log.UserID, log.Event, dayStart).
+				Scan(&earned).Error; err != nil {
+				return err
+			}
+			if remaining := dailyCap - earned; log.XP > remaining {
+				log.XP = max(remaining, 0)
+			}
+		}
+
+		if err := tx.Create(log).Error; err != nil {
+			return err
+		}
+		if log.XP == 0 {
+			return nil
+		}
+		return tx.Model(&models.User{}).Where("id = ?", log.UserID).
+			Update("experience_points", gorm.Expr("experience_points + ?", log.XP)).Error
+	})
+	return duplicate, err
+}
+
+// RaiseLevel 只升不降，并发加经验时不会被较早计算的等级覆盖
+func (r *userRepository) RaiseLevel(userID string, level int) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND level < ?", userID, level).
+		Update("level", level).Error
+}
+
+// GetDailyExperience 当天各事件已获得的经验
+func (r *userRepository) GetDailyExperience(userID string, dayStart time.Time) (map[string]int, error) {
+	var rows []struct {
+		Event string
+		XP    int
+	}
+	err := r.db.Model(&models.ExperienceLog{}).
+		Select("event, COALESCE(SUM(xp), 0) AS xp").
+		Where("user_id = ? AND created_at >= ?", userID, dayStart).
+		Group("event").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	earned := make(map[string]int, len(rows))
+	for _, row := range rows {
+		earned[row.Event] = row.XP
+	}
+	return earned, nil
+}
+
+func (r *userRepository) HasExperienceEvent(userID, event, eventID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ExperienceLog{}).
+		Where("user_id = ? AND event = ? AND event_id = ?", userID, event, eventID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepository) GetAllExperienceLogs(userID string) ([]models.ExperienceLog, error) {
+	var logs []models.ExperienceLog
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&logs).Error
+	return logs, err
+}
+
+// GetByIDs 批量查询有效用户，不存在或已注销的 ID 直接跳过
+func (r *userRepository) GetByIDs(ids []string) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.db.Where("id IN ? AND is_active = ?", ids, true).Find(&users).Error
+	return users, err
+}
+
+// Follow 重复关注不报错
+func (r *userRepository) Follow(followerID, followeeID string) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserFollow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}).Error
+}
+
+func (r *userRepository) Unfollow(followerID, followeeID string) error {
+	return r.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.UserFollow{}).Error
+}
+
+func (r *userRepository) IsFollowing(followerID, followeeID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UserFollow{}).
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetFollowingAmong followeeIDs 中哪些已被 followerID 关注
+func (r *userRepository) GetFollowingAmong(followerID string, followeeIDs []string) (map[string]bool, error) {
+	following := make(map[string]bool, len(followeeIDs))
+	if len(followeeIDs) == 0 {
+		return following, nil
+	}
+	var ids []string
+	err := r.db.Model(&models.UserFollow{}).
+		Where("follower_id = ? AND followee_id IN ?", followerID, followeeIDs).
+		Pluck("followee_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		following[id] = true
+	}
+	return following, nil
+}
+
+func (r *userRepository) CountFollows(userID string) (followers, following int64, err error) {
+	if err = r.db.Model(&models.UserFollow{}).Where("followee_id = ?", userID).Count(&followers).Error; err != nil {
+		return
+	}
+	err = r.db.Model(&models.UserFollow{}).Where("follower_id = ?", userID).Count(&following).Error
+	return
+}
+
+// GetFollowers 粉丝列表，按关注时间倒序
+func (r *userRepository) GetFollowers(userID string, page, size int) ([]models.UserFollow, int64, error) {
+	return r.pageFollows(r.db.Model(&models.UserFollow{}).Where("followee_id = ?", userID), page, size)
+}
+
+// GetFollowing 关注列表，按关注时间倒序
+func (r *userRepository) GetFollowing(userID string, page, size int) ([]models.UserFollow, int64, error) {
+	return r.pageFollows(r.db.Model(&models.UserFollow{}).Where("follower_id = ?", userID), page, size)
+}
+
+func (r *userRepository) pageFollows(query *gorm.DB, page, size int) ([]models.UserFollow, int64, error) {
+	var follows []models.UserFollow
+	var total int64
+
+	query.Count(&total)
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+	offset := (page - 1) * size
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&follows).Error
+	return follows, total, err
+}
+
+// GetFollowingIDs 最近关注的 limit 个用户，用于生成动态
+func (r *userRepository) GetFollowingIDs(userID string, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.Model(&models.UserFollow{}).
+		Where("follower_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Pluck("followee_id", &ids).Error
+	return ids, err
+}
+
+// GetAllFollowing 全部关注，用于个人数据导出
+func (r *userRepository) GetAllFollowing(userID string) ([]models.UserFollow, error) {
+	var follows []models.UserFollow
+	err := r.db.Where("follower_id = ?", userID).Order("created_at ASC").Find(&follows).Error
+	return follows, err
+}
+
+// GetPrivacy 没有设置过时返回默认值
+func (r *userRepository) GetPrivacy(userID string) (*models.UserPrivacy, error) {
+	var privacy models.UserPrivacy
+	err := r.db.Where("user_id = ?", userID).First(&privacy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.NewUserPrivacy(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &privacy, nil
+}
+
+// GetPrivacies 批量查询隐私设置，没有设置过的用户使用默认值
+func (r *userRepository) GetPrivacies(userIDs []string) (map[string]*models.UserPrivacy, error) {
+	privacies := make(map[string]*models.UserPrivacy, len(userIDs))
+	if len(userIDs) == 0 {
+		return privacies, nil
+	}
+	var rows []models.UserPrivacy
+	if err := r.db.Where("user_id IN ?", userIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		privacies[rows[i].UserID] = &rows[i]
+	}
+	for _, id := range userIDs {
+		if privacies[id] == nil {
+			privacies[id] = models.NewUserPrivacy(id)
+		}
+	}
+	return privacies, nil
+}
+
+func (r *userRepository) SavePrivacy(privacy *models.UserPrivacy) error {
+	return r.db.Save(privacy).Error
+}
+
+// GetTeenMode 没有记录时返回空设置（未开启、没有监护密码）
+func (r *userRepository) GetTeenMode(userID string) (*models.TeenMode, error) {
+	var mode models.TeenMode
+	err := r.db.Where("user_id = ?", userID).First(&mode).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.TeenMode{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mode, nil
+}
+
+func (r *userRepository) SaveTeenMode(mode *models.TeenMode) error {
+	return r.db.Save(mode).Error
+}
+
+// GetPreferences 指定设备的阅读偏好，空字符串为账号级设置；没有记录的设备不返回
+func (r *userRepository) GetPreferences(userID string, deviceIDs []string) ([]models.UserPreference, error) {
+	var prefs []models.UserPreference
+	err := r.db.Where("user_id = ? AND device_id IN ?", userID, deviceIDs).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *userRepository) GetAllPreferences(userID string) ([]models.UserPreference, error) {
+	var prefs []models.UserPreference
+	err := r.db.Where("user_id = ?", userID).Order("device_id").Find(&prefs).Error
+	return prefs, err
+}
+
+// UpdatePreference 锁定用户行后读取、修改并保存阅读偏好，没有记录时从空文档开始，
+// 保证多个设备同时提交时按项合并而不是互相覆盖
+func (r *userRepository) UpdatePreference(userID, deviceID string, fn func(pref *models.UserPreference) error) (*models.UserPreference, error) {
+	var pref models.UserPreference
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ? AND is_active = ?", userID, true).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("user_id = ? AND device_id = ?", userID, deviceID).First(&pref).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			pref = models.UserPreference{UserID: userID, DeviceID: deviceID}
+		} else if err != nil {
+			return err
+		}
+		if err := fn(&pref); err != nil {
+			return err
+		}
+		return tx.Save(&pref).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pref, nil
+}
+
+func (r *userRepository) GetReferralCode(userID string) (*models.ReferralCode, error) {
+	var code models.ReferralCode
+	if err := r.db.Where("user_id = ?", userID).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *userRepository) GetReferralCodeByCode(code string) (*models.ReferralCode, error) {
+	var referralCode models.ReferralCode
+	if err := r.db.Where("code = ?", code).First(&referralCode).Error; err != nil {
+		return nil, err
+	}
+	return &referralCode, nil
+}
+
+func (r *userRepository) CreateReferralCode(code *models.ReferralCode) error {
+	return r.db.Create(code).Error
+}
+
+func (r *userRepository) CreateReferral(referral *models.Referral) error {
+	return r.db.Create(referral).Error
+}
+
+func (r *userRepository) GetReferralByInvitee(inviteeID string) (*models.Referral, error) {
+	var referral models.Referral
+	if err := r.db.Where("invitee_id = ?", inviteeID).First(&referral).Error; err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// HasReferralFromDevice 该设备是否已注册过被邀请账号
+func (r *userRepository) HasReferralFromDevice(deviceID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Referral{}).Where("device_id = ?", deviceID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// CountReferralsFromIP since 之后邀请人从该 IP 邀请到的有效注册数
+func (r *userRepository) CountReferralsFromIP(inviterID, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Referral{}).
+		Where("inviter_id = ? AND ip_address = ? AND status = ? AND created_at >= ?", inviterID, ip, models.ReferralStatusValid, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) CountReferrals(inviterID string) (total, valid int64, err error) {
+	query := r.db.Model(&models.Referral{}).Where("inviter_id = ?", inviterID)
+	if err = query.Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	err = r.db.Model(&models.Referral{}).
+		Where("inviter_id = ? AND status = ?", inviterID, models.ReferralStatusValid).
+		Count(&valid).Error
+	return total, valid, err
+}
+
+// GetReferrals 邀请的用户，按注册时间倒序
+func (r *userRepository) GetReferrals(inviterID string, page, size int) ([]models.Referral, int64, error) {
+	var referrals []models.Referral
+	var total int64
+
+	query := r.db.Model(&models.Referral{}).Where("inviter_id = ?", inviterID)
+	query.Count(&total)
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 20
+	}
+	offset := (page - 1) * size
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(size).Find(&referrals).Error
+	return referrals, total, err
+}
+
+// GetAllReferrals 全部邀请记录，用于个人数据导出
+func (r *userRepository) GetAllReferrals(inviterID string) ([]models.Referral, error) {
+	var referrals []models.Referral
+	err := r.db.Where("inviter_id = ?", inviterID).Order("created_at DESC").Find(&referrals).Error
+	return referrals, err
+}
+
+// CreateReferralRewards 已存在的里程碑奖励跳过，重复达到里程碑不会重复记奖励
+func (r *userRepository) CreateReferralRewards(rewards []models.ReferralReward) error {
+	if len(rewards) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rewards).Error
+}
+
+func (r *userRepository) GetReferralRewards(referralID string) ([]models.ReferralReward, error) {
+	var rewards []models.ReferralReward
+	err := r.db.Where("referral_id = ?", referralID).Order("created_at").Find(&rewards).Error
+	return rewards, err
+}
+
+// GetUserReferralRewards 用户获得的邀请奖励，referralIDs 为空时返回全部
+func (r *userRepository) GetUserReferralRewards(userID string, referralIDs []string) ([]models.ReferralReward, error) {
+	var rewards []models.ReferralReward
+	query := r.db.Where("user_id = ?", userID)
+	if len(referralIDs) > 0 {
+		query = query.Where("referral_id IN ?", referralIDs)
+	}
+	err := query.Order("created_at").Find(&rewards).Error
+	return rewards, err
+}
+
+func (r *userRepository) MarkReferralRewardGranted(id string, grantedAt time.Time) error {
+	return r.db.Model(&models.ReferralReward{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": models.ReferralRewardGranted, "granted_at": grantedAt}).Error
 }
@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/accountdata"
+	auth "reading-microservices/user-service/services/auth"
+)
+
+var (
+	ErrExportNotFound     = errors.New("data export not found")
+	ErrExportNotReady     = errors.New("data export is not ready")
+	ErrExportExpired      = errors.New("data export has expired")
+	ErrDeletionPending    = errors.New("account deletion already requested")
+	ErrNoDeletionRequest  = errors.New("no pending account deletion request")
+	ErrDeletionPassword   = errors.New("invalid password")
+	ErrAccountDeactivated = errors.New("account has been deleted")
+)
+
+// 导出任务超过该时长仍未结束视为中断（如服务重启），允许重新发起
+const exportStaleAfter = time.Hour
+
+// 每轮最多处理的注销申请数
+const deletionBatchSize = 50
+
+// AccountDataService 个人数据导出与账号注销。
+// 用户数据分散在各服务，这里通过各服务的内部接口汇总导出或逐一删除
+type AccountDataService struct {
+	userRepo       repositories.UserRepository
+	authManager    *auth.AuthManager
+	sessionManager *auth.SessionManager
+	avatars        *AvatarService
+	clients        []*accountdata.ServiceClient
+	exportDir      string
+	exportTTL      time.Duration
+	gracePeriod    time.Duration
+	interval       time.Duration
+}
+
+func NewAccountDataService(
+	userRepo repositories.UserRepository,
+	authManager *auth.AuthManager,
+	sessionManager *auth.SessionManager,
+	avatars *AvatarService,
+	clients []*accountdata.ServiceClient,
+	cfg config.AccountDataConfig,
+) *AccountDataService {
+	return &AccountDataService{
+		userRepo:       userRepo,
+		authManager:    authManager,
+		sessionManager: sessionManager,
+		avatars:        avatars,
+		clients:        clients,
+		exportDir:      cfg.ExportDir,
+		exportTTL:      time.Duration(cfg.ExportTTL) * time.Second,
+		gracePeriod:    time.Duration(cfg.DeletionGraceDays) * 24 * time.Hour,
+		interval:       time.Duration(cfg.WorkerInterval) * time.Second,
+	}
+}
+
+// ------------------- 数据导出 -------------------
+
+// RequestExport 发起导出，已有进行中的任务时直接返回该任务
+func (s *AccountDataService) RequestExport(userID string) (*models.DataExportJob, error) {
+	job, err := s.userRepo.GetActiveExportJob(userID)
+	if err == nil {
+		if time.Since(job.CreatedAt) < exportStaleAfter {
+			return job, nil
+		}
+		s.failExport(job, errors.New("export interrupted"))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	job = &models.DataExportJob{UserID: userID, Status: "pending"}
+	if err := s.userRepo.CreateExportJob(job); err != nil {
+		return nil, err
+	}
+	go s.runExport(job)
+	return job, nil
+}
+
+// ExportStatus 导出任务状态
+func (s *AccountDataService) ExportStatus(userID, jobID string) (*models.DataExportJob, error) {
+	job, err := s.userRepo.GetExportJob(userID, jobID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportNotFound
+	}
+	return job, err
+}
+
+// ExportFile 已完成且未过期的导出文件路径
+func (s *AccountDataService) ExportFile(userID, jobID string) (string, error) {
+	job, err := s.ExportStatus(userID, jobID)
+	if err != nil {
+		return "", err
+	}
+	if job.Status != "completed" || job.FilePath == nil {
+		return "", ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(time.Now()) {
+		return "", ErrExportExpired
+	}
+	return *job.FilePath, nil
+}
+
+// runExport 收集本服务和各服务的数据写入压缩包，任一服务失败则整个任务失败
+func (s *AccountDataService) runExport(job *models.DataExportJob) {
+	job.Status = "running"
+	if err := s.userRepo.UpdateExportJob(job); err != nil {
+		log.Printf("warning: start data export %s failed: %v", job.ID, err)
+		return
+	}
+
+	files, err := s.collectUserData(job.UserID)
+	if err != nil {
+		s.failExport(job, err)
+		return
+	}
+
+	path := filepath.Join(s.exportDir, job.ID+".zip")
+	size, err := accountdata.WriteArchive(path, files)
+	if err != nil {
+		s.failExport(job, err)
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.exportTTL)
+	job.Status = "completed"
+	job.FilePath = &path
+	job.FileSize = size
+	job.CompletedAt = &now
+	job.ExpiresAt = &expiresAt
+	if err := s.userRepo.UpdateExportJob(job); err != nil {
+		log.Printf("warning: complete data export %s failed: %v", job.ID, err)
+	}
+}
+
+func (s *AccountDataService) collectUserData(userID string) (map[string]interface{}, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.userRepo.GetUserThirdPartyAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.userRepo.GetAllLoginLogs(userID)
+	if err != nil {
+		return nil, err
+	}
+	experience, err := s.userRepo.GetAllExperienceLogs(userID)
+	if err != nil {
+		return nil, err
+	}
+	following, err := s.userRepo.GetAllFollowing(userID)
+	if err != nil {
+		return nil, err
+	}
+	privacy, err := s.userRepo.GetPrivacy(userID)
+	if err != nil {
+		return nil, err
+	}
+	teenMode, err := s.userRepo.GetTeenMode(userID)
+	if err != nil {
+		return nil, err
+	}
+	invitees, err := s.userRepo.GetAllReferrals(userID)
+	if err != nil {
+		return nil, err
+	}
+	referralRewards, err := s.userRepo.GetUserReferralRewards(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 没有邀请码或不是被邀请注册时为 null
+	referralCode, err := s.userRepo.GetReferralCode(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	invitedBy, err := s.userRepo.GetReferralByInvitee(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	preferences, err := s.userRepo.GetAllPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]interface{}{
+		"user": map[string]interface{}{
+			"profile":              user,
+			"third_party_accounts": accounts,
+			"login_history":        logs,
+			"experience_history":   experience,
+			"following":            following,
+			"privacy":              privacy,
+			"teen_mode":            teenMode,
+			"referral_code":        referralCode,
+			"invited_by":           invitedBy,
+			"invitees":             invitees,
+			"referral_rewards":     referralRewards,
+			"preferences":          preferences,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportStaleAfter)
+	defer cancel()
+	for _, client := range s.clients {
+		data, err := client.Export(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		files[client.Name()] = data
+	}
+	return files, nil
+}
+
+func (s *AccountDataService) failExport(job *models.DataExportJob, cause error) {
+	message := truncate(cause.Error(), 500)
+	job.Status = "failed"
+	job.ErrorMessage = &message
+	if err := s.userRepo.UpdateExportJob(job); err != nil {
+		log.Printf("warning: mark data export %s failed: %v", job.ID, err)
+	}
+}
+
+// CleanupExpiredExports 删除过期的导出文件
+func (s *AccountDataService) CleanupExpiredExports() {
+	jobs, err := s.userRepo.GetExpiredExportJobs(time.Now())
+	if err != nil {
+		log.Printf("warning: list expired data exports failed: %v", err)
+		return
+	}
+	for i := range jobs {
+		s.removeExport(&jobs[i])
+	}
+}
+
+func (s *AccountDataService) removeExport(job *models.DataExportJob) {
+	if job.FilePath != nil {
+		if err := os.Remove(*job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("warning: remove data export file %s failed: %v", *job.FilePath, err)
+			return
+		}
+	}
+	if err := s.userRepo.DeleteExportJob(job.ID); err != nil {
+		log.Printf("warning: delete data export %s failed: %v", job.ID, err)
+	}
+}
+
+// ------------------- 账号注销 -------------------
+
+// RequestDeletion 申请注销，冷静期结束后执行；密码注册的账号需要验证密码
+func (s *AccountDataService) RequestDeletion(userID string, req *models.RequestAccountDeletionRequest) (*models.AccountDeletionRequest, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDeactivated
+	}
+	if user.LoginType == "password" {
+		if err := s.authManager.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+			return nil, ErrDeletionPassword
+		}
+	}
+
+	if _, err := s.userRepo.GetPendingDeletionRequest(userID); err == nil {
+		return nil, ErrDeletionPending
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	deletion := &models.AccountDeletionRequest{
+		UserID:      userID,
+		Status:      "pending",
+		Reason:      optionalString(strings.TrimSpace(req.Reason)),
+		ScheduledAt: time.Now().Add(s.gracePeriod),
+	}
+	if err := s.userRepo.CreateDeletionRequest(deletion); err != nil {
+		return nil, err
+	}
+	return deletion, nil
+}
+
+// DeletionStatus 进行中的注销申请
+func (s *AccountDataService) DeletionStatus(userID string) (*models.AccountDeletionRequest, error) {
+	deletion, err := s.userRepo.GetPendingDeletionRequest(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoDeletionRequest
+	}
+	return deletion, err
+}
+
+// CancelDeletion 冷静期内撤销注销
+func (s *AccountDataService) CancelDeletion(userID string) error {
+	deletion, err := s.DeletionStatus(userID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	deletion.Status = "cancelled"
+	deletion.CancelledAt = &now
+	return s.userRepo.UpdateDeletionRequest(deletion)
+}
+
+// ProcessDueDeletions 执行冷静期已结束的注销申请，失败的留到下一轮重试
+func (s *AccountDataService) ProcessDueDeletions(ctx context.Context) {
+	deletions, err := s.userRepo.GetDueDeletionRequests(time.Now(), deletionBatchSize)
+	if err != nil {
+		log.Printf("warning: list due account deletions failed: %v", err)
+		return
+	}
+	for i := range deletions {
+		deletion := &deletions[i]
+		if err := s.eraseAccount(ctx, deletion.UserID); err != nil {
+			message := truncate(err.Error(), 500)
+			deletion.Attempts++
+			deletion.LastError = &message
+			log.Printf("warning: account deletion for user %s failed (attempt %d): %v", deletion.UserID, deletion.Attempts, err)
+		} else {
+			now := time.Now()
+			deletion.Status = "completed"
+			deletion.CompletedAt = &now
+			deletion.LastError = nil
+		}
+		if err := s.userRepo.UpdateDeletionRequest(deletion); err != nil {
+			log.Printf("warning: update account deletion %s failed: %v", deletion.ID, err)
+		}
+	}
+}
+
+// eraseAccount 先删除各服务的数据，再匿名化本服务的账号并下线所有设备。
+// 各步骤均可重复执行，中途失败后重试不会出错
+func (s *AccountDataService) eraseAccount(ctx context.Context, userID string) error {
+	for _, client := range s.clients {
+		if err := client.Delete(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	jobs, err := s.userRepo.GetUserExportJobs(userID)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		s.removeExport(&jobs[i])
+	}
+
+	// 重试时账号可能已匿名化，查不到用户说明头像已处理过
+	if err := s.avatars.Remove(ctx, userID); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		return err
+	}
+	passwordHash, err := s.authManager.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	username := "deleted_" + strings.ReplaceAll(userID, "-", "")
+	if err := s.userRepo.AnonymizeUser(userID, username, passwordHash); err != nil {
+		return err
+	}
+	return s.sessionManager.InvalidateAllUserSessions(userID, true)
+}
+
+// Run 后台定期执行到期的注销申请并清理过期导出文件，ctx 取消后退出
+func (s *AccountDataService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.ProcessDueDeletions(ctx)
+		s.CleanupExpiredExports()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate random password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/accountdata"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/sender"
+)
+
+var (
+	ErrMergeTokenInvalid     = errors.New("invalid secondary account token")
+	ErrMergeSameAccount      = errors.New("cannot merge an account into itself")
+	ErrMergePlatformConflict = errors.New("both accounts are linked to the same platform, unlink it from one account first")
+	ErrMergeProofRequired    = errors.New("password of the secondary account or a verification code sent to it is required")
+	ErrMergeProofInvalid     = errors.New("invalid password or verification code for the secondary account")
+	ErrMergeNoContact        = errors.New("secondary account has no verified email or phone, use its password instead")
+)
+
+// AccountMergeService 合并同一个人的两个账号（如先用密码注册、后来又用微信登录）：
+// 副账号的第三方绑定、关注关系和各服务中的数据转入当前登录的主账号，之后注销副账号。
+// 用户需要先登录副账号，用副账号的 access token 预览；执行合并时还要提供副账号的密码
+// 或发到副账号已验证邮箱、手机号的验证码，token 泄露时不能直接把账号并走
+type AccountMergeService struct {
+	userRepo       repositories.UserRepository
+	authManager    *auth.AuthManager
+	sessionManager *auth.SessionManager
+	loginGuard     *auth.LoginGuard
+	codes          *auth.CodeManager
+	emailSender    sender.EmailSender
+	smsSender      sender.SMSSender
+	avatars        *AvatarService
+	clients        []*accountdata.ServiceClient
+	resendAfter    int
+}
+
+func NewAccountMergeService(
+	userRepo repositories.UserRepository,
+	authManager *auth.AuthManager,
+	sessionManager *auth.SessionManager,
+	loginGuard *auth.LoginGuard,
+	codes *auth.CodeManager,
+	emailSender sender.EmailSender,
+	smsSender sender.SMSSender,
+	avatars *AvatarService,
+	clients []*accountdata.ServiceClient,
+	verificationCfg config.VerificationConfig,
+) *AccountMergeService {
+	return &AccountMergeService{
+		userRepo:       userRepo,
+		authManager:    authManager,
+		sessionManager: sessionManager,
+		loginGuard:     loginGuard,
+		codes:          codes,
+		emailSender:    emailSender,
+		smsSender:      smsSender,
+		avatars:        avatars,
+		clients:        clients,
+		resendAfter:    verificationCfg.ResendInterval,
+	}
+}
+
+// SendCode 向副账号已验证的邮箱（优先）或手机号发送合并验证码
+func (s *AccountMergeService) SendCode(ctx context.Context, primaryID string, req *models.SendMergeCodeRequest) (*models.SendCodeResponse, error) {
+	_, secondary, err := s.accounts(primaryID, req.SecondaryToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var target string
+	switch {
+	case secondary.IsEmailVerified && stringValue(secondary.Email) != "":
+		target = maskEmail(*secondary.Email)
+	case secondary.IsPhoneVerified && stringValue(secondary.Phone) != "":
+		target = maskPhone(*secondary.Phone)
+	default:
+		return nil, ErrMergeNoContact
+	}
+
+	// 按副账号 ID 签发，执行合并时不需要再指定发送渠道
+	code, err := s.codes.Issue(ctx, auth.PurposeAccountMerge, secondary.ID)
+	if err != nil {
+		return nil, err
+	}
+	minutes := int(s.codes.TTL().Minutes())
+	if secondary.IsEmailVerified && stringValue(secondary.Email) != "" {
+		body := fmt.Sprintf("您正在把账号 %s 合并到另一个账号，合并后该账号将被注销。验证码是 %s，%d 分钟内有效。如非本人操作请立即修改密码。",
+			secondary.Username, code, minutes)
+		err = s.emailSender.SendEmail(ctx, *secondary.Email, "账号合并", body)
+	} else {
+		content := fmt.Sprintf("您正在合并账号，合并后该账号将被注销。验证码是 %s，%d 分钟内有效，请勿泄露。", code, minutes)
+		err = s.smsSender.SendSMS(ctx, *secondary.Phone, content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("send verification code failed: %w", err)
+	}
+
+	return &models.SendCodeResponse{
+		Target:      target,
+		ExpiresIn:   int(s.codes.TTL().Seconds()),
+		ResendAfter: s.resendAfter,
+	}, nil
+}
+
+// Preview 各服务将转移和丢弃的数据条数，不修改数据
+func (s *AccountMergeService) Preview(ctx context.Context, primaryID string, req *models.MergeAccountRequest) (*models.AccountMergePreview, error) {
+	primary, secondary, err := s.accounts(primaryID, req.SecondaryToken)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.merge(ctx, secondary.ID, primary.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AccountMergePreview{
+		Primary:   mergeUserSummary(primary),
+		Secondary: mergeUserSummary(secondary),
+		Summary:   summary,
+	}, nil
+}
+
+// Merge 执行合并。先转移各服务的数据，再转移本服务的数据并注销副账号；
+// 中途失败时副账号保持可用，用同样的请求重试即可，已转移的数据不会重复计入
+func (s *AccountMergeService) Merge(ctx context.Context, primaryID string, req *models.MergeAccountRequest) (*models.AccountMerge, error) {
+	primary, secondary, err := s.accounts(primaryID, req.SecondaryToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyOwnership(ctx, secondary, req); err != nil {
+		return nil, err
+	}
+
+	merge := &models.AccountMerge{
+		PrimaryUserID:   primary.ID,
+		SecondaryUserID: secondary.ID,
+		Status:          "running",
+	}
+	if err := s.userRepo.CreateAccountMerge(merge); err != nil {
+		return nil, err
+	}
+
+	merge.Summary, err = s.merge(ctx, secondary.ID, primary.ID, false)
+	if err == nil {
+		err = s.closeSecondary(ctx, secondary.ID)
+	}
+	if err != nil {
+		message := truncate(err.Error(), 500)
+		merge.Status = "failed"
+		merge.LastError = &message
+		if updateErr := s.userRepo.UpdateAccountMerge(merge); updateErr != nil {
+			log.Printf("warning: mark account merge %s failed: %v", merge.ID, updateErr)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	merge.Status = "completed"
+	merge.CompletedAt = &now
+	if err := s.userRepo.UpdateAccountMerge(merge); err != nil {
+		log.Printf("warning: complete account merge %s failed: %v", merge.ID, err)
+	}
+	return merge, nil
+}
+
+// accounts 校验副账号 token 对应仍在登录状态的会话，并检查两个账号能否合并
+func (s *AccountMergeService) accounts(primaryID, secondaryToken string) (*models.User, *models.User, error) {
+	claims, err := s.authManager.VerifyToken(secondaryToken)
+	if err != nil {
+		return nil, nil, ErrMergeTokenInvalid
+	}
+	session, err := s.sessionManager.GetSession(secondaryToken, true)
+	if err != nil || session.UserID != claims.UserID {
+		return nil, nil, ErrMergeTokenInvalid
+	}
+	if claims.UserID == primaryID {
+		return nil, nil, ErrMergeSameAccount
+	}
+
+	primary, err := s.userRepo.GetByID(primaryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, err
+	}
+	secondary, err := s.userRepo.GetByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrMergeTokenInvalid
+		}
+		return nil, nil, err
+	}
+	// 副账号被封禁或锁定时不允许通过合并转移数据
+	if err := auth.CheckAccountStatus(secondary); err != nil {
+		return nil, nil, ErrMergeTokenInvalid
+	}
+
+	// 每个平台只能绑定一个账号，两边都绑定了同一平台时需要用户先解绑一个
+	primaryAccounts, err := s.userRepo.GetUserThirdPartyAccounts(primary.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	secondaryAccounts, err := s.userRepo.GetUserThirdPartyAccounts(secondary.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	linked := make(map[string]bool, len(primaryAccounts))
+	for _, account := range primaryAccounts {
+		linked[account.Platform] = true
+	}
+	for _, account := range secondaryAccounts {
+		if linked[account.Platform] {
+			return nil, nil, ErrMergePlatformConflict
+		}
+	}
+	return primary, secondary, nil
+}
+
+// verifyOwnership 校验副账号的密码或合并验证码。密码错误计入副账号的登录失败次数，
+// 与登录共用退避和锁定；验证码连续输错后作废
+func (s *AccountMergeService) verifyOwnership(ctx context.Context, secondary *models.User, req *models.MergeAccountRequest) error {
+	switch {
+	case req.Password != "":
+		if err := s.loginGuard.Check(ctx, secondary.Username, req.IPAddress); err != nil {
+			return err
+		}
+		if err := s.authManager.VerifyPassword(secondary.PasswordHash, req.Password); err != nil {
+			s.loginGuard.RecordFailure(ctx, secondary.Username, req.IPAddress)
+			return ErrMergeProofInvalid
+		}
+		s.loginGuard.RecordSuccess(ctx, secondary.Username)
+		return nil
+	case req.Code != "":
+		if err := s.codes.Verify(ctx, auth.PurposeAccountMerge, secondary.ID, req.Code); err != nil {
+			if errors.Is(err, auth.ErrCodeInvalid) || errors.Is(err, auth.ErrCodeExpired) {
+				return ErrMergeProofInvalid
+			}
+			return err
+		}
+		return nil
+	default:
+		return ErrMergeProofRequired
+	}
+}
+
+// merge 依次转移各服务和本服务的数据，返回各部分的统计，本服务的统计放在 user 下
+func (s *AccountMergeService) merge(ctx context.Context, secondaryID, primaryID string, dryRun bool) (map[string]json.RawMessage, error) {
+	summary := make(map[string]json.RawMessage, len(s.clients)+1)
+	for _, client := range s.clients {
+		data, err := client.Merge(ctx, secondaryID, primaryID, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		summary[client.Name()] = data
+	}
+
+	local, err := s.userRepo.MergeUsers(secondaryID, primaryID, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(local)
+	if err != nil {
+		return nil, err
+	}
+	summary["user"] = data
+	return summary, nil
+}
+
+// closeSecondary 数据已全部转出，删除副账号头像、匿名化并下线所有设备
+func (s *AccountMergeService) closeSecondary(ctx context.Context, userID string) error {
+	if err := s.avatars.Remove(ctx, userID); err != nil && !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	password, err := randomPassword()
+	if err != nil {
+		return err
+	}
+	passwordHash, err := s.authManager.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	username := "merged_" + strings.ReplaceAll(userID, "-", "")
+	if err := s.userRepo.AnonymizeUser(userID, username, passwordHash); err != nil {
+		return err
+	}
+	return s.sessionManager.InvalidateAllUserSessions(userID, true)
+}
+
+func mergeUserSummary(user *models.User) models.UserSummary {
+	return models.UserSummary{
+		ID:               user.ID,
+		Username:         user.Username,
+		Nickname:         user.Nickname,
+		AvatarURL:        user.AvatarURL,
+		AvatarThumbnails: user.AvatarThumbnails,
+	}
+}
@@ -0,0 +1,102 @@
+package accountdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"reading-microservices/shared/utils"
+)
+
+// ServiceClient 调用其他服务的内部接口导出、删除或合并某个用户的数据
+type ServiceClient struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewServiceClients 按服务名排序，保证导出文件和删除顺序稳定
+func NewServiceClients(services map[string]string, token string, timeout time.Duration) []*ServiceClient {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	clients := make([]*ServiceClient, 0, len(names))
+	for _, name := range names {
+		clients = append(clients, &ServiceClient{
+			name:    name,
+			baseURL: strings.TrimRight(services[name], "/"),
+			client:  utils.NewInternalClient(token, timeout),
+		})
+	}
+	return clients
+}
+
+func (c *ServiceClient) Name() string {
+	return c.name
+}
+
+// Export 返回该服务中用户数据的原始 JSON
+func (c *ServiceClient) Export(ctx context.Context, userID string) (json.RawMessage, error) {
+	return c.do(ctx, http.MethodGet, userID, "data", nil)
+}
+
+// Delete 删除该服务中的用户数据，重复调用是安全的
+func (c *ServiceClient) Delete(ctx context.Context, userID string) error {
+	_, err := c.do(ctx, http.MethodDelete, userID, "data", nil)
+	return err
+}
+
+// Merge 把 sourceID 的数据转移到 targetID，返回各类数据转移和丢弃的条数；dryRun 时只统计。重复调用是安全的
+func (c *ServiceClient) Merge(ctx context.Context, sourceID, targetID string, dryRun bool) (json.RawMessage, error) {
+	return c.do(ctx, http.MethodPost, sourceID, "merge", &utils.MergeUserDataRequest{
+		TargetUserID: targetID,
+		DryRun:       dryRun,
+	})
+}
+
+func (c *ServiceClient) do(ctx context.Context, method, userID, action string, payload interface{}) (json.RawMessage, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/internal/%s/users/%s/%s", c.baseURL, c.name, url.PathEscape(userID), action)
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s service: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%s service: status %d: %w", c.name, resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != 0 {
+		return nil, fmt.Errorf("%s service: %s", c.name, result.Message)
+	}
+	return result.Data, nil
+}
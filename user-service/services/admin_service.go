@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrAdminSelf         = errors.New("cannot perform this action on your own account")
+	ErrAdminInsufficient = errors.New("insufficient permission for this user")
+	ErrNotAdmin          = errors.New("admin permission required")
+)
+
+// roleRank 角色等级，只能管理等级比自己低的用户
+var roleRank = map[string]int{"user": 0, "moderator": 1, "admin": 2}
+
+// 用户详情中展示的管理操作条数
+const recentAdminActions = 20
+
+// AdminService 管理后台的用户管理：查询、封禁、强制下线、重置密码和调整角色
+type AdminService struct {
+	userRepo       repositories.UserRepository
+	userService    *UserService
+	authManager    *auth.AuthManager
+	sessionManager *auth.SessionManager
+	loginGuard     *auth.LoginGuard
+}
+
+func NewAdminService(
+	userRepo repositories.UserRepository,
+	userService *UserService,
+	authManager *auth.AuthManager,
+	sessionManager *auth.SessionManager,
+	loginGuard *auth.LoginGuard,
+) *AdminService {
+	return &AdminService{
+		userRepo:       userRepo,
+		userService:    userService,
+		authManager:    authManager,
+		sessionManager: sessionManager,
+		loginGuard:     loginGuard,
+	}
+}
+
+// Role 当前角色，以数据库为准，token 中的角色可能已过时；被封禁或停用的账号视为无权限。
+// 管理员和审核员未开启两步验证时返回 ErrTwoFactorRequired，开启后才能使用管理后台
+func (s *AdminService) Role(userID string) (string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return "", ErrNotAdmin
+	}
+	if err := auth.CheckAccountStatus(user); err != nil {
+		return "", ErrNotAdmin
+	}
+	if roleRank[user.Role] > 0 && s.userService.twoFactor != nil {
+		if err := s.userService.twoFactor.RequireEnabled(user); err != nil {
+			if errors.Is(err, ErrTwoFactorRequired) {
+				return "", err
+			}
+			log.Printf("warning: check two-factor for admin %s failed: %v", user.ID, err)
+			return "", ErrNotAdmin
+		}
+	}
+	return user.Role, nil
+}
+
+// ------------------- 查询 -------------------
+
+// SearchUsers 按关键字、状态、角色和 VIP 等级筛选用户
+func (s *AdminService) SearchUsers(query *models.AdminUserQuery) ([]*models.AdminUserItem, int64, error) {
+	users, total, err := s.userRepo.SearchUsers(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	items := make([]*models.AdminUserItem, 0, len(users))
+	for i := range users {
+		u := &users[i]
+		items = append(items, &models.AdminUserItem{
+			ID:          u.ID,
+			Username:    u.Username,
+			Nickname:    u.Nickname,
+			Email:       u.Email,
+			Phone:       u.Phone,
+			Role:        u.Role,
+			VipLevel:    u.VipLevel,
+			LoginType:   u.LoginType,
+			IsActive:    u.IsActive,
+			IsBanned:    isBanned(u, now),
+			BannedUntil: u.BannedUntil,
+			LastLoginAt: u.LastLoginAt,
+			CreatedAt:   u.CreatedAt,
+		})
+	}
+	return items, total, nil
+}
+
+// GetUser 用户详情，含封禁信息和最近的管理操作
+func (s *AdminService) GetUser(userID string) (*models.AdminUserDetail, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &models.AdminUserDetail{
+		UserInfo:    *convertToUserInfo(user),
+		Role:        user.Role,
+		LoginType:   user.LoginType,
+		IsActive:    user.IsActive,
+		IsBanned:    isBanned(user, time.Now()),
+		BanReason:   user.BanReason,
+		BannedAt:    user.BannedAt,
+		BannedUntil: user.BannedUntil,
+		LastLoginAt: user.LastLoginAt,
+		CreatedAt:   user.CreatedAt,
+	}
+	if twoFactor, err := s.userRepo.GetTwoFactor(userID); err == nil {
+		detail.TwoFactorEnabled = twoFactor.IsEnabled
+	}
+	actions, err := s.userRepo.GetAdminActionLogs(userID, recentAdminActions)
+	if err != nil {
+		return nil, err
+	}
+	detail.RecentActions = actions
+	return detail, nil
+}
+
+// ListSessions 用户当前在线的设备
+func (s *AdminService) ListSessions(userID string) ([]*models.DeviceInfo, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, err
+	}
+	return s.userService.ListDevices(userID, "")
+}
+
+// LoginHistory 用户登录记录
+func (s *AdminService) LoginHistory(userID string, page, size int) ([]*models.LoginHistoryItem, int64, error) {
+	if _, err := s.getUser(userID); err != nil {
+		return nil, 0, err
+	}
+	return s.userService.LoginHistory(userID, page, size)
+}
+
+// ------------------- 操作 -------------------
+
+// BanUser 封禁并下线所有设备，Duration 为 0 时永久封禁
+func (s *AdminService) BanUser(adminID, adminRole, userID string, req *models.AdminBanRequest) error {
+	user, err := s.manageableUser(adminID, adminRole, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reason := strings.TrimSpace(req.Reason)
+	user.BanReason = &reason
+	user.BannedAt = &now
+	user.BannedUntil = nil
+	detail := "permanent: " + reason
+	if req.Duration > 0 {
+		until := now.Add(time.Duration(req.Duration) * time.Hour)
+		user.BannedUntil = &until
+		detail = fmt.Sprintf("%dh: %s", req.Duration, reason)
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	s.recordAction(adminID, user.ID, "ban", detail)
+	// 网关只放行会话仍存在的 token，下线失败时返回错误，重试封禁会再次下线
+	return s.sessionManager.InvalidateAllUserSessions(user.ID, true)
+}
+
+// UnbanUser 解除封禁，已注销（停用）的账号不会因此恢复
+func (s *AdminService) UnbanUser(adminID, adminRole, userID string) error {
+	user, err := s.manageableUser(adminID, adminRole, userID)
+	if err != nil {
+		return err
+	}
+	user.BanReason = nil
+	user.BannedAt = nil
+	user.BannedUntil = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	s.recordAction(adminID, user.ID, "unban", "")
+	return nil
+}
+
+// ForceLogout 下线用户的所有设备
+func (s *AdminService) ForceLogout(adminID, adminRole, userID string) error {
+	user, err := s.manageableUser(adminID, adminRole, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.sessionManager.InvalidateAllUserSessions(user.ID, true); err != nil {
+		return err
+	}
+	s.recordAction(adminID, user.ID, "logout", "")
+	return nil
+}
+
+// ResetPassword 重置密码并下线所有设备，未指定新密码时生成临时密码
+func (s *AdminService) ResetPassword(adminID, adminRole, userID string, req *models.AdminResetPasswordRequest) (*models.AdminResetPasswordResponse, error) {
+	user, err := s.manageableUser(adminID, adminRole, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.AdminResetPasswordResponse{}
+	password := req.NewPassword
+	if password != "" {
+		if err := s.authManager.ValidatePassword(password, user.Username); err != nil {
+			return nil, err
+		}
+	} else {
+		password, err = randomPassword()
+		if err != nil {
+			return nil, err
+		}
+		password = password[:12]
+		resp.TemporaryPassword = password
+	}
+	hashed, err := s.authManager.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user.PasswordHash = hashed
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionManager.InvalidateAllUserSessions(user.ID, true); err != nil {
+		log.Printf("warning: invalidate sessions after admin password reset failed for user %s: %v", user.ID, err)
+	}
+	if err := s.loginGuard.Unlock(context.Background(), user.Username); err != nil {
+		log.Printf("warning: clear login lock after admin password reset failed for user %s: %v", user.ID, err)
+	}
+	s.recordAction(adminID, user.ID, "reset_password", "")
+	return resp, nil
+}
+
+// UpdateRole 调整角色（不能高于自己的角色）并下线所有设备，重新登录后 token 中的角色才会更新
+func (s *AdminService) UpdateRole(adminID, adminRole, userID, role string) error {
+	user, err := s.manageableUser(adminID, adminRole, userID)
+	if err != nil {
+		return err
+	}
+	if roleRank[role] > roleRank[adminRole] {
+		return ErrAdminInsufficient
+	}
+	return s.setRole(adminID, user, role)
+}
+
+// SetRoleByUsername 运维通过命令行参数 -bootstrap-admin 指定角色，用于初始化第一个管理员
+func (s *AdminService) SetRoleByUsername(username, role string) error {
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	return s.setRole("system", user, role)
+}
+
+func (s *AdminService) setRole(adminID string, user *models.User, role string) error {
+	if user.Role == role {
+		return nil
+	}
+	previous := user.Role
+	user.Role = role
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	s.recordAction(adminID, user.ID, "set_role", previous+" -> "+role)
+	// 旧 token 中仍是原角色，必须随会话一起失效；失败时由管理员强制下线重试
+	return s.sessionManager.InvalidateAllUserSessions(user.ID, true)
+}
+
+// ------------------- Helper -------------------
+
+func (s *AdminService) getUser(userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// manageableUser 不能操作自己，也不能操作同级或更高角色的用户
+func (s *AdminService) manageableUser(adminID, adminRole, userID string) (*models.User, error) {
+	if adminID == userID {
+		return nil, ErrAdminSelf
+	}
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if roleRank[user.Role] >= roleRank[adminRole] {
+		return nil, ErrAdminInsufficient
+	}
+	return user, nil
+}
+
+func (s *AdminService) recordAction(adminID, userID, action, detail string) {
+	entry := &models.AdminActionLog{
+		AdminID:      adminID,
+		TargetUserID: userID,
+		Action:       action,
+		Detail:       optionalString(truncate(detail, 500)),
+	}
+	if err := s.userRepo.CreateAdminActionLog(entry); err != nil {
+		log.Printf("warning: record admin action %s on user %s failed: %v", action, userID, err)
+	}
+}
+
+func isBanned(user *models.User, now time.Time) bool {
+	return user.BannedAt != nil && (user.BannedUntil == nil || user.BannedUntil.After(now))
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"reading-microservices/user-service/config"
+)
+
+const (
+	verifyCodePrefix     = "verify_code:"
+	verifyCooldownPrefix = "verify_cooldown:"
+	verifyDailyPrefix    = "verify_daily:"
+	verifyIPPrefix       = "verify_ip:"
+
+	defaultCodeMaxAttempts = 5
+)
+
+// codeAttemptScript 验证码仍存在时才累加输错次数，达到上限即删除；
+// 验证码已过期时返回 -1，避免 HINCRBY 重建一个没有过期时间的 hash
+var codeAttemptScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts >= tonumber(ARGV[1]) then
+	redis.call("DEL", KEYS[1])
+end
+return attempts
+`)
+
+// 验证码用途
+const (
+	PurposeEmailVerify   = "email_verify"
+	PurposePhoneVerify   = "phone_verify"
+	PurposePasswordReset = "password_reset"
+	PurposeSMSLogin      = "sms_login"
+	PurposeAccountMerge  = "account_merge"
+)
+
+var (
+	ErrCodeTooFrequent  = errors.New("verification code requested too frequently")
+	ErrCodeDailyLimit   = errors.New("verification code daily limit reached")
+	ErrCodeExpired      = errors.New("verification code expired or not found")
+	ErrCodeInvalid      = errors.New("invalid verification code")
+	ErrCodeTooManyTries = errors.New("too many wrong attempts, please request a new code")
+	ErrCodeIPLimit      = errors.New("too many requests from this ip, please try again later")
+)
+
+// CodeManager 验证码的签发与校验，存 Redis，按用途和目标（邮箱/手机号）隔离
+type CodeManager struct {
+	redisClient *redis.Client
+	cfg         config.VerificationConfig
+}
+
+func NewCodeManager(redisClient *redis.Client, cfg config.VerificationConfig) *CodeManager {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultCodeMaxAttempts
+	}
+	return &CodeManager{redisClient: redisClient, cfg: cfg}
+}
+
+// Issue 生成新验证码，受发送间隔和每日次数限制
+func (m *CodeManager) Issue(ctx context.Context, purpose, target string) (string, error) {
+	if err := m.Throttle(ctx, purpose, target); err != nil {
+		return "", err
+	}
+
+	code, err := randomDigits(m.cfg.CodeLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := verifyCodePrefix + purpose + ":" + target
+	pipe := m.redisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "code", code, "attempts", 0)
+	pipe.Expire(ctx, key, m.TTL())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// Throttle 只计入发送间隔和每日次数，不生成验证码。
+// 目标账号不存在时也调用，限流结果与已注册账号一致，调用方无法据此判断账号是否存在
+func (m *CodeManager) Throttle(ctx context.Context, purpose, target string) error {
+	cooldownKey := verifyCooldownPrefix + purpose + ":" + target
+	if m.cfg.ResendInterval > 0 {
+		ok, err := m.redisClient.SetNX(ctx, cooldownKey, 1, time.Duration(m.cfg.ResendInterval)*time.Second).Result()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCodeTooFrequent
+		}
+	}
+
+	if m.cfg.DailyLimit > 0 {
+		dailyKey := verifyDailyPrefix + target + ":" + time.Now().Format("20060102")
+		count, err := m.redisClient.Incr(ctx, dailyKey).Result()
+		if err != nil {
+			return err
+		}
+		if count == 1 {
+			m.redisClient.Expire(ctx, dailyKey, 24*time.Hour)
+		}
+		if count > int64(m.cfg.DailyLimit) {
+			return ErrCodeDailyLimit
+		}
+	}
+	return nil
+}
+
+// CheckIP 同一 IP 每小时的请求次数限制，防止借助发送接口枚举账号或刷短信
+func (m *CodeManager) CheckIP(ctx context.Context, purpose, ip string) error {
+	if m.cfg.IPHourlyLimit <= 0 || ip == "" {
+		return nil
+	}
+	key := verifyIPPrefix + purpose + ":" + ip + ":" + time.Now().Format("2006010215")
+	count, err := m.redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		m.redisClient.Expire(ctx, key, time.Hour)
+	}
+	if count > int64(m.cfg.IPHourlyLimit) {
+		return ErrCodeIPLimit
+	}
+	return nil
+}
+
+// Verify 校验验证码，成功后作废；输错次数超限同样作废
+func (m *CodeManager) Verify(ctx context.Context, purpose, target, code string) error {
+	key := verifyCodePrefix + purpose + ":" + target
+	stored, err := m.redisClient.HGet(ctx, key, "code").Result()
+	if err == redis.Nil {
+		return ErrCodeExpired
+	}
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) != 1 {
+		attempts, err := codeAttemptScript.Run(ctx, m.redisClient, []string{key}, m.cfg.MaxAttempts).Int64()
+		if err != nil {
+			return err
+		}
+		if attempts < 0 {
+			return ErrCodeExpired
+		}
+		if attempts >= int64(m.cfg.MaxAttempts) {
+			return ErrCodeTooManyTries
+		}
+		return ErrCodeInvalid
+	}
+
+	// 并发校验时只有一个请求能删除成功
+	deleted, err := m.redisClient.Del(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrCodeExpired
+	}
+	return nil
+}
+
+// TTL 验证码有效期
+func (m *CodeManager) TTL() time.Duration {
+	return time.Duration(m.cfg.CodeTTL) * time.Second
+}
+
+func randomDigits(n int) (string, error) {
+	digits := make([]byte, n)
+	for i := range digits {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + d.Int64())
+	}
+	return string(digits), nil
+}
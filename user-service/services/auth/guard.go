@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"reading-microservices/user-service/cache"
+	"reading-microservices/user-service/config"
+)
+
+// LoginError 登录失败或被拒绝时返回给客户端的信息
+type LoginError struct {
+	Message         string
+	CaptchaRequired bool // 客户端需要展示验证码
+	Locked          bool // 账号或 IP 已被临时锁定
+	RetryAfter      int  // 多少秒后可以重试
+}
+
+func (e *LoginError) Error() string {
+	return e.Message
+}
+
+// LoginGuard 登录防暴力破解：按账号和 IP 统计失败次数，逐步退避并临时锁定
+type LoginGuard struct {
+	cache cache.UserCache
+	cfg   config.LoginGuardConfig
+}
+
+func NewLoginGuard(userCache cache.UserCache, cfg config.LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{cache: userCache, cfg: cfg}
+}
+
+func accountKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func delayKey(username string) string {
+	return "delay:" + accountKey(username)
+}
+
+// Check 登录前检查，账号或 IP 被锁定、处于退避期时拒绝。Redis 异常时放行。
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	keys := []string{accountKey(username)}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	for _, key := range keys {
+		ttl, err := g.cache.GetLoginLock(ctx, key)
+		if err != nil {
+			log.Printf("warning: get login lock failed: %v", err)
+			return nil
+		}
+		if ttl > 0 {
+			return &LoginError{
+				Message:         "too many failed attempts, temporarily locked",
+				CaptchaRequired: true,
+				Locked:          true,
+				RetryAfter:      seconds(ttl),
+			}
+		}
+	}
+
+	ttl, err := g.cache.GetLoginLock(ctx, delayKey(username))
+	if err != nil {
+		log.Printf("warning: get login delay failed: %v", err)
+		return nil
+	}
+	if ttl > 0 {
+		return &LoginError{
+			Message:         "too many failed attempts, please retry later",
+			CaptchaRequired: g.CaptchaRequired(ctx, username),
+			RetryAfter:      seconds(ttl),
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次失败，返回给客户端的错误（含验证码、退避和锁定信息）
+func (g *LoginGuard) RecordFailure(ctx context.Context, username, ip string) *LoginError {
+	loginErr := &LoginError{Message: "invalid username or password"}
+	window := time.Duration(g.cfg.Window) * time.Second
+
+	failures, err := g.cache.IncrementLoginAttempts(ctx, accountKey(username), window)
+	if err != nil {
+		log.Printf("warning: increment login attempts failed: %v", err)
+		return loginErr
+	}
+
+	if g.cfg.CaptchaAfter > 0 && failures >= int64(g.cfg.CaptchaAfter) {
+		loginErr.CaptchaRequired = true
+	}
+
+	switch {
+	case g.cfg.AccountLockAfter > 0 && failures >= int64(g.cfg.AccountLockAfter):
+		g.lock(ctx, accountKey(username), loginErr)
+	case g.cfg.DelayAfter > 0 && failures >= int64(g.cfg.DelayAfter):
+		delay := g.backoff(failures - int64(g.cfg.DelayAfter))
+		if err := g.cache.SetLoginLock(ctx, delayKey(username), delay); err != nil {
+			log.Printf("warning: set login delay failed: %v", err)
+		} else {
+			loginErr.RetryAfter = seconds(delay)
+		}
+	}
+
+	if ip != "" && g.cfg.IPLockAfter > 0 {
+		ipFailures, err := g.cache.IncrementLoginAttempts(ctx, ipKey(ip), window)
+		if err != nil {
+			log.Printf("warning: increment ip login attempts failed: %v", err)
+		} else if ipFailures >= int64(g.cfg.IPLockAfter) {
+			g.lock(ctx, ipKey(ip), loginErr)
+		}
+	}
+	return loginErr
+}
+
+// RecordSuccess 登录成功后清零账号计数（IP 计数保留，防止撞库时用成功账号刷新）
+func (g *LoginGuard) RecordSuccess(ctx context.Context, username string) {
+	if err := g.cache.ResetLoginAttempts(ctx, accountKey(username)); err != nil {
+		log.Printf("warning: reset login attempts failed: %v", err)
+	}
+	if err := g.cache.ClearLoginLock(ctx, delayKey(username)); err != nil {
+		log.Printf("warning: clear login delay failed: %v", err)
+	}
+}
+
+// Unlock 解除账号锁定，供找回密码和管理员操作使用
+func (g *LoginGuard) Unlock(ctx context.Context, username string) error {
+	if err := g.cache.ResetLoginAttempts(ctx, accountKey(username)); err != nil {
+		return err
+	}
+	if err := g.cache.ClearLoginLock(ctx, delayKey(username)); err != nil {
+		return err
+	}
+	return g.cache.ClearLoginLock(ctx, accountKey(username))
+}
+
+// CaptchaRequired 账号失败次数是否已达到验证码阈值
+func (g *LoginGuard) CaptchaRequired(ctx context.Context, username string) bool {
+	if g.cfg.CaptchaAfter <= 0 {
+		return false
+	}
+	failures, err := g.cache.GetLoginAttempts(ctx, accountKey(username))
+	return err == nil && failures >= int64(g.cfg.CaptchaAfter)
+}
+
+func (g *LoginGuard) lock(ctx context.Context, key string, loginErr *LoginError) {
+	duration := time.Duration(g.cfg.LockDuration) * time.Second
+	if err := g.cache.SetLoginLock(ctx, key, duration); err != nil {
+		log.Printf("warning: set login lock failed: %v", err)
+		return
+	}
+	loginErr.Locked = true
+	loginErr.CaptchaRequired = true
+	loginErr.RetryAfter = seconds(duration)
+}
+
+// backoff 退避时长从 BaseDelay 开始每次翻倍，不超过 MaxDelay
+func (g *LoginGuard) backoff(step int64) time.Duration {
+	if step > 20 {
+		step = 20
+	}
+	delay := time.Duration(g.cfg.BaseDelay) * time.Second << uint(step)
+	maxDelay := time.Duration(g.cfg.MaxDelay) * time.Second
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
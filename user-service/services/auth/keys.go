@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/oauth"
+)
+
+// 遇到本实例未知的 kid 时，最短间隔多久再从数据库同步一次
+const keyReloadMinInterval = 10 * time.Second
+
+// KeyManager 管理 token 签名密钥：密钥存数据库，多个实例共用；
+// 到期轮换，旧密钥停止签发后继续在 JWKS 中发布 overlap 时长
+type KeyManager struct {
+	repo     repositories.UserRepository
+	cipher   *oauth.TokenCipher
+	legacy   *oauth.TokenCipher // 改用专用密钥前私钥由第三方 token 密钥加密，这些密钥只用于验证
+	alg      string
+	rotation time.Duration
+	overlap  time.Duration
+	interval time.Duration
+
+	mu         sync.RWMutex
+	current    *utils.SigningKey
+	currentAt  time.Time
+	keys       map[string]*utils.SigningKey
+	jwks       utils.JWKS
+	lastReload time.Time
+}
+
+// NewKeyManager cipher 加密签名私钥；legacy 可为空，用于解密旧的私钥，解出的密钥不再签发新 token
+func NewKeyManager(repo repositories.UserRepository, cipher, legacy *oauth.TokenCipher, cfg config.SigningConfig) *KeyManager {
+	return &KeyManager{
+		repo:     repo,
+		cipher:   cipher,
+		legacy:   legacy,
+		alg:      cfg.Algorithm,
+		rotation: time.Duration(cfg.RotationInterval) * time.Second,
+		overlap:  time.Duration(cfg.Overlap) * time.Second,
+		interval: time.Duration(cfg.CheckInterval) * time.Second,
+		keys:     map[string]*utils.SigningKey{},
+	}
+}
+
+// Init 加载密钥，没有可用的签名密钥时生成一个
+func (m *KeyManager) Init() error {
+	return m.rotateIfDue(time.Now())
+}
+
+// Current 当前签名密钥
+func (m *KeyManager) Current() (*utils.SigningKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.current == nil {
+		return nil, errors.New("no signing key available")
+	}
+	return m.current, nil
+}
+
+// JWKS 当前发布的公钥（签名密钥和仍在重叠期内的旧密钥）
+func (m *KeyManager) JWKS() utils.JWKS {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.jwks
+}
+
+// PublicKey 实现 utils.KeyResolver，未知 kid 可能是其他实例刚轮换出的新密钥，从数据库同步一次
+func (m *KeyManager) PublicKey(kid string) (crypto.PublicKey, string, error) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	recent := time.Since(m.lastReload) < keyReloadMinInterval
+	m.mu.RUnlock()
+
+	if !ok && !recent {
+		if err := m.reload(time.Now()); err != nil {
+			return nil, "", err
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+	}
+	if !ok {
+		return nil, "", utils.ErrUnknownKey
+	}
+	return key.PrivateKey.Public(), key.Algorithm, nil
+}
+
+// Run 定期检查轮换，并同步其他实例生成的密钥
+func (m *KeyManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := m.rotateIfDue(time.Now()); err != nil {
+			log.Printf("warning: signing key rotation failed: %v", err)
+		}
+	}
+}
+
+func (m *KeyManager) rotateIfDue(now time.Time) error {
+	if err := m.reload(now); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	due := m.current == nil || now.Sub(m.currentAt) >= m.rotation
+	m.mu.RUnlock()
+	if !due {
+		return nil
+	}
+
+	key, err := utils.GenerateSigningKey(m.alg)
+	if err != nil {
+		return err
+	}
+	privatePEM, err := key.MarshalPrivateKey()
+	if err != nil {
+		return err
+	}
+	encrypted, err := m.cipher.Encrypt(privatePEM)
+	if err != nil {
+		return fmt.Errorf("encrypt signing key: %w", err)
+	}
+	if err := m.repo.CreateSigningKey(&models.JWTSigningKey{
+		ID:         key.ID,
+		Algorithm:  key.Algorithm,
+		PrivateKey: *encrypted,
+	}); err != nil {
+		return err
+	}
+
+	// 旧密钥签发的 token 在 overlap 内仍可验证
+	if err := m.repo.RetireSigningKeys(key.ID, now, now.Add(m.overlap)); err != nil {
+		return err
+	}
+	if err := m.repo.DeleteExpiredSigningKeys(now); err != nil {
+		log.Printf("warning: failed to delete expired signing keys: %v", err)
+	}
+	log.Printf("signing key rotated, new kid %s", key.ID)
+
+	return m.reload(now)
+}
+
+// reload 从数据库加载未过期的密钥，最新的未停用密钥作为签名密钥
+func (m *KeyManager) reload(now time.Time) error {
+	records, err := m.repo.GetValidSigningKeys(now)
+	if err != nil {
+		return err
+	}
+
+	var current *utils.SigningKey
+	var currentAt time.Time
+	keys := make(map[string]*utils.SigningKey, len(records))
+	jwks := utils.JWKS{Keys: []utils.JWK{}}
+	for i := range records {
+		record := &records[i]
+		privatePEM, err := m.cipher.Decrypt(&record.PrivateKey)
+		legacy := false
+		if err != nil && m.legacy != nil {
+			privatePEM, err = m.legacy.Decrypt(&record.PrivateKey)
+			legacy = err == nil
+		}
+		if err != nil {
+			log.Printf("warning: failed to decrypt signing key %s: %v", record.ID, err)
+			continue
+		}
+		key, err := utils.ParseSigningKey(record.ID, record.Algorithm, privatePEM)
+		if err != nil {
+			log.Printf("warning: failed to parse signing key %s: %v", record.ID, err)
+			continue
+		}
+
+		keys[key.ID] = key
+		jwks.Keys = append(jwks.Keys, key.PublicJWK())
+		if current == nil && record.RetiredAt == nil && !legacy {
+			current = key
+			currentAt = record.CreatedAt
+		}
+	}
+
+	m.mu.Lock()
+	m.current = current
+	m.currentAt = currentAt
+	m.keys = keys
+	m.jwks = jwks
+	m.lastReload = time.Now()
+	m.mu.Unlock()
+	return nil
+}
@@ -1,47 +1,163 @@
 package services
 
 import (
+	"context"
 	"log"
 	"reading-microservices/user-service/models"
 	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/geoip"
+	"reading-microservices/user-service/services/notifier"
+	"strings"
+	"time"
 )
 
+// ClientInfo 登录请求的客户端信息
+type ClientInfo struct {
+	Platform  string
+	DeviceID  string
+	IPAddress string
+	UserAgent string
+}
+
+// GeneratedDeviceIDPrefix 客户端没有上报设备 ID 时由服务端生成的 ID 前缀。
+// 这类 ID 每次登录都不同，不能用来判断是否为新设备
+const GeneratedDeviceIDPrefix = "gen-"
+
+// IsGeneratedDeviceID 设备 ID 是否由服务端生成
+func IsGeneratedDeviceID(deviceID string) bool {
+	return strings.HasPrefix(deviceID, GeneratedDeviceIDPrefix)
+}
+
 type LoginLogger struct {
 	userRepo repositories.UserRepository
+	locator  geoip.Locator
+	notifier notifier.SecurityNotifier
 }
 
-func NewLoginLogger(userRepo repositories.UserRepository) *LoginLogger {
-	return &LoginLogger{userRepo: userRepo}
+func NewLoginLogger(userRepo repositories.UserRepository, locator geoip.Locator, securityNotifier notifier.SecurityNotifier) *LoginLogger {
+	return &LoginLogger{userRepo: userRepo, locator: locator, notifier: securityNotifier}
 }
 
-func (l *LoginLogger) LogSuccess(userID string, sessionID *string, platform, deviceID string) {
+// LogSuccess 记录登录成功；新设备或新地区登录时发送安全提醒
+func (l *LoginLogger) LogSuccess(userID string, sessionID *string, loginType string, client ClientInfo) {
 	logEntry := &models.LoginLog{
 		UserID:    userID,
 		SessionID: sessionID,
-		LoginType: "password",
-		Platform:  platform,
+		LoginType: loginType,
+		EventType: "login",
+		Platform:  client.Platform,
 		IsSuccess: true,
 	}
-	if deviceID != "" {
-		logEntry.DeviceID = &deviceID
-	}
+	l.fillClientInfo(logEntry, client)
+	l.flagNewLogin(logEntry)
 	if err := l.userRepo.CreateLoginLog(logEntry); err != nil {
 		log.Printf("warning: create login log failed: %v", err)
 	}
+
+	if logEntry.IsNewDevice || logEntry.IsNewLocation {
+		alert := &notifier.LoginAlert{
+			UserID:      userID,
+			LoginType:   loginType,
+			Platform:    client.Platform,
+			DeviceID:    client.DeviceID,
+			IPAddress:   client.IPAddress,
+			Location:    stringValue(logEntry.Location),
+			NewDevice:   logEntry.IsNewDevice,
+			NewLocation: logEntry.IsNewLocation,
+			LoginAt:     time.Now(),
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := l.notifier.NotifyNewLogin(ctx, alert); err != nil {
+				log.Printf("warning: send login alert failed: %v", err)
+			}
+		}()
+	}
 }
 
-func (l *LoginLogger) LogFailure(userID, reason, platform, deviceID string) {
+// flagNewLogin 与历史成功登录比较设备和地区，首次登录的账号不提醒；
+// 服务端生成的设备 ID 只比较地区
+func (l *LoginLogger) flagNewLogin(logEntry *models.LoginLog) {
+	hasHistory, err := l.userRepo.HasSuccessfulLogin(logEntry.UserID)
+	if err != nil || !hasHistory {
+		return
+	}
+	if logEntry.DeviceID != nil && !IsGeneratedDeviceID(*logEntry.DeviceID) {
+		seen, err := l.userRepo.HasLoginFromDevice(logEntry.UserID, *logEntry.DeviceID)
+		logEntry.IsNewDevice = err == nil && !seen
+	}
+	if logEntry.Location != nil {
+		seen, err := l.userRepo.HasLoginFromLocation(logEntry.UserID, *logEntry.Location)
+		logEntry.IsNewLocation = err == nil && !seen
+	}
+}
+
+func (l *LoginLogger) LogFailure(userID, reason, loginType string, client ClientInfo) {
 	logEntry := &models.LoginLog{
 		UserID:        userID,
-		LoginType:     "password",
-		Platform:      platform,
+		LoginType:     loginType,
+		EventType:     "login",
+		Platform:      client.Platform,
 		IsSuccess:     false,
 		FailureReason: &reason,
 	}
-	if deviceID != "" {
-		logEntry.DeviceID = &deviceID
-	}
+	l.fillClientInfo(logEntry, client)
 	if err := l.userRepo.CreateLoginLog(logEntry); err != nil {
 		log.Printf("warning: create login log failed: %v", err)
 	}
 }
+
+// LogSecurityEvent 记录安全事件，如 refresh token 重放
+func (l *LoginLogger) LogSecurityEvent(userID string, sessionID *string, loginType, eventType, detail string, client ClientInfo) {
+	logEntry := &models.LoginLog{
+		UserID:        userID,
+		SessionID:     sessionID,
+		LoginType:     loginType,
+		EventType:     eventType,
+		Platform:      client.Platform,
+		IsSuccess:     false,
+		FailureReason: &detail,
+	}
+	l.fillClientInfo(logEntry, client)
+	if err := l.userRepo.CreateLoginLog(logEntry); err != nil {
+		log.Printf("warning: create security log failed: %v", err)
+	}
+}
+
+// LogAccountEvent 记录成功的账号操作，如重置密码
+func (l *LoginLogger) LogAccountEvent(userID, loginType, eventType string, client ClientInfo) {
+	logEntry := &models.LoginLog{
+		UserID:    userID,
+		LoginType: loginType,
+		EventType: eventType,
+		Platform:  client.Platform,
+		IsSuccess: true,
+	}
+	l.fillClientInfo(logEntry, client)
+	if err := l.userRepo.CreateLoginLog(logEntry); err != nil {
+		log.Printf("warning: create account log failed: %v", err)
+	}
+}
+
+func (l *LoginLogger) fillClientInfo(logEntry *models.LoginLog, client ClientInfo) {
+	if client.DeviceID != "" {
+		logEntry.DeviceID = &client.DeviceID
+	}
+	if client.IPAddress != "" {
+		logEntry.IPAddress = &client.IPAddress
+		if location := l.locator.Lookup(client.IPAddress); location != "" {
+			logEntry.Location = &location
+		}
+	}
+	if client.UserAgent != "" {
+		logEntry.UserAgent = &client.UserAgent
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
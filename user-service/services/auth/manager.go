@@ -1,38 +1,70 @@
 package services
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"time"
+
 	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/services/password"
 )
 
 // AuthManager 只负责用户认证和 Token 生成
 type AuthManager struct {
-	jwtSecret string
+	keys     *KeyManager
+	verifier *utils.KeyVerifier
+	hasher   *password.Hasher
+	policy   *password.Policy
 }
 
-func NewAuthManager(jwtSecret string) *AuthManager {
+// NewAuthManager token 用 KeyManager 的当前密钥签发。legacySecret 非空且设置了 legacyCutover 时，
+// 仍接受 legacyCutover 之前签发、不超过 legacyMaxAge 且不带角色的迁移前 HS256 token
+func NewAuthManager(keys *KeyManager, legacySecret string, legacyCutover time.Time, legacyMaxAge time.Duration, hasher *password.Hasher, policy *password.Policy) *AuthManager {
+	if legacyCutover.IsZero() {
+		legacySecret = ""
+	}
+	verifier := utils.NewKeyVerifier(keys, legacySecret)
+	verifier.LimitLegacy(legacyCutover, legacyMaxAge)
 	return &AuthManager{
-		jwtSecret: jwtSecret,
+		keys:     keys,
+		verifier: verifier,
+		hasher:   hasher,
+		policy:   policy,
 	}
 }
 
-// VerifyPassword 验证明文密码与哈希是否匹配
+// VerifyPassword 验证明文密码与哈希是否匹配，支持 argon2id 和历史 bcrypt 哈希
 func (a *AuthManager) VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return a.hasher.Verify(hashedPassword, password)
+}
+
+// PasswordNeedsRehash 哈希算法或参数已过时，验证通过后应用 HashPassword 重新生成
+func (a *AuthManager) PasswordNeedsRehash(hashedPassword string) bool {
+	return a.hasher.NeedsRehash(hashedPassword)
 }
 
 // HashPassword 加密密码
 func (a *AuthManager) HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return a.hasher.Hash(password)
 }
 
-// GenerateToken 根据用户 ID 生成 JWT，支持自定义过期时间
-func (a *AuthManager) GenerateToken(userID, username string, expiresIn int) (string, error) {
-	return utils.GenerateToken(userID, username, a.jwtSecret, expiresIn)
+// ValidatePassword 按密码策略检查用户设置的新密码，不符合时返回包装了 password.ErrWeakPassword 的错误
+func (a *AuthManager) ValidatePassword(password, username string) error {
+	return a.policy.Validate(password, username)
+}
+
+// GenerateToken 根据用户 ID 生成 JWT，支持自定义过期时间；普通用户 role 为空
+func (a *AuthManager) GenerateToken(userID, username, role string, expiresIn int) (string, error) {
+	key, err := a.keys.Current()
+	if err != nil {
+		return "", err
+	}
+	claims, err := utils.NewClaims(userID, username, role, expiresIn)
+	if err != nil {
+		return "", err
+	}
+	return utils.SignToken(claims, key)
 }
 
-// ParseToken 验证并解析 JWT
-func (a *AuthManager) ParseToken(token string) (*utils.Claims, error) {
-	return utils.ParseToken(token, a.jwtSecret)
+// VerifyToken 验证并解析 JWT，实现 utils.TokenVerifier
+func (a *AuthManager) VerifyToken(token string) (*utils.Claims, error) {
+	return a.verifier.VerifyToken(token)
 }
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
 )
 
 const (
@@ -22,6 +23,16 @@ const (
 	redisJitterMax       = 300 // 秒
 )
 
+// refreshReuseGrace 轮换后的宽限期，期间再次使用旧 token 多为客户端并发刷新，不视为重放
+const refreshReuseGrace = 10 * time.Second
+
+var (
+	// ErrRefreshTokenReused 已轮换的 refresh token 被再次使用
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrRefreshTokenRotated 旧 token 刚在宽限期内被轮换，客户端应使用并发请求拿到的新 token
+	ErrRefreshTokenRotated = errors.New("refresh token already rotated, use the latest token")
+)
+
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
@@ -92,6 +103,14 @@ func (s *SessionManager) CreateSessionPair(accessSession, refreshSession *models
 	if err != nil {
 		return err
 	}
+	return s.storeRedisPair(ctx, accessSession, refreshSession)
+}
+
+// storeRedisPair refresh session 已写入数据库后，把会话对写入 Redis
+func (s *SessionManager) storeRedisPair(ctx context.Context, accessSession, refreshSession *models.UserSession) error {
+	// access session 与 refresh session 共用 ID 和族 ID，用于定位会话对
+	accessSession.ID = refreshSession.ID
+	accessSession.FamilyID = refreshSession.FamilyID
 
 	// --- 写Redis（存储 access 和 refresh token）---
 	accessData, err := json.Marshal(accessSession)
@@ -125,61 +144,6 @@ func (s *SessionManager) CreateSessionPair(accessSession, refreshSession *models
 	return nil
 }
 
-// RefreshSession 刷新会话（更新现有的 refresh session）
-func (s *SessionManager) RefreshSession(accessSession *models.UserSession, refreshToken string) error {
-	ctx := context.Background()
-
-	// 检查新的 access session 是否已存在
-	if existing, _ := s.GetSession(accessSession.SessionToken, true); existing != nil {
-		return errors.New("access session already exists")
-	}
-
-	// 获取现有的 refresh session
-	existingRefreshSession, err := s.GetSession(refreshToken, false)
-	if err != nil {
-		return fmt.Errorf("refresh session not found: %v", err)
-	}
-
-	// 更新 refresh session 的 access token 引用和最后活动时间
-	existingRefreshSession.AccessToken = accessSession.SessionToken
-	existingRefreshSession.LastActivityAt = time.Now()
-
-	// --- 更新数据库中的 refresh session ---
-	if err := s.userRepo.UpdateRefreshSession(refreshToken, accessSession.SessionToken, time.Now()); err != nil {
-		return fmt.Errorf("failed to update refresh session in database: %v", err)
-	}
-
-	// --- 更新 Redis 中的 refresh session ---
-	refreshData, err := json.Marshal(existingRefreshSession)
-	if err != nil {
-		return err
-	}
-
-	refreshKey := s.refreshSessionKey(refreshToken)
-	if err := s.redisClient.Set(ctx, refreshKey, refreshData, ttlWithJitter(existingRefreshSession.ExpiresAt)).Err(); err != nil {
-		log.Printf("warning: redis update refresh session failed: %v", err)
-	}
-
-	// --- 创建新的 access session ---
-	accessData, err := json.Marshal(accessSession)
-	if err != nil {
-		return err
-	}
-
-	accessKey := s.accessSessionKey(accessSession.SessionToken)
-	if err := s.redisClient.Set(ctx, accessKey, accessData, ttlWithJitter(accessSession.ExpiresAt)).Err(); err != nil {
-		log.Printf("warning: redis set access session failed: %v", err)
-	}
-
-	// 把新的 access session key 放入 user_sessions:{userID} 集合
-	userSessionsKey := s.userSessionsKey(accessSession.UserID)
-	if err := s.redisClient.SAdd(ctx, userSessionsKey, accessKey).Err(); err != nil {
-		log.Printf("warning: redis add user_sessions failed: %v", err)
-	}
-
-	return nil
-}
-
 // GetSession 根据 token 获取会话，isAccessToken 标识是否为 access token
 func (s *SessionManager) GetSession(token string, isAccessToken bool) (*models.UserSession, error) {
 	ctx := context.Background()
@@ -290,76 +254,110 @@ func (s *SessionManager) InvalidateAllUserSessions(userID string, invalidateDB b
 	return s.userRepo.InvalidateAllUserSessions(userID)
 }
 
-// RefreshSessionPair 刷新会话对
-func (s *SessionManager) RefreshSessionPair(newAccessSession, newRefreshSession *models.UserSession) error {
-	// 使旧的会话对失效
-	if err := s.InvalidateAllUserSessions(newRefreshSession.UserID, false); err != nil {
-		log.Printf("warning: invalidate old session pair failed: %v", err)
+// RotateSessionPair 轮换 refresh token：旧 refresh session 标记为已轮换，同族创建新的会话对，
+// 两步在同一个数据库事务中完成。旧 token 刚被并发请求轮换时返回 ErrRefreshTokenRotated。
+func (s *SessionManager) RotateSessionPair(oldRefreshSession, newAccessSession, newRefreshSession *models.UserSession) error {
+	newRefreshSession.FamilyID = oldRefreshSession.FamilyID
+	if newRefreshSession.FamilyID == "" {
+		// 族 ID 上线前创建的会话，以旧会话 ID 作为族 ID
+		newRefreshSession.FamilyID = oldRefreshSession.ID
+	}
+	if err := s.userRepo.RotateSession(oldRefreshSession.ID, newRefreshSession); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenRotated
+		}
+		return err
+	}
+	if err := s.storeRedisPair(context.Background(), newAccessSession, newRefreshSession); err != nil {
+		return err
+	}
+
+	// 旧会话对从 Redis 移除
+	oldID := oldRefreshSession.ID
+	if err := s.invalidateRedisSessions(oldRefreshSession.UserID, func(session *models.UserSession) bool {
+		return session.ID == oldID
+	}); err != nil {
+		log.Printf("warning: invalidate rotated session failed: %v", err)
 	}
+	return nil
+}
 
-	// 更新sql session
-	return s.RefreshSession(newAccessSession, newRefreshSession.SessionToken)
+// RotatedWithinGrace 已轮换的 refresh session 是否仍在宽限期内
+func RotatedWithinGrace(session *models.UserSession) bool {
+	return session.RotatedAt != nil && time.Since(*session.RotatedAt) < refreshReuseGrace
 }
 
-// UpdateRefreshSession 更新 refresh session 的 access token 引用和最后活动时间
-func (s *SessionManager) UpdateRefreshSession(refreshToken, newAccessToken string) error {
-	ctx := context.Background()
+// RevokeFamily 让整个 refresh token 族失效（检测到重放时调用）
+func (s *SessionManager) RevokeFamily(userID, familyID string) error {
+	if err := s.invalidateRedisSessions(userID, func(session *models.UserSession) bool {
+		return session.FamilyID == familyID
+	}); err != nil {
+		log.Printf("warning: invalidate family sessions failed: %v", err)
+	}
+	return s.userRepo.InvalidateSessionFamily(familyID)
+}
 
-	// 获取当前的 refresh session
-	refreshKey := s.refreshSessionKey(refreshToken)
-	data, err := s.redisClient.Get(ctx, refreshKey).Result()
+// InvalidateSessionPairByAccessToken 通过 access token 找到对应的会话对并失效
+func (s *SessionManager) InvalidateSessionPairByAccessToken(accessToken string) error {
+	accessSession, err := s.GetSession(accessToken, true)
 	if err != nil {
-		return errors.New("refresh session not found in redis")
+		if err == redis.Nil {
+			return errors.New("access session not found")
+		}
+		return fmt.Errorf("failed to get access session: %v", err)
 	}
 
-	var refreshSession models.UserSession
-	if err := json.Unmarshal([]byte(data), &refreshSession); err != nil {
-		return errors.New("invalid refresh session data")
+	if accessSession.UserID == "" {
+		return errors.New("user ID not found in access session")
 	}
 
-	// 更新字段
-	refreshSession.AccessToken = newAccessToken
-	refreshSession.LastActivityAt = time.Now()
+	// 只失效当前设备的会话对
+	return s.InvalidateSessionByID(accessSession.UserID, accessSession.ID)
+}
 
-	// 更新 Redis
-	updatedData, err := json.Marshal(refreshSession)
-	if err != nil {
-		return err
-	}
+// ----------------- Device -----------------
 
-	if err := s.redisClient.Set(ctx, refreshKey, updatedData, ttlWithJitter(refreshSession.ExpiresAt)).Err(); err != nil {
-		return fmt.Errorf("redis update refresh session failed: %v", err)
-	}
+// ListActiveSessions 用户所有有效的 refresh session，每个设备一条
+func (s *SessionManager) ListActiveSessions(userID string) ([]models.UserSession, error) {
+	return s.userRepo.GetActiveUserSessions(userID)
+}
 
-	// 更新数据库（只更新必要的字段）
-	return s.userRepo.UpdateRefreshSession(refreshToken, newAccessToken, time.Now())
+// InvalidateSessionByID 让指定会话对（access + refresh）失效
+func (s *SessionManager) InvalidateSessionByID(userID, sessionID string) error {
+	if err := s.invalidateRedisSessions(userID, func(session *models.UserSession) bool {
+		return session.ID == sessionID
+	}); err != nil {
+		log.Printf("warning: invalidate redis sessions failed: %v", err)
+	}
+	return s.userRepo.InvalidateSessionByID(sessionID)
 }
 
-// InvalidateSessionPairByAccessToken 通过 access token 找到对应的用户并失效所有会话
-func (s *SessionManager) InvalidateSessionPairByAccessToken(accessToken string) error {
+// invalidateRedisSessions 删除 Redis 中满足 match 的 session，顺便清理 user_sessions 集合中已过期的 key
+func (s *SessionManager) invalidateRedisSessions(userID string, match func(*models.UserSession) bool) error {
 	ctx := context.Background()
+	userSessionsKey := s.userSessionsKey(userID)
 
-	// 获取 access session 信息
-	accessKey := s.accessSessionKey(accessToken)
-	data, err := s.redisClient.Get(ctx, accessKey).Result()
+	sessionKeys, err := s.redisClient.SMembers(ctx, userSessionsKey).Result()
 	if err != nil {
-		if err == redis.Nil {
-			return errors.New("access session not found")
-		}
-		return fmt.Errorf("failed to get access session: %v", err)
+		return err
 	}
 
-	var accessSession models.UserSession
-	if err := json.Unmarshal([]byte(data), &accessSession); err != nil {
-		return errors.New("invalid access session data")
-	}
+	for _, key := range sessionKeys {
+		data, err := s.redisClient.Get(ctx, key).Result()
+		if err == redis.Nil {
+			s.redisClient.SRem(ctx, userSessionsKey, key)
+			continue
+		}
+		if err != nil {
+			return err
+		}
 
-	// 获取用户ID
-	userID := accessSession.UserID
-	if userID == "" {
-		return errors.New("user ID not found in access session")
+		var session models.UserSession
+		if err := json.Unmarshal([]byte(data), &session); err != nil || !match(&session) {
+			continue
+		}
+		s.redisClient.Del(ctx, key)
+		s.redisClient.SRem(ctx, userSessionsKey, key)
 	}
-
-	// 失效该用户的所有会话
-	return s.InvalidateAllUserSessions(userID, true)
+	return nil
 }
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"time"
+
+	"reading-microservices/user-service/models"
+)
+
+// CheckAccountStatus 登录、刷新和校验 token 时检查账号是否已停用或被封禁
+func CheckAccountStatus(user *models.User) error {
+	if !user.IsActive {
+		return &LoginError{Message: "account is disabled", Locked: true}
+	}
+	if user.BannedAt == nil {
+		return nil
+	}
+
+	message := "account is banned"
+	if user.BanReason != nil && *user.BanReason != "" {
+		message += ": " + *user.BanReason
+	}
+	if user.BannedUntil == nil {
+		return &LoginError{Message: message, Locked: true}
+	}
+	if remaining := time.Until(*user.BannedUntil); remaining > 0 {
+		return &LoginError{Message: message, Locked: true, RetryAfter: seconds(remaining)}
+	}
+	return nil
+}
+
+// TokenRole 写入 token 的角色，普通用户不写
+func TokenRole(user *models.User) string {
+	if user.Role == "user" {
+		return ""
+	}
+	return user.Role
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+)
+
+const (
+	twoFactorChallengePrefix = "2fa_challenge:"
+	twoFactorUsedStepPrefix  = "2fa_used:"
+)
+
+var (
+	ErrChallengeNotFound   = errors.New("two-factor challenge expired or not found")
+	ErrChallengeTooManyTry = errors.New("too many wrong two-factor codes, please login again")
+)
+
+// TwoFactorChallenge 密码等第一步通过后、等待动态码的登录
+type TwoFactorChallenge struct {
+	UserID    string
+	LoginType string
+	Client    ClientInfo
+}
+
+// TwoFactorStore 登录第二步的挑战和已用时间步，存 Redis
+type TwoFactorStore struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+	maxAttempts int
+}
+
+func NewTwoFactorStore(redisClient *redis.Client, cfg config.TwoFactorConfig) *TwoFactorStore {
+	return &TwoFactorStore{
+		redisClient: redisClient,
+		ttl:         time.Duration(cfg.ChallengeTTL) * time.Second,
+		maxAttempts: cfg.MaxAttempts,
+	}
+}
+
+// CreateChallenge 返回一次性的挑战 token
+func (s *TwoFactorStore) CreateChallenge(ctx context.Context, challenge *TwoFactorChallenge) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	key := twoFactorChallengePrefix + token
+	pipe := s.redisClient.TxPipeline()
+	pipe.HSet(ctx, key,
+		"user_id", challenge.UserID,
+		"login_type", challenge.LoginType,
+		"platform", challenge.Client.Platform,
+		"device_id", challenge.Client.DeviceID,
+		"ip_address", challenge.Client.IPAddress,
+		"user_agent", challenge.Client.UserAgent,
+		"attempts", 0,
+	)
+	pipe.Expire(ctx, key, s.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *TwoFactorStore) GetChallenge(ctx context.Context, token string) (*TwoFactorChallenge, error) {
+	values, err := s.redisClient.HGetAll(ctx, twoFactorChallengePrefix+token).Result()
+	if err != nil {
+		return nil, err
+	}
+	if values["user_id"] == "" {
+		return nil, ErrChallengeNotFound
+	}
+	return &TwoFactorChallenge{
+		UserID:    values["user_id"],
+		LoginType: values["login_type"],
+		Client: ClientInfo{
+			Platform:  values["platform"],
+			DeviceID:  values["device_id"],
+			IPAddress: values["ip_address"],
+			UserAgent: values["user_agent"],
+		},
+	}, nil
+}
+
+// RecordFailure 输错一次，超过次数后挑战作废，需要重新登录
+func (s *TwoFactorStore) RecordFailure(ctx context.Context, token string) error {
+	key := twoFactorChallengePrefix + token
+	attempts, err := s.redisClient.HIncrBy(ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return err
+	}
+	if attempts >= int64(s.maxAttempts) {
+		s.redisClient.Del(ctx, key)
+		return ErrChallengeTooManyTry
+	}
+	return nil
+}
+
+// ConsumeChallenge 验证通过后删除，并发请求只有一个能成功
+func (s *TwoFactorStore) ConsumeChallenge(ctx context.Context, token string) error {
+	deleted, err := s.redisClient.Del(ctx, twoFactorChallengePrefix+token).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrChallengeNotFound
+	}
+	return nil
+}
+
+// UseStep 同一个时间步的动态码只能用一次
+func (s *TwoFactorStore) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	key := twoFactorUsedStepPrefix + userID + ":" + strconv.FormatInt(step, 10)
+	return s.redisClient.SetNX(ctx, key, 1, time.Duration((2*totpSkew+1)*totpPeriod)*time.Second).Result()
+}
+
+// TwoFactorRule 返回 true 表示该用户必须开启两步验证
+type TwoFactorRule func(user *models.User) bool
+
+// TwoFactorPolicy 哪些用户必须开启两步验证，由多条规则组成，任一命中即要求开启
+type TwoFactorPolicy struct {
+	rules []TwoFactorRule
+}
+
+// NewTwoFactorPolicy 按配置注册余额和 VIP 等级规则，其他规则（如管理员）通过 AddRule 追加
+func NewTwoFactorPolicy(cfg config.TwoFactorConfig) *TwoFactorPolicy {
+	p := &TwoFactorPolicy{}
+	if cfg.RequiredCoinBalance > 0 {
+		p.AddRule(func(user *models.User) bool {
+			return user.ReadingCoins >= cfg.RequiredCoinBalance
+		})
+	}
+	if len(cfg.RequiredVIPLevels) > 0 {
+		levels := make(map[string]bool, len(cfg.RequiredVIPLevels))
+		for _, level := range cfg.RequiredVIPLevels {
+			levels[level] = true
+		}
+		p.AddRule(func(user *models.User) bool {
+			return levels[user.VipLevel]
+		})
+	}
+	return p
+}
+
+func (p *TwoFactorPolicy) AddRule(rule TwoFactorRule) {
+	p.rules = append(p.rules, rule)
+}
+
+func (p *TwoFactorPolicy) Required(user *models.User) bool {
+	for _, rule := range p.rules {
+		if rule(user) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/imaging"
+	"reading-microservices/user-service/services/storage"
+)
+
+var ErrAvatarTooLarge = errors.New("avatar file is too large")
+
+// AvatarService 头像上传：校验类型和大小，按 EXIF 摆正后裁成正方形，生成多个尺寸的 JPEG。
+// 重新编码会去掉 EXIF（含拍摄地点）。文件名带内容哈希，地址不变内容就不变，可长期缓存
+type AvatarService struct {
+	userRepo     repositories.UserRepository
+	storage      storage.Storage
+	maxSize      int
+	maxDimension int
+	sizes        []int
+	quality      int
+}
+
+func NewAvatarService(userRepo repositories.UserRepository, store storage.Storage, cfg config.AvatarConfig) *AvatarService {
+	sizes := append([]int(nil), cfg.Sizes...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+	return &AvatarService{
+		userRepo:     userRepo,
+		storage:      store,
+		maxSize:      cfg.MaxSize,
+		maxDimension: cfg.MaxDimension,
+		sizes:        sizes,
+		quality:      cfg.Quality,
+	}
+}
+
+// MaxSize 上传文件大小上限（字节）
+func (s *AvatarService) MaxSize() int {
+	return s.maxSize
+}
+
+// Upload 处理并保存头像，crop 为空时取图片中间的正方形
+func (s *AvatarService) Upload(ctx context.Context, userID string, data []byte, crop *image.Rectangle) (*models.AvatarResponse, error) {
+	if len(data) > s.maxSize {
+		return nil, ErrAvatarTooLarge
+	}
+	if _, err := imaging.DetectType(data); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	img, err := imaging.Decode(data, s.maxDimension)
+	if err != nil {
+		return nil, err
+	}
+	square, err := imaging.CropSquare(img, crop)
+	if err != nil {
+		return nil, err
+	}
+
+	// 从大到小依次缩放，小尺寸基于上一个尺寸生成；原图比目标尺寸小时不放大
+	files := make(map[int][]byte, len(s.sizes))
+	current := square
+	for _, size := range s.sizes {
+		side := size
+		if current.Bounds().Dx() < side {
+			side = current.Bounds().Dx()
+		}
+		resized := imaging.Resize(current, side, side)
+		encoded, err := imaging.EncodeJPEG(resized, s.quality)
+		if err != nil {
+			return nil, err
+		}
+		files[size] = encoded
+		current = resized
+	}
+
+	sum := sha256.Sum256(files[s.sizes[0]])
+	prefix := fmt.Sprintf("avatars/%s/%s", userID, hex.EncodeToString(sum[:8]))
+	thumbnails := make(models.Thumbnails, len(files))
+	for size, encoded := range files {
+		key := avatarFileKey(prefix, size)
+		if err := s.storage.Put(ctx, key, encoded, "image/jpeg"); err != nil {
+			return nil, err
+		}
+		thumbnails[strconv.Itoa(size)] = s.storage.URL(key)
+	}
+
+	oldKey, oldThumbnails := user.AvatarKey, user.AvatarThumbnails
+	avatarURL := thumbnails[strconv.Itoa(s.sizes[0])]
+	user.AvatarURL = &avatarURL
+	user.AvatarKey = &prefix
+	user.AvatarThumbnails = thumbnails
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	if oldKey != nil && *oldKey != prefix {
+		s.removeFiles(ctx, *oldKey, oldThumbnails)
+	}
+
+	return &models.AvatarResponse{AvatarURL: avatarURL, Thumbnails: thumbnails}, nil
+}
+
+// Remove 删除已上传的头像，恢复为默认头像
+func (s *AvatarService) Remove(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	oldKey, oldThumbnails := user.AvatarKey, user.AvatarThumbnails
+	user.AvatarURL = nil
+	user.AvatarKey = nil
+	user.AvatarThumbnails = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if oldKey != nil {
+		s.removeFiles(ctx, *oldKey, oldThumbnails)
+	}
+	return nil
+}
+
+// removeFiles 删除旧头像文件，失败只记日志，不影响更换头像。
+// 尺寸取旧记录和当前配置的并集，配置调整过或缩略图记录已清空时也能删干净
+func (s *AvatarService) removeFiles(ctx context.Context, prefix string, thumbnails models.Thumbnails) {
+	sizes := map[int]bool{}
+	for _, size := range s.sizes {
+		sizes[size] = true
+	}
+	for sizeKey := range thumbnails {
+		if size, err := strconv.Atoi(sizeKey); err == nil {
+			sizes[size] = true
+		}
+	}
+	for size := range sizes {
+		key := avatarFileKey(prefix, size)
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("warning: failed to delete avatar file %s: %v", key, err)
+		}
+	}
+}
+
+func avatarFileKey(prefix string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", prefix, size)
+}
@@ -0,0 +1,67 @@
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/geoip2-golang"
+	"github.com/sirupsen/logrus"
+)
+
+// Locator 根据 IP 解析大致位置（国家 + 省份），解析不到返回空字符串
+type Locator interface {
+	Lookup(ip string) string
+}
+
+// NewLocator 打开本地 GeoIP2/GeoLite2 City 数据库，未配置或打开失败时不解析位置
+func NewLocator(databasePath, language string) Locator {
+	if databasePath == "" {
+		return noopLocator{}
+	}
+	db, err := geoip2.Open(databasePath)
+	if err != nil {
+		logrus.Warnf("GeoIP database %s unavailable, login location disabled: %v", databasePath, err)
+		return noopLocator{}
+	}
+	if language == "" {
+		language = "zh-CN"
+	}
+	return &mmdbLocator{db: db, language: language}
+}
+
+type mmdbLocator struct {
+	db       *geoip2.Reader
+	language string
+}
+
+func (l *mmdbLocator) Lookup(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if parsed.IsLoopback() || parsed.IsPrivate() {
+		return "局域网"
+	}
+
+	record, err := l.db.City(parsed)
+	if err != nil {
+		return ""
+	}
+	parts := []string{l.name(record.Country.Names)}
+	if len(record.Subdivisions) > 0 {
+		parts = append(parts, l.name(record.Subdivisions[0].Names))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// name 优先使用配置的语言，没有时退回英文
+func (l *mmdbLocator) name(names map[string]string) string {
+	if name := names[l.language]; name != "" {
+		return name
+	}
+	return names["en"]
+}
+
+type noopLocator struct{}
+
+func (noopLocator) Lookup(string) string { return "" }
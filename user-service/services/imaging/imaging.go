@@ -0,0 +1,142 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format, use jpeg, png or gif")
+	ErrImageTooLarge     = errors.New("image dimensions too large")
+	ErrInvalidCrop       = errors.New("crop area is outside the image")
+)
+
+// 支持的图片类型，按文件内容判断，不信任扩展名和客户端传的 Content-Type
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// DetectType 按文件头判断图片类型，不支持时返回 ErrUnsupportedFormat
+func DetectType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !supportedTypes[contentType] {
+		return "", ErrUnsupportedFormat
+	}
+	return contentType, nil
+}
+
+// Decode 解码图片并按 EXIF 方向摆正。先只读尺寸，超过 maxDimension 的不解码；
+// GIF 只取第一帧。重新编码后 EXIF 等元数据不会保留
+func Decode(data []byte, maxDimension int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if cfg.Width > maxDimension || cfg.Height > maxDimension {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	return orient(img, jpegOrientation(data)), nil
+}
+
+// CropSquare 裁成正方形。crop 为空时取中间最大的正方形，否则按 crop 裁剪，crop 不是正方形时取其中心的正方形
+func CropSquare(img image.Image, crop *image.Rectangle) (image.Image, error) {
+	bounds := img.Bounds()
+	var rect image.Rectangle
+	if crop == nil {
+		rect = bounds
+	} else {
+		rect = crop.Add(bounds.Min)
+		if rect.Empty() || !rect.In(bounds) {
+			return nil, ErrInvalidCrop
+		}
+	}
+
+	side := rect.Dx()
+	if rect.Dy() < side {
+		side = rect.Dy()
+	}
+	x := rect.Min.X + (rect.Dx()-side)/2
+	y := rect.Min.Y + (rect.Dy()-side)/2
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), img, image.Pt(x, y), draw.Src)
+	return square, nil
+}
+
+// Resize 缩放到 width x height，每个目标像素取对应源区域的平均值（box filter），缩小时不会产生锯齿
+func Resize(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		sy0 := y * sh / height
+		sy1 := (y + 1) * sh / height
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < width; x++ {
+			sx0 := x * sw / width
+			sx1 := (x + 1) * sw / width
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, a, n uint32
+			for sy := sy0; sy < sy1; sy++ {
+				offset := sy*src.Stride + sx0*4
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG 编码为 JPEG，透明部分以白色填充
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	flat := image.NewRGBA(img.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// toRGBA 转为起点为 (0,0) 的 RGBA（预乘 alpha）
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
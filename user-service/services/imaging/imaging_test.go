@@ -0,0 +1,116 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// quadrants 左半红、右半蓝的测试图片
+func quadrants(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCropSquare(t *testing.T) {
+	tests := []struct {
+		name     string
+		w, h     int
+		crop     *image.Rectangle
+		wantSide int
+		wantErr  error
+	}{
+		{name: "横图取中间", w: 200, h: 100, wantSide: 100},
+		{name: "竖图取中间", w: 80, h: 120, wantSide: 80},
+		{name: "正方形不变", w: 64, h: 64, wantSide: 64},
+		{name: "指定区域", w: 200, h: 100, crop: &image.Rectangle{Min: image.Pt(10, 10), Max: image.Pt(60, 60)}, wantSide: 50},
+		{name: "非正方形区域取中心", w: 200, h: 100, crop: &image.Rectangle{Min: image.Pt(0, 0), Max: image.Pt(100, 40)}, wantSide: 40},
+		{name: "区域超出图片", w: 200, h: 100, crop: &image.Rectangle{Min: image.Pt(150, 0), Max: image.Pt(250, 100)}, wantErr: ErrInvalidCrop},
+		{name: "空区域", w: 200, h: 100, crop: &image.Rectangle{Min: image.Pt(10, 10), Max: image.Pt(10, 10)}, wantErr: ErrInvalidCrop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CropSquare(quadrants(tt.w, tt.h), tt.crop)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			b := got.Bounds()
+			if b.Dx() != tt.wantSide || b.Dy() != tt.wantSide {
+				t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantSide, tt.wantSide)
+			}
+		})
+	}
+}
+
+func TestCropSquareKeepsCenter(t *testing.T) {
+	// 200x100 的图片取中间 100x100，左右两半仍分别是红色和蓝色
+	got, err := CropSquare(quadrants(200, 100), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, b, _ := got.At(10, 50).RGBA(); r == 0 || b != 0 {
+		t.Errorf("left side is not red")
+	}
+	if r, _, b, _ := got.At(90, 50).RGBA(); r != 0 || b == 0 {
+		t.Errorf("right side is not blue")
+	}
+}
+
+func TestCropSquareOffsetBounds(t *testing.T) {
+	// crop 相对于图片左上角，图片 Bounds 不从 (0,0) 开始时也一样
+	img := quadrants(200, 100).SubImage(image.Rect(100, 0, 200, 100))
+	got, err := CropSquare(img, &image.Rectangle{Max: image.Pt(50, 50)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, b, _ := got.At(0, 0).RGBA(); r != 0 || b == 0 {
+		t.Errorf("crop did not start at the sub-image origin")
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name          string
+		w, h          int
+		width, height int
+	}{
+		{name: "缩小", w: 512, h: 512, width: 256, height: 256},
+		{name: "非整数倍缩小", w: 300, h: 300, width: 64, height: 64},
+		{name: "放大", w: 10, h: 10, width: 40, height: 40},
+		{name: "改变比例", w: 100, h: 50, width: 20, height: 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Resize(quadrants(tt.w, tt.h), tt.width, tt.height)
+			if b := got.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+				t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.width, tt.height)
+			}
+			if c := got.RGBAAt(0, 0); c != (color.RGBA{R: 255, A: 255}) {
+				t.Errorf("top-left = %v, want red", c)
+			}
+			if c := got.RGBAAt(tt.width-1, tt.height-1); c != (color.RGBA{B: 255, A: 255}) {
+				t.Errorf("bottom-right = %v, want blue", c)
+			}
+		})
+	}
+}
+
+func TestResizeAverages(t *testing.T) {
+	// 左红右蓝缩成 1x1，取平均值
+	got := Resize(quadrants(2, 2), 1, 1).RGBAAt(0, 0)
+	if got.R != 127 || got.B != 127 || got.A != 255 {
+		t.Errorf("average = %v", got)
+	}
+}
@@ -1,6 +1,11 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"image"
+	"time"
+
 	"reading-microservices/user-service/models"
 )
 
@@ -15,8 +20,8 @@ type UserServiceInterface interface {
 	// Logout 用户登出
 	Logout(accessToken string) error
 
-	// RefreshToken 刷新令牌（双token模式）
-	RefreshToken(refreshToken string) (*models.LoginResponse, error)
+	// RefreshToken 刷新令牌，refresh token 每次轮换
+	RefreshToken(refreshToken, ipAddress, userAgent string) (*models.LoginResponse, error)
 
 	// ValidateToken 验证令牌有效性
 	ValidateToken(accessToken string) (*models.UserSession, error)
@@ -29,7 +34,286 @@ type UserServiceInterface interface {
 
 	// ChangePassword 修改密码
 	ChangePassword(userID string, req *models.ChangePasswordRequest) error
+
+	// UnlockLogin 解除登录锁定
+	UnlockLogin(username string) error
+
+	// InvalidateUserCache 清除用户缓存
+	InvalidateUserCache(userID string) error
+
+	// BatchGetUsers 批量查询用户公开信息
+	BatchGetUsers(ids []string) ([]*models.UserPublicInfo, error)
+
+	// LoginHistory 登录记录
+	LoginHistory(userID string, page, size int) ([]*models.LoginHistoryItem, int64, error)
+
+	// ListDevices 在线设备列表
+	ListDevices(userID, currentAccessToken string) ([]*models.DeviceInfo, error)
+
+	// RevokeDevice 下线指定设备
+	RevokeDevice(userID, deviceID string) error
+
+	// RevokeOtherDevices 下线其他所有设备
+	RevokeOtherDevices(userID, currentAccessToken string) error
+}
+
+// OAuthServiceInterface 第三方登录相关方法
+type OAuthServiceInterface interface {
+	// Providers 已启用的第三方平台
+	Providers() []string
+
+	// Authorize 生成授权地址，userID 非空时为绑定流程
+	Authorize(ctx context.Context, platform, userID string) (*models.OAuthAuthorizeResponse, error)
+
+	// Login 第三方登录，首次登录自动注册
+	Login(ctx context.Context, platform string, req *models.OAuthLoginRequest) (*models.LoginResponse, error)
+
+	// Link 绑定第三方账号
+	Link(ctx context.Context, userID, platform string, req *models.OAuthLinkRequest) (*models.ThirdPartyAccountInfo, error)
+
+	// Unlink 解绑第三方账号
+	Unlink(userID, platform string) error
+
+	// SetPrimary 设置主账号
+	SetPrimary(userID, platform string) error
+
+	// ListAccounts 已绑定的第三方账号
+	ListAccounts(userID string) ([]*models.ThirdPartyAccountInfo, error)
+}
+
+// VerificationServiceInterface 邮箱和手机号验证
+type VerificationServiceInterface interface {
+	// SendEmailCode 发送邮箱验证码
+	SendEmailCode(ctx context.Context, userID string) (*models.SendCodeResponse, error)
+
+	// ConfirmEmail 确认邮箱验证码
+	ConfirmEmail(ctx context.Context, userID, code string) error
+
+	// SendPhoneCode 发送手机验证码
+	SendPhoneCode(ctx context.Context, userID string) (*models.SendCodeResponse, error)
+
+	// ConfirmPhone 确认手机验证码
+	ConfirmPhone(ctx context.Context, userID, code string) error
+}
+
+// PasswordResetServiceInterface 忘记密码与重置
+type PasswordResetServiceInterface interface {
+	// ForgotPassword 发送重置密码验证码
+	ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) (*models.SendCodeResponse, error)
+
+	// ResetPassword 使用验证码重置密码
+	ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error
+}
+
+// SMSLoginServiceInterface 短信验证码登录
+type SMSLoginServiceInterface interface {
+	// SendLoginCode 发送登录验证码
+	SendLoginCode(ctx context.Context, req *models.SendSMSCodeRequest) (*models.SendCodeResponse, error)
+
+	// Login 短信验证码登录，首次登录自动注册
+	Login(ctx context.Context, req *models.SMSLoginRequest) (*models.LoginResponse, error)
+}
+
+// TwoFactorServiceInterface 两步验证
+type TwoFactorServiceInterface interface {
+	// Status 两步验证状态
+	Status(userID string) (*models.TwoFactorStatus, error)
+
+	// Setup 生成 TOTP 密钥
+	Setup(userID string) (*models.TwoFactorSetupResponse, error)
+
+	// Enable 确认动态码并开启，返回恢复码
+	Enable(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error)
+
+	// Disable 关闭两步验证
+	Disable(ctx context.Context, userID, code string) error
+
+	// RegenerateRecoveryCodes 重新生成恢复码
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error)
+
+	// VerifyLogin 登录第二步
+	VerifyLogin(ctx context.Context, req *models.TwoFactorLoginRequest) (*models.LoginResponse, error)
+}
+
+// AccountDataServiceInterface 个人数据导出与账号注销
+type AccountDataServiceInterface interface {
+	// RequestExport 发起数据导出
+	RequestExport(userID string) (*models.DataExportJob, error)
+
+	// ExportStatus 导出任务状态
+	ExportStatus(userID, jobID string) (*models.DataExportJob, error)
+
+	// ExportFile 导出文件路径
+	ExportFile(userID, jobID string) (string, error)
+
+	// RequestDeletion 申请注销账号
+	RequestDeletion(userID string, req *models.RequestAccountDeletionRequest) (*models.AccountDeletionRequest, error)
+
+	// DeletionStatus 注销申请状态
+	DeletionStatus(userID string) (*models.AccountDeletionRequest, error)
+
+	// CancelDeletion 撤销注销申请
+	CancelDeletion(userID string) error
+}
+
+// AdminServiceInterface 管理后台用户管理
+type AdminServiceInterface interface {
+	// SearchUsers 筛选用户
+	SearchUsers(query *models.AdminUserQuery) ([]*models.AdminUserItem, int64, error)
+
+	// GetUser 用户详情
+	GetUser(userID string) (*models.AdminUserDetail, error)
+
+	// ListSessions 用户在线设备
+	ListSessions(userID string) ([]*models.DeviceInfo, error)
+
+	// LoginHistory 用户登录记录
+	LoginHistory(userID string, page, size int) ([]*models.LoginHistoryItem, int64, error)
+
+	// BanUser 封禁用户
+	BanUser(adminID, adminRole, userID string, req *models.AdminBanRequest) error
+
+	// UnbanUser 解除封禁
+	UnbanUser(adminID, adminRole, userID string) error
+
+	// ForceLogout 强制下线
+	ForceLogout(adminID, adminRole, userID string) error
+
+	// ResetPassword 重置密码
+	ResetPassword(adminID, adminRole, userID string, req *models.AdminResetPasswordRequest) (*models.AdminResetPasswordResponse, error)
+
+	// UpdateRole 调整角色
+	UpdateRole(adminID, adminRole, userID, role string) error
+
+	// SetRoleByUsername 命令行初始化管理员时指定角色
+	SetRoleByUsername(username, role string) error
 }
 
 // 可选：验证 UserService 是否实现了接口
 var _ UserServiceInterface = (*UserService)(nil)
+var _ OAuthServiceInterface = (*OAuthService)(nil)
+var _ VerificationServiceInterface = (*VerificationService)(nil)
+var _ PasswordResetServiceInterface = (*PasswordResetService)(nil)
+var _ SMSLoginServiceInterface = (*SMSLoginService)(nil)
+var _ TwoFactorServiceInterface = (*TwoFactorService)(nil)
+var _ AccountDataServiceInterface = (*AccountDataService)(nil)
+
+// AccountMergeServiceInterface 账号合并
+type AccountMergeServiceInterface interface {
+	// Preview 合并预览，列出各服务将转移和丢弃的数据条数
+	Preview(ctx context.Context, primaryID string, req *models.MergeAccountRequest) (*models.AccountMergePreview, error)
+
+	// SendCode 向副账号发送合并验证码
+	SendCode(ctx context.Context, primaryID string, req *models.SendMergeCodeRequest) (*models.SendCodeResponse, error)
+
+	// Merge 校验副账号的密码或验证码后，把副账号合并到当前账号并注销副账号
+	Merge(ctx context.Context, primaryID string, req *models.MergeAccountRequest) (*models.AccountMerge, error)
+}
+
+var _ AccountMergeServiceInterface = (*AccountMergeService)(nil)
+var _ AdminServiceInterface = (*AdminService)(nil)
+
+// ProgressionServiceInterface 经验与等级
+type ProgressionServiceInterface interface {
+	// Award 记一次经验事件（内部接口）
+	Award(req *models.AwardExperienceRequest) (*models.AwardExperienceResponse, error)
+
+	// CheckIn 每日签到
+	CheckIn(userID string) (*models.AwardExperienceResponse, error)
+
+	// Progress 等级进度
+	Progress(userID string) (*models.LevelProgress, error)
+
+	// Levels 全部等级
+	Levels() []models.LevelInfo
+}
+
+// SocialServiceInterface 关注、公开主页和动态
+type SocialServiceInterface interface {
+	// PublicProfile 公开主页
+	PublicProfile(viewerID, userID string) (*models.PublicProfile, error)
+
+	// Follow 关注
+	Follow(followerID, followeeID string) error
+
+	// Unfollow 取消关注
+	Unfollow(followerID, followeeID string) error
+
+	// Followers 粉丝列表
+	Followers(viewerID, userID string, page, size int) ([]*models.FollowUserItem, int64, error)
+
+	// Following 关注列表
+	Following(viewerID, userID string, page, size int) ([]*models.FollowUserItem, int64, error)
+
+	// Bookshelf 公开书架
+	Bookshelf(ctx context.Context, viewerID, userID, shelfType string, page, size int) (json.RawMessage, int64, error)
+
+	// Activity 某个用户的动态
+	Activity(ctx context.Context, viewerID, userID string, before *time.Time, limit int) (*models.FeedResponse, error)
+
+	// Feed 关注的人的动态
+	Feed(ctx context.Context, userID string, before *time.Time, limit int) (*models.FeedResponse, error)
+
+	// GetPrivacy 隐私设置
+	GetPrivacy(userID string) (*models.UserPrivacy, error)
+
+	// UpdatePrivacy 修改隐私设置
+	UpdatePrivacy(userID string, req *models.UpdatePrivacyRequest) (*models.UserPrivacy, error)
+}
+
+var _ SocialServiceInterface = (*SocialService)(nil)
+
+// AvatarServiceInterface 头像上传
+type AvatarServiceInterface interface {
+	// MaxSize 上传文件大小上限（字节）
+	MaxSize() int
+
+	// Upload 处理并保存头像，返回各尺寸地址
+	Upload(ctx context.Context, userID string, data []byte, crop *image.Rectangle) (*models.AvatarResponse, error)
+
+	// Remove 删除头像
+	Remove(ctx context.Context, userID string) error
+}
+
+var _ AvatarServiceInterface = (*AvatarService)(nil)
+
+// TeenModeServiceInterface 青少年模式
+type TeenModeServiceInterface interface {
+	// Status 当前状态和今日阅读时长
+	Status(ctx context.Context, userID string) (*models.TeenModeStatus, error)
+
+	// RecordReading 阅读服务上报阅读时长（内部接口）
+	RecordReading(ctx context.Context, req *models.RecordTeenReadingRequest) (*models.TeenModeStatus, error)
+
+	// Enable 监护人开启并设置监护密码
+	Enable(userID string, req *models.EnableTeenModeRequest) (*models.TeenModeStatus, error)
+
+	// Update 修改设置，需要监护密码
+	Update(ctx context.Context, userID string, req *models.UpdateTeenModeRequest) (*models.TeenModeStatus, error)
+
+	// Disable 关闭，需要监护密码
+	Disable(ctx context.Context, userID string, req *models.TeenModePinRequest) error
+}
+
+var _ TeenModeServiceInterface = (*TeenModeService)(nil)
+
+// PreferenceServiceInterface 阅读偏好
+type PreferenceServiceInterface interface {
+	// Get 账号级设置、设备覆盖项和合并后的生效设置
+	Get(userID, deviceID string) (*models.PreferencesResponse, error)
+	// Update 部分更新，按项比较最后写入时间解决多端冲突
+	Update(userID string, req *models.UpdatePreferencesRequest) (*models.PreferencesResponse, error)
+}
+
+var _ PreferenceServiceInterface = (*PreferenceService)(nil)
+
+// ReferralServiceInterface 邀请注册
+type ReferralServiceInterface interface {
+	// Summary 邀请码、邀请人数和获得的奖励
+	Summary(userID string) (*models.ReferralSummary, error)
+
+	// Invitees 邀请的用户和因每个人获得的奖励
+	Invitees(userID string, page, size int) ([]models.InviteeItem, int64, error)
+}
+
+var _ ReferralServiceInterface = (*ReferralService)(nil)
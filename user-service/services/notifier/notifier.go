@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"reading-microservices/shared/utils"
+)
+
+// LoginAlert 新设备或新地区登录提醒
+type LoginAlert struct {
+	UserID      string
+	LoginType   string
+	Platform    string
+	DeviceID    string
+	IPAddress   string
+	Location    string
+	NewDevice   bool
+	NewLocation bool
+	LoginAt     time.Time
+}
+
+// SecurityNotifier 发送账号安全提醒
+type SecurityNotifier interface {
+	NotifyNewLogin(ctx context.Context, alert *LoginAlert) error
+}
+
+// NewSecurityNotifier driver 为 http（通知服务站内信）或 log，token 为调用通知服务内部接口的凭证
+func NewSecurityNotifier(driver, notificationURL, token string) (SecurityNotifier, error) {
+	switch driver {
+	case "http":
+		if notificationURL == "" {
+			return nil, fmt.Errorf("notification url is required for http notifier")
+		}
+		return &httpNotifier{
+			endpoint: strings.TrimRight(notificationURL, "/") + "/api/v1/internal/notification/",
+			client:   utils.NewInternalClient(token, 5*time.Second),
+		}, nil
+	case "", "log":
+		return logNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown security notifier driver: %s", driver)
+	}
+}
+
+// Message 提醒标题和正文
+func (a *LoginAlert) Message() (string, string) {
+	var what string
+	switch {
+	case a.NewDevice && a.NewLocation:
+		what = "新设备和新地区"
+	case a.NewLocation:
+		what = "新地区"
+	default:
+		what = "新设备"
+	}
+	location := a.Location
+	if location == "" {
+		location = "未知地区"
+	}
+	content := fmt.Sprintf("您的账号于 %s 在%s登录（%s，IP %s，%s）。如非本人操作，请立即修改密码并在设备管理中下线该设备。",
+		a.LoginAt.Format("2006-01-02 15:04"), what, location, a.IPAddress, a.Platform)
+	return "账号登录提醒", content
+}
+
+// httpNotifier 调用通知服务内部接口发送系统通知
+type httpNotifier struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (n *httpNotifier) NotifyNewLogin(ctx context.Context, alert *LoginAlert) error {
+	title, content := alert.Message()
+	body, err := json.Marshal(map[string]interface{}{
+		"user_id":      alert.UserID,
+		"title":        title,
+		"content":      content,
+		"type":         "system",
+		"related_type": "login",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("notification service: status %d: %w", resp.StatusCode, err)
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("notification service: %s", result.Message)
+	}
+	return nil
+}
+
+// logNotifier 本地开发只写日志
+type logNotifier struct{}
+
+func (logNotifier) NotifyNewLogin(ctx context.Context, alert *LoginAlert) error {
+	title, content := alert.Message()
+	logrus.WithField("user_id", alert.UserID).Info(title + ": " + content)
+	return nil
+}
@@ -0,0 +1,141 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"reading-microservices/user-service/config"
+)
+
+const appleIssuer = "https://appleid.apple.com"
+
+// appleProvider Sign in with Apple
+type appleProvider struct {
+	cfg        config.ProviderConfig
+	privateKey *ecdsa.PrivateKey
+}
+
+func NewAppleProvider(cfg config.ProviderConfig) (Provider, error) {
+	pemData, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read apple private key failed: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(pemData)
+	if err != nil {
+		return nil, fmt.Errorf("parse apple private key failed: %w", err)
+	}
+	return &appleProvider{cfg: cfg, privateKey: key}, nil
+}
+
+func (p *appleProvider) Name() string {
+	return "apple"
+}
+
+func (p *appleProvider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("scope", "name email")
+	query.Set("response_mode", "form_post")
+	query.Set("state", state)
+	return appleIssuer + "/auth/authorize?" + query.Encode()
+}
+
+// clientSecret Apple 要求用开发者私钥签发 ES256 JWT 作为 client_secret
+func (p *appleProvider) clientSecret() (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    p.cfg.TeamID,
+		Subject:   p.cfg.ClientID,
+		Audience:  jwt.ClaimStrings{appleIssuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = p.cfg.KeyID
+	return token.SignedString(p.privateKey)
+}
+
+func (p *appleProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	secret, err := p.clientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", secret)
+	form.Set("code", code)
+	form.Set("grant_type", "authorization_code")
+	form.Set("redirect_uri", p.cfg.RedirectURI)
+
+	req, err := http.NewRequest(http.MethodPost, appleIssuer+"/auth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.IDToken == "" {
+		return nil, errors.New("apple id_token not returned")
+	}
+
+	return &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		IDToken:      resp.IDToken,
+	}, nil
+}
+
+type appleIDClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// FetchProfile Apple 没有用户信息接口，资料来自 id_token。
+// id_token 是服务端通过 TLS 直接从 Apple token 接口取得的，这里只校验签发方和受众。
+func (p *appleProvider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	var claims appleIDClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token.IDToken, &claims); err != nil {
+		return nil, fmt.Errorf("parse apple id_token failed: %w", err)
+	}
+	if claims.Issuer != appleIssuer {
+		return nil, errors.New("apple id_token issuer mismatch")
+	}
+	audienceMatched := false
+	for _, aud := range claims.Audience {
+		if aud == p.cfg.ClientID {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched {
+		return nil, errors.New("apple id_token audience mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("apple id_token subject missing")
+	}
+
+	return &Profile{
+		PlatformUserID: claims.Subject,
+		Email:          claims.Email,
+	}, nil
+}
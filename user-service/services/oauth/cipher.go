@@ -0,0 +1,66 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// TokenCipher 第三方 token 加密存储（AES-256-GCM）
+type TokenCipher struct {
+	aead cipher.AEAD
+}
+
+// NewTokenCipher 由配置的密钥派生 256 位加密密钥
+func NewTokenCipher(secret string) (*TokenCipher, error) {
+	if secret == "" {
+		return nil, errors.New("oauth token encryption key is empty")
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenCipher{aead: aead}, nil
+}
+
+// Encrypt 返回 base64(nonce || ciphertext)，空字符串返回 nil
+func (c *TokenCipher) Encrypt(plain string) (*string, error) {
+	if plain == "" {
+		return nil, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plain), nil)
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	return &encoded, nil
+}
+
+func (c *TokenCipher) Decrypt(encoded *string) (string, error) {
+	if encoded == nil || *encoded == "" {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(*encoded)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := c.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// fakeProvider 本地联调用的假平台，不访问外部网络。
+// 授权码即平台用户标识，同一个 code 总是得到同一个第三方用户。
+type fakeProvider struct {
+	name        string
+	redirectURI string
+}
+
+func NewFakeProvider(name, redirectURI string) Provider {
+	return &fakeProvider{name: name, redirectURI: redirectURI}
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("code", "fake-user")
+	query.Set("state", state)
+	return p.redirectURI + "?" + query.Encode()
+}
+
+func (p *fakeProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("fake provider: empty code")
+	}
+	sum := sha1.Sum([]byte(p.name + ":" + code))
+	openID := hex.EncodeToString(sum[:])
+	return &Token{
+		AccessToken:  "fake-access-" + openID,
+		RefreshToken: "fake-refresh-" + openID,
+		ExpiresIn:    7200,
+		OpenID:       openID,
+	}, nil
+}
+
+func (p *fakeProvider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	return &Profile{
+		PlatformUserID: token.OpenID,
+		OpenID:         token.OpenID,
+		Nickname:       p.name + "_" + token.OpenID[:8],
+	}, nil
+}
@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrProviderNotFound = errors.New("oauth provider not supported")
+	ErrInvalidState     = errors.New("invalid or expired oauth state")
+)
+
+// Token 第三方平台返回的授权凭证
+type Token struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int
+	OpenID       string
+	UnionID      string
+	IDToken      string // Apple 在 token 接口中直接返回用户信息
+}
+
+// ExpiresAt 计算过期时间，平台未返回时为 nil
+func (t *Token) ExpiresAt() *time.Time {
+	if t.ExpiresIn <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+	return &expiresAt
+}
+
+// Profile 第三方平台的用户资料
+type Profile struct {
+	PlatformUserID string
+	UnionID        string
+	OpenID         string
+	Username       string
+	Nickname       string
+	Avatar         string
+	Email          string
+	Phone          string
+}
+
+// Provider 第三方登录平台
+type Provider interface {
+	// Name 平台名称，与 ThirdPartyAccount.Platform 一致
+	Name() string
+
+	// AuthorizeURL 生成跳转到平台授权页的地址
+	AuthorizeURL(state string) string
+
+	// ExchangeCode 用授权码换取 token
+	ExchangeCode(ctx context.Context, code string) (*Token, error)
+
+	// FetchProfile 获取平台用户资料
+	FetchProfile(ctx context.Context, token *Token) (*Profile, error)
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// getJSON 请求平台接口并解析 JSON 响应
+func getJSON(ctx context.Context, req *http.Request, out interface{}) error {
+	resp, err := httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oauth provider returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,148 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"reading-microservices/user-service/config"
+)
+
+// qqProvider QQ 互联登录
+type qqProvider struct {
+	cfg config.ProviderConfig
+}
+
+func NewQQProvider(cfg config.ProviderConfig) Provider {
+	return &qqProvider{cfg: cfg}
+}
+
+func (p *qqProvider) Name() string {
+	return "qq"
+}
+
+func (p *qqProvider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURI)
+	query.Set("state", state)
+	query.Set("scope", "get_user_info")
+	return "https://graph.qq.com/oauth2.0/authorize?" + query.Encode()
+}
+
+func (p *qqProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	query := url.Values{}
+	query.Set("grant_type", "authorization_code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("client_secret", p.cfg.ClientSecret)
+	query.Set("code", code)
+	query.Set("redirect_uri", p.cfg.RedirectURI)
+	query.Set("fmt", "json")
+
+	req, err := http.NewRequest(http.MethodGet, "https://graph.qq.com/oauth2.0/token?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		AccessToken      string      `json:"access_token"`
+		ExpiresIn        json.Number `json:"expires_in"` // QQ 返回字符串形式的数字
+		RefreshToken     string      `json:"refresh_token"`
+		Error            int         `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != 0 {
+		return nil, fmt.Errorf("qq error %d: %s", resp.Error, resp.ErrorDescription)
+	}
+
+	expiresIn, _ := resp.ExpiresIn.Int64()
+	token := &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    int(expiresIn),
+	}
+
+	// QQ 需要额外请求一次获取 openid / unionid
+	if err := p.fetchOpenID(ctx, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (p *qqProvider) fetchOpenID(ctx context.Context, token *Token) error {
+	query := url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("unionid", "1")
+	query.Set("fmt", "json")
+
+	req, err := http.NewRequest(http.MethodGet, "https://graph.qq.com/oauth2.0/me?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		ClientID string `json:"client_id"`
+		OpenID   string `json:"openid"`
+		UnionID  string `json:"unionid"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return err
+	}
+	if resp.OpenID == "" {
+		return errors.New("qq openid not returned")
+	}
+
+	token.OpenID = resp.OpenID
+	token.UnionID = resp.UnionID
+	return nil
+}
+
+func (p *qqProvider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	query := url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("oauth_consumer_key", p.cfg.ClientID)
+	query.Set("openid", token.OpenID)
+
+	req, err := http.NewRequest(http.MethodGet, "https://graph.qq.com/user/get_user_info?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Ret          int    `json:"ret"`
+		Msg          string `json:"msg"`
+		Nickname     string `json:"nickname"`
+		FigureURLQQ2 string `json:"figureurl_qq_2"`
+		FigureURLQQ1 string `json:"figureurl_qq_1"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Ret != 0 {
+		return nil, fmt.Errorf("qq error %d: %s", resp.Ret, resp.Msg)
+	}
+
+	avatar := resp.FigureURLQQ2
+	if avatar == "" {
+		avatar = resp.FigureURLQQ1
+	}
+	platformUserID := token.UnionID
+	if platformUserID == "" {
+		platformUserID = token.OpenID
+	}
+
+	return &Profile{
+		PlatformUserID: platformUserID,
+		UnionID:        token.UnionID,
+		OpenID:         token.OpenID,
+		Nickname:       resp.Nickname,
+		Avatar:         avatar,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"reading-microservices/user-service/config"
+)
+
+// Registry 已启用的第三方平台
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry 根据配置创建平台，mode 为 fake 或列在 fake_providers 中时使用假平台。
+// 假平台把授权码当作第三方身份，任何人都能冒充任意第三方用户，未开启 allow_fake 时返回错误
+func NewRegistry(cfg config.OAuthConfig) (*Registry, error) {
+	providers := make(map[string]config.ProviderConfig, len(cfg.Providers))
+	for name, pc := range cfg.Providers {
+		providers[name] = pc
+	}
+	for _, name := range cfg.FakeProviders {
+		pc := providers[name]
+		pc.Enabled = true
+		pc.Mode = "fake"
+		providers[name] = pc
+	}
+
+	r := &Registry{providers: make(map[string]Provider)}
+	for name, pc := range providers {
+		if !pc.Enabled {
+			continue
+		}
+		if pc.Mode == "fake" {
+			if !cfg.AllowFake {
+				return nil, fmt.Errorf("oauth provider %s uses fake mode but allow_fake is not set", name)
+			}
+			logrus.Warnf("OAuth provider %s uses fake mode, do not use in production", name)
+			r.providers[name] = NewFakeProvider(name, pc.RedirectURI)
+			continue
+		}
+
+		switch name {
+		case "wechat":
+			r.providers[name] = NewWechatProvider(pc)
+		case "qq":
+			r.providers[name] = NewQQProvider(pc)
+		case "weibo":
+			r.providers[name] = NewWeiboProvider(pc)
+		case "apple":
+			provider, err := NewAppleProvider(pc)
+			if err != nil {
+				logrus.Warnf("Apple oauth provider disabled: %v", err)
+				continue
+			}
+			r.providers[name] = provider
+		default:
+			logrus.Warnf("Unknown oauth provider %s ignored", name)
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) Get(name string) (Provider, error) {
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+	return provider, nil
+}
+
+// Names 已启用的平台名称
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
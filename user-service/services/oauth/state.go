@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const statePrefix = "oauth_state:"
+
+const (
+	PurposeLogin = "login"
+	PurposeLink  = "link"
+)
+
+// State 发起授权时记录的上下文，回调时校验
+type State struct {
+	Platform string `json:"platform"`
+	Purpose  string `json:"purpose"`
+	UserID   string `json:"user_id,omitempty"` // 绑定时的当前用户
+}
+
+// StateStore 防 CSRF 的一次性 state，存 Redis
+type StateStore struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+}
+
+func NewStateStore(redisClient *redis.Client, ttl time.Duration) *StateStore {
+	return &StateStore{redisClient: redisClient, ttl: ttl}
+}
+
+func (s *StateStore) Create(ctx context.Context, state *State) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := hex.EncodeToString(b)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	if err := s.redisClient.Set(ctx, statePrefix+key, data, s.ttl).Err(); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Consume 取出并删除 state，同一个 state 只能使用一次
+func (s *StateStore) Consume(ctx context.Context, key string) (*State, error) {
+	var get *redis.StringCmd
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, statePrefix+key)
+		pipe.Del(ctx, statePrefix+key)
+		return nil
+	})
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+
+	var state State
+	if err := json.Unmarshal([]byte(get.Val()), &state); err != nil {
+		return nil, ErrInvalidState
+	}
+	return &state, nil
+}
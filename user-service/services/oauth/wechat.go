@@ -0,0 +1,120 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"reading-microservices/user-service/config"
+)
+
+// wechatProvider 微信开放平台网站应用登录
+type wechatProvider struct {
+	cfg config.ProviderConfig
+}
+
+func NewWechatProvider(cfg config.ProviderConfig) Provider {
+	return &wechatProvider{cfg: cfg}
+}
+
+func (p *wechatProvider) Name() string {
+	return "wechat"
+}
+
+func (p *wechatProvider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("appid", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("scope", "snsapi_login")
+	query.Set("state", state)
+	return "https://open.weixin.qq.com/connect/qrconnect?" + query.Encode() + "#wechat_redirect"
+}
+
+type wechatError struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+func (e wechatError) err() error {
+	if e.ErrCode == 0 {
+		return nil
+	}
+	return fmt.Errorf("wechat error %d: %s", e.ErrCode, e.ErrMsg)
+}
+
+func (p *wechatProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	query := url.Values{}
+	query.Set("appid", p.cfg.ClientID)
+	query.Set("secret", p.cfg.ClientSecret)
+	query.Set("code", code)
+	query.Set("grant_type", "authorization_code")
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.weixin.qq.com/sns/oauth2/access_token?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		wechatError
+		AccessToken  string `json:"access_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+		OpenID       string `json:"openid"`
+		UnionID      string `json:"unionid"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		OpenID:       resp.OpenID,
+		UnionID:      resp.UnionID,
+	}, nil
+}
+
+func (p *wechatProvider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	query := url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("openid", token.OpenID)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.weixin.qq.com/sns/userinfo?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		wechatError
+		OpenID     string `json:"openid"`
+		UnionID    string `json:"unionid"`
+		Nickname   string `json:"nickname"`
+		HeadImgURL string `json:"headimgurl"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+
+	// 同一开放平台下 unionid 稳定，优先作为平台用户 ID
+	platformUserID := resp.UnionID
+	if platformUserID == "" {
+		platformUserID = resp.OpenID
+	}
+
+	return &Profile{
+		PlatformUserID: platformUserID,
+		UnionID:        resp.UnionID,
+		OpenID:         resp.OpenID,
+		Nickname:       resp.Nickname,
+		Avatar:         resp.HeadImgURL,
+	}, nil
+}
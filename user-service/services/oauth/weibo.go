@@ -0,0 +1,105 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"reading-microservices/user-service/config"
+)
+
+// weiboProvider 微博开放平台登录
+type weiboProvider struct {
+	cfg config.ProviderConfig
+}
+
+func NewWeiboProvider(cfg config.ProviderConfig) Provider {
+	return &weiboProvider{cfg: cfg}
+}
+
+func (p *weiboProvider) Name() string {
+	return "weibo"
+}
+
+func (p *weiboProvider) AuthorizeURL(state string) string {
+	query := url.Values{}
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURI)
+	query.Set("response_type", "code")
+	query.Set("state", state)
+	return "https://api.weibo.com/oauth2/authorize?" + query.Encode()
+}
+
+func (p *weiboProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	form := url.Values{}
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURI)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.weibo.com/oauth2/access_token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		UID         string `json:"uid"`
+		Error       string `json:"error"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New("weibo error: " + resp.Error)
+	}
+
+	return &Token{
+		AccessToken: resp.AccessToken,
+		ExpiresIn:   resp.ExpiresIn,
+		OpenID:      resp.UID,
+	}, nil
+}
+
+func (p *weiboProvider) FetchProfile(ctx context.Context, token *Token) (*Profile, error) {
+	query := url.Values{}
+	query.Set("access_token", token.AccessToken)
+	query.Set("uid", token.OpenID)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.weibo.com/2/users/show.json?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		IDStr       string `json:"idstr"`
+		ScreenName  string `json:"screen_name"`
+		Name        string `json:"name"`
+		AvatarLarge string `json:"avatar_large"`
+		Error       string `json:"error"`
+	}
+	if err := getJSON(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New("weibo error: " + resp.Error)
+	}
+
+	platformUserID := resp.IDStr
+	if platformUserID == "" {
+		platformUserID = token.OpenID
+	}
+
+	return &Profile{
+		PlatformUserID: platformUserID,
+		OpenID:         token.OpenID,
+		Username:       resp.Name,
+		Nickname:       resp.ScreenName,
+		Avatar:         resp.AvatarLarge,
+	}, nil
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/oauth"
+)
+
+// OAuthService 第三方登录、绑定与解绑
+type OAuthService struct {
+	userRepo    repositories.UserRepository
+	userService *UserService
+	registry    *oauth.Registry
+	states      *oauth.StateStore
+	cipher      *oauth.TokenCipher
+}
+
+func NewOAuthService(
+	userRepo repositories.UserRepository,
+	userService *UserService,
+	registry *oauth.Registry,
+	states *oauth.StateStore,
+	cipher *oauth.TokenCipher,
+) *OAuthService {
+	return &OAuthService{
+		userRepo:    userRepo,
+		userService: userService,
+		registry:    registry,
+		states:      states,
+		cipher:      cipher,
+	}
+}
+
+// Providers 已启用的第三方平台
+func (s *OAuthService) Providers() []string {
+	return s.registry.Names()
+}
+
+// ------------------- Authorize -------------------
+
+// Authorize 生成授权地址；userID 非空时为绑定流程
+func (s *OAuthService) Authorize(ctx context.Context, platform, userID string) (*models.OAuthAuthorizeResponse, error) {
+	provider, err := s.registry.Get(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &oauth.State{Platform: platform, Purpose: oauth.PurposeLogin}
+	if userID != "" {
+		state.Purpose = oauth.PurposeLink
+		state.UserID = userID
+	}
+	key, err := s.states.Create(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OAuthAuthorizeResponse{
+		Platform:     platform,
+		AuthorizeURL: provider.AuthorizeURL(key),
+		State:        key,
+	}, nil
+}
+
+// ------------------- Login -------------------
+
+func (s *OAuthService) Login(ctx context.Context, platform string, req *models.OAuthLoginRequest) (*models.LoginResponse, error) {
+	provider, token, profile, err := s.exchange(ctx, platform, req.Code, req.State, "")
+	if err != nil {
+		return nil, err
+	}
+
+	isNewUser := false
+	account, err := s.userRepo.GetThirdPartyAccount(provider.Name(), profile.PlatformUserID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		account, err = s.register(provider.Name(), token, profile)
+		if err != nil {
+			return nil, err
+		}
+		isNewUser = true
+	case err != nil:
+		return nil, err
+	default:
+		if err := s.refreshAccount(account, token, profile); err != nil {
+			log.Printf("warning: update third party account failed: %v", err)
+		}
+	}
+
+	user, err := s.userRepo.GetByID(account.UserID)
+	if err != nil {
+		return nil, errors.New("user not found or disabled")
+	}
+	now := time.Now()
+	user.LastThirdPartyLogin = &now
+	user.LastLoginAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		log.Printf("warning: update user last login failed: %v", err)
+	}
+
+	client := auth.ClientInfo{
+		Platform:  req.Platform,
+		DeviceID:  req.DeviceID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}
+	resp, err := s.userService.startSession(user, provider.Name(), client)
+	if err != nil {
+		return nil, err
+	}
+	resp.IsNewUser = isNewUser
+	return resp, nil
+}
+
+// register 第三方首次登录，自动创建用户
+func (s *OAuthService) register(platform string, token *oauth.Token, profile *oauth.Profile) (*models.ThirdPartyAccount, error) {
+	username, err := generateUsername(s.userRepo, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第三方用户没有密码，随机生成一个不可猜测的哈希占位
+	randomPassword, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.userService.authManager.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     username,
+		PasswordHash: hashedPassword,
+		LoginType:    platform,
+	}
+	if profile.Nickname != "" {
+		user.Nickname = &profile.Nickname
+	}
+	if profile.Avatar != "" {
+		user.AvatarURL = &profile.Avatar
+	}
+	// 邮箱未被其他账号占用时才带入
+	if profile.Email != "" {
+		if _, err := s.userRepo.GetByEmail(profile.Email); errors.Is(err, gorm.ErrRecordNotFound) {
+			user.Email = &profile.Email
+		}
+	}
+
+	account := &models.ThirdPartyAccount{
+		Platform:       platform,
+		PlatformUserID: profile.PlatformUserID,
+		IsPrimary:      true,
+		IsActive:       true,
+	}
+	if err := s.fillAccount(account, token, profile); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.CreateUserWithThirdPartyAccount(user, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ------------------- Link / Unlink -------------------
+
+// Link 将第三方账号绑定到当前用户
+func (s *OAuthService) Link(ctx context.Context, userID, platform string, req *models.OAuthLinkRequest) (*models.ThirdPartyAccountInfo, error) {
+	provider, token, profile, err := s.exchange(ctx, platform, req.Code, req.State, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.userRepo.GetThirdPartyAccount(provider.Name(), profile.PlatformUserID)
+	if err == nil {
+		if existing.UserID != userID {
+			return nil, errors.New("third party account already linked to another user")
+		}
+		// 重复绑定视为刷新 token
+		if err := s.refreshAccount(existing, token, profile); err != nil {
+			return nil, err
+		}
+		return convertToThirdPartyAccountInfo(existing), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if _, err := s.userRepo.GetUserThirdPartyAccount(userID, provider.Name()); err == nil {
+		return nil, errors.New("platform already linked, unlink it first")
+	}
+
+	accounts, err := s.userRepo.GetUserThirdPartyAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.ThirdPartyAccount{
+		Platform:       provider.Name(),
+		PlatformUserID: profile.PlatformUserID,
+		IsPrimary:      len(accounts) == 0,
+		IsActive:       true,
+	}
+	if err := s.fillAccount(account, token, profile); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.LinkThirdPartyAccount(userID, account); err != nil {
+		return nil, err
+	}
+	return convertToThirdPartyAccountInfo(account), nil
+}
+
+// Unlink 解绑第三方账号，不允许解绑唯一的登录方式
+func (s *OAuthService) Unlink(userID, platform string) error {
+	account, err := s.userRepo.GetUserThirdPartyAccount(userID, platform)
+	if err != nil {
+		return errors.New("platform not linked")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	accounts, err := s.userRepo.GetUserThirdPartyAccounts(userID)
+	if err != nil {
+		return err
+	}
+	if user.LoginType != "password" && len(accounts) <= 1 {
+		return errors.New("cannot unlink the only login method")
+	}
+
+	if err := s.userRepo.DeleteThirdPartyAccount(account.ID); err != nil {
+		return err
+	}
+
+	// 主账号被解绑时，把最早绑定的账号设为主账号
+	if account.IsPrimary {
+		for _, other := range accounts {
+			if other.ID != account.ID {
+				return s.userRepo.SetPrimaryThirdPartyAccount(userID, other.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// SetPrimary 设置主账号
+func (s *OAuthService) SetPrimary(userID, platform string) error {
+	account, err := s.userRepo.GetUserThirdPartyAccount(userID, platform)
+	if err != nil {
+		return errors.New("platform not linked")
+	}
+	return s.userRepo.SetPrimaryThirdPartyAccount(userID, account.ID)
+}
+
+// ListAccounts 当前用户已绑定的第三方账号
+func (s *OAuthService) ListAccounts(userID string) ([]*models.ThirdPartyAccountInfo, error) {
+	accounts, err := s.userRepo.GetUserThirdPartyAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.ThirdPartyAccountInfo, 0, len(accounts))
+	for i := range accounts {
+		result = append(result, convertToThirdPartyAccountInfo(&accounts[i]))
+	}
+	return result, nil
+}
+
+// ------------------- Helper -------------------
+
+// exchange 校验 state 后用授权码换取 token 和用户资料
+func (s *OAuthService) exchange(ctx context.Context, platform, code, stateKey, userID string) (oauth.Provider, *oauth.Token, *oauth.Profile, error) {
+	provider, err := s.registry.Get(platform)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	state, err := s.states.Consume(ctx, stateKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	expectedPurpose := oauth.PurposeLogin
+	if userID != "" {
+		expectedPurpose = oauth.PurposeLink
+	}
+	if state.Platform != platform || state.Purpose != expectedPurpose || state.UserID != userID {
+		return nil, nil, nil, oauth.ErrInvalidState
+	}
+
+	token, err := provider.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	profile, err := provider.FetchProfile(ctx, token)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if profile.PlatformUserID == "" {
+		return nil, nil, nil, errors.New("third party user id missing")
+	}
+	return provider, token, profile, nil
+}
+
+// refreshAccount 每次登录/绑定时更新第三方资料和 token
+func (s *OAuthService) refreshAccount(account *models.ThirdPartyAccount, token *oauth.Token, profile *oauth.Profile) error {
+	if err := s.fillAccount(account, token, profile); err != nil {
+		return err
+	}
+	return s.userRepo.UpdateThirdPartyAccount(account)
+}
+
+// fillAccount 写入第三方资料，token 加密后保存
+func (s *OAuthService) fillAccount(account *models.ThirdPartyAccount, token *oauth.Token, profile *oauth.Profile) error {
+	accessToken, err := s.cipher.Encrypt(token.AccessToken)
+	if err != nil {
+		return err
+	}
+	refreshToken, err := s.cipher.Encrypt(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	account.AccessToken = accessToken
+	account.RefreshToken = refreshToken
+	account.TokenExpiresAt = token.ExpiresAt()
+	account.LastLoginAt = &now
+	account.PlatformUsername = optionalString(profile.Username)
+	account.PlatformNickname = optionalString(profile.Nickname)
+	account.PlatformAvatar = optionalString(profile.Avatar)
+	account.PlatformEmail = optionalString(profile.Email)
+	account.PlatformPhone = optionalString(profile.Phone)
+	account.UnionID = optionalString(profile.UnionID)
+	account.OpenID = optionalString(profile.OpenID)
+	return nil
+}
+
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func convertToThirdPartyAccountInfo(account *models.ThirdPartyAccount) *models.ThirdPartyAccountInfo {
+	return &models.ThirdPartyAccountInfo{
+		ID:               account.ID,
+		Platform:         account.Platform,
+		PlatformNickname: account.PlatformNickname,
+		PlatformAvatar:   account.PlatformAvatar,
+		IsPrimary:        account.IsPrimary,
+		LastLoginAt:      account.LastLoginAt,
+		CreatedAt:        account.CreatedAt,
+	}
+}
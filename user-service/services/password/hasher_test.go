@@ -0,0 +1,104 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"reading-microservices/user-service/config"
+)
+
+// 测试用较小的 argon2 参数，避免拖慢测试
+var testArgon2 = config.Argon2Config{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestHasher(t *testing.T, cfg config.PasswordHashConfig) *Hasher {
+	t.Helper()
+	h, err := NewHasher(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestHasherHashVerify(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmArgon2id, AlgorithmBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			h := newTestHasher(t, config.PasswordHashConfig{Algorithm: algorithm, BcryptCost: bcrypt.MinCost, Argon2: testArgon2})
+			encoded, err := h.Hash("Blue-river42")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := h.Verify(encoded, "Blue-river42"); err != nil {
+				t.Errorf("Verify correct password = %v", err)
+			}
+			if err := h.Verify(encoded, "blue-river42"); !errors.Is(err, ErrMismatch) {
+				t.Errorf("Verify wrong password = %v, want ErrMismatch", err)
+			}
+			if h.NeedsRehash(encoded) {
+				t.Errorf("NeedsRehash of a fresh hash = true")
+			}
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	argon := newTestHasher(t, config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2})
+	argonHash, err := argon.Hash("Blue-river42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("Blue-river42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testArgon2
+	stronger.Memory *= 2
+	longerKey := testArgon2
+	longerKey.KeyLength = 64
+
+	tests := []struct {
+		name    string
+		cfg     config.PasswordHashConfig
+		encoded string
+		want    bool
+	}{
+		{name: "argon2id 参数相同", cfg: config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2}, encoded: argonHash, want: false},
+		{name: "argon2id 内存参数变化", cfg: config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: stronger}, encoded: argonHash, want: true},
+		{name: "argon2id 输出长度变化", cfg: config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: longerKey}, encoded: argonHash, want: true},
+		{name: "历史 bcrypt 升级为 argon2id", cfg: config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2}, encoded: string(bcryptHash), want: true},
+		{name: "bcrypt cost 相同", cfg: config.PasswordHashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, encoded: string(bcryptHash), want: false},
+		{name: "bcrypt cost 变化", cfg: config.PasswordHashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1}, encoded: string(bcryptHash), want: true},
+		{name: "argon2id 改回 bcrypt", cfg: config.PasswordHashConfig{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}, encoded: argonHash, want: true},
+		{name: "无法识别的格式", cfg: config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2}, encoded: "$argon2id$v=19$broken", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHasher(t, tt.cfg)
+			if got := h.NeedsRehash(tt.encoded); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasherVerifyUnknownFormat(t *testing.T) {
+	h := newTestHasher(t, config.PasswordHashConfig{Algorithm: AlgorithmArgon2id, Argon2: testArgon2})
+	for _, encoded := range []string{"", "plaintext", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"} {
+		if err := h.Verify(encoded, "x"); !errors.Is(err, ErrUnknownHashFormat) {
+			t.Errorf("Verify(%q) = %v, want ErrUnknownHashFormat", encoded, err)
+		}
+	}
+}
+
+func TestNewHasherRejectsInvalidConfig(t *testing.T) {
+	for _, cfg := range []config.PasswordHashConfig{
+		{Algorithm: "md5"},
+		{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MaxCost + 1},
+		{Algorithm: AlgorithmBcrypt, BcryptCost: 0},
+	} {
+		if _, err := NewHasher(cfg); err == nil {
+			t.Errorf("NewHasher(%+v) succeeded", cfg)
+		}
+	}
+}
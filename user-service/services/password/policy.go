@@ -0,0 +1,120 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"reading-microservices/user-service/config"
+)
+
+// bcrypt 只接受 72 字节以内的密码
+const bcryptMaxBytes = 72
+
+// ErrWeakPassword 密码不符合策略，具体原因在错误信息中
+var ErrWeakPassword = errors.New("password does not meet requirements")
+
+// 内置的常见密码列表，可通过 common_passwords_file 追加
+//
+//go:embed common_passwords.txt
+var builtinCommonPasswords string
+
+// Policy 设置新密码时的强度要求，不影响已有密码登录
+type Policy struct {
+	minLength     int
+	maxLength     int
+	maxBytes      int
+	minClasses    int
+	allowUsername bool
+	common        map[string]struct{}
+}
+
+func NewPolicy(cfg config.PasswordConfig) (*Policy, error) {
+	p := &Policy{
+		minLength:     cfg.MinLength,
+		maxLength:     cfg.MaxLength,
+		minClasses:    cfg.MinClasses,
+		allowUsername: cfg.AllowUsername,
+		common:        map[string]struct{}{},
+	}
+	if cfg.Hash.Algorithm == AlgorithmBcrypt {
+		p.maxBytes = bcryptMaxBytes
+	}
+
+	p.addCommon(bufio.NewScanner(strings.NewReader(builtinCommonPasswords)))
+	if cfg.CommonPasswordsFile != "" {
+		f, err := os.Open(cfg.CommonPasswordsFile)
+		if err != nil {
+			return nil, fmt.Errorf("open common passwords file: %w", err)
+		}
+		defer f.Close()
+		if err := p.addCommon(bufio.NewScanner(f)); err != nil {
+			return nil, fmt.Errorf("read common passwords file: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *Policy) addCommon(scanner *bufio.Scanner) error {
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.common[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate 检查新密码，不符合时返回包装了 ErrWeakPassword 的错误
+func (p *Policy) Validate(password, username string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.minLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.minLength)
+	}
+	if length > p.maxLength || (p.maxBytes > 0 && len(password) > p.maxBytes) {
+		return fmt.Errorf("%w: too long", ErrWeakPassword)
+	}
+	if classes := countClasses(password); classes < p.minClasses {
+		return fmt.Errorf("%w: must contain at least %d of lowercase letters, uppercase letters, digits and symbols", ErrWeakPassword, p.minClasses)
+	}
+
+	lower := strings.ToLower(password)
+	if !p.allowUsername && username != "" && strings.Contains(lower, strings.ToLower(username)) {
+		return fmt.Errorf("%w: must not contain the username", ErrWeakPassword)
+	}
+	if _, ok := p.common[lower]; ok {
+		return fmt.Errorf("%w: too common", ErrWeakPassword)
+	}
+	return nil
+}
+
+// countClasses 小写、大写、数字、其他字符（含中文）各算一类
+func countClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	count := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"reading-microservices/user-service/config"
+)
+
+func testPolicyConfig() config.PasswordConfig {
+	return config.PasswordConfig{
+		MinLength:  8,
+		MaxLength:  64,
+		MinClasses: 3,
+		Hash:       config.PasswordHashConfig{Algorithm: AlgorithmArgon2id},
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	p, err := NewPolicy(testPolicyConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		wantErr  string // 为空表示应通过
+	}{
+		{name: "符合要求", password: "Blue-river42", username: "alice"},
+		{name: "中文算作符号", password: "阅读Reading1", username: "alice"},
+		{name: "太短", password: "Ab1!", wantErr: "at least 8 characters"},
+		{name: "按字符而不是字节计算长度", password: "读书Ab1", wantErr: "at least 8 characters"},
+		{name: "太长", password: strings.Repeat("Ab1!", 17), wantErr: "too long"},
+		{name: "字符种类不足", password: "abcdefgh12", wantErr: "at least 3 of"},
+		{name: "包含用户名", password: "xAlice-2024", username: "alice", wantErr: "username"},
+		{name: "常见密码忽略大小写", password: "P@ssw0rd", wantErr: "too common"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Validate(tt.password, tt.username)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate(%q) = %v, want nil", tt.password, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrWeakPassword) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate(%q) = %v, want error containing %q", tt.password, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowUsername(t *testing.T) {
+	cfg := testPolicyConfig()
+	cfg.AllowUsername = true
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate("xAlice-2024", "alice"); err != nil {
+		t.Fatalf("Validate = %v, want nil", err)
+	}
+}
+
+func TestPolicyBcryptByteLimit(t *testing.T) {
+	cfg := testPolicyConfig()
+	cfg.MaxLength = 100
+	cfg.Hash.Algorithm = AlgorithmBcrypt
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 30 个汉字占 90 字节，字符数未超限但超过 bcrypt 的 72 字节
+	password := strings.Repeat("读", 30) + "Ab1"
+	if err := p.Validate(password, ""); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("Validate = %v, want ErrWeakPassword", err)
+	}
+}
+
+func TestPolicyCommonPasswordsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(file, []byte("# 注释\n\nCorrect-Horse9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testPolicyConfig()
+	cfg.CommonPasswordsFile = file
+	p, err := NewPolicy(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Validate("correct-horse9", ""); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("Validate = %v, want ErrWeakPassword", err)
+	}
+
+	cfg.CommonPasswordsFile = filepath.Join(t.TempDir(), "missing.txt")
+	if _, err := NewPolicy(cfg); err == nil {
+		t.Fatal("NewPolicy with missing file succeeded")
+	}
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/sender"
+)
+
+// ErrResetInvalid 账号不存在和验证码错误返回同一个错误，避免枚举账号
+var ErrResetInvalid = errors.New("invalid account or verification code")
+
+// PasswordResetService 忘记密码后通过邮箱或短信验证码重置
+type PasswordResetService struct {
+	userRepo       repositories.UserRepository
+	authManager    *auth.AuthManager
+	sessionManager *auth.SessionManager
+	loginLogger    *auth.LoginLogger
+	loginGuard     *auth.LoginGuard
+	codes          *auth.CodeManager
+	emailSender    sender.EmailSender
+	smsSender      sender.SMSSender
+	resetLinkURL   string
+	resendAfter    int
+}
+
+func NewPasswordResetService(
+	userRepo repositories.UserRepository,
+	authManager *auth.AuthManager,
+	sessionManager *auth.SessionManager,
+	loginLogger *auth.LoginLogger,
+	loginGuard *auth.LoginGuard,
+	codes *auth.CodeManager,
+	emailSender sender.EmailSender,
+	smsSender sender.SMSSender,
+	cfg config.VerificationConfig,
+) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:       userRepo,
+		authManager:    authManager,
+		sessionManager: sessionManager,
+		loginLogger:    loginLogger,
+		loginGuard:     loginGuard,
+		codes:          codes,
+		emailSender:    emailSender,
+		smsSender:      smsSender,
+		resetLinkURL:   cfg.ResetLinkURL,
+		resendAfter:    cfg.ResendInterval,
+	}
+}
+
+// ForgotPassword 向账号绑定的邮箱或手机号发送重置验证码
+// 账号不存在或该联系方式未验证时同样计入发送间隔和每日次数并返回成功，发送失败也只记日志，调用方无法据此判断账号是否注册
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) (*models.SendCodeResponse, error) {
+	if err := s.codes.CheckIP(ctx, auth.PurposePasswordReset, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	account := strings.TrimSpace(req.Account)
+	isEmail := strings.Contains(account, "@")
+	user, err := s.findUser(account, isEmail)
+	if err != nil {
+		if account == "" {
+			return s.sendCodeResponse(account, isEmail), nil
+		}
+		if err := s.codes.Throttle(ctx, auth.PurposePasswordReset, account); err != nil {
+			return nil, err
+		}
+		return s.sendCodeResponse(account, isEmail), nil
+	}
+
+	code, err := s.codes.Issue(ctx, auth.PurposePasswordReset, account)
+	if err != nil {
+		return nil, err
+	}
+	// 异步发送，响应时间不因账号是否存在而不同
+	go s.sendResetCode(user.ID, account, code, isEmail)
+
+	return s.sendCodeResponse(account, isEmail), nil
+}
+
+// sendResetCode 在请求结束后发送，不使用请求的 context；失败只记日志
+func (s *PasswordResetService) sendResetCode(userID, account, code string, isEmail bool) {
+	ctx := context.Background()
+	minutes := int(s.codes.TTL().Minutes())
+	var err error
+	if isEmail {
+		body := fmt.Sprintf("您正在重置密码，验证码是 %s，%d 分钟内有效。如非本人操作请忽略本邮件。", code, minutes)
+		if s.resetLinkURL != "" {
+			link := s.resetLinkURL + "?account=" + url.QueryEscape(account) + "&code=" + code
+			body += "\n\n也可以点击以下链接设置新密码：\n" + link
+		}
+		err = s.emailSender.SendEmail(ctx, account, "重置密码", body)
+	} else {
+		content := fmt.Sprintf("您正在重置密码，验证码是 %s，%d 分钟内有效，请勿泄露。", code, minutes)
+		err = s.smsSender.SendSMS(ctx, account, content)
+	}
+	if err != nil {
+		log.Printf("warning: send password reset code to user %s failed: %v", userID, err)
+	}
+}
+
+// ResetPassword 校验验证码后设置新密码，并让所有已登录设备下线
+func (s *PasswordResetService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	// 先做与账号无关的检查，账号不存在时返回同样的结果
+	if err := s.authManager.ValidatePassword(req.NewPassword, ""); err != nil {
+		return err
+	}
+
+	account := strings.TrimSpace(req.Account)
+	isEmail := strings.Contains(account, "@")
+	user, err := s.findUser(account, isEmail)
+	if err != nil {
+		return ErrResetInvalid
+	}
+
+	// 验证码错误、过期和尝试次数过多都返回同一个错误：不存在的账号永远不会出现“次数过多”
+	if err := s.codes.Verify(ctx, auth.PurposePasswordReset, account, req.Code); err != nil {
+		if !errors.Is(err, auth.ErrCodeInvalid) && !errors.Is(err, auth.ErrCodeExpired) && !errors.Is(err, auth.ErrCodeTooManyTries) {
+			log.Printf("warning: verify password reset code failed: %v", err)
+		}
+		return ErrResetInvalid
+	}
+	// 与用户名相关的检查放在验证码之后，否则不持有验证码也能据此判断账号是否存在；
+	// 验证码已作废，密码不合格时需要重新获取验证码
+	if err := s.authManager.ValidatePassword(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+
+	hashed, err := s.authManager.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hashed
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	if err := s.sessionManager.InvalidateAllUserSessions(user.ID, true); err != nil {
+		log.Printf("warning: invalidate sessions after password reset failed for user %s: %v", user.ID, err)
+	}
+	if err := s.loginGuard.Unlock(ctx, user.Username); err != nil {
+		log.Printf("warning: clear login lock after password reset failed for user %s: %v", user.ID, err)
+	}
+
+	channel := "sms"
+	if isEmail {
+		channel = "email"
+	}
+	s.loginLogger.LogAccountEvent(user.ID, "password", "password_reset_"+channel, auth.ClientInfo{
+		Platform:  req.Platform,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	})
+	return nil
+}
+
+// findUser 只查找验证过该邮箱或手机号的账号，未验证的联系方式可能是别人填写的，不能用来重置密码
+func (s *PasswordResetService) findUser(account string, isEmail bool) (*models.User, error) {
+	if account == "" {
+		return nil, ErrResetInvalid
+	}
+	if isEmail {
+		user, err := s.userRepo.GetByEmail(account)
+		if err != nil {
+			return nil, err
+		}
+		if !user.IsEmailVerified {
+			return nil, ErrResetInvalid
+		}
+		return user, nil
+	}
+	user, err := s.userRepo.GetByPhone(account)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsPhoneVerified {
+		return nil, ErrResetInvalid
+	}
+	return user, nil
+}
+
+func (s *PasswordResetService) sendCodeResponse(account string, isEmail bool) *models.SendCodeResponse {
+	target := maskPhone(account)
+	if isEmail {
+		target = maskEmail(account)
+	}
+	return &models.SendCodeResponse{
+		Target:      target,
+		ExpiresIn:   int(s.codes.TTL().Seconds()),
+		ResendAfter: s.resendAfter,
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+)
+
+var ErrPreferencesSchemaUnsupported = errors.New("preferences schema version is newer than server")
+
+// PreferenceService 多端同步的阅读偏好：账号级设置加可选的设备覆盖项。
+// 每一项单独记录最后写入时间，多个设备离线修改后同步时按项取最后写入的值
+type PreferenceService struct {
+	userRepo repositories.UserRepository
+}
+
+func NewPreferenceService(userRepo repositories.UserRepository) *PreferenceService {
+	return &PreferenceService{
+		userRepo: userRepo,
+	}
+}
+
+// Get 账号级设置和 deviceID 的覆盖项，deviceID 为空时只返回账号级设置
+func (s *PreferenceService) Get(userID, deviceID string) (*models.PreferencesResponse, error) {
+	deviceIDs := []string{""}
+	if deviceID != "" {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	prefs, err := s.userRepo.GetPreferences(userID, deviceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.PreferencesResponse{
+		SchemaVersion: models.PreferencesSchemaVersion,
+		Account:       emptyPreference(""),
+	}
+	if deviceID != "" {
+		resp.Device = emptyPreference(deviceID)
+	}
+	for i := range prefs {
+		if prefs[i].DeviceID == "" {
+			resp.Account = &prefs[i]
+		} else {
+			resp.Device = &prefs[i]
+		}
+	}
+
+	effective, err := preferenceFields(resp.Account.Settings)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Device != nil {
+		overrides, err := preferenceFields(resp.Device.Settings)
+		if err != nil {
+			return nil, err
+		}
+		for field, value := range overrides {
+			effective[field] = value
+		}
+	}
+	if err := applyPreferenceFields(&resp.Effective, effective); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Update 部分更新：只修改提交的项和 Reset 中的项；某项在服务端的最后写入时间晚于本次修改时不覆盖，
+// 放入 Ignored 返回。恢复默认也记录写入时间，避免更早的离线修改把已删除的项写回来
+func (s *PreferenceService) Update(userID string, req *models.UpdatePreferencesRequest) (*models.PreferencesResponse, error) {
+	if req.SchemaVersion > models.PreferencesSchemaVersion {
+		return nil, ErrPreferencesSchemaUnsupported
+	}
+	changes, err := preferenceFields(req.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// 客户端时钟可能超前，不能晚于服务端当前时间，否则之后的修改都会被忽略
+	now := time.Now()
+	writtenAt := now
+	if req.UpdatedAt != nil && req.UpdatedAt.Before(now) {
+		writtenAt = *req.UpdatedAt
+	}
+
+	var ignored []string
+	_, err = s.userRepo.UpdatePreference(userID, req.DeviceID, func(pref *models.UserPreference) error {
+		ignored = nil
+		current, err := preferenceFields(pref.Settings)
+		if err != nil {
+			return err
+		}
+		if pref.FieldUpdatedAt == nil {
+			pref.FieldUpdatedAt = make(map[string]time.Time)
+		}
+		stale := func(field string) bool {
+			if last, ok := pref.FieldUpdatedAt[field]; ok && last.After(writtenAt) {
+				ignored = append(ignored, field)
+				return true
+			}
+			pref.FieldUpdatedAt[field] = writtenAt
+			return false
+		}
+
+		for field, value := range changes {
+			if !stale(field) {
+				current[field] = value
+			}
+		}
+		for _, field := range req.Reset {
+			if _, ok := changes[field]; ok {
+				continue
+			}
+			if !stale(field) {
+				delete(current, field)
+			}
+		}
+
+		pref.SchemaVersion = models.PreferencesSchemaVersion
+		pref.Settings = models.ReadingPreferences{}
+		return applyPreferenceFields(&pref.Settings, current)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	resp, err := s.Get(userID, req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ignored)
+	resp.Ignored = ignored
+	return resp, nil
+}
+
+func emptyPreference(deviceID string) *models.UserPreference {
+	return &models.UserPreference{
+		DeviceID:       deviceID,
+		SchemaVersion:  models.PreferencesSchemaVersion,
+		FieldUpdatedAt: map[string]time.Time{},
+	}
+}
+
+// preferenceFields 按 JSON 字段名拆出已设置的项，未设置的项因 omitempty 不出现
+func preferenceFields(settings models.ReadingPreferences) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func applyPreferenceFields(settings *models.ReadingPreferences, fields map[string]json.RawMessage) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, settings)
+}
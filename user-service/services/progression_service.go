@@ -0,0 +1,198 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+)
+
+// 经验事件
+const (
+	XPEventReadingTime     = "reading_time"
+	XPEventChapterFinished = "chapter_finished"
+	XPEventComment         = "comment"
+	XPEventDailyCheckin    = "daily_checkin"
+)
+
+var (
+	ErrUnknownXPEvent   = errors.New("unknown experience event")
+	ErrAlreadyCheckedIn = errors.New("already checked in today")
+)
+
+// ProgressionService 经验与等级：按规则给事件加经验，受每日上限约束，达到门槛自动升级
+type ProgressionService struct {
+	userRepo repositories.UserRepository
+	rules    map[string]config.XPRule
+	levels   []config.LevelConfig
+	referral *ReferralService // 升级后发放邀请的等级奖励
+}
+
+func NewProgressionService(userRepo repositories.UserRepository, cfg config.ProgressionConfig, referral *ReferralService) *ProgressionService {
+	levels := append([]config.LevelConfig(nil), cfg.Levels...)
+	sort.Slice(levels, func(i, j int) bool { return levels[i].XP < levels[j].XP })
+	return &ProgressionService{
+		userRepo: userRepo,
+		rules:    cfg.Rules,
+		levels:   levels,
+		referral: referral,
+	}
+}
+
+// Award 给用户记一次经验事件，同一 event_id 重复提交只计一次
+func (s *ProgressionService) Award(req *models.AwardExperienceRequest) (*models.AwardExperienceResponse, error) {
+	rule, ok := s.rules[req.Event]
+	if !ok {
+		return nil, ErrUnknownXPEvent
+	}
+	amount := req.Amount
+	if amount <= 0 {
+		amount = 1
+	}
+
+	entry := &models.ExperienceLog{
+		UserID:  req.UserID,
+		Event:   req.Event,
+		EventID: req.EventID,
+		Amount:  amount,
+		XP:      rule.XP * amount,
+	}
+	duplicate, err := s.userRepo.AwardExperience(entry, rule.DailyCap, startOfDay(time.Now()))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.AwardExperienceResponse{
+		Duplicate:        duplicate,
+		ExperiencePoints: user.ExperiencePoints,
+		Level:            user.Level,
+	}
+	if !duplicate {
+		resp.Awarded = entry.XP
+		resp.Capped = entry.XP < rule.XP*amount
+	}
+
+	if level := s.levelFor(user.ExperiencePoints); level.Level > user.Level {
+		if err := s.userRepo.RaiseLevel(user.ID, level.Level); err != nil {
+			return nil, err
+		}
+		resp.Level = level.Level
+		resp.LeveledUp = true
+		go s.referral.Reach(user.ID, ReferralMilestoneLevel, level.Level)
+	}
+	return resp, nil
+}
+
+// CheckIn 每日签到，每天一次
+func (s *ProgressionService) CheckIn(userID string) (*models.AwardExperienceResponse, error) {
+	resp, err := s.Award(&models.AwardExperienceRequest{
+		UserID:  userID,
+		Event:   XPEventDailyCheckin,
+		EventID: time.Now().Format("2006-01-02"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Duplicate {
+		return nil, ErrAlreadyCheckedIn
+	}
+	return resp, nil
+}
+
+// Progress 当前等级、到下一级的进度、已解锁特权和今日各事件经验
+func (s *ProgressionService) Progress(userID string) (*models.LevelProgress, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	dayStart := startOfDay(time.Now())
+	earned, err := s.userRepo.GetDailyExperience(userID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &models.LevelProgress{
+		Level:            user.Level,
+		ExperiencePoints: user.ExperiencePoints,
+		Perks:            []string{},
+		NextPerks:        []string{},
+		Progress:         1,
+	}
+	for _, level := range s.levels {
+		if level.XP > user.ExperiencePoints {
+			next := level
+			progress.NextLevelXP = next.XP
+			progress.XPToNext = next.XP - user.ExperiencePoints
+			progress.NextPerks = append(progress.NextPerks, next.Perks...)
+			if span := next.XP - progress.CurrentLevelXP; span > 0 {
+				progress.Progress = float64(user.ExperiencePoints-progress.CurrentLevelXP) / float64(span)
+			}
+			break
+		}
+		progress.Level = max(progress.Level, level.Level)
+		progress.Title = level.Title
+		progress.CurrentLevelXP = level.XP
+		progress.Perks = append(progress.Perks, level.Perks...)
+	}
+
+	events := make([]string, 0, len(s.rules))
+	for event := range s.rules {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+	for _, event := range events {
+		progress.Today = append(progress.Today, models.DailyExperience{
+			Event:    event,
+			Earned:   earned[event],
+			DailyCap: s.rules[event].DailyCap,
+		})
+	}
+
+	checkedIn, err := s.userRepo.HasExperienceEvent(userID, XPEventDailyCheckin, dayStart.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	progress.CheckedInToday = checkedIn
+	return progress, nil
+}
+
+// Levels 全部等级及特权
+func (s *ProgressionService) Levels() []models.LevelInfo {
+	levels := make([]models.LevelInfo, 0, len(s.levels))
+	for _, level := range s.levels {
+		perks := level.Perks
+		if perks == nil {
+			perks = []string{}
+		}
+		levels = append(levels, models.LevelInfo{Level: level.Level, XP: level.XP, Title: level.Title, Perks: perks})
+	}
+	return levels
+}
+
+// levelFor 累计经验对应的等级
+func (s *ProgressionService) levelFor(xp int) config.LevelConfig {
+	current := config.LevelConfig{Level: 1}
+	for _, level := range s.levels {
+		if level.XP > xp {
+			break
+		}
+		current = level
+	}
+	return current
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
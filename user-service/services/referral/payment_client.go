@@ -0,0 +1,71 @@
+package referral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"reading-microservices/shared/utils"
+)
+
+// 支付服务中邀请奖励的来源，related_id 为奖励记录 ID，支付服务据此去重
+const (
+	rewardSource      = "referral"
+	rewardRelatedType = "referral_reward"
+)
+
+// PaymentClient 调用支付服务的内部接口发放积分和阅读币
+type PaymentClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewPaymentClient(baseURL, token string, timeout time.Duration) *PaymentClient {
+	return &PaymentClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  utils.NewInternalClient(token, timeout),
+	}
+}
+
+// Grant 发放一条奖励，同一 rewardID 重复调用只会到账一次
+func (c *PaymentClient) Grant(ctx context.Context, userID, rewardID string, points, coins int, description string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"user_id":      userID,
+		"points":       points,
+		"coins":        coins,
+		"source":       rewardSource,
+		"related_id":   rewardID,
+		"related_type": rewardRelatedType,
+		"description":  description,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/internal/payment/rewards", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("payment service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("payment service: status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != 0 {
+		return fmt.Errorf("payment service: %s", result.Message)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/referral"
+)
+
+// 邀请奖励的里程碑
+const (
+	ReferralMilestoneRegistered = "registered"
+	ReferralMilestoneVerified   = "verified" // 验证邮箱或手机号
+	ReferralMilestoneLevel      = "level"
+)
+
+// 邀请被判定为刷量的原因
+const (
+	referralRejectSameDevice = "same_device"
+	referralRejectSameIP     = "same_ip"
+)
+
+// 邀请码去掉了容易混淆的 0、O、1、I，长度为 32 保证按字节取模没有偏差
+const referralCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+var ErrReferralCodeInvalid = errors.New("invalid referral code")
+
+// ReferralService 邀请注册：每个用户一个邀请码，注册时填写邀请码建立邀请关系；
+// 被邀请人达到里程碑后按配置通过支付服务给双方发放积分和阅读币，同设备、同 IP 的邀请不发放奖励
+type ReferralService struct {
+	userRepo repositories.UserRepository
+	payment  *referral.PaymentClient
+	cfg      config.ReferralConfig
+}
+
+func NewReferralService(userRepo repositories.UserRepository, payment *referral.PaymentClient, cfg config.ReferralConfig) *ReferralService {
+	return &ReferralService{
+		userRepo: userRepo,
+		payment:  payment,
+		cfg:      cfg,
+	}
+}
+
+// Summary 邀请码、邀请人数和获得的奖励
+func (s *ReferralService) Summary(userID string) (*models.ReferralSummary, error) {
+	code, err := s.code(userID)
+	if err != nil {
+		return nil, err
+	}
+	total, valid, err := s.userRepo.CountReferrals(userID)
+	if err != nil {
+		return nil, err
+	}
+	rewards, err := s.userRepo.GetUserReferralRewards(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ReferralSummary{
+		Code:         code,
+		InvitedCount: total,
+		ValidCount:   valid,
+	}
+	for _, reward := range rewards {
+		if reward.Status == models.ReferralRewardGranted {
+			summary.EarnedPoints += reward.Points
+			summary.EarnedCoins += reward.Coins
+		} else {
+			summary.PendingPoints += reward.Points
+			summary.PendingCoins += reward.Coins
+		}
+	}
+	return summary, nil
+}
+
+// Invitees 邀请的用户和因每个人获得的奖励，按注册时间倒序
+func (s *ReferralService) Invitees(userID string, page, size int) ([]models.InviteeItem, int64, error) {
+	referrals, total, err := s.userRepo.GetReferrals(userID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]models.InviteeItem, 0, len(referrals))
+	if len(referrals) == 0 {
+		return items, total, nil
+	}
+
+	inviteeIDs := make([]string, len(referrals))
+	referralIDs := make([]string, len(referrals))
+	for i, r := range referrals {
+		inviteeIDs[i] = r.InviteeID
+		referralIDs[i] = r.ID
+	}
+	users, err := s.userRepo.GetByIDs(inviteeIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	usersByID := make(map[string]*models.User, len(users))
+	for i := range users {
+		usersByID[users[i].ID] = &users[i]
+	}
+	rewards, err := s.userRepo.GetUserReferralRewards(userID, referralIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	rewardsByReferral := make(map[string][]models.ReferralReward, len(referrals))
+	for _, reward := range rewards {
+		rewardsByReferral[reward.ReferralID] = append(rewardsByReferral[reward.ReferralID], reward)
+	}
+
+	for _, r := range referrals {
+		// 已注销的用户只返回 ID
+		summary := models.UserSummary{ID: r.InviteeID}
+		if user, ok := usersByID[r.InviteeID]; ok {
+			summary.Username = user.Username
+			summary.Nickname = user.Nickname
+			summary.AvatarURL = user.AvatarURL
+			summary.AvatarThumbnails = user.AvatarThumbnails
+		}
+		itemRewards := rewardsByReferral[r.ID]
+		if itemRewards == nil {
+			itemRewards = []models.ReferralReward{}
+		}
+		items = append(items, models.InviteeItem{
+			UserSummary:  summary,
+			Status:       r.Status,
+			RejectReason: r.RejectReason,
+			Rewards:      itemRewards,
+			InvitedAt:    r.CreatedAt,
+		})
+	}
+	return items, total, nil
+}
+
+// Resolve 注册前校验邀请码，邀请人已注销时同样视为无效
+func (s *ReferralService) Resolve(code string) (*models.ReferralCode, error) {
+	referralCode, err := s.userRepo.GetReferralCodeByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReferralCodeInvalid
+		}
+		return nil, err
+	}
+	if _, err := s.userRepo.GetByID(referralCode.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReferralCodeInvalid
+		}
+		return nil, err
+	}
+	return referralCode, nil
+}
+
+// Bind 新用户注册后记录邀请关系；疑似刷量的邀请照常记录，但标记为 rejected 不发放奖励
+func (s *ReferralService) Bind(inviteeID string, code *models.ReferralCode, client auth.ClientInfo) error {
+	r := &models.Referral{
+		InviterID: code.UserID,
+		InviteeID: inviteeID,
+		Code:      code.Code,
+		Status:    models.ReferralStatusValid,
+	}
+	if client.DeviceID != "" {
+		r.DeviceID = &client.DeviceID
+	}
+	if client.IPAddress != "" {
+		r.IPAddress = &client.IPAddress
+	}
+
+	reason, err := s.check(code.UserID, client)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		r.Status = models.ReferralStatusRejected
+		r.RejectReason = &reason
+	}
+	return s.userRepo.CreateReferral(r)
+}
+
+// Reach 被邀请人达到里程碑，记录并发放双方的奖励；没有邀请关系或邀请被拒绝时忽略。
+// 同一里程碑只记一次奖励，发放失败的奖励保持 pending，下次达到任意里程碑时重试。
+// 需要调用支付服务，调用方应在后台执行
+func (s *ReferralService) Reach(inviteeID, milestone string, level int) {
+	r, err := s.userRepo.GetReferralByInvitee(inviteeID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("warning: get referral of user %s failed: %v", inviteeID, err)
+		}
+		return
+	}
+	if r.Status != models.ReferralStatusValid {
+		return
+	}
+
+	var rewards []models.ReferralReward
+	for _, rule := range s.cfg.Rewards {
+		if rule.Milestone != milestone || (milestone == ReferralMilestoneLevel && rule.Level > level) {
+			continue
+		}
+		key := rule.Milestone
+		if rule.Milestone == ReferralMilestoneLevel {
+			key = fmt.Sprintf("level_%d", rule.Level)
+		}
+		if rule.InviterPoints > 0 || rule.InviterCoins > 0 {
+			rewards = append(rewards, models.ReferralReward{
+				ReferralID: r.ID,
+				UserID:     r.InviterID,
+				Milestone:  key,
+				Points:     rule.InviterPoints,
+				Coins:      rule.InviterCoins,
+				Status:     models.ReferralRewardPending,
+			})
+		}
+		if rule.InviteePoints > 0 || rule.InviteeCoins > 0 {
+			rewards = append(rewards, models.ReferralReward{
+				ReferralID: r.ID,
+				UserID:     r.InviteeID,
+				Milestone:  key,
+				Points:     rule.InviteePoints,
+				Coins:      rule.InviteeCoins,
+				Status:     models.ReferralRewardPending,
+			})
+		}
+	}
+	if err := s.userRepo.CreateReferralRewards(rewards); err != nil {
+		log.Printf("warning: record referral rewards for user %s failed: %v", inviteeID, err)
+		return
+	}
+	s.grantPending(r)
+}
+
+// grantPending 发放该邀请关系下所有未到账的奖励，支付服务按奖励 ID 去重
+func (s *ReferralService) grantPending(r *models.Referral) {
+	rewards, err := s.userRepo.GetReferralRewards(r.ID)
+	if err != nil {
+		log.Printf("warning: get referral rewards %s failed: %v", r.ID, err)
+		return
+	}
+	for _, reward := range rewards {
+		if reward.Status != models.ReferralRewardPending {
+			continue
+		}
+		description := "邀请奖励：" + reward.Milestone
+		if err := s.payment.Grant(context.Background(), reward.UserID, reward.ID, reward.Points, reward.Coins, description); err != nil {
+			log.Printf("warning: grant referral reward %s failed: %v", reward.ID, err)
+			continue
+		}
+		if err := s.userRepo.MarkReferralRewardGranted(reward.ID, time.Now()); err != nil {
+			log.Printf("warning: mark referral reward %s granted failed: %v", reward.ID, err)
+		}
+	}
+}
+
+// check 防刷：设备登录过邀请人账号或已注册过被邀请账号；
+// 与邀请人近期登录 IP 相同，或同一 IP 的被邀请人超过上限
+func (s *ReferralService) check(inviterID string, client auth.ClientInfo) (string, error) {
+	if client.DeviceID != "" {
+		used, err := s.userRepo.HasLoginFromDevice(inviterID, client.DeviceID)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			used, err = s.userRepo.HasReferralFromDevice(client.DeviceID)
+			if err != nil {
+				return "", err
+			}
+		}
+		if used {
+			return referralRejectSameDevice, nil
+		}
+	}
+
+	if client.IPAddress != "" {
+		since := time.Now().Add(-time.Duration(s.cfg.IPWindow) * time.Second)
+		shared, err := s.userRepo.HasLoginFromIP(inviterID, client.IPAddress, since)
+		if err != nil {
+			return "", err
+		}
+		if shared {
+			return referralRejectSameIP, nil
+		}
+		count, err := s.userRepo.CountReferralsFromIP(inviterID, client.IPAddress, since)
+		if err != nil {
+			return "", err
+		}
+		if count >= int64(s.cfg.MaxPerIP) {
+			return referralRejectSameIP, nil
+		}
+	}
+	return "", nil
+}
+
+// code 用户的邀请码，没有时生成；并发生成或与已有邀请码冲突时重试
+func (s *ReferralService) code(userID string) (string, error) {
+	existing, err := s.userRepo.GetReferralCode(userID)
+	if err == nil {
+		return existing.Code, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := randomReferralCode(s.cfg.CodeLength)
+		if err != nil {
+			return "", err
+		}
+		if err := s.userRepo.CreateReferralCode(&models.ReferralCode{UserID: userID, Code: code}); err == nil {
+			return code, nil
+		}
+		if existing, err := s.userRepo.GetReferralCode(userID); err == nil {
+			return existing.Code, nil
+		}
+	}
+	return "", errors.New("generate referral code failed")
+}
+
+func randomReferralCode(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = referralCodeAlphabet[int(b[i])%len(referralCodeAlphabet)]
+	}
+	return string(b), nil
+}
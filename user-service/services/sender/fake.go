@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"context"
+	"sync"
+)
+
+// Message 假发送器记录的一条消息
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject,omitempty"`
+	Content string `json:"content"`
+}
+
+// FakeSender 测试用，不真正发送，只在内存里保留每个收件人最近的一条消息
+type FakeSender struct {
+	mu       sync.RWMutex
+	messages map[string]Message
+}
+
+func NewFakeSender() *FakeSender {
+	return &FakeSender{messages: make(map[string]Message)}
+}
+
+func (s *FakeSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.record(Message{To: to, Subject: subject, Content: body})
+	return nil
+}
+
+func (s *FakeSender) SendSMS(ctx context.Context, phone, content string) error {
+	s.record(Message{To: phone, Content: content})
+	return nil
+}
+
+// Last 收件人最近收到的消息
+func (s *FakeSender) Last(to string) (Message, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	msg, ok := s.messages[to]
+	return msg, ok
+}
+
+func (s *FakeSender) record(msg Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[msg.To] = msg
+}
@@ -0,0 +1,47 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogSender 本地开发用，把邮件和短信写到日志，配置了文件时同时追加到文件
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogSender(path string) *LogSender {
+	return &LogSender{path: path}
+}
+
+func (s *LogSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	logrus.WithFields(logrus.Fields{"to": to, "subject": subject}).Info("Email (log sender): " + body)
+	return s.appendFile(fmt.Sprintf("[%s] EMAIL to=%s subject=%s\n%s\n\n", time.Now().Format(time.RFC3339), to, subject, body))
+}
+
+func (s *LogSender) SendSMS(ctx context.Context, phone, content string) error {
+	logrus.WithField("phone", phone).Info("SMS (log sender): " + content)
+	return s.appendFile(fmt.Sprintf("[%s] SMS to=%s\n%s\n\n", time.Now().Format(time.RFC3339), phone, content))
+}
+
+func (s *LogSender) appendFile(entry string) error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(entry)
+	return err
+}
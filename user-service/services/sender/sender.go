@@ -0,0 +1,46 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+
+	"reading-microservices/user-service/config"
+)
+
+// EmailSender 发送邮件
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// SMSSender 发送短信
+type SMSSender interface {
+	SendSMS(ctx context.Context, phone, content string) error
+}
+
+// NewEmailSender 按配置创建邮件发送器，driver 为 smtp、log 或 fake
+func NewEmailSender(cfg config.EmailConfig) (EmailSender, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPSender(cfg.SMTP), nil
+	case "fake":
+		return NewFakeSender(), nil
+	case "", "log":
+		return NewLogSender(cfg.LogFile), nil
+	default:
+		return nil, fmt.Errorf("unknown email driver: %s", cfg.Driver)
+	}
+}
+
+// NewSMSSender 按配置创建短信发送器，driver 为 http、log 或 fake
+func NewSMSSender(cfg config.SMSConfig) (SMSSender, error) {
+	switch cfg.Driver {
+	case "http":
+		return NewHTTPSMSSender(cfg.HTTP), nil
+	case "fake":
+		return NewFakeSender(), nil
+	case "", "log":
+		return NewLogSender(cfg.LogFile), nil
+	default:
+		return nil, fmt.Errorf("unknown sms driver: %s", cfg.Driver)
+	}
+}
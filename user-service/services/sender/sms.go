@@ -0,0 +1,58 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"reading-microservices/user-service/config"
+)
+
+// httpSMSSender 调用短信网关的 HTTP 接口
+type httpSMSSender struct {
+	cfg    config.SMSHTTPConfig
+	client *http.Client
+}
+
+func NewHTTPSMSSender(cfg config.SMSHTTPConfig) SMSSender {
+	return &httpSMSSender{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *httpSMSSender) SendSMS(ctx context.Context, phone, content string) error {
+	payload, err := json.Marshal(map[string]string{
+		"phone":     phone,
+		"sign_name": s.cfg.SignName,
+		"content":   content,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.APIKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/sender"
+)
+
+var phonePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// ErrInvalidPhone 手机号格式错误
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// SMSLoginService 手机号 + 短信验证码登录，首次登录自动注册
+type SMSLoginService struct {
+	userRepo    repositories.UserRepository
+	userService *UserService
+	codes       *auth.CodeManager
+	smsSender   sender.SMSSender
+	resendAfter int
+}
+
+func NewSMSLoginService(
+	userRepo repositories.UserRepository,
+	userService *UserService,
+	codes *auth.CodeManager,
+	smsSender sender.SMSSender,
+	cfg config.VerificationConfig,
+) *SMSLoginService {
+	return &SMSLoginService{
+		userRepo:    userRepo,
+		userService: userService,
+		codes:       codes,
+		smsSender:   smsSender,
+		resendAfter: cfg.ResendInterval,
+	}
+}
+
+// SendLoginCode 发送登录验证码，未注册的手机号同样发送
+func (s *SMSLoginService) SendLoginCode(ctx context.Context, req *models.SendSMSCodeRequest) (*models.SendCodeResponse, error) {
+	phone := strings.TrimSpace(req.Phone)
+	if !phonePattern.MatchString(phone) {
+		return nil, ErrInvalidPhone
+	}
+	if err := s.codes.CheckIP(ctx, auth.PurposeSMSLogin, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	code, err := s.codes.Issue(ctx, auth.PurposeSMSLogin, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("您的登录验证码是 %s，%d 分钟内有效，请勿泄露。", code, int(s.codes.TTL().Minutes()))
+	if err := s.smsSender.SendSMS(ctx, phone, content); err != nil {
+		return nil, fmt.Errorf("send sms failed: %w", err)
+	}
+
+	return &models.SendCodeResponse{
+		Target:      maskPhone(phone),
+		ExpiresIn:   int(s.codes.TTL().Seconds()),
+		ResendAfter: s.resendAfter,
+	}, nil
+}
+
+// Login 校验验证码后登录，手机号未注册时自动创建账号
+func (s *SMSLoginService) Login(ctx context.Context, req *models.SMSLoginRequest) (*models.LoginResponse, error) {
+	phone := strings.TrimSpace(req.Phone)
+	if !phonePattern.MatchString(phone) {
+		return nil, ErrInvalidPhone
+	}
+
+	client := auth.ClientInfo{
+		Platform:  req.Platform,
+		DeviceID:  req.DeviceID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+	}
+
+	if err := s.codes.Verify(ctx, auth.PurposeSMSLogin, phone, req.Code); err != nil {
+		if user, findErr := s.userRepo.GetByPhone(phone); findErr == nil && user.IsPhoneVerified {
+			s.userService.loginLogger.LogFailure(user.ID, err.Error(), "sms", client)
+		}
+		return nil, err
+	}
+
+	// 只有验证过该手机号的账号才能用它登录；资料里填写但未验证的号码可能属于别人，
+	// 收到验证码的人才是号码的主人，先从原账号上解绑，再为其注册新账号
+	isNewUser := false
+	user, err := s.userRepo.GetByPhone(phone)
+	if err == nil && !user.IsPhoneVerified {
+		if err := s.releasePhone(user); err != nil {
+			return nil, err
+		}
+		err = gorm.ErrRecordNotFound
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user, err = s.register(phone)
+		if err != nil {
+			return nil, err
+		}
+		isNewUser = true
+	case err != nil:
+		return nil, err
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		log.Printf("warning: update user last login failed: %v", err)
+	}
+
+	resp, err := s.userService.startSession(user, "sms", client)
+	if err != nil {
+		return nil, err
+	}
+	resp.IsNewUser = isNewUser
+	return resp, nil
+}
+
+// releasePhone 解除账号上未验证的手机号
+func (s *SMSLoginService) releasePhone(user *models.User) error {
+	user.Phone = nil
+	user.PhoneVerifiedAt = nil
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	log.Printf("released unverified phone from user %s after sms login by the number's owner", user.ID)
+	return nil
+}
+
+// register 手机号首次登录，自动创建用户
+func (s *SMSLoginService) register(phone string) (*models.User, error) {
+	username, err := generateUsername(s.userRepo, "sms")
+	if err != nil {
+		return nil, err
+	}
+
+	// 短信登录用户没有密码，随机生成一个不可猜测的哈希占位，之后可通过重置密码设置
+	randomPassword, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.userService.authManager.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:        username,
+		Phone:           &phone,
+		PasswordHash:    hashedPassword,
+		LoginType:       "sms",
+		IsPhoneVerified: true,
+		PhoneVerifiedAt: &now,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		// 手机号被已停用的账号占用时唯一索引冲突
+		return nil, errors.New("phone number is not available")
+	}
+	return user, nil
+}
@@ -0,0 +1,101 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"reading-microservices/shared/utils"
+	"reading-microservices/user-service/models"
+)
+
+// ReadingClient 调用阅读服务的内部接口获取用户书架和动态，隐私检查由调用方负责
+type ReadingClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewReadingClient(baseURL, token string, timeout time.Duration) *ReadingClient {
+	return &ReadingClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  utils.NewInternalClient(token, timeout),
+	}
+}
+
+// Bookshelf 用户书架的一页，返回阅读服务原样的书架数据和总数
+func (c *ReadingClient) Bookshelf(ctx context.Context, userID, shelfType string, page, size int) (json.RawMessage, int64, error) {
+	query := url.Values{}
+	if shelfType != "" {
+		query.Set("shelf_type", shelfType)
+	}
+	query.Set("page", strconv.Itoa(page))
+	query.Set("size", strconv.Itoa(size))
+	endpoint := fmt.Sprintf("%s/api/v1/internal/reading/users/%s/bookshelf?%s", c.baseURL, url.PathEscape(userID), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	result, err := c.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result.Data, result.Total, nil
+}
+
+// Activity 多个用户在 before 之前的书评和读完的书，按时间倒序
+func (c *ReadingClient) Activity(ctx context.Context, userIDs []string, before *time.Time, limit int) ([]models.ActivityItem, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"user_ids": userIDs,
+		"before":   before,
+		"limit":    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/internal/reading/activity", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	result, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ActivityItem
+	if err := json.Unmarshal(result.Data, &items); err != nil {
+		return nil, fmt.Errorf("reading service: %w", err)
+	}
+	return items, nil
+}
+
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Total   int64           `json:"total"`
+}
+
+func (c *ReadingClient) do(req *http.Request) (*response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("reading service: status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != 0 {
+		return nil, fmt.Errorf("reading service: %s", result.Message)
+	}
+	return &result, nil
+}
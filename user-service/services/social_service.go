@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	"reading-microservices/user-service/services/social"
+)
+
+var (
+	ErrFollowSelf     = errors.New("cannot follow yourself")
+	ErrProfilePrivate = errors.New("this information is private")
+)
+
+// SocialService 关注关系、公开主页和关注动态。
+// 他人资料按隐私设置过滤：public 所有人可见，private 仅本人可见。
+// 关注不需要对方同意，因此不提供“仅粉丝可见”，书架和动态默认仅自己可见
+type SocialService struct {
+	userRepo      repositories.UserRepository
+	reading       *social.ReadingClient
+	feedFollowing int
+}
+
+func NewSocialService(userRepo repositories.UserRepository, reading *social.ReadingClient, cfg config.SocialConfig) *SocialService {
+	return &SocialService{
+		userRepo:      userRepo,
+		reading:       reading,
+		feedFollowing: cfg.FeedFollowing,
+	}
+}
+
+// PublicProfile 公开主页，viewerID 为空表示未登录
+func (s *SocialService) PublicProfile(viewerID, userID string) (*models.PublicProfile, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	privacy, err := s.userRepo.GetPrivacy(userID)
+	if err != nil {
+		return nil, err
+	}
+	isFollower, err := s.isFollowing(viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	followers, following, err := s.userRepo.CountFollows(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.PublicProfile{
+		ID:               user.ID,
+		Username:         user.Username,
+		Nickname:         user.Nickname,
+		AvatarURL:        user.AvatarURL,
+		AvatarThumbnails: user.AvatarThumbnails,
+		FollowersCount:   followers,
+		FollowingCount:   following,
+		IsFollowing:      isFollower,
+		IsSelf:           viewerID == userID,
+		CreatedAt:        user.CreatedAt,
+	}
+	if viewerID != "" && !profile.IsSelf {
+		if profile.IsFollowedBy, err = s.userRepo.IsFollowing(userID, viewerID); err != nil {
+			return nil, err
+		}
+	}
+
+	canSee := func(visibility string) bool {
+		return visible(visibility, profile.IsSelf)
+	}
+	if canSee(privacy.Bio) {
+		profile.Bio = user.Bio
+	}
+	if canSee(privacy.Gender) {
+		profile.Gender = user.Gender
+	}
+	if canSee(privacy.BirthDate) {
+		profile.BirthDate = user.BirthDate
+	}
+	if canSee(privacy.Level) {
+		level := user.Level
+		profile.Level = &level
+	}
+	return profile, nil
+}
+
+// Follow 关注用户，重复关注不报错
+func (s *SocialService) Follow(followerID, followeeID string) error {
+	if followerID == followeeID {
+		return ErrFollowSelf
+	}
+	if _, err := s.getUser(followeeID); err != nil {
+		return err
+	}
+	return s.userRepo.Follow(followerID, followeeID)
+}
+
+// Unfollow 取消关注，未关注时不报错
+func (s *SocialService) Unfollow(followerID, followeeID string) error {
+	return s.userRepo.Unfollow(followerID, followeeID)
+}
+
+// Followers 粉丝列表
+func (s *SocialService) Followers(viewerID, userID string, page, size int) ([]*models.FollowUserItem, int64, error) {
+	if err := s.checkVisible(viewerID, userID, func(p *models.UserPrivacy) string { return p.Follows }); err != nil {
+		return nil, 0, err
+	}
+	follows, total, err := s.userRepo.GetFollowers(userID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]string, len(follows))
+	for i, follow := range follows {
+		ids[i] = follow.FollowerID
+	}
+	items, err := s.followItems(viewerID, follows, ids)
+	return items, total, err
+}
+
+// Following 关注列表
+func (s *SocialService) Following(viewerID, userID string, page, size int) ([]*models.FollowUserItem, int64, error) {
+	if err := s.checkVisible(viewerID, userID, func(p *models.UserPrivacy) string { return p.Follows }); err != nil {
+		return nil, 0, err
+	}
+	follows, total, err := s.userRepo.GetFollowing(userID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]string, len(follows))
+	for i, follow := range follows {
+		ids[i] = follow.FolloweeID
+	}
+	items, err := s.followItems(viewerID, follows, ids)
+	return items, total, err
+}
+
+// Bookshelf 公开书架
+func (s *SocialService) Bookshelf(ctx context.Context, viewerID, userID, shelfType string, page, size int) (json.RawMessage, int64, error) {
+	if err := s.checkVisible(viewerID, userID, func(p *models.UserPrivacy) string { return p.Bookshelf }); err != nil {
+		return nil, 0, err
+	}
+	return s.reading.Bookshelf(ctx, userID, shelfType, page, size)
+}
+
+// Activity 某个用户的动态
+func (s *SocialService) Activity(ctx context.Context, viewerID, userID string, before *time.Time, limit int) (*models.FeedResponse, error) {
+	if err := s.checkVisible(viewerID, userID, func(p *models.UserPrivacy) string { return p.Activity }); err != nil {
+		return nil, err
+	}
+	return s.buildFeed(ctx, []string{userID}, before, limit)
+}
+
+// Feed 关注的人的书评和读完的书，按时间倒序，before 为上一页最后一条的时间
+func (s *SocialService) Feed(ctx context.Context, userID string, before *time.Time, limit int) (*models.FeedResponse, error) {
+	ids, err := s.userRepo.GetFollowingIDs(userID, s.feedFollowing)
+	if err != nil {
+		return nil, err
+	}
+	privacies, err := s.userRepo.GetPrivacies(ids)
+	if err != nil {
+		return nil, err
+	}
+	visibleIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if visible(privacies[id].Activity, false) {
+			visibleIDs = append(visibleIDs, id)
+		}
+	}
+	return s.buildFeed(ctx, visibleIDs, before, limit)
+}
+
+// GetPrivacy 隐私设置
+func (s *SocialService) GetPrivacy(userID string) (*models.UserPrivacy, error) {
+	return s.userRepo.GetPrivacy(userID)
+}
+
+// UpdatePrivacy 修改隐私设置，未传的项保持不变
+func (s *SocialService) UpdatePrivacy(userID string, req *models.UpdatePrivacyRequest) (*models.UserPrivacy, error) {
+	privacy, err := s.userRepo.GetPrivacy(userID)
+	if err != nil {
+		return nil, err
+	}
+	for field, value := range map[*string]*string{
+		&privacy.Bio:       req.Bio,
+		&privacy.Gender:    req.Gender,
+		&privacy.BirthDate: req.BirthDate,
+		&privacy.Level:     req.Level,
+		&privacy.Bookshelf: req.Bookshelf,
+		&privacy.Follows:   req.Follows,
+		&privacy.Activity:  req.Activity,
+	} {
+		if value != nil {
+			*field = *value
+		}
+	}
+	if err := s.userRepo.SavePrivacy(privacy); err != nil {
+		return nil, err
+	}
+	return privacy, nil
+}
+
+func (s *SocialService) getUser(userID string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *SocialService) isFollowing(viewerID, userID string) (bool, error) {
+	if viewerID == "" || viewerID == userID {
+		return false, nil
+	}
+	return s.userRepo.IsFollowing(viewerID, userID)
+}
+
+// checkVisible 检查 viewer 能否看到 userID 的某项资料
+func (s *SocialService) checkVisible(viewerID, userID string, field func(*models.UserPrivacy) string) error {
+	if _, err := s.getUser(userID); err != nil {
+		return err
+	}
+	if viewerID == userID {
+		return nil
+	}
+	privacy, err := s.userRepo.GetPrivacy(userID)
+	if err != nil {
+		return err
+	}
+	if !visible(field(privacy), false) {
+		return ErrProfilePrivate
+	}
+	return nil
+}
+
+func visible(visibility string, isSelf bool) bool {
+	return visibility == models.VisibilityPublic || isSelf
+}
+
+// followItems 关注记录转为列表项，ids 为列表中展示的用户，已注销的用户跳过
+func (s *SocialService) followItems(viewerID string, follows []models.UserFollow, ids []string) ([]*models.FollowUserItem, error) {
+	summaries, err := s.summaries(ids)
+	if err != nil {
+		return nil, err
+	}
+	following := map[string]bool{}
+	if viewerID != "" {
+		if following, err = s.userRepo.GetFollowingAmong(viewerID, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	items := make([]*models.FollowUserItem, 0, len(follows))
+	for i, follow := range follows {
+		summary, ok := summaries[ids[i]]
+		if !ok {
+			continue
+		}
+		items = append(items, &models.FollowUserItem{
+			UserSummary: *summary,
+			IsFollowing: following[ids[i]],
+			FollowedAt:  follow.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+func (s *SocialService) buildFeed(ctx context.Context, userIDs []string, before *time.Time, limit int) (*models.FeedResponse, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+	resp := &models.FeedResponse{Items: []*models.FeedItem{}}
+	if len(userIDs) == 0 {
+		return resp, nil
+	}
+
+	activity, err := s.reading.Activity(ctx, userIDs, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(activity))
+	for i, item := range activity {
+		ids[i] = item.UserID
+	}
+	summaries, err := s.summaries(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range activity {
+		summary, ok := summaries[item.UserID]
+		if !ok {
+			continue
+		}
+		resp.Items = append(resp.Items, &models.FeedItem{ActivityItem: item, User: summary})
+	}
+	if len(activity) == limit {
+		last := activity[len(activity)-1].CreatedAt
+		resp.NextBefore = &last
+	}
+	return resp, nil
+}
+
+// summaries 按 ID 批量查询用户展示信息
+func (s *SocialService) summaries(ids []string) (map[string]*models.UserSummary, error) {
+	users, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make(map[string]*models.UserSummary, len(users))
+	for _, user := range users {
+		summaries[user.ID] = &models.UserSummary{
+			ID:               user.ID,
+			Username:         user.Username,
+			Nickname:         user.Nickname,
+			AvatarURL:        user.AvatarURL,
+			AvatarThumbnails: user.AvatarThumbnails,
+		}
+	}
+	return summaries, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePath(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "http://localhost/uploads/")
+
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "普通 key", key: "avatars/u1/abc_256.jpg", want: filepath.Join(dir, "avatars", "u1", "abc_256.jpg")},
+		{name: "开头的斜杠被忽略", key: "/avatars/u1/a.jpg", want: filepath.Join(dir, "avatars", "u1", "a.jpg")},
+		{name: "空 key", key: "", wantErr: true},
+		{name: "根目录", key: "/", wantErr: true},
+		{name: "跳出存储目录", key: "../etc/passwd", wantErr: true},
+		{name: "中间的上级目录", key: "avatars/../../secret", wantErr: true},
+		{name: "清理后仍在目录内也拒绝", key: "avatars/u1/../u2/a.jpg", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.path(tt.key)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("path(%q) = %q, want error", tt.key, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("path(%q) error: %v", tt.key, err)
+			}
+			if got != tt.want {
+				t.Errorf("path(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStoragePutRejectsTraversal(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	s := NewLocalStorage(dir, "")
+
+	if err := s.Put(context.Background(), "../escaped.txt", []byte("x"), "text/plain"); err == nil {
+		t.Fatal("Put with traversal key succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(root, "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file written outside storage dir: %v", err)
+	}
+	if err := s.Delete(context.Background(), "../escaped.txt"); err == nil {
+		t.Fatal("Delete with traversal key succeeded")
+	}
+
+	key := "avatars/u1/a.jpg"
+	if err := s.Put(context.Background(), key, []byte("data"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "avatars", "u1", "a.jpg"))
+	if err != nil || string(data) != "data" {
+		t.Fatalf("read back = %q, %v", data, err)
+	}
+	if err := s.Delete(context.Background(), key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(context.Background(), key); err != nil {
+		t.Fatalf("Delete of missing file: %v", err)
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "http://localhost/uploads/")
+	if got, want := s.URL("avatars/a.jpg"), "http://localhost/uploads/avatars/a.jpg"; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
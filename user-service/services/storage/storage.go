@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"reading-microservices/user-service/config"
+)
+
+// Storage 保存用户上传的文件，key 形如 avatars/<user_id>/<hash>_256.jpg
+type Storage interface {
+	// Put 写入文件，同一 key 重复写入会覆盖
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// Delete 删除文件，不存在时不报错
+	Delete(ctx context.Context, key string) error
+
+	// URL 文件的公开访问地址
+	URL(key string) string
+}
+
+// NewStorage 按配置创建文件存储，driver 为 local 或 s3
+func NewStorage(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.Local.Dir, cfg.Local.PublicURL), nil
+	case "s3":
+		return NewS3Storage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/cache"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+)
+
+// 阅读时长计数保留两天，跨时区的当天计数不会提前过期
+const teenReadingTTL = 48 * time.Hour
+
+var (
+	ErrTeenModeActive   = errors.New("teen mode is already enabled")
+	ErrTeenModeInactive = errors.New("teen mode is not enabled")
+	ErrTeenModeRequired = errors.New("teen mode cannot be disabled for minors")
+	ErrTeenPinNotSet    = errors.New("guardian PIN is not set")
+	ErrTeenPinInvalid   = errors.New("invalid guardian PIN")
+	ErrTeenPinLocked    = errors.New("too many wrong guardian PIN attempts, please retry later")
+	ErrTeenLimitTooHigh = errors.New("daily reading time exceeds the allowed maximum")
+)
+
+// TeenModeService 青少年模式：按生日未成年自动开启，成年用户可由监护人设置密码开启。
+// 开启后每日阅读时长和宵禁由阅读服务上报进度时检查，支付服务禁止购买，内容服务屏蔽成人向作品
+type TeenModeService struct {
+	userRepo    repositories.UserRepository
+	cache       cache.UserCache
+	authManager *auth.AuthManager
+	cfg         config.TeenModeConfig
+	location    *time.Location
+}
+
+func NewTeenModeService(userRepo repositories.UserRepository, userCache cache.UserCache, authManager *auth.AuthManager, cfg config.TeenModeConfig) *TeenModeService {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Printf("warning: load teen mode timezone %s failed, using local time: %v", cfg.Timezone, err)
+		location = time.Local
+	}
+	return &TeenModeService{
+		userRepo:    userRepo,
+		cache:       userCache,
+		authManager: authManager,
+		cfg:         cfg,
+		location:    location,
+	}
+}
+
+// Status 当前状态和今日已读时长
+func (s *TeenModeService) Status(ctx context.Context, userID string) (*models.TeenModeStatus, error) {
+	user, mode, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(s.location)
+	if !s.active(user, mode, now) {
+		return s.status(user, mode, 0, now), nil
+	}
+
+	used, err := s.cache.GetReadingTime(ctx, userID, now.Format("20060102"))
+	if err != nil {
+		log.Printf("warning: get reading time failed for user %s: %v", userID, err)
+	}
+	return s.status(user, mode, used, now), nil
+}
+
+// RecordReading 累加今日阅读时长并返回是否还能继续阅读；未开启时不计数
+func (s *TeenModeService) RecordReading(ctx context.Context, req *models.RecordTeenReadingRequest) (*models.TeenModeStatus, error) {
+	user, mode, err := s.load(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(s.location)
+	if !s.active(user, mode, now) {
+		return s.status(user, mode, 0, now), nil
+	}
+
+	day := now.Format("20060102")
+	var used int64
+	if req.Seconds > 0 {
+		used, err = s.cache.AddReadingTime(ctx, req.UserID, day, req.Seconds, teenReadingTTL)
+	} else {
+		used, err = s.cache.GetReadingTime(ctx, req.UserID, day)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.status(user, mode, used, now), nil
+}
+
+// Enable 监护人开启并设置监护密码；未成年用户已自动开启但没有密码时，用于设置密码和限制
+func (s *TeenModeService) Enable(userID string, req *models.EnableTeenModeRequest) (*models.TeenModeStatus, error) {
+	user, mode, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(s.location)
+	if s.active(user, mode, now) && mode.PinHash != "" {
+		return nil, ErrTeenModeActive
+	}
+
+	pinHash, err := s.authManager.HashPassword(req.Pin)
+	if err != nil {
+		return nil, err
+	}
+	mode.Enabled = true
+	mode.PinHash = pinHash
+	mode.DailyMinutes = s.cfg.DailyMinutes
+	mode.CurfewStart = s.cfg.CurfewStart
+	mode.CurfewEnd = s.cfg.CurfewEnd
+	if err := s.apply(mode, req.DailyMinutes, req.CurfewStart, req.CurfewEnd); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SaveTeenMode(mode); err != nil {
+		return nil, err
+	}
+	return s.status(user, mode, 0, now), nil
+}
+
+// Update 修改每日时长、宵禁或监护密码
+func (s *TeenModeService) Update(ctx context.Context, userID string, req *models.UpdateTeenModeRequest) (*models.TeenModeStatus, error) {
+	user, mode, err := s.load(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.active(user, mode, time.Now().In(s.location)) {
+		return nil, ErrTeenModeInactive
+	}
+	if err := s.verifyPin(ctx, mode, req.Pin); err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(mode, req.DailyMinutes, req.CurfewStart, req.CurfewEnd); err != nil {
+		return nil, err
+	}
+	if req.NewPin != nil {
+		pinHash, err := s.authManager.HashPassword(*req.NewPin)
+		if err != nil {
+			return nil, err
+		}
+		mode.PinHash = pinHash
+	}
+	if err := s.userRepo.SaveTeenMode(mode); err != nil {
+		return nil, err
+	}
+	return s.Status(ctx, userID)
+}
+
+// Disable 监护人关闭，未成年用户不能关闭
+func (s *TeenModeService) Disable(ctx context.Context, userID string, req *models.TeenModePinRequest) error {
+	user, mode, err := s.load(userID)
+	if err != nil {
+		return err
+	}
+	if s.isMinor(user, time.Now().In(s.location)) {
+		return ErrTeenModeRequired
+	}
+	if !mode.Enabled {
+		return ErrTeenModeInactive
+	}
+	if err := s.verifyPin(ctx, mode, req.Pin); err != nil {
+		return err
+	}
+
+	mode.Enabled = false
+	return s.userRepo.SaveTeenMode(mode)
+}
+
+func (s *TeenModeService) load(userID string) (*models.User, *models.TeenMode, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrUserNotFound
+		}
+		return nil, nil, err
+	}
+	mode, err := s.userRepo.GetTeenMode(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.persistForMinor(user, mode); err != nil {
+		log.Printf("warning: persist teen mode failed for user %s: %v", userID, err)
+	}
+	// 没有监护人设置时使用默认限制
+	if mode.PinHash == "" {
+		mode.DailyMinutes = s.cfg.DailyMinutes
+		mode.CurfewStart = s.cfg.CurfewStart
+		mode.CurfewEnd = s.cfg.CurfewEnd
+	}
+	return user, mode, nil
+}
+
+// EnsureForMinor 用户按当前生日未成年时把青少年模式保存为开启。
+// 之后即使把生日改成成年，也只能由监护人凭密码关闭
+func (s *TeenModeService) EnsureForMinor(user *models.User) error {
+	if !s.isMinor(user, time.Now().In(s.location)) {
+		return nil
+	}
+	mode, err := s.userRepo.GetTeenMode(user.ID)
+	if err != nil {
+		return err
+	}
+	return s.persistForMinor(user, mode)
+}
+
+func (s *TeenModeService) persistForMinor(user *models.User, mode *models.TeenMode) error {
+	if mode.Enabled || !s.isMinor(user, time.Now().In(s.location)) {
+		return nil
+	}
+	mode.Enabled = true
+	return s.userRepo.SaveTeenMode(mode)
+}
+
+func (s *TeenModeService) active(user *models.User, mode *models.TeenMode, now time.Time) bool {
+	return mode.Enabled || s.isMinor(user, now)
+}
+
+func (s *TeenModeService) isMinor(user *models.User, now time.Time) bool {
+	if user.BirthDate == nil {
+		return false
+	}
+	// 生日只有日期，直接取存储的年月日，不做时区换算
+	birth := *user.BirthDate
+	age := now.Year() - birth.Year()
+	if now.Month() < birth.Month() || (now.Month() == birth.Month() && now.Day() < birth.Day()) {
+		age--
+	}
+	return age < s.cfg.AdultAge
+}
+
+// apply 修改限制，每日时长不能超过配置的上限
+func (s *TeenModeService) apply(mode *models.TeenMode, dailyMinutes *int, curfewStart, curfewEnd *string) error {
+	if dailyMinutes != nil {
+		if *dailyMinutes > s.cfg.MaxDailyMinutes {
+			return fmt.Errorf("%w (%d minutes)", ErrTeenLimitTooHigh, s.cfg.MaxDailyMinutes)
+		}
+		mode.DailyMinutes = *dailyMinutes
+	}
+	if curfewStart != nil {
+		mode.CurfewStart = *curfewStart
+	}
+	if curfewEnd != nil {
+		mode.CurfewEnd = *curfewEnd
+	}
+	return nil
+}
+
+// verifyPin 校验监护密码，连续输错后锁定一段时间
+func (s *TeenModeService) verifyPin(ctx context.Context, mode *models.TeenMode, pin string) error {
+	if mode.PinHash == "" {
+		return ErrTeenPinNotSet
+	}
+	key := "teen_pin:" + mode.UserID
+	if ttl, err := s.cache.GetLoginLock(ctx, key); err != nil {
+		log.Printf("warning: get teen pin lock failed: %v", err)
+	} else if ttl > 0 {
+		return ErrTeenPinLocked
+	}
+
+	if err := s.authManager.VerifyPassword(mode.PinHash, pin); err != nil {
+		lockDuration := time.Duration(s.cfg.PinLockDuration) * time.Second
+		attempts, cacheErr := s.cache.IncrementLoginAttempts(ctx, key, lockDuration)
+		if cacheErr != nil {
+			log.Printf("warning: count teen pin failure failed: %v", cacheErr)
+		} else if attempts >= int64(s.cfg.PinMaxAttempts) {
+			s.cache.SetLoginLock(ctx, key, lockDuration)
+			s.cache.ResetLoginAttempts(ctx, key)
+		}
+		return ErrTeenPinInvalid
+	}
+	s.cache.ResetLoginAttempts(ctx, key)
+	return nil
+}
+
+func (s *TeenModeService) status(user *models.User, mode *models.TeenMode, usedSeconds int64, now time.Time) *models.TeenModeStatus {
+	status := &models.TeenModeStatus{
+		HasPin:         mode.PinHash != "",
+		ReadingAllowed: true,
+	}
+	if !s.active(user, mode, now) {
+		return status
+	}
+
+	status.Enabled = true
+	status.Source = "guardian"
+	if s.isMinor(user, now) {
+		status.Source = "age"
+	}
+	status.DailyMinutes = mode.DailyMinutes
+	status.CurfewStart = mode.CurfewStart
+	status.CurfewEnd = mode.CurfewEnd
+	status.UsedMinutes = int(usedSeconds / 60)
+	if remaining := mode.DailyMinutes - status.UsedMinutes; remaining > 0 {
+		status.RemainingMinutes = remaining
+	}
+
+	switch {
+	case inCurfew(now, mode.CurfewStart, mode.CurfewEnd):
+		status.ReadingAllowed = false
+		status.Reason = models.TeenReasonCurfew
+	case status.RemainingMinutes == 0:
+		status.ReadingAllowed = false
+		status.Reason = models.TeenReasonDailyLimit
+	}
+	return status
+}
+
+// inCurfew 宵禁可跨零点，开始与结束相同表示不设宵禁
+func inCurfew(now time.Time, start, end string) bool {
+	startMinute, ok := clockMinute(start)
+	if !ok {
+		return false
+	}
+	endMinute, ok := clockMinute(end)
+	if !ok || startMinute == endMinute {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
+
+func clockMinute(clock string) (int, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/oauth"
+)
+
+var (
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetup       = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication is required for this account")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+)
+
+// TwoFactorService TOTP 两步验证：开启、关闭、恢复码以及登录第二步
+type TwoFactorService struct {
+	userRepo      repositories.UserRepository
+	userService   *UserService
+	store         *auth.TwoFactorStore
+	policy        *auth.TwoFactorPolicy
+	cipher        *oauth.TokenCipher
+	issuer        string
+	recoveryCodes int
+}
+
+func NewTwoFactorService(
+	userRepo repositories.UserRepository,
+	userService *UserService,
+	store *auth.TwoFactorStore,
+	policy *auth.TwoFactorPolicy,
+	cipher *oauth.TokenCipher,
+	cfg config.TwoFactorConfig,
+) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:      userRepo,
+		userService:   userService,
+		store:         store,
+		policy:        policy,
+		cipher:        cipher,
+		issuer:        cfg.Issuer,
+		recoveryCodes: cfg.RecoveryCodes,
+	}
+}
+
+// ------------------- 设置 -------------------
+
+// Status 当前用户的两步验证状态
+func (s *TwoFactorService) Status(userID string) (*models.TwoFactorStatus, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.TwoFactorStatus{Required: s.policy.Required(user)}
+
+	twoFactor, err := s.enabledTwoFactor(userID)
+	if errors.Is(err, ErrTwoFactorNotEnabled) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = true
+	status.EnabledAt = twoFactor.EnabledAt
+	count, err := s.userRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	status.RecoveryCodesRemaining = int(count)
+	return status, nil
+}
+
+// Setup 生成新密钥，确认前不生效；重复调用会替换未确认的密钥
+func (s *TwoFactorService) Setup(userID string) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.enabledTwoFactor(userID); err == nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SaveTwoFactor(&models.UserTwoFactor{UserID: userID, Secret: encrypted}); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(s.issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 用验证器 App 上的动态码确认后开启，返回一次性展示的恢复码
+func (s *TwoFactorService) Enable(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error) {
+	twoFactor, err := s.userRepo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotSetup
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err := s.verifyTOTP(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	twoFactor.IsEnabled = true
+	twoFactor.EnabledAt = &now
+	if err := s.userRepo.SaveTwoFactor(twoFactor); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(userID)
+}
+
+// Disable 关闭两步验证，需要动态码或恢复码；策略要求开启的用户不能关闭
+func (s *TwoFactorService) Disable(ctx context.Context, userID, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if s.policy.Required(user) {
+		return ErrTwoFactorRequired
+	}
+	twoFactor, err := s.enabledTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyCode(ctx, twoFactor, code); err != nil {
+		return err
+	}
+	return s.userRepo.DeleteTwoFactor(userID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*models.RecoveryCodesResponse, error) {
+	twoFactor, err := s.enabledTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyTOTP(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+	return s.generateRecoveryCodes(userID)
+}
+
+// ------------------- 登录第二步 -------------------
+
+// challenge 由 UserService.startSession 调用，已开启两步验证时返回挑战而不是 token
+func (s *TwoFactorService) challenge(ctx context.Context, user *models.User, loginType string, client auth.ClientInfo) (*models.LoginResponse, bool, error) {
+	if _, err := s.enabledTwoFactor(user.ID); err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	token, err := s.store.CreateChallenge(ctx, &auth.TwoFactorChallenge{
+		UserID:    user.ID,
+		LoginType: loginType,
+		Client:    client,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &models.LoginResponse{
+		DeviceID:          client.DeviceID,
+		TwoFactorRequired: true,
+		TwoFactorToken:    token,
+	}, true, nil
+}
+
+// RequireEnabled 策略要求开启的用户未开启时返回 ErrTwoFactorRequired
+func (s *TwoFactorService) RequireEnabled(user *models.User) error {
+	if !s.policy.Required(user) {
+		return nil
+	}
+	if _, err := s.enabledTwoFactor(user.ID); err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			return ErrTwoFactorRequired
+		}
+		return err
+	}
+	return nil
+}
+
+// setupRequired 策略要求开启但尚未开启
+func (s *TwoFactorService) setupRequired(user *models.User) bool {
+	return s.policy.Required(user)
+}
+
+// VerifyLogin 登录第二步，校验动态码或恢复码后签发 token
+func (s *TwoFactorService) VerifyLogin(ctx context.Context, req *models.TwoFactorLoginRequest) (*models.LoginResponse, error) {
+	challenge, err := s.store.GetChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	// 动态码失败次数单独计数，密码登录成功清零账号计数时不受影响
+	guardKey := "2fa:" + challenge.UserID
+	guard := s.userService.loginGuard
+	if err := guard.Check(ctx, guardKey, req.IPAddress); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.enabledTwoFactor(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyCode(ctx, twoFactor, req.Code); err != nil {
+		s.userService.loginLogger.LogFailure(challenge.UserID, "invalid two-factor code", challenge.LoginType, challenge.Client)
+		if loginErr := guard.RecordFailure(ctx, guardKey, req.IPAddress); loginErr.Locked {
+			loginErr.Message = "too many wrong two-factor codes, temporarily locked"
+			return nil, loginErr
+		}
+		if limitErr := s.store.RecordFailure(ctx, req.ChallengeToken); limitErr != nil {
+			return nil, limitErr
+		}
+		return nil, err
+	}
+	if err := s.store.ConsumeChallenge(ctx, req.ChallengeToken); err != nil {
+		return nil, err
+	}
+	guard.RecordSuccess(ctx, guardKey)
+
+	user, err := s.userRepo.GetByID(challenge.UserID)
+	if err != nil {
+		return nil, errors.New("user not found or disabled")
+	}
+	if err := auth.CheckAccountStatus(user); err != nil {
+		return nil, err
+	}
+	return s.userService.completeSession(user, challenge.LoginType, challenge.Client)
+}
+
+// ------------------- Helper -------------------
+
+func (s *TwoFactorService) enabledTwoFactor(userID string) (*models.UserTwoFactor, error) {
+	twoFactor, err := s.userRepo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return twoFactor, nil
+}
+
+// verifyCode 6 位数字按动态码校验，其他按恢复码校验
+func (s *TwoFactorService) verifyCode(ctx context.Context, twoFactor *models.UserTwoFactor, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 && isDigits(code) {
+		return s.verifyTOTP(ctx, twoFactor, code)
+	}
+
+	ok, err := s.userRepo.UseRecoveryCode(twoFactor.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, twoFactor *models.UserTwoFactor, code string) error {
+	secret, err := s.cipher.Decrypt(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := auth.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	fresh, err := s.store.UseStep(ctx, twoFactor.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) generateRecoveryCodes(userID string) (*models.RecoveryCodesResponse, error) {
+	plain := make([]string, 0, s.recoveryCodes)
+	records := make([]models.UserRecoveryCode, 0, s.recoveryCodes)
+	for i := 0; i < s.recoveryCodes; i++ {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		plain = append(plain, code)
+		records = append(records, models.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(code)})
+	}
+	if err := s.userRepo.ReplaceRecoveryCodes(userID, records); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodesResponse{Codes: plain}, nil
+}
+
+// hashRecoveryCode 忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,14 +1,21 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
 	"reading-microservices/user-service/models"
 	"reading-microservices/user-service/repositories"
 	services "reading-microservices/user-service/services/auth"
 	"reading-microservices/user-service/utils"
+	"strings"
 	"sync"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // UserService 组合 AuthManager + SessionManager + LoginLogger
@@ -18,11 +25,25 @@ type UserService struct {
 	authManager    *services.AuthManager
 	sessionManager *services.SessionManager
 	loginLogger    *services.LoginLogger
+	loginGuard     *services.LoginGuard
 	loginLocks     sync.Map // 用户登录锁
 
 	// 双 token 配置
 	accessExpiresIn  int
 	refreshExpiresIn int
+
+	// 各 VIP 等级允许同时在线的设备数
+	maxDevices map[string]int
+
+	// 两步验证，为空时登录直接签发 token
+	twoFactor *TwoFactorService
+
+	// 注册时绑定邀请关系
+	referral    *ReferralService
+	preferences *PreferenceService
+
+	// 未成年用户修改生日前锁定青少年模式
+	teenMode *TeenModeService
 }
 
 func NewUserService(
@@ -30,16 +51,24 @@ func NewUserService(
 	authManager *services.AuthManager,
 	sessionManager *services.SessionManager,
 	loginLogger *services.LoginLogger,
+	loginGuard *services.LoginGuard,
 	accessExpiresIn int,
 	refreshExpiresIn int,
+	maxDevices map[string]int,
+	referral *ReferralService,
+	preferences *PreferenceService,
 ) *UserService {
 	return &UserService{
 		userRepo:         userRepo,
 		authManager:      authManager,
 		sessionManager:   sessionManager,
 		loginLogger:      loginLogger,
+		loginGuard:       loginGuard,
 		accessExpiresIn:  accessExpiresIn,
 		refreshExpiresIn: refreshExpiresIn,
+		maxDevices:       maxDevices,
+		referral:         referral,
+		preferences:      preferences,
 	}
 }
 
@@ -48,8 +77,25 @@ func NewUserService(
 func (s *UserService) Register(req *models.RegisterRequest) (*models.LoginResponse, error) {
 	// 检查唯一性略，可调用 userRepo.GetByUsername/GetByEmail/GetByPhone
 
+	if err := s.authManager.ValidatePassword(req.Password, req.Username); err != nil {
+		return nil, err
+	}
+
+	// 邀请码填错时不创建账号，让用户修改或清空后重试
+	var referralCode *models.ReferralCode
+	if req.ReferralCode != "" {
+		code, err := s.referral.Resolve(req.ReferralCode)
+		if err != nil {
+			return nil, err
+		}
+		referralCode = code
+	}
+
 	// 加密密码
-	hashedPassword, _ := s.authManager.HashPassword(req.Password)
+	hashedPassword, err := s.authManager.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
 	user := &models.User{
 		Username:     req.Username,
 		PasswordHash: hashedPassword,
@@ -65,51 +111,30 @@ func (s *UserService) Register(req *models.RegisterRequest) (*models.LoginRespon
 		return nil, err
 	}
 
-	// 生成双 token
-	accessToken, _ := s.authManager.GenerateToken(user.ID, user.Username, s.accessExpiresIn)
-	refreshToken, _ := s.authManager.GenerateToken(user.ID, user.Username, s.refreshExpiresIn)
-
-	// 创建 refresh session（存数据库）
-	refreshSession := &models.UserSession{
-		UserID:         user.ID,
-		SessionToken:   refreshToken,
-		SessionType:    "refresh",
-		Platform:       req.Platform,
-		ExpiresAt:      time.Now().Add(time.Duration(s.refreshExpiresIn) * time.Second),
-		LastActivityAt: time.Now(),
-	}
-	if req.DeviceID != "" {
-		refreshSession.DeviceID = &req.DeviceID
-	}
-
-	// 创建 access session（只存 Redis）
-	accessSession := &models.UserSession{
-		UserID:         user.ID,
-		SessionToken:   accessToken,
-		SessionType:    "access",
-		Platform:       req.Platform,
-		ExpiresAt:      time.Now().Add(time.Duration(s.accessExpiresIn) * time.Second),
-		LastActivityAt: time.Now(),
+	client := services.ClientInfo{
+		Platform:  req.Platform,
+		DeviceID:  req.DeviceID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
 	}
-	if req.DeviceID != "" {
-		accessSession.DeviceID = &req.DeviceID
+	if referralCode != nil {
+		// 账号已创建，邀请关系记录失败不影响注册
+		if err := s.referral.Bind(user.ID, referralCode, client); err != nil {
+			log.Printf("warning: bind referral for user %s failed: %v", user.ID, err)
+		} else {
+			go s.referral.Reach(user.ID, ReferralMilestoneRegistered, 0)
+		}
 	}
 
-	// 保存双 session
-	if err := s.sessionManager.CreateSessionPair(accessSession, refreshSession); err != nil {
+	resp, refreshSession, err := s.issueTokens(user, &client)
+	if err != nil {
 		return nil, err
 	}
 
 	// 登录日志
-	s.loginLogger.LogSuccess(user.ID, &refreshSession.ID, req.Platform, req.DeviceID)
+	s.loginLogger.LogSuccess(user.ID, &refreshSession.ID, "password", client)
 
-	return &models.LoginResponse{
-		AccessToken:      accessToken,
-		RefreshToken:     refreshToken,
-		AccessExpiresIn:  s.accessExpiresIn,
-		RefreshExpiresIn: s.refreshExpiresIn,
-		User:             convertToUserInfo(user),
-	}, nil
+	return resp, nil
 }
 
 // ------------------- Login -------------------
@@ -122,91 +147,36 @@ func (s *UserService) Login(req *models.LoginRequest) (*models.LoginResponse, er
 	defer mutex.Unlock()
 	defer s.loginLocks.Delete(req.Username)
 
-	// 1. 查询用户
-	user, err := s.userRepo.GetByUsername(req.Username)
-	if err != nil {
-		return nil, errors.New("invalid username or password")
-	}
-
-	// 2. 校验密码
-	if err := s.authManager.VerifyPassword(user.PasswordHash, req.Password); err != nil {
-		s.loginLogger.LogFailure(user.ID, "invalid password", req.Platform, req.DeviceID)
-		return nil, errors.New("invalid username or password")
+	ctx := context.Background()
+	client := services.ClientInfo{
+		Platform:  req.Platform,
+		DeviceID:  req.DeviceID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
 	}
 
-	// 3. 生成双 token
-	accessToken, err := s.authManager.GenerateToken(user.ID, user.Username, s.accessExpiresIn)
-	if err != nil {
-		return nil, errors.New("failed to generate access token")
+	// 1. 账号或 IP 被锁定、处于退避期时直接拒绝
+	if err := s.loginGuard.Check(ctx, req.Username, req.IPAddress); err != nil {
+		return nil, err
 	}
 
-	refreshToken, err := s.authManager.GenerateToken(user.ID, user.Username, s.refreshExpiresIn)
+	// 2. 查询用户（不存在的账号同样计入失败次数）
+	user, err := s.userRepo.GetByUsername(req.Username)
 	if err != nil {
-		return nil, errors.New("failed to generate refresh token")
-	}
-
-	// 4. 清理旧的 session（不中断登录流程）
-	if err := s.sessionManager.InvalidateAllUserSessions(user.ID, true); err != nil {
-		s.loginLogger.LogFailure(user.ID, "warning: failed to invalidate old sessions", req.Platform, req.DeviceID)
-	}
-
-	// 5. 创建新的 session 对
-	refreshSession := &models.UserSession{
-		UserID:         user.ID,
-		SessionToken:   refreshToken,
-		SessionType:    "refresh",
-		Platform:       req.Platform,
-		ExpiresAt:      time.Now().Add(time.Duration(s.refreshExpiresIn) * time.Second),
-		LastActivityAt: time.Now(),
-	}
-	if req.DeviceID != "" {
-		refreshSession.DeviceID = &req.DeviceID
-	}
-
-	accessSession := &models.UserSession{
-		UserID:         user.ID,
-		SessionToken:   accessToken,
-		SessionType:    "access",
-		Platform:       req.Platform,
-		ExpiresAt:      time.Now().Add(time.Duration(s.accessExpiresIn) * time.Second),
-		LastActivityAt: time.Now(),
-	}
-	if req.DeviceID != "" {
-		accessSession.DeviceID = &req.DeviceID
+		return nil, s.loginGuard.RecordFailure(ctx, req.Username, req.IPAddress)
 	}
-	refreshSession.AccessToken = accessSession.SessionToken
 
-	// 使用重试机制创建 session 对
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		err = s.sessionManager.CreateSessionPair(accessSession, refreshSession)
-		if err == nil {
-			break
-		}
-
-		// 如果是唯一性冲突，重新生成 token
-		if utils.IsDuplicateError(err) && i < maxRetries-1 {
-			accessToken, _ = s.authManager.GenerateToken(user.ID, user.Username, s.accessExpiresIn)
-			refreshToken, _ = s.authManager.GenerateToken(user.ID, user.Username, s.refreshExpiresIn)
-			accessSession.SessionToken = accessToken
-			refreshSession.SessionToken = refreshToken
-			continue
-		}
-
-		return nil, err
+	// 3. 校验密码
+	if err := s.authManager.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		loginErr := s.loginGuard.RecordFailure(ctx, req.Username, req.IPAddress)
+		s.loginLogger.LogFailure(user.ID, "invalid password", "password", client)
+		return nil, loginErr
 	}
+	s.loginGuard.RecordSuccess(ctx, req.Username)
+	s.upgradePasswordHash(user, req.Password)
 
-	// 6. 记录登录成功日志
-	s.loginLogger.LogSuccess(user.ID, &refreshSession.ID, req.Platform, req.DeviceID)
-
-	// 7. 返回响应
-	return &models.LoginResponse{
-		AccessToken:      accessToken,
-		RefreshToken:     refreshToken,
-		AccessExpiresIn:  s.accessExpiresIn,
-		RefreshExpiresIn: s.refreshExpiresIn,
-		User:             convertToUserInfo(user),
-	}, nil
+	// 4. 建立会话
+	return s.startSession(user, "password", client)
 }
 
 // ------------------- Logout -------------------
@@ -218,53 +188,40 @@ func (s *UserService) Logout(accessToken string) error {
 
 // ------------------- RefreshToken -------------------
 
-func (s *UserService) RefreshToken(refreshToken string) (*models.LoginResponse, error) {
+// RefreshToken 轮换 refresh token：每次刷新签发新的 refresh token，旧 token 立即作废。
+// 已作废的 token 再次出现说明可能被盗用，整个 token 族失效并记录安全事件。
+func (s *UserService) RefreshToken(refreshToken, ipAddress, userAgent string) (*models.LoginResponse, error) {
 	// 验证 refresh token
-	claims, err := s.authManager.ParseToken(refreshToken)
+	claims, err := s.authManager.VerifyToken(refreshToken)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
 
-	// 获取 refresh session（从数据库）
-	refreshSession, err := s.sessionManager.GetSession(refreshToken, false)
-	if err != nil {
-		return nil, errors.New("refresh session expired or invalid")
-	}
-
-	// 检查 refresh token 是否即将过期（比如7天内过期）
-	if time.Until(refreshSession.ExpiresAt) < 1*time.Hour {
-		// 如果 refresh token 即将过期，可以在这里选择：
-		// 1. 返回错误提示用户重新登录
-		// 2. 或者生成新的 refresh token（轮换）
-		// 这里采用方案1：提示用户重新登录
-		return nil, errors.New("refresh token is about to expire, please login again")
+	// 从数据库获取 refresh session（包括已失效的，用于重放检测）
+	refreshSession, err := s.userRepo.GetSessionByToken(refreshToken)
+	if err != nil || refreshSession.SessionType != "refresh" || refreshSession.UserID != claims.UserID {
+		return nil, errors.New("invalid refresh token")
 	}
 
-	// 只生成新的 access token
-	newAccessToken, _ := s.authManager.GenerateToken(claims.UserID, "", s.accessExpiresIn)
-	// 创建新的 access session
-	newAccessSession := &models.UserSession{
-		UserID:         claims.UserID,
-		SessionToken:   newAccessToken,
-		SessionType:    "access",
-		Platform:       refreshSession.Platform,
-		ExpiresAt:      time.Now().Add(time.Duration(s.accessExpiresIn) * time.Second),
-		LastActivityAt: time.Now(),
-		DeviceID:       refreshSession.DeviceID,
+	client := services.ClientInfo{
+		Platform:  refreshSession.Platform,
+		DeviceID:  stringValue(refreshSession.DeviceID),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
 	}
 
-	// 更新 refresh session 的 access token 引用和最后活动时间
-	refreshSession.AccessToken = newAccessToken
-	refreshSession.LastActivityAt = time.Now()
-
-	if err := s.sessionManager.RefreshSessionPair(newAccessSession, refreshSession); err != nil {
-		log.Printf("warning: failed to refresh session: %v", err)
-		return nil, errors.New("refresh session failed")
+	if !refreshSession.IsActive {
+		if services.RotatedWithinGrace(refreshSession) {
+			return nil, services.ErrRefreshTokenRotated
+		}
+		if refreshSession.RotatedAt != nil {
+			s.handleRefreshTokenReuse(refreshSession, client)
+			return nil, services.ErrRefreshTokenReused
+		}
+		return nil, errors.New("refresh session expired or invalid")
 	}
-
-	// 更新 refresh session 的最后活动时间（在数据库中）
-	if err := s.sessionManager.UpdateRefreshSession(refreshToken, newAccessToken); err != nil {
-		log.Printf("warning: failed to update refresh session: %v", err)
+	if time.Now().After(refreshSession.ExpiresAt) {
+		return nil, errors.New("refresh session expired or invalid")
 	}
 
 	// 获取用户信息
@@ -272,14 +229,43 @@ func (s *UserService) RefreshToken(refreshToken string) (*models.LoginResponse,
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		return nil, err
+	}
 
-	return &models.LoginResponse{
-		AccessToken:      newAccessToken,
-		RefreshToken:     refreshToken, // 返回相同的 refresh token
-		AccessExpiresIn:  s.accessExpiresIn,
-		RefreshExpiresIn: int(time.Until(refreshSession.ExpiresAt).Seconds()),
-		User:             convertToUserInfo(user),
-	}, nil
+	// 生成新的会话对并轮换
+	accessSession, newRefreshSession, err := s.buildSessionPair(user, &client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessionManager.RotateSessionPair(refreshSession, accessSession, newRefreshSession); err != nil {
+		if errors.Is(err, services.ErrRefreshTokenRotated) {
+			return nil, err
+		}
+		log.Printf("warning: failed to rotate session: %v", err)
+		return nil, errors.New("refresh session failed")
+	}
+
+	return s.loginResponse(user, accessSession, newRefreshSession), nil
+}
+
+// handleRefreshTokenReuse 吊销整个 token 族并记录安全事件
+func (s *UserService) handleRefreshTokenReuse(session *models.UserSession, client services.ClientInfo) {
+	// 族 ID 上线前创建的会话轮换时以自身 ID 作为新会话的族 ID，见 RotateSessionPair
+	familyID := session.FamilyID
+	if familyID == "" {
+		familyID = session.ID
+	}
+	if err := s.sessionManager.RevokeFamily(session.UserID, familyID); err != nil {
+		log.Printf("warning: revoke token family failed: %v", err)
+	}
+
+	loginType := "password"
+	if user, err := s.userRepo.GetByID(session.UserID); err == nil && user.LoginType != "" {
+		loginType = user.LoginType
+	}
+	s.loginLogger.LogSecurityEvent(session.UserID, &session.ID, loginType, "refresh_token_reuse",
+		"refresh token reuse detected, token family revoked", client)
 }
 
 // ------------------- ValidateToken -------------------
@@ -289,6 +275,13 @@ func (s *UserService) ValidateToken(accessToken string) (*models.UserSession, er
 	if err != nil {
 		return nil, errors.New("invalid or expired access token")
 	}
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := services.CheckAccountStatus(user); err != nil {
+		return nil, err
+	}
 	return session, nil
 }
 
@@ -309,6 +302,9 @@ func (s *UserService) UpdateProfile(userID string, req *models.UpdateProfileRequ
 	if err != nil {
 		return err
 	}
+	if err := s.applyContactChanges(user, req); err != nil {
+		return err
+	}
 	if req.Nickname != nil {
 		user.Nickname = req.Nickname
 	}
@@ -319,16 +315,53 @@ func (s *UserService) UpdateProfile(userID string, req *models.UpdateProfileRequ
 		user.Gender = *req.Gender
 	}
 	if req.AvatarURL != nil {
+		// 改用外部地址后上传头像的缩略图不再对应，旧文件在下次上传时清理
+		if user.AvatarURL == nil || *user.AvatarURL != *req.AvatarURL {
+			user.AvatarThumbnails = nil
+		}
 		user.AvatarURL = req.AvatarURL
 	}
 	if req.BirthDate != nil {
 		if bd, err := time.Parse("2006-01-02", *req.BirthDate); err == nil {
+			// 按原生日未成年时先保存青少年模式为开启，改成成年的生日后仍需监护密码才能关闭
+			if s.teenMode != nil {
+				if err := s.teenMode.EnsureForMinor(user); err != nil {
+					return err
+				}
+			}
 			user.BirthDate = &bd
 		}
 	}
 	return s.userRepo.Update(user)
 }
 
+// applyContactChanges 修改邮箱或手机号时检查唯一性，并重置验证状态
+func (s *UserService) applyContactChanges(user *models.User, req *models.UpdateProfileRequest) error {
+	if req.Email != nil && *req.Email != stringValue(user.Email) {
+		email := strings.TrimSpace(*req.Email)
+		if email != "" {
+			if existing, err := s.userRepo.GetByEmail(email); err == nil && existing.ID != user.ID {
+				return errors.New("email already in use")
+			}
+		}
+		user.Email = optionalString(email)
+		user.IsEmailVerified = false
+		user.EmailVerifiedAt = nil
+	}
+	if req.Phone != nil && *req.Phone != stringValue(user.Phone) {
+		phone := strings.TrimSpace(*req.Phone)
+		if phone != "" {
+			if existing, err := s.userRepo.GetByPhone(phone); err == nil && existing.ID != user.ID {
+				return errors.New("phone already in use")
+			}
+		}
+		user.Phone = optionalString(phone)
+		user.IsPhoneVerified = false
+		user.PhoneVerifiedAt = nil
+	}
+	return nil
+}
+
 // ------------------- ChangePassword -------------------
 
 func (s *UserService) ChangePassword(userID string, req *models.ChangePasswordRequest) error {
@@ -339,13 +372,406 @@ func (s *UserService) ChangePassword(userID string, req *models.ChangePasswordRe
 	if err := s.authManager.VerifyPassword(user.PasswordHash, req.OldPassword); err != nil {
 		return errors.New("invalid old password")
 	}
-	hashed, _ := s.authManager.HashPassword(req.NewPassword)
+	if err := s.authManager.ValidatePassword(req.NewPassword, user.Username); err != nil {
+		return err
+	}
+	hashed, err := s.authManager.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
 	user.PasswordHash = hashed
 	return s.userRepo.Update(user)
 }
 
+// upgradePasswordHash 旧算法或旧参数的哈希用本次登录的明文重新生成，失败不影响登录
+func (s *UserService) upgradePasswordHash(user *models.User, password string) {
+	if !s.authManager.PasswordNeedsRehash(user.PasswordHash) {
+		return
+	}
+	hashed, err := s.authManager.HashPassword(password)
+	if err != nil {
+		log.Printf("warning: rehash password failed for user %s: %v", user.ID, err)
+		return
+	}
+	// 以旧哈希为条件更新，避免覆盖同时发生的改密
+	if err := s.userRepo.UpdatePasswordHash(user.ID, user.PasswordHash, hashed); err != nil {
+		log.Printf("warning: save upgraded password hash failed for user %s: %v", user.ID, err)
+		return
+	}
+	user.PasswordHash = hashed
+}
+
+// SetTwoFactor 启用两步验证；TwoFactorService 依赖 UserService 签发 token，只能在构造后注入
+func (s *UserService) SetTwoFactor(twoFactor *TwoFactorService) {
+	s.twoFactor = twoFactor
+}
+
+// SetTeenMode TeenModeService 在 UserService 之后创建，构造后注入
+func (s *UserService) SetTeenMode(teenMode *TeenModeService) {
+	s.teenMode = teenMode
+}
+
+// ------------------- UnlockLogin -------------------
+
+// UnlockLogin 解除登录锁定
+func (s *UserService) UnlockLogin(username string) error {
+	if _, err := s.userRepo.GetByUsername(username); err != nil {
+		return errors.New("user not found")
+	}
+	return s.loginGuard.Unlock(context.Background(), username)
+}
+
+// ------------------- InvalidateUserCache -------------------
+
+// InvalidateUserCache 清除用户缓存，其他服务修改余额、VIP 等用户字段后调用
+func (s *UserService) InvalidateUserCache(userID string) error {
+	return s.userRepo.InvalidateUser(userID)
+}
+
+// BatchGetUsers 批量查询用户公开信息（内部接口），按 ids 顺序返回，不存在或已注销的跳过。
+// 调用方不区分查看者，等级只在隐私设置为公开时返回
+func (s *UserService) BatchGetUsers(ids []string) ([]*models.UserPublicInfo, error) {
+	users, err := s.userRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]string, len(users))
+	for i := range users {
+		userIDs[i] = users[i].ID
+	}
+	privacies, err := s.userRepo.GetPrivacies(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]*models.UserPublicInfo, len(users))
+	for i := range users {
+		user := &users[i]
+		info := &models.UserPublicInfo{
+			ID:               user.ID,
+			Username:         user.Username,
+			Nickname:         user.Nickname,
+			AvatarURL:        user.AvatarURL,
+			AvatarThumbnails: user.AvatarThumbnails,
+			VipLevel:         user.VipLevel,
+		}
+		if user.VipExpiresAt != nil && user.VipExpiresAt.Before(now) {
+			info.VipLevel = "none"
+		}
+		if privacies[user.ID].Level == models.VisibilityPublic {
+			level := user.Level
+			info.Level = &level
+		}
+		result[i] = info
+	}
+	return result, nil
+}
+
+// ------------------- LoginHistory -------------------
+
+// LoginHistory 登录记录，含失败和安全事件
+func (s *UserService) LoginHistory(userID string, page, size int) ([]*models.LoginHistoryItem, int64, error) {
+	logs, total, err := s.userRepo.GetLoginHistory(userID, page, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]*models.LoginHistoryItem, 0, len(logs))
+	for _, l := range logs {
+		items = append(items, &models.LoginHistoryItem{
+			ID:            l.ID,
+			LoginType:     l.LoginType,
+			EventType:     l.EventType,
+			Platform:      l.Platform,
+			DeviceID:      l.DeviceID,
+			IPAddress:     l.IPAddress,
+			UserAgent:     l.UserAgent,
+			Location:      l.Location,
+			IsSuccess:     l.IsSuccess,
+			FailureReason: l.FailureReason,
+			IsNewDevice:   l.IsNewDevice,
+			IsNewLocation: l.IsNewLocation,
+			CreatedAt:     l.CreatedAt,
+		})
+	}
+	return items, total, nil
+}
+
+// ------------------- Devices -------------------
+
+// ListDevices 当前在线的设备，currentAccessToken 用于标记本机
+func (s *UserService) ListDevices(userID, currentAccessToken string) ([]*models.DeviceInfo, error) {
+	sessions, err := s.sessionManager.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	currentID := s.currentSessionID(currentAccessToken)
+	devices := make([]*models.DeviceInfo, 0, len(sessions))
+	for _, session := range sessions {
+		devices = append(devices, &models.DeviceInfo{
+			SessionID:      session.ID,
+			DeviceID:       stringValue(session.DeviceID),
+			Platform:       session.Platform,
+			IPAddress:      session.IPAddress,
+			UserAgent:      session.UserAgent,
+			LastActivityAt: session.LastActivityAt,
+			CreatedAt:      session.CreatedAt,
+			IsCurrent:      session.ID == currentID,
+		})
+	}
+	return devices, nil
+}
+
+// RevokeDevice 下线指定设备
+func (s *UserService) RevokeDevice(userID, deviceID string) error {
+	sessions, err := s.sessionManager.ListActiveSessions(userID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, session := range sessions {
+		if stringValue(session.DeviceID) != deviceID {
+			continue
+		}
+		found = true
+		if err := s.sessionManager.InvalidateSessionByID(userID, session.ID); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errors.New("device not found")
+	}
+	return nil
+}
+
+// RevokeOtherDevices 下线除当前设备外的所有设备
+func (s *UserService) RevokeOtherDevices(userID, currentAccessToken string) error {
+	currentID := s.currentSessionID(currentAccessToken)
+	if currentID == "" {
+		return errors.New("current session not found")
+	}
+
+	sessions, err := s.sessionManager.ListActiveSessions(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err := s.sessionManager.InvalidateSessionByID(userID, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *UserService) currentSessionID(accessToken string) string {
+	session, err := s.sessionManager.GetSession(accessToken, true)
+	if err != nil {
+		return ""
+	}
+	return session.ID
+}
+
 // ------------------- Helper -------------------
 
+// startSession 登录成功后建立会话并记录日志，密码登录和第三方登录共用
+func (s *UserService) startSession(user *models.User, loginType string, client services.ClientInfo) (*models.LoginResponse, error) {
+	if err := services.CheckAccountStatus(user); err != nil {
+		s.loginLogger.LogFailure(user.ID, err.Error(), loginType, client)
+		return nil, err
+	}
+	if client.DeviceID == "" {
+		client.DeviceID = generateDeviceID()
+	}
+	if s.twoFactor == nil {
+		return s.completeSession(user, loginType, client)
+	}
+
+	// 已开启两步验证时先返回挑战，验证通过后再由 TwoFactorService 完成登录
+	resp, challenged, err := s.twoFactor.challenge(context.Background(), user, loginType, client)
+	if err != nil {
+		return nil, err
+	}
+	if challenged {
+		return resp, nil
+	}
+	resp, err = s.completeSession(user, loginType, client)
+	if err != nil {
+		return nil, err
+	}
+	resp.TwoFactorSetupRequired = s.twoFactor.setupRequired(user)
+	return resp, nil
+}
+
+// completeSession 签发 token 并记录登录日志
+func (s *UserService) completeSession(user *models.User, loginType string, client services.ClientInfo) (*models.LoginResponse, error) {
+	// 同一设备重复登录替换旧会话；设备数超出上限时踢掉最久未活动的设备
+	if err := s.enforceDeviceLimit(user, client.DeviceID); err != nil {
+		log.Printf("warning: enforce device limit failed for user %s: %v", user.ID, err)
+	}
+
+	resp, refreshSession, err := s.issueTokens(user, &client)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录登录成功日志
+	s.loginLogger.LogSuccess(user.ID, &refreshSession.ID, loginType, client)
+	return resp, nil
+}
+
+// enforceDeviceLimit 为即将登录的设备腾出位置
+func (s *UserService) enforceDeviceLimit(user *models.User, deviceID string) error {
+	sessions, err := s.sessionManager.ListActiveSessions(user.ID)
+	if err != nil {
+		return err
+	}
+
+	// sessions 按最后活动时间倒序，保留最近活跃的 limit-1 个其他设备
+	limit := s.deviceLimit(user)
+	kept := 0
+	for _, session := range sessions {
+		if stringValue(session.DeviceID) != deviceID && (limit <= 0 || kept < limit-1) {
+			kept++
+			continue
+		}
+		if err := s.sessionManager.InvalidateSessionByID(user.ID, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deviceLimit 按有效 VIP 等级取设备上限，0 表示不限制
+func (s *UserService) deviceLimit(user *models.User) int {
+	level := user.VipLevel
+	if level == "" || (user.VipExpiresAt != nil && user.VipExpiresAt.Before(time.Now())) {
+		level = "none"
+	}
+	return s.maxDevices[level]
+}
+
+// issueTokens 生成双 token 并保存会话对，返回登录响应和 refresh session
+func (s *UserService) issueTokens(user *models.User, client *services.ClientInfo) (*models.LoginResponse, *models.UserSession, error) {
+	// 使用重试机制创建 session 对
+	maxRetries := 3
+	for i := 0; ; i++ {
+		accessSession, refreshSession, err := s.buildSessionPair(user, client)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = s.sessionManager.CreateSessionPair(accessSession, refreshSession)
+		if err == nil {
+			resp := s.loginResponse(user, accessSession, refreshSession)
+			// 偏好只影响阅读界面，读取失败不影响登录，客户端可稍后单独拉取
+			if resp.Preferences, err = s.preferences.Get(user.ID, client.DeviceID); err != nil {
+				log.Printf("warning: load preferences of user %s failed: %v", user.ID, err)
+			}
+			return resp, refreshSession, nil
+		}
+
+		// 如果是唯一性冲突，重新生成 token
+		if utils.IsDuplicateError(err) && i < maxRetries-1 {
+			continue
+		}
+		return nil, nil, err
+	}
+}
+
+// buildSessionPair 生成双 token 及对应的会话，refresh session 存数据库，access session 只存 Redis
+func (s *UserService) buildSessionPair(user *models.User, client *services.ClientInfo) (*models.UserSession, *models.UserSession, error) {
+	if client.DeviceID == "" {
+		client.DeviceID = generateDeviceID()
+	}
+
+	role := s.tokenRole(user)
+	accessToken, err := s.authManager.GenerateToken(user.ID, user.Username, role, s.accessExpiresIn)
+	if err != nil {
+		return nil, nil, errors.New("failed to generate access token")
+	}
+
+	refreshToken, err := s.authManager.GenerateToken(user.ID, user.Username, role, s.refreshExpiresIn)
+	if err != nil {
+		return nil, nil, errors.New("failed to generate refresh token")
+	}
+
+	refreshSession := &models.UserSession{
+		UserID:         user.ID,
+		SessionToken:   refreshToken,
+		SessionType:    "refresh",
+		Platform:       client.Platform,
+		DeviceID:       &client.DeviceID,
+		ExpiresAt:      time.Now().Add(time.Duration(s.refreshExpiresIn) * time.Second),
+		LastActivityAt: time.Now(),
+		AccessToken:    accessToken,
+	}
+	accessSession := &models.UserSession{
+		UserID:         user.ID,
+		SessionToken:   accessToken,
+		SessionType:    "access",
+		Platform:       client.Platform,
+		DeviceID:       &client.DeviceID,
+		ExpiresAt:      time.Now().Add(time.Duration(s.accessExpiresIn) * time.Second),
+		LastActivityAt: time.Now(),
+	}
+	if client.IPAddress != "" {
+		refreshSession.IPAddress = &client.IPAddress
+		accessSession.IPAddress = &client.IPAddress
+	}
+	if client.UserAgent != "" {
+		refreshSession.UserAgent = &client.UserAgent
+		accessSession.UserAgent = &client.UserAgent
+	}
+	return accessSession, refreshSession, nil
+}
+
+func (s *UserService) loginResponse(user *models.User, accessSession, refreshSession *models.UserSession) *models.LoginResponse {
+	return &models.LoginResponse{
+		AccessToken:      accessSession.SessionToken,
+		RefreshToken:     refreshSession.SessionToken,
+		AccessExpiresIn:  s.accessExpiresIn,
+		RefreshExpiresIn: s.refreshExpiresIn,
+		User:             convertToUserInfo(user),
+		DeviceID:         stringValue(refreshSession.DeviceID),
+	}
+}
+
+// tokenRole 管理员和审核员未开启两步验证时按普通用户签发，网关拒绝其访问各服务的管理接口
+func (s *UserService) tokenRole(user *models.User) string {
+	role := services.TokenRole(user)
+	if role == "" || s.twoFactor == nil {
+		return role
+	}
+	if err := s.twoFactor.RequireEnabled(user); err != nil {
+		if !errors.Is(err, ErrTwoFactorRequired) {
+			log.Printf("warning: check two-factor for user %s failed: %v", user.ID, err)
+		}
+		return ""
+	}
+	return role
+}
+
+// generateDeviceID 带 GeneratedDeviceIDPrefix 前缀，登录提醒据此跳过新设备判断
+func generateDeviceID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s%d", services.GeneratedDeviceIDPrefix, time.Now().UnixNano())
+	}
+	return services.GeneratedDeviceIDPrefix + hex.EncodeToString(b)
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
 func convertToUserInfo(user *models.User) *models.UserInfo {
 	return &models.UserInfo{
 		ID:               user.ID,
@@ -353,6 +779,7 @@ func convertToUserInfo(user *models.User) *models.UserInfo {
 		Email:            user.Email,
 		Phone:            user.Phone,
 		AvatarURL:        user.AvatarURL,
+		AvatarThumbnails: user.AvatarThumbnails,
 		Nickname:         user.Nickname,
 		Bio:              user.Bio,
 		Gender:           user.Gender,
@@ -364,3 +791,18 @@ func convertToUserInfo(user *models.User) *models.UserInfo {
 		IsEmailVerified:  user.IsEmailVerified,
 	}
 }
+
+// generateUsername 自动注册时生成的用户名，如 wechat_1a2b3c4d5e
+func generateUsername(userRepo repositories.UserRepository, prefix string) (string, error) {
+	for i := 0; i < 5; i++ {
+		suffix, err := randomHex(5)
+		if err != nil {
+			return "", err
+		}
+		username := prefix + "_" + suffix
+		if _, err := userRepo.GetByUsername(username); errors.Is(err, gorm.ErrRecordNotFound) {
+			return username, nil
+		}
+	}
+	return "", errors.New("failed to generate username")
+}
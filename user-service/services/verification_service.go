@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"reading-microservices/user-service/config"
+	"reading-microservices/user-service/models"
+	"reading-microservices/user-service/repositories"
+	auth "reading-microservices/user-service/services/auth"
+	"reading-microservices/user-service/services/sender"
+)
+
+// VerificationService 邮箱和手机号验证
+type VerificationService struct {
+	userRepo     repositories.UserRepository
+	codes        *auth.CodeManager
+	emailSender  sender.EmailSender
+	smsSender    sender.SMSSender
+	emailLinkURL string
+	resendAfter  int
+	referral     *ReferralService
+}
+
+func NewVerificationService(
+	userRepo repositories.UserRepository,
+	codes *auth.CodeManager,
+	emailSender sender.EmailSender,
+	smsSender sender.SMSSender,
+	cfg config.VerificationConfig,
+	referral *ReferralService,
+) *VerificationService {
+	return &VerificationService{
+		userRepo:     userRepo,
+		codes:        codes,
+		emailSender:  emailSender,
+		smsSender:    smsSender,
+		emailLinkURL: cfg.EmailLinkURL,
+		resendAfter:  cfg.ResendInterval,
+		referral:     referral,
+	}
+}
+
+// ------------------- Email -------------------
+
+// SendEmailCode 向当前邮箱发送验证码和验证链接
+func (s *VerificationService) SendEmailCode(ctx context.Context, userID string) (*models.SendCodeResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email == nil || *user.Email == "" {
+		return nil, errors.New("email not set")
+	}
+	if user.IsEmailVerified {
+		return nil, errors.New("email already verified")
+	}
+
+	email := *user.Email
+	code, err := s.codes.Issue(ctx, auth.PurposeEmailVerify, email)
+	if err != nil {
+		return nil, err
+	}
+
+	minutes := int(s.codes.TTL().Minutes())
+	body := fmt.Sprintf("您的邮箱验证码是 %s，%d 分钟内有效。", code, minutes)
+	if s.emailLinkURL != "" {
+		link := s.emailLinkURL + "?email=" + url.QueryEscape(email) + "&code=" + code
+		body += "\n\n也可以点击以下链接完成验证：\n" + link
+	}
+	if err := s.emailSender.SendEmail(ctx, email, "邮箱验证", body); err != nil {
+		return nil, fmt.Errorf("send email failed: %w", err)
+	}
+
+	return s.sendCodeResponse(maskEmail(email)), nil
+}
+
+// ConfirmEmail 校验验证码并标记邮箱已验证
+func (s *VerificationService) ConfirmEmail(ctx context.Context, userID, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil || *user.Email == "" {
+		return errors.New("email not set")
+	}
+
+	// 验证码按邮箱地址签发，邮箱修改后旧验证码自然失效
+	if err := s.codes.Verify(ctx, auth.PurposeEmailVerify, *user.Email, code); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.IsEmailVerified = true
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	go s.referral.Reach(user.ID, ReferralMilestoneVerified, 0)
+	return nil
+}
+
+// ------------------- Phone -------------------
+
+// SendPhoneCode 向当前手机号发送验证码
+func (s *VerificationService) SendPhoneCode(ctx context.Context, userID string) (*models.SendCodeResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Phone == nil || *user.Phone == "" {
+		return nil, errors.New("phone not set")
+	}
+	if user.IsPhoneVerified {
+		return nil, errors.New("phone already verified")
+	}
+
+	phone := *user.Phone
+	code, err := s.codes.Issue(ctx, auth.PurposePhoneVerify, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("您的手机验证码是 %s，%d 分钟内有效，请勿泄露。", code, int(s.codes.TTL().Minutes()))
+	if err := s.smsSender.SendSMS(ctx, phone, content); err != nil {
+		return nil, fmt.Errorf("send sms failed: %w", err)
+	}
+
+	return s.sendCodeResponse(maskPhone(phone)), nil
+}
+
+// ConfirmPhone 校验验证码并标记手机号已验证
+func (s *VerificationService) ConfirmPhone(ctx context.Context, userID, code string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Phone == nil || *user.Phone == "" {
+		return errors.New("phone not set")
+	}
+
+	if err := s.codes.Verify(ctx, auth.PurposePhoneVerify, *user.Phone, code); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.IsPhoneVerified = true
+	user.PhoneVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	go s.referral.Reach(user.ID, ReferralMilestoneVerified, 0)
+	return nil
+}
+
+// ------------------- Helper -------------------
+
+func (s *VerificationService) sendCodeResponse(target string) *models.SendCodeResponse {
+	return &models.SendCodeResponse{
+		Target:      target,
+		ExpiresIn:   int(s.codes.TTL().Seconds()),
+		ResendAfter: s.resendAfter,
+	}
+}
+
+// maskEmail a***@example.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// maskPhone 138****1234
+func maskPhone(phone string) string {
+	if len(phone) < 7 {
+		return "****"
+	}
+	return phone[:3] + "****" + phone[len(phone)-4:]
+}